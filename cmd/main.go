@@ -7,8 +7,16 @@ import (
 	repository "admin-panel/internal/repository/postgres"
 	"admin-panel/internal/service"
 	"admin-panel/pkg/database"
+	"admin-panel/pkg/encryption"
 	utils "admin-panel/pkg/lib/utils"
 	"admin-panel/pkg/logger"
+	"admin-panel/pkg/mailer"
+	"admin-panel/pkg/phone"
+	"admin-panel/pkg/sms"
+	"admin-panel/pkg/storage"
+	"context"
+	"crypto/sha256"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -20,6 +28,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	httpSwagger "github.com/swaggo/http-swagger"
+	"golang.org/x/crypto/hkdf"
 )
 
 // @title Admin Panel API
@@ -47,10 +56,23 @@ func main() {
 	}
 	defer db.Close()
 
+	phoneParser, err := phone.NewParser(cfg.Phone.DefaultRegion, cfg.Phone.AllowedRegions)
+	if err != nil {
+		slog.Error("Invalid phone number configuration:", utils.Err(err))
+		os.Exit(1)
+	}
+
+	cipher, err := encryption.New(cfg.Encryption.Keys, cfg.Encryption.CurrentKey, cfg.Encryption.IndexKey)
+	if err != nil {
+		slog.Error("Invalid encryption configuration:", utils.Err(err))
+		os.Exit(1)
+	}
+
 	mainRouter := chi.NewRouter()
 
 	authMiddlewareForAdmin := middleware.AuthMiddleware(cfg, []string{"admin"})
 	authMiddlewareForSuperAdmin := middleware.AuthMiddleware(cfg, []string{"super_admin"})
+	requireIfMatch := middleware.RequireIfMatch(cfg)
 
 	// Authentication routes
 	authRouter := chi.NewRouter()
@@ -71,7 +93,7 @@ func main() {
 
 	adminRepository := repository.NewPostgresAdminRepository(db.GetDB())
 	adminService := service.NewAdminService(adminRepository)
-	routers.SetupAdminRoutes(adminRepository, adminService, adminRouter)
+	routers.SetupAdminRoutes(adminRepository, adminService, adminRouter, requireIfMatch)
 
 	// User routes
 	userRouter := chi.NewRouter()
@@ -80,9 +102,149 @@ func main() {
 		r.Mount("/", userRouter)
 	})
 
-	userRepository := repository.NewPostgresUserRepository(db.GetDB())
-	userService := service.NewUserService(userRepository)
-	routers.SetupUserRoutes(userRepository, userService, userRouter)
+	attributeRepository := repository.NewPostgresAttributeRepository(db.GetDB())
+
+	userRepository := repository.NewPostgresUserRepository(db.GetDB(), cipher)
+	userService := service.NewUserService(userRepository, attributeRepository, phoneParser)
+	if err := userService.BackfillContactIndexes(); err != nil {
+		slog.Error("Failed to index phone numbers and email addresses:", utils.Err(err))
+		os.Exit(1)
+	}
+	routers.SetupUserRoutes(userRepository, userService, userRouter, requireIfMatch)
+
+	// Custom attribute schema; reading is open to admins, changes are
+	// restricted to super admins by the handlers.
+	attributeRouter := chi.NewRouter()
+	attributeRouter.Use(authMiddlewareForAdmin)
+	mainRouter.Route("/api/attributes", func(r chi.Router) {
+		r.Mount("/", attributeRouter)
+	})
+
+	attributeService := service.NewAttributeService(attributeRepository)
+	routers.SetupAttributeRoutes(attributeService, attributeRouter)
+
+	// Tags
+	tagRouter := chi.NewRouter()
+	tagRouter.Use(authMiddlewareForAdmin)
+	mainRouter.Route("/api/tags", func(r chi.Router) {
+		r.Mount("/", tagRouter)
+	})
+
+	tagRepository := repository.NewPostgresTagRepository(db.GetDB())
+	tagService := service.NewTagService(tagRepository)
+	routers.SetupTagRoutes(tagService, tagRouter, userRouter)
+
+	// Segments
+	segmentRouter := chi.NewRouter()
+	segmentRouter.Use(authMiddlewareForAdmin)
+	mainRouter.Route("/api/segments", func(r chi.Router) {
+		r.Mount("/", segmentRouter)
+	})
+
+	segmentRepository := repository.NewPostgresSegmentRepository(db.GetDB())
+	segmentService := service.NewSegmentService(segmentRepository, userRepository, attributeRepository)
+	routers.SetupSegmentRoutes(segmentService, segmentRouter)
+
+	// Reports
+	reportRouter := chi.NewRouter()
+	reportRouter.Use(authMiddlewareForAdmin)
+	mainRouter.Route("/api/reports", func(r chi.Router) {
+		r.Mount("/", reportRouter)
+	})
+
+	reportRepository := repository.NewPostgresReportRepository(db.GetDB())
+	reportService := service.NewReportService(reportRepository, cfg.Reports)
+	routers.SetupReportRoutes(reportService, reportRouter)
+
+	// Saved views
+	viewRepository := repository.NewPostgresViewRepository(db.GetDB())
+	viewService := service.NewViewService(viewRepository, userRepository, attributeRepository, phoneParser)
+	routers.SetupViewRoutes(viewService, userRouter)
+
+	// Statistics
+	statsRepository := repository.NewPostgresStatsRepository(db.GetDB())
+	statsService := service.NewStatsService(statsRepository, cfg.Stats)
+	routers.SetupStatsRoutes(statsService, userRouter)
+
+	// Notes
+	noteRepository := repository.NewPostgresNoteRepository(db.GetDB())
+	noteService := service.NewNoteService(noteRepository)
+	routers.SetupNoteRoutes(noteService, userRouter)
+
+	smsGateway, err := sms.New(cfg.SMS, cfg.Env)
+	if err != nil {
+		slog.Error("Failed to init SMS gateway:", utils.Err(err))
+		os.Exit(1)
+	}
+
+	if cfg.PhoneChange.SecretKey == "" {
+		cfg.PhoneChange.SecretKey = deriveSecretKey(cfg.JWT.AccessSecretKey, "phone change codes")
+	}
+
+	phoneChangeRepository := repository.NewPostgresPhoneChangeRepository(db.GetDB(), cipher)
+	phoneChangeService := service.NewPhoneChangeService(phoneChangeRepository, userRepository, smsGateway, phoneParser, cfg.PhoneChange)
+	routers.SetupPhoneChangeRoutes(phoneChangeService, userRouter)
+
+	// Email verification is optional, so that deployments without a key for
+	// it keep starting.
+	if cfg.EmailVerification.SecretKey == "" {
+		slog.Warn("Email verification is disabled: email_verification.secret_key is not set")
+	} else {
+		// Email verification links are opened by users, so they are not behind auth.
+		emailRouter := chi.NewRouter()
+		mainRouter.Route("/email", func(r chi.Router) {
+			r.Mount("/", emailRouter)
+		})
+
+		mail, err := mailer.New(cfg.Mailer)
+		if err != nil {
+			slog.Error("Failed to init mailer:", utils.Err(err))
+			os.Exit(1)
+		}
+
+		emailVerificationRepository := repository.NewPostgresEmailVerificationRepository(db.GetDB(), cipher)
+		emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, userRepository, mail, cfg.EmailVerification)
+		routers.SetupEmailVerificationRoutes(emailVerificationService, userRouter, emailRouter)
+	}
+
+	photoStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		slog.Error("Failed to init storage:", utils.Err(err))
+		os.Exit(1)
+	}
+
+	// Local uploads are served by the application itself.
+	if cfg.Storage.Driver == "local" {
+		mainRouter.Handle("/media/*", http.StripPrefix("/media/", http.FileServer(http.Dir(cfg.Storage.LocalDir))))
+	}
+
+	photoService := service.NewPhotoService(userRepository, photoStorage, cfg.Photos)
+	routers.SetupPhotoRoutes(photoService, userRouter)
+
+	privacyRepository := repository.NewPostgresPrivacyRepository(db.GetDB(), cipher)
+	privacyService := service.NewPrivacyService(privacyRepository, userRepository, noteRepository, photoStorage)
+	routers.SetupPrivacyRoutes(privacyService, userRouter)
+
+	importService := service.NewImportService(userRepository, attributeRepository, phoneParser, cfg.Import)
+	routers.SetupImportRoutes(importService, userRouter)
+
+	exportService := service.NewExportService(userRepository, attributeRepository)
+	routers.SetupExportRoutes(exportService, userRouter)
+
+	if cfg.Bulk.SecretKey == "" {
+		cfg.Bulk.SecretKey = deriveSecretKey(cfg.JWT.AccessSecretKey, "bulk confirmation tokens")
+	}
+	bulkService := service.NewBulkService(userRepository, segmentRepository, attributeRepository, phoneParser, cfg.Bulk)
+	routers.SetupBulkRoutes(bulkService, userRouter)
+
+	duplicateService := service.NewDuplicateService(userRepository, cfg.Duplicates)
+	routers.SetupDuplicateRoutes(duplicateService, userRouter)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go userService.RunBlockExpiryWorker(workerCtx, cfg.Blocks.ExpiryCheckInterval)
+	go userService.BackfillNameKeys(workerCtx)
 
 	mainRouter.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
@@ -95,6 +257,8 @@ func main() {
 		<-stop
 		log.Info("Shutting down the server gracefully...")
 
+		stopWorkers()
+
 		if err := db.Close(); err != nil {
 			slog.Error("Error closing database:", utils.Err(err))
 		}
@@ -106,3 +270,15 @@ func main() {
 		slog.Error("Server failed to start:", utils.Err(err))
 	}
 }
+
+// deriveSecretKey derives a key for purpose from secret with HKDF, for
+// features whose own secret key is not configured. Leaking the derived key
+// does not reveal secret.
+func deriveSecretKey(secret, purpose string) string {
+	key := make([]byte, 32)
+	// HKDF-SHA256 yields up to 8160 bytes, so reading 32 cannot fail.
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(purpose)), key); err != nil {
+		panic(err)
+	}
+	return string(key)
+}
@@ -0,0 +1,145 @@
+// Command normalize-phones rewrites the phone numbers of existing users to
+// E.164 using the phone rules from the service configuration. Run it once
+// after enabling new countries, first with -dry-run to review the changes.
+//
+// Numbers that cannot be parsed, or that would collide with another user's
+// number once normalized, are reported and left untouched.
+package main
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/pkg/database"
+	"admin-panel/pkg/encryption"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/logger"
+	"admin-panel/pkg/phone"
+	"database/sql"
+	"flag"
+	"log/slog"
+	"os"
+)
+
+type userPhone struct {
+	id          int32
+	phoneNumber string
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the changes without writing them")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	logger.SetupLogger(cfg.Env)
+
+	parser, err := phone.NewParser(cfg.Phone.DefaultRegion, cfg.Phone.AllowedRegions)
+	if err != nil {
+		slog.Error("Invalid phone number configuration:", utils.Err(err))
+		os.Exit(1)
+	}
+
+	cipher, err := encryption.New(cfg.Encryption.Keys, cfg.Encryption.CurrentKey, cfg.Encryption.IndexKey)
+	if err != nil {
+		slog.Error("Invalid encryption configuration:", utils.Err(err))
+		os.Exit(1)
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		slog.Error("Failed to init database:", utils.Err(err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := normalize(db.GetDB(), parser, cipher, *dryRun); err != nil {
+		slog.Error("Failed to normalize phone numbers:", utils.Err(err))
+		os.Exit(1)
+	}
+}
+
+func normalize(db *sql.DB, parser *phone.Parser, cipher *encryption.Cipher, dryRun bool) error {
+	users, err := loadPhoneNumbers(db, cipher)
+	if err != nil {
+		return err
+	}
+
+	owners := make(map[string]int32, len(users))
+	for _, user := range users {
+		owners[user.phoneNumber] = user.id
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var updated, failed int
+	for _, user := range users {
+		normalized, err := parser.Parse(user.phoneNumber)
+		if err != nil {
+			slog.Warn("Cannot normalize phone number", slog.Int("user_id", int(user.id)), utils.Err(err))
+			failed++
+			continue
+		}
+
+		if normalized == user.phoneNumber {
+			continue
+		}
+
+		if owner, taken := owners[normalized]; taken && owner != user.id {
+			slog.Warn("Normalized phone number belongs to another user", slog.Int("user_id", int(user.id)), slog.Int("owner_id", int(owner)))
+			failed++
+			continue
+		}
+
+		slog.Info("Normalizing phone number", slog.Int("user_id", int(user.id)))
+		owners[normalized] = user.id
+		delete(owners, user.phoneNumber)
+		updated++
+
+		if dryRun {
+			continue
+		}
+
+		encrypted, err := cipher.Encrypt(normalized)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE users SET phone_number = $2, phone_number_index = $3, version = version + 1 WHERE id = $1`, user.id, encrypted, cipher.BlindIndex(encryption.PhoneNumberField, normalized)); err != nil {
+			return err
+		}
+	}
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Phone number normalization finished", slog.Bool("dry_run", dryRun), slog.Int("updated", updated), slog.Int("failed", failed))
+
+	return nil
+}
+
+func loadPhoneNumbers(db *sql.DB, cipher *encryption.Cipher) ([]userPhone, error) {
+	rows, err := db.Query(`SELECT id, phone_number FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []userPhone
+	for rows.Next() {
+		var user userPhone
+		if err := rows.Scan(&user.id, &user.phoneNumber); err != nil {
+			return nil, err
+		}
+		if user.phoneNumber, err = cipher.Decrypt(user.phoneNumber); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,104 @@
+// Command rotate-encryption-keys re-encrypts the stored phone numbers and
+// email addresses with the current encryption key from the service
+// configuration and recomputes their blind indexes.
+//
+// Run it after making a new key current, keeping the old keys configured
+// until it has finished, after changing the index key, and once after
+// enabling encryption, which encrypts the values stored before. Rows are
+// processed in batches, each in its own transaction, so it can run next to
+// the service and be restarted if interrupted.
+//
+// With -decrypt it stores the plaintext instead, which the down migration of
+// the encryption requires. Stop the service first, as it would encrypt the
+// values it writes again.
+package main
+
+import (
+	"admin-panel/internal/config"
+	repository "admin-panel/internal/repository/postgres"
+	"admin-panel/pkg/database"
+	"admin-panel/pkg/encryption"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/logger"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// reencryptBatch re-encrypts the rows following afterID, see
+// repository.PostgresEncryptionRepository.
+type reencryptBatch func(afterID int64, batchSize int) (int64, int, error)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "number of rows re-encrypted per transaction")
+	decrypt := flag.Bool("decrypt", false, "store the data decrypted, before rolling back the encryption migration")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	logger.SetupLogger(cfg.Env)
+
+	if *batchSize <= 0 {
+		slog.Error("The batch size must be positive")
+		os.Exit(1)
+	}
+
+	cipher, err := encryption.New(cfg.Encryption.Keys, cfg.Encryption.CurrentKey, cfg.Encryption.IndexKey)
+	if err != nil {
+		slog.Error("Invalid encryption configuration:", utils.Err(err))
+		os.Exit(1)
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		slog.Error("Failed to init database:", utils.Err(err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	encryptionRepository := repository.NewPostgresEncryptionRepository(db.GetDB(), cipher)
+	encryptionRepository.Decrypt = *decrypt
+
+	for _, table := range []struct {
+		name      string
+		reencrypt reencryptBatch
+	}{
+		{"users", encryptionRepository.ReencryptUsers},
+		{"user_history", encryptionRepository.ReencryptUserHistory},
+		{"phone_changes", encryptionRepository.ReencryptPhoneChanges},
+		{"email_verifications", encryptionRepository.ReencryptEmailVerifications},
+	} {
+		if err := rotate(table.name, table.reencrypt, *batchSize); err != nil {
+			slog.Error("Failed to re-encrypt "+table.name+":", utils.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	if *decrypt {
+		slog.Info("Decryption finished")
+	} else {
+		slog.Info("Encryption key rotation finished", slog.Int("current_key", cfg.Encryption.CurrentKey))
+	}
+}
+
+func rotate(table string, reencrypt reencryptBatch, batchSize int) error {
+	var afterID int64
+	var total int
+	for {
+		lastID, updated, err := reencrypt(afterID, batchSize)
+		if err != nil {
+			return fmt.Errorf("after id %d: %w", afterID, err)
+		}
+		if lastID == 0 {
+			break
+		}
+
+		total += updated
+		afterID = lastID
+		slog.Debug("Re-encrypted batch", slog.String("table", table), slog.Int64("last_id", lastID), slog.Int("updated", updated))
+	}
+
+	slog.Info("Re-encrypted table", slog.String("table", table), slog.Int("updated", total))
+
+	return nil
+}
@@ -13,6 +13,21 @@ type Config struct {
 	Database   `yaml:"database"`
 	HTTPServer `yaml:"http_server"`
 	JWT
+	Blocks            `yaml:"blocks"`
+	Concurrency       `yaml:"concurrency"`
+	PhoneChange       `yaml:"phone_change"`
+	SMS               `yaml:"sms"`
+	Phone             `yaml:"phone"`
+	Mailer            `yaml:"mailer"`
+	EmailVerification `yaml:"email_verification"`
+	Storage           `yaml:"storage"`
+	Photos            `yaml:"photos"`
+	Import            `yaml:"import"`
+	Bulk              `yaml:"bulk"`
+	Duplicates        `yaml:"duplicates"`
+	Stats             `yaml:"stats"`
+	Reports           `yaml:"reports"`
+	Encryption        `yaml:"encryption"`
 }
 
 type Database struct {
@@ -35,6 +50,148 @@ type JWT struct {
 	RefreshSecretKey string `yaml:"refresh_secret_key"`
 }
 
+type Blocks struct {
+	ExpiryCheckInterval time.Duration `yaml:"expiry_check_interval" env-default:"1m"`
+}
+
+type Concurrency struct {
+	// RequireIfMatch rejects PUT, PATCH and DELETE requests without an
+	// If-Match header. When false such requests overwrite unconditionally.
+	RequireIfMatch bool `yaml:"require_if_match" env-default:"false"`
+}
+
+type PhoneChange struct {
+	// CodeTTL is how long a verification code sent to the new number is valid.
+	CodeTTL     time.Duration `yaml:"code_ttl" env-default:"10m"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"5"`
+	// SecretKey keys the hashes verification codes are stored as. A key
+	// derived from the JWT access secret key is used when it is empty.
+	SecretKey string `yaml:"secret_key"`
+}
+
+type SMS struct {
+	// Driver selects how text messages are delivered: "http" or, in the
+	// local and dev environments only, "fake".
+	Driver string `yaml:"driver" env-default:"fake"`
+	// HTTPURL receives a JSON POST with the "to" and "text" of every message
+	// when Driver is "http". HTTPToken is sent as a bearer token if set.
+	HTTPURL     string        `yaml:"http_url"`
+	HTTPToken   string        `yaml:"http_token"`
+	HTTPTimeout time.Duration `yaml:"http_timeout" env-default:"10s"`
+}
+
+type Phone struct {
+	// DefaultRegion is the country assumed for numbers written without a
+	// calling code, as an ISO 3166-1 alpha-2 code.
+	DefaultRegion  string   `yaml:"default_region" env-default:"TM"`
+	AllowedRegions []string `yaml:"allowed_regions" env-default:"TM"`
+}
+
+type Mailer struct {
+	// Driver selects how emails are delivered: "smtp", "file" or "memory".
+	Driver       string `yaml:"driver" env-default:"file"`
+	From         string `yaml:"from" env-default:"no-reply@localhost"`
+	FileDir      string `yaml:"file_dir" env-default:"./mail"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port" env-default:"587"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+}
+
+type EmailVerification struct {
+	// SecretKey signs verification links. Email verification is disabled
+	// while it is empty.
+	SecretKey string        `yaml:"secret_key"`
+	TokenTTL  time.Duration `yaml:"token_ttl" env-default:"24h"`
+	// BaseURL is the public address of the API used in verification links.
+	BaseURL string `yaml:"base_url" env-default:"http://localhost:8080"`
+}
+
+type Storage struct {
+	// Driver selects where uploaded files are kept: "local" or "s3".
+	Driver string `yaml:"driver" env-default:"local"`
+	// LocalDir is served by the application under LocalBaseURL.
+	LocalDir     string `yaml:"local_dir" env-default:"./media"`
+	LocalBaseURL string `yaml:"local_base_url" env-default:"http://localhost:8080/media"`
+
+	S3Endpoint      string `yaml:"s3_endpoint"`
+	S3Region        string `yaml:"s3_region" env-default:"us-east-1"`
+	S3Bucket        string `yaml:"s3_bucket"`
+	S3AccessKey     string `yaml:"s3_access_key"`
+	S3SecretKey     string `yaml:"s3_secret_key"`
+	S3PathStyle     bool   `yaml:"s3_path_style" env-default:"true"`
+	S3PublicBaseURL string `yaml:"s3_public_base_url"`
+}
+
+type Photos struct {
+	// MaxUploadSize is the largest accepted upload in bytes.
+	MaxUploadSize int64 `yaml:"max_upload_size" env-default:"5242880"`
+	// MaxDimension bounds the width and height of uploaded images, which
+	// protects against images that are small on disk but huge decoded.
+	MaxDimension int `yaml:"max_dimension" env-default:"6000"`
+}
+
+type Import struct {
+	// MaxUploadSize is the largest accepted import file in bytes.
+	MaxUploadSize int64 `yaml:"max_upload_size" env-default:"20971520"`
+	MaxRows       int   `yaml:"max_rows" env-default:"50000"`
+	// BatchSize is the number of rows written per transaction. A failing
+	// batch is reported row by row and does not stop the import.
+	BatchSize int `yaml:"batch_size" env-default:"500"`
+}
+
+type Bulk struct {
+	// MaxUsers is the hard cap on users affected by one bulk operation.
+	MaxUsers int `yaml:"max_users" env-default:"1000"`
+	// ConfirmThreshold is the number of users above which a bulk operation
+	// needs a confirmation token.
+	ConfirmThreshold int           `yaml:"confirm_threshold" env-default:"50"`
+	ConfirmationTTL  time.Duration `yaml:"confirmation_ttl" env-default:"5m"`
+	// SecretKey signs confirmation tokens. A key derived from the JWT access
+	// secret key is used when it is empty.
+	SecretKey string `yaml:"secret_key"`
+}
+
+type Duplicates struct {
+	// MinScore is the lowest score of a pair listed as likely duplicates
+	// unless a request asks for another one.
+	MinScore float64 `yaml:"min_score" env-default:"0.8"`
+	// MaxBlockSize bounds the number of users compared pairwise because they
+	// share an email address, a name or a birth date. Larger groups, such as
+	// a placeholder birth date, are skipped.
+	MaxBlockSize int `yaml:"max_block_size" env-default:"200"`
+}
+
+type Stats struct {
+	// CacheTTL is how long computed statistics are served from memory
+	// before they are computed again. Zero disables caching.
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"1m"`
+	// TimeZone is the IANA time zone dates are taken in when a request
+	// names none.
+	TimeZone string `yaml:"time_zone" env-default:"Asia/Ashgabat"`
+}
+
+type Reports struct {
+	// MaxRows caps the rows of a report; reports asking for no limit get
+	// this many.
+	MaxRows int `yaml:"max_rows" env-default:"10000"`
+	// Timeout cancels report queries running longer.
+	Timeout time.Duration `yaml:"timeout" env-default:"30s"`
+}
+
+type Encryption struct {
+	// Keys encrypt the data keys of encrypted personal data, by version. Each
+	// is 32 random bytes encoded in base64. Keys replaced by a new current
+	// key are kept until rotate-encryption-keys has re-encrypted all data.
+	Keys map[int]string `yaml:"keys"`
+	// CurrentKey is the version of the key new data is encrypted with.
+	CurrentKey int `yaml:"current_key" env-default:"1"`
+	// IndexKey keys the blind indexes phone numbers and email addresses are
+	// looked up by, at least 32 random bytes encoded in base64. After
+	// changing it run rotate-encryption-keys before starting the service.
+	IndexKey string `yaml:"index_key"`
+}
+
 func LoadConfig() *Config {
 	configPath := "./config/config.yaml"
 
@@ -88,7 +88,7 @@ func TestGetAllAdminsHandler(t *testing.T) {
 				AdminService: mockAdminService,
 			}
 
-			mockAdminService.On("GetAllAdmins", mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(tc.mockReturn, tc.mockReturnErr)
+			mockAdminService.On("GetAllAdmins", mock.AnythingOfType("int"), mock.AnythingOfType("int"), domain.Sort(nil)).Return(tc.mockReturn, tc.mockReturnErr)
 			mockAdminService.On("GetTotalAdminsCount").Return(tc.mockCountReturn, tc.mockCountErr)
 
 			rr := httptest.NewRecorder()
@@ -266,6 +266,81 @@ func TestUpdateAdminHandler(t *testing.T) {
 	}
 }
 
+func TestPatchAdminHandler(t *testing.T) {
+	testCases := []struct {
+		name           string
+		contentType    string
+		requestBody    string
+		mockReturn     *domain.UpdateAdminResponse
+		mockReturnErr  error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Success",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"role":"super_admin"}`,
+			mockReturn: &domain.UpdateAdminResponse{
+				ID:       1,
+				Username: "Admin1",
+				Role:     "super_admin",
+			},
+			mockReturnErr:  nil,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1,"username":"Admin1","role":"super_admin"}`,
+		},
+		{
+			name:           "Username Taken",
+			contentType:    "application/json-patch+json",
+			requestBody:    `[{"op":"replace","path":"/username","value":"Admin2"}]`,
+			mockReturn:     nil,
+			mockReturnErr:  errors.ErrAdminAlreadyExists,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"Admin with the same username already exists"}`,
+		},
+		{
+			name:           "Admin Not Found",
+			contentType:    "application/merge-patch+json",
+			requestBody:    `{"role":"admin"}`,
+			mockReturn:     nil,
+			mockReturnErr:  errors.ErrAdminNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"Admin not found"}`,
+		},
+		{
+			name:           "Invalid Role",
+			contentType:    "application/merge-patch+json",
+			requestBody:    `{"role":"owner"}`,
+			mockReturn:     nil,
+			mockReturnErr:  errors.ErrInvalidAdminRole,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Role must be admin or super_admin"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAdminService := new(mocks.MockAdminService)
+			router := chi.NewRouter()
+			handler := handlers.AdminHandler{
+				AdminService: mockAdminService,
+			}
+
+			mockAdminService.On("PatchAdmin", int32(1), mock.AnythingOfType("domain.PatchFormat"), []byte(tc.requestBody), mock.Anything).Return(tc.mockReturn, tc.mockReturnErr)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/api/admin/1", bytes.NewBuffer([]byte(tc.requestBody)))
+			req.Header.Set("Content-Type", tc.contentType)
+
+			router.Patch("/api/admin/{id}", handler.PatchAdminHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
 func TestDeleteAdminHandler(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -295,7 +370,7 @@ func TestDeleteAdminHandler(t *testing.T) {
 				AdminService: mockAdminService,
 			}
 
-			mockAdminService.On("DeleteAdmin", mock.AnythingOfType("int32")).Return(tc.mockReturnErr)
+			mockAdminService.On("DeleteAdmin", mock.AnythingOfType("int32"), mock.Anything).Return(tc.mockReturnErr)
 
 			rr := httptest.NewRecorder()
 			req, _ := http.NewRequest("DELETE", "/api/admin/1", nil)
@@ -375,7 +450,7 @@ func TestSearchAdminsHandler(t *testing.T) {
 				AdminService: mockAdminService,
 			}
 
-			mockAdminService.On("SearchAdmins", tc.query, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(tc.mockReturn, tc.mockReturnErr)
+			mockAdminService.On("SearchAdmins", tc.query, mock.AnythingOfType("int"), mock.AnythingOfType("int"), domain.Sort(nil)).Return(tc.mockReturn, tc.mockReturnErr)
 			mockAdminService.On("GetTotalAdminsCount").Return(tc.mockCountReturn, tc.mockCountErr)
 
 			rr := httptest.NewRecorder()
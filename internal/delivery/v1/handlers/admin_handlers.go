@@ -9,6 +9,7 @@ import (
 	"admin-panel/pkg/lib/utils"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -38,7 +39,9 @@ func NewAdminHandler(repository repository.AdminRepository, service service.Admi
 // @Security jwt
 // @Param page query int false "Page number (default: 1)"
 // @Param pageSize query int false "Page size (default: 8)"
+// @Param sort query string false "Comma-separated fields to sort by, each prefixed with - for descending order, e.g. role,-username. Sortable: id, username, role. Ties are broken by id; defaults to id"
 // @Success 200 {object} domain.AdminListResponse
+// @Failure 400 {string} string
 // @Failure 500 {string} string
 // @Router /api/admin [get]
 func (h *AdminHandler) GetAllAdminsHandler(w http.ResponseWriter, r *http.Request) {
@@ -59,8 +62,11 @@ func (h *AdminHandler) GetAllAdminsHandler(w http.ResponseWriter, r *http.Reques
 		previousPage = 1
 	}
 
-	admins, err := h.AdminService.GetAllAdmins(page, pageSize)
+	admins, err := h.AdminService.GetAllAdmins(page, pageSize, sortFromRequest(r))
 	if err != nil {
+		if respondWithSortError(w, err) {
+			return
+		}
 		slog.Error("Error getting admins: ", utils.Err(err))
 		http.Error(w, errors.InternalServerError, status.InternalServerError)
 		return
@@ -101,6 +107,7 @@ func (h *AdminHandler) GetAllAdminsHandler(w http.ResponseWriter, r *http.Reques
 // @Security jwt
 // @Param id path int true "Admin ID"
 // @Success 200 {object} domain.Admin
+// @Header 200 {string} ETag "Current version of the admin, for use in If-Match"
 // @Failure 400 {string} string
 // @Failure 404 {string} string
 // @Failure 500 {string} string
@@ -125,6 +132,7 @@ func (h *AdminHandler) GetAdminByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, admin.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(admin)
 }
@@ -177,10 +185,14 @@ func (h *AdminHandler) CreateAdminHandler(w http.ResponseWriter, r *http.Request
 // @Produce json
 // @Security jwt
 // @Param id path int true "Admin ID"
+// @Param If-Match header string false "ETag returned by GET /api/admin/{id}; required when strict concurrency is enabled"
 // @Param admin body domain.UpdateAdminRequest true "Updated admin data"
 // @Success 200 {object} domain.Admin
+// @Header 200 {string} ETag "New version of the admin"
 // @Failure 400 {string} string
 // @Failure 404 {string} string
+// @Failure 412 {string} string
+// @Failure 428 {string} string
 // @Failure 500 {string} string
 // @Router /api/admin/{id} [put]
 func (h *AdminHandler) UpdateAdminHandler(w http.ResponseWriter, r *http.Request) {
@@ -191,6 +203,12 @@ func (h *AdminHandler) UpdateAdminHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
 	var updateAdminRequest domain.UpdateAdminRequest
 
 	err = json.NewDecoder(r.Body).Decode(&updateAdminRequest)
@@ -198,12 +216,19 @@ func (h *AdminHandler) UpdateAdminHandler(w http.ResponseWriter, r *http.Request
 		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
 		return
 	}
+	updateAdminRequest.ExpectedVersion = expectedVersion
 
 	admin, err := h.AdminService.UpdateAdmin(int32(id), &updateAdminRequest)
 	if err != nil {
 		if err == errors.ErrAdminNotFound {
 			utils.RespondWithErrorJSON(w, status.NotFound, errors.AdminNotFound)
 			return
+		} else if err == errors.ErrPreconditionFailed {
+			utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+			return
+		} else if err == errors.ErrInvalidAdminRole {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidAdminRole)
+			return
 		}
 
 		slog.Error("Error updating admin: ", utils.Err(err))
@@ -211,11 +236,83 @@ func (h *AdminHandler) UpdateAdminHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	setETag(w, admin.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status.OK)
 	json.NewEncoder(w).Encode(admin)
 }
 
+// @Summary Patch admin
+// @Description Partially updates an administrator. Send a JSON Merge Patch (RFC 7396) with Content-Type application/merge-patch+json, or a JSON Patch (RFC 6902) with Content-Type application/json-patch+json. Only the supplied fields are changed; null is rejected because every admin field is required.
+// @Tags admins
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "Admin ID"
+// @Param If-Match header string false "ETag returned by GET /api/admin/{id}; required when strict concurrency is enabled"
+// @Param admin body domain.PatchAdminRequest true "Merge patch or JSON Patch document"
+// @Success 200 {object} domain.UpdateAdminResponse
+// @Header 200 {string} ETag "New version of the admin"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 412 {string} string
+// @Failure 415 {string} string
+// @Failure 428 {string} string
+// @Failure 500 {string} string
+// @Router /api/admin/{id} [patch]
+func (h *AdminHandler) PatchAdminHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
+	format, ok := patchFormatFromRequest(r)
+	if !ok {
+		w.Header().Set("Accept-Patch", acceptPatchHeader)
+		utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.UnsupportedPatchFormat)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	admin, err := h.AdminService.PatchAdmin(int32(id), format, body, expectedVersion)
+	if err != nil {
+		if respondWithPatchError(w, err) {
+			return
+		}
+		switch err {
+		case errors.ErrAdminNotFound:
+			utils.RespondWithErrorJSON(w, status.NotFound, errors.AdminNotFound)
+		case errors.ErrAdminAlreadyExists:
+			utils.RespondWithErrorJSON(w, status.Conflict, errors.AdminAlreadyExists)
+		case errors.ErrInvalidAdminRole:
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidAdminRole)
+		case errors.ErrPreconditionFailed:
+			utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		default:
+			slog.Error("Error patching admin: ", utils.Err(err))
+			utils.RespondWithErrorJSON(w, status.InternalServerError, fmt.Sprintf("error patching admin: %v", err))
+		}
+		return
+	}
+
+	setETag(w, admin.Version)
+	utils.RespondWithJSON(w, status.OK, admin)
+}
+
 // @Summary Delete admin
 // @Description Deletes an administrator by their unique ID.
 // @Tags admins
@@ -223,9 +320,12 @@ func (h *AdminHandler) UpdateAdminHandler(w http.ResponseWriter, r *http.Request
 // @Produce json
 // @Security jwt
 // @Param id path int true "Admin ID"
+// @Param If-Match header string false "ETag returned by GET /api/admin/{id}; required when strict concurrency is enabled"
 // @Success 200 {object} StatusMessage
 // @Failure 400 {string} string
 // @Failure 404 {string} string
+// @Failure 412 {string} string
+// @Failure 428 {string} string
 // @Failure 500 {string} string
 // @Router /api/admin/{id} [delete]
 func (h *AdminHandler) DeleteAdminHandler(w http.ResponseWriter, r *http.Request) {
@@ -236,10 +336,19 @@ func (h *AdminHandler) DeleteAdminHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := h.AdminService.DeleteAdmin(int32(id)); err != nil {
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
+	if err := h.AdminService.DeleteAdmin(int32(id), expectedVersion); err != nil {
 		if err == errors.ErrAdminNotFound {
 			utils.RespondWithErrorJSON(w, status.NotFound, errors.AdminNotFound)
 			return
+		} else if err == errors.ErrPreconditionFailed {
+			utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+			return
 		}
 
 		slog.Error("Error deleting admin: ", utils.Err(err))
@@ -262,6 +371,7 @@ func (h *AdminHandler) DeleteAdminHandler(w http.ResponseWriter, r *http.Request
 // @Param query query string true "Search query"
 // @Param page query int false "Page number (default: 1)"
 // @Param pageSize query int false "Page size (default: 8)"
+// @Param sort query string false "Comma-separated fields to sort by, each prefixed with - for descending order, e.g. role,-username. Sortable: id, username, role. Ties are broken by id; defaults to id"
 // @Success 200 {object} domain.AdminListResponse
 // @Failure 400 {string} string
 // @Failure 500 {string} string
@@ -283,8 +393,11 @@ func (h *AdminHandler) SearchAdminsHandler(w http.ResponseWriter, r *http.Reques
 		pageSize = 8 // Default page size
 	}
 
-	admins, err := h.AdminService.SearchAdmins(query, page, pageSize)
+	admins, err := h.AdminService.SearchAdmins(query, page, pageSize, sortFromRequest(r))
 	if err != nil {
+		if respondWithSortError(w, err) {
+			return
+		}
 		slog.Error("Error searching admins: ", utils.Err(err))
 		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
 		return
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AttributeHandler struct {
+	AttributeService service.AttributeService
+	Router           *chi.Mux
+}
+
+func NewAttributeHandler(service service.AttributeService, router *chi.Mux) *AttributeHandler {
+	return &AttributeHandler{
+		AttributeService: service,
+		Router:           router,
+	}
+}
+
+// @Summary List custom user attributes
+// @Description Lists the definitions of the custom attributes users may carry.
+// @Tags attributes
+// @Produce json
+// @Security jwt
+// @Success 200 {object} domain.AttributeDefinitionsList "Success"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/attributes [get]
+func (h *AttributeHandler) GetAttributeDefinitionsHandler(w http.ResponseWriter, r *http.Request) {
+	definitions, err := h.AttributeService.GetAttributeDefinitions()
+	if err != nil {
+		slog.Error("Error getting attribute definitions: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, definitions)
+}
+
+// @Summary Define custom user attribute
+// @Description Defines a new custom attribute. Only super admins may change the attribute schema.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param request body domain.AttributeDefinitionRequest true "Attribute definition"
+// @Success 201 {object} domain.AttributeDefinition "Created"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody or errors.InvalidAttributeDefinition
+// @Failure 403 {string} string "Forbidden: " + errors.InsufficientPermission
+// @Failure 409 {string} string "Conflict: " + errors.AttributeAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/attributes [post]
+func (h *AttributeHandler) CreateAttributeDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	if role, _ := middleware.RoleFromContext(r.Context()); role != "super_admin" {
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.InsufficientPermission)
+		return
+	}
+
+	var request domain.AttributeDefinitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	definition, err := h.AttributeService.CreateAttributeDefinition(&request)
+	if err != nil {
+		respondWithAttributeError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Created, definition)
+}
+
+// @Summary Update custom user attribute
+// @Description Replaces the definition of a custom attribute. Values users already carry are checked against it the next time they are written.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param key path string true "Attribute key"
+// @Param request body domain.AttributeDefinitionRequest true "Attribute definition; key is taken from the path"
+// @Success 200 {object} domain.AttributeDefinition "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody or errors.InvalidAttributeDefinition
+// @Failure 403 {string} string "Forbidden: " + errors.InsufficientPermission
+// @Failure 404 {string} string "Not Found: " + errors.AttributeNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/attributes/{key} [put]
+func (h *AttributeHandler) UpdateAttributeDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	if role, _ := middleware.RoleFromContext(r.Context()); role != "super_admin" {
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.InsufficientPermission)
+		return
+	}
+
+	var request domain.AttributeDefinitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+	request.Key = chi.URLParam(r, "key")
+
+	definition, err := h.AttributeService.UpdateAttributeDefinition(&request)
+	if err != nil {
+		respondWithAttributeError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, definition)
+}
+
+// @Summary Delete custom user attribute
+// @Description Deletes a custom attribute and removes its value from every user.
+// @Tags attributes
+// @Produce json
+// @Security jwt
+// @Param key path string true "Attribute key"
+// @Success 200 {object} StatusMessage "Deleted"
+// @Failure 403 {string} string "Forbidden: " + errors.InsufficientPermission
+// @Failure 404 {string} string "Not Found: " + errors.AttributeNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/attributes/{key} [delete]
+func (h *AttributeHandler) DeleteAttributeDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	if role, _ := middleware.RoleFromContext(r.Context()); role != "super_admin" {
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.InsufficientPermission)
+		return
+	}
+
+	if err := h.AttributeService.DeleteAttributeDefinition(chi.URLParam(r, "key")); err != nil {
+		respondWithAttributeError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "Attribute deleted successfully",
+	})
+}
+
+func respondWithAttributeError(w http.ResponseWriter, err error) {
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidAttributeDefinition):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrAttributeNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.AttributeNotFound)
+	case err == errors.ErrAttributeAlreadyExists:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.AttributeAlreadyExists)
+	default:
+		slog.Error("Error changing attribute definition: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
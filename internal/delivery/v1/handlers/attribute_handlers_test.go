@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateAttributeDefinitionHandler(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		role           string
+		body           string
+		mockResponse   *domain.AttributeDefinition
+		mockErr        error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "created",
+			role:           "super_admin",
+			body:           `{"key":"tier","type":"string","enum":["gold","silver"]}`,
+			mockResponse:   &domain.AttributeDefinition{Key: "tier", Type: domain.AttributeTypeString, Enum: []string{"gold", "silver"}, CreatedAt: createdAt, UpdatedAt: createdAt},
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"key":"tier","type":"string","enum":["gold","silver"],"required":false,"description":"","created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T12:00:00Z"}`,
+		},
+		{
+			name:           "admin may not change the schema",
+			role:           "admin",
+			body:           `{"key":"tier","type":"string"}`,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":403,"message":"Insufficient permissions"}`,
+		},
+		{
+			name:           "invalid definition",
+			role:           "super_admin",
+			body:           `{"key":"tier","type":"object"}`,
+			mockErr:        fmt.Errorf("%w: unknown type %q", errors.ErrInvalidAttributeDefinition, "object"),
+			expectCall:     true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid attribute definition: unknown type \"object\""}`,
+		},
+		{
+			name:           "already exists",
+			role:           "super_admin",
+			body:           `{"key":"tier","type":"string"}`,
+			mockErr:        errors.ErrAttributeAlreadyExists,
+			expectCall:     true,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"Attribute already exists"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributeService := &mocks.MockAttributeService{}
+			if tt.expectCall {
+				attributeService.On("CreateAttributeDefinition", mock.Anything).Return(tt.mockResponse, tt.mockErr)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/attributes", strings.NewReader(tt.body))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": tt.role}))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewAttributeHandler(attributeService, router)
+			router.Post("/api/attributes", handler.CreateAttributeDefinitionHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+			attributeService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteAttributeDefinitionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "deleted",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":200,"message":"Attribute deleted successfully"}`,
+		},
+		{
+			name:           "not found",
+			mockErr:        errors.ErrAttributeNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"Attribute not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributeService := &mocks.MockAttributeService{}
+			attributeService.On("DeleteAttributeDefinition", "tier").Return(tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/api/attributes/tier", nil)
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "super_admin"}))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewAttributeHandler(attributeService, router)
+			router.Delete("/api/attributes/{key}", handler.DeleteAttributeDefinitionHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
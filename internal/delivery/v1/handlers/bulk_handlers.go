@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BulkHandler struct {
+	BulkService service.BulkService
+	Router      *chi.Mux
+}
+
+func NewBulkHandler(service service.BulkService, router *chi.Mux) *BulkHandler {
+	return &BulkHandler{
+		BulkService: service,
+		Router:      router,
+	}
+}
+
+// @Summary Run a bulk action
+// @Description Blocks, unblocks or deletes users given by ids, by a search query and filter as used by GET /api/user/search, or by segment_id. In atomic mode (default) all users are changed in one transaction, or none if any is missing; in best_effort mode each user is changed on its own. Operations over the confirmation threshold answer 428 with a confirmation_token that must be sent back in a repeated request.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param request body domain.BulkRequest true "Bulk action"
+// @Success 200 {object} domain.BulkResponse "Per-user results"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody + ", " + errors.InvalidBulkAction + ", " + errors.InvalidBulkMode + ", " + errors.BulkTargetRequired + ", " + errors.InvalidBlockReason + ", " + errors.InvalidBlockExpiry + ", too many users or an invalid filter"
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 404 {string} string "Not Found: " + errors.SegmentNotFound
+// @Failure 428 {object} domain.BulkConfirmation "Confirmation required"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/bulk [post]
+func (h *BulkHandler) RunBulkActionHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	adminID, ok := middleware.AdminIDFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+	request.ActorID = adminID
+
+	response, confirmation, err := h.BulkService.RunBulkAction(&request)
+	if err != nil {
+		if err == errors.ErrBulkConfirmationRequired {
+			confirmation.Status = status.PreconditionRequired
+			confirmation.Message = errors.BulkConfirmationRequired
+			utils.RespondWithJSON(w, status.PreconditionRequired, confirmation)
+			return
+		}
+		respondWithBulkError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, response)
+}
+
+func respondWithBulkError(w http.ResponseWriter, err error) {
+	if respondWithFilterError(w, err) {
+		return
+	}
+
+	switch {
+	case err == errors.ErrInvalidBulkAction:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidBulkAction)
+	case err == errors.ErrInvalidBulkMode:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidBulkMode)
+	case err == errors.ErrBulkTargetRequired:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.BulkTargetRequired)
+	case err == errors.ErrInvalidBlockReason:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidBlockReason)
+	case err == errors.ErrInvalidBlockExpiry:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidBlockExpiry)
+	case stderrors.Is(err, errors.ErrBulkTooManyUsers):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrSegmentNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.SegmentNotFound)
+	default:
+		slog.Error("Error running bulk action: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBulkActionHandler(t *testing.T) {
+	expiresAt := time.Date(2024, 5, 1, 12, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		body             string
+		withClaims       bool
+		expectedRequest  *domain.BulkRequest
+		mockResponse     *domain.BulkResponse
+		mockConfirmation *domain.BulkConfirmation
+		mockErr          error
+		expectedStatus   int
+		expectedBody     string
+	}{
+		{
+			name:       "Success",
+			body:       `{"action":"delete","mode":"best_effort","ids":[1,2]}`,
+			withClaims: true,
+			expectedRequest: &domain.BulkRequest{
+				Action:  domain.BulkActionDelete,
+				Mode:    domain.BulkModeBestEffort,
+				IDs:     []int32{1, 2},
+				ActorID: 1,
+			},
+			mockResponse: &domain.BulkResponse{
+				Action:    domain.BulkActionDelete,
+				Mode:      domain.BulkModeBestEffort,
+				Total:     2,
+				Succeeded: 1,
+				Failed:    1,
+				Results: []domain.BulkResult{
+					{ID: 1, Status: domain.BulkStatusOK},
+					{ID: 2, Status: domain.BulkStatusNotFound, Error: errors.UserNotFound},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"action":"delete","mode":"best_effort","total":2,"succeeded":1,"failed":1,"results":[{"id":1,"status":"ok"},{"id":2,"status":"not_found","error":"` + errors.UserNotFound + `"}]}`,
+		},
+		{
+			name:            "Confirmation required",
+			body:            `{"action":"unblock","query":"kem"}`,
+			withClaims:      true,
+			expectedRequest: &domain.BulkRequest{Action: domain.BulkActionUnblock, Query: "kem", ActorID: 1},
+			mockConfirmation: &domain.BulkConfirmation{
+				Token:     "token",
+				Affected:  120,
+				ExpiresAt: expiresAt,
+			},
+			mockErr:        errors.ErrBulkConfirmationRequired,
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedBody:   `{"status":428,"message":"` + errors.BulkConfirmationRequired + `","confirmation_token":"token","affected":120,"expires_at":"2024-05-01T12:05:00Z"}`,
+		},
+		{
+			name:            "Too many users",
+			body:            `{"action":"delete","filter":{}}`,
+			withClaims:      true,
+			expectedRequest: &domain.BulkRequest{Action: domain.BulkActionDelete, Filter: &domain.UserFilter{}, ActorID: 1},
+			mockErr:         fmt.Errorf("%w: at most %d users are allowed", errors.ErrBulkTooManyUsers, 1000),
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    `{"status":400,"message":"` + errors.ErrBulkTooManyUsers.Error() + `: at most 1000 users are allowed"}`,
+		},
+		{
+			name:            "No target",
+			body:            `{"action":"delete"}`,
+			withClaims:      true,
+			expectedRequest: &domain.BulkRequest{Action: domain.BulkActionDelete, ActorID: 1},
+			mockErr:         errors.ErrBulkTargetRequired,
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    `{"status":400,"message":"` + errors.BulkTargetRequired + `"}`,
+		},
+		{
+			name:           "Invalid body",
+			body:           `{"ids":"1"}`,
+			withClaims:     true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidRequestBody + `"}`,
+		},
+		{
+			name:           "Missing claims",
+			body:           `{"action":"delete","ids":[1]}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":401,"message":"` + errors.TokenClaimsNotFound + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockBulkService)
+			router := chi.NewRouter()
+			handler := handlers.NewBulkHandler(mockService, router)
+			router.Post("/api/user/bulk", handler.RunBulkActionHandler)
+
+			if tt.expectedRequest != nil {
+				mockService.On("RunBulkAction", tt.expectedRequest).Return(tt.mockResponse, tt.mockConfirmation, tt.mockErr)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/bulk", strings.NewReader(tt.body))
+			if tt.withClaims {
+				req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "admin"}))
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type DuplicateHandler struct {
+	DuplicateService service.DuplicateService
+	Router           *chi.Mux
+}
+
+func NewDuplicateHandler(service service.DuplicateService, router *chi.Mux) *DuplicateHandler {
+	return &DuplicateHandler{
+		DuplicateService: service,
+		Router:           router,
+	}
+}
+
+// @Summary List likely duplicate users
+// @Description Lists pairs of users that likely belong to the same person, best match first. Each pair is scored from the similarity of the names, email addresses, dates of birth and locations; fields missing on either user are not compared.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param min_score query number false "Lowest score listed, between 0 and 1; defaults to the configured minimum"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} domain.DuplicatesListResponse "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidMinScore
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/duplicates [get]
+func (h *DuplicateHandler) GetDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	var minScore float64
+	if value := r.URL.Query().Get("min_score"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidMinScore)
+			return
+		}
+		minScore = parsed
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	duplicates, totalPairs, err := h.DuplicateService.FindDuplicates(minScore, page, pageSize)
+	if err != nil {
+		respondWithDuplicateError(w, err)
+		return
+	}
+
+	var previousPage int
+	if page > 1 {
+		previousPage = page - 1
+	} else {
+		previousPage = 1
+	}
+
+	lastPage := (totalPairs + pageSize - 1) / pageSize
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	nextPage := page + 1
+	if page >= lastPage {
+		nextPage = lastPage
+	}
+
+	utils.RespondWithJSON(w, status.OK, domain.DuplicatesListResponse{
+		Duplicates:  duplicates,
+		CurrentPage: page,
+		PrevPage:    previousPage,
+		NextPage:    nextPage,
+		FirstPage:   1,
+		LastPage:    lastPage,
+	})
+}
+
+// @Summary Merge a duplicate user
+// @Description Merges the user duplicate_id into the user of the path. fields picks, for first_name, last_name, gender, date_of_birth, location, email and profile_photo_url, whether the survivor's or the duplicate's value is kept; the survivor's is kept by default and its phone number always. Custom attributes the survivor lacks are taken from the duplicate. Notes, tags and change history move to the survivor and the duplicate is soft-deleted.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "Surviving user ID"
+// @Param request body domain.MergeUsersRequest true "Merge request"
+// @Success 200 {object} domain.UpdateUserResponse "Merged user"
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID + ", " + errors.InvalidRequestBody + ", " + errors.MergeSameUser + " or invalid merge fields"
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.EmailAlreadyInUse
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/merge [post]
+func (h *DuplicateHandler) MergeUsersHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.MergeUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	adminID, ok := middleware.AdminIDFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+	request.MergedBy = adminID
+
+	user, err := h.DuplicateService.MergeUsers(int32(id), &request)
+	if err != nil {
+		respondWithDuplicateError(w, err)
+		return
+	}
+
+	setETag(w, user.Version)
+	utils.RespondWithJSON(w, status.OK, user)
+}
+
+func respondWithDuplicateError(w http.ResponseWriter, err error) {
+	switch {
+	case err == errors.ErrInvalidMinScore:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidMinScore)
+	case err == errors.ErrMergeSameUser:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.MergeSameUser)
+	case stderrors.Is(err, errors.ErrInvalidMergeFields):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrUserNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+	case err == errors.ErrEmailInUse:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.EmailAlreadyInUse)
+	default:
+		slog.Error("Error handling duplicates: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
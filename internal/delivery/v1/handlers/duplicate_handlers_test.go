@@ -0,0 +1,175 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDuplicatesHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		url              string
+		expectedMinScore float64
+		expectedPage     int
+		expectedPageSize int
+		mockDuplicates   *domain.DuplicatesList
+		mockTotal        int
+		mockErr          error
+		expectedStatus   int
+		expectedBody     string
+	}{
+		{
+			name:             "Success",
+			url:              "/api/user/duplicates?min_score=0.9&page=2&pageSize=1",
+			expectedMinScore: 0.9,
+			expectedPage:     2,
+			expectedPageSize: 1,
+			mockDuplicates: &domain.DuplicatesList{Pairs: []domain.DuplicatePair{{
+				Score:   0.95,
+				Signals: domain.DuplicateSignals{Name: 0.95},
+				Users:   []domain.DuplicateProfile{{ID: 1, FirstName: "Kemal"}, {ID: 3, FirstName: "Kemal"}},
+			}}},
+			mockTotal:      3,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"duplicates":{"pairs":[{"score":0.95,"signals":{"name":0.95},"users":[` +
+				`{"id":1,"first_name":"Kemal","last_name":"","phone_number":"","email":"","date_of_birth":"0001-01-01T00:00:00Z","location":"","registration_date":"0001-01-01T00:00:00Z"},` +
+				`{"id":3,"first_name":"Kemal","last_name":"","phone_number":"","email":"","date_of_birth":"0001-01-01T00:00:00Z","location":"","registration_date":"0001-01-01T00:00:00Z"}]}]},` +
+				`"currentPage":2,"previousPage":1,"nextPage":3,"firstPage":1,"lastPage":3}`,
+		},
+		{
+			name:             "Minimum score out of range",
+			url:              "/api/user/duplicates?min_score=2",
+			expectedMinScore: 2,
+			expectedPage:     1,
+			expectedPageSize: 20,
+			mockDuplicates:   (*domain.DuplicatesList)(nil),
+			mockErr:          errors.ErrInvalidMinScore,
+			expectedStatus:   http.StatusBadRequest,
+			expectedBody:     `{"status":400,"message":"` + errors.InvalidMinScore + `"}`,
+		},
+		{
+			name:           "Minimum score not a number",
+			url:            "/api/user/duplicates?min_score=high",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidMinScore + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockDuplicateService)
+			router := chi.NewRouter()
+			handler := handlers.NewDuplicateHandler(mockService, router)
+			router.Get("/api/user/duplicates", handler.GetDuplicatesHandler)
+
+			if tt.expectedPage != 0 {
+				mockService.On("FindDuplicates", tt.expectedMinScore, tt.expectedPage, tt.expectedPageSize).Return(tt.mockDuplicates, tt.mockTotal, tt.mockErr)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMergeUsersHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		expectedRequest *domain.MergeUsersRequest
+		mockUser        *domain.UpdateUserResponse
+		mockErr         error
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{
+			name: "Success",
+			body: `{"duplicate_id":2,"fields":{"email":"duplicate"}}`,
+			expectedRequest: &domain.MergeUsersRequest{
+				DuplicateID: 2,
+				Fields:      map[string]domain.MergeSource{"email": domain.MergeSourceDuplicate},
+				MergedBy:    1,
+			},
+			mockUser:       &domain.UpdateUserResponse{ID: 5, FirstName: "Kemal", Email: "kemal@example.com", Version: 4},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":5,"first_name":"Kemal","last_name":"","phone_number":"","blocked":false,"gender":"","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"0001-01-01T00:00:00Z","location":"","email":"kemal@example.com","profile_photo_url":"","email_verified":false}`,
+		},
+		{
+			name:            "Invalid merge fields",
+			body:            `{"duplicate_id":2,"fields":{"phone_number":"duplicate"}}`,
+			expectedRequest: &domain.MergeUsersRequest{DuplicateID: 2, Fields: map[string]domain.MergeSource{"phone_number": domain.MergeSourceDuplicate}, MergedBy: 1},
+			mockUser:        (*domain.UpdateUserResponse)(nil),
+			mockErr:         fmt.Errorf("%w: %q cannot be merged", errors.ErrInvalidMergeFields, "phone_number"),
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    `{"status":400,"message":"invalid merge fields: \"phone_number\" cannot be merged"}`,
+		},
+		{
+			name:            "Duplicate not found",
+			body:            `{"duplicate_id":9}`,
+			expectedRequest: &domain.MergeUsersRequest{DuplicateID: 9, MergedBy: 1},
+			mockUser:        (*domain.UpdateUserResponse)(nil),
+			mockErr:         errors.ErrUserNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedBody:    `{"status":404,"message":"` + errors.UserNotFound + `"}`,
+		},
+		{
+			name:            "Email taken meanwhile",
+			body:            `{"duplicate_id":2,"fields":{"email":"duplicate"}}`,
+			expectedRequest: &domain.MergeUsersRequest{DuplicateID: 2, Fields: map[string]domain.MergeSource{"email": domain.MergeSourceDuplicate}, MergedBy: 1},
+			mockUser:        (*domain.UpdateUserResponse)(nil),
+			mockErr:         errors.ErrEmailInUse,
+			expectedStatus:  http.StatusConflict,
+			expectedBody:    `{"status":409,"message":"` + errors.EmailAlreadyInUse + `"}`,
+		},
+		{
+			name:           "Invalid body",
+			body:           `{"duplicate_id":"2"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidRequestBody + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockDuplicateService)
+			router := chi.NewRouter()
+			handler := handlers.NewDuplicateHandler(mockService, router)
+			router.Post("/api/user/{id}/merge", handler.MergeUsersHandler)
+
+			if tt.expectedRequest != nil {
+				mockService.On("MergeUsers", int32(5), tt.expectedRequest).Return(tt.mockUser, tt.mockErr)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/5/merge", strings.NewReader(tt.body))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "admin"}))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			if tt.mockUser != nil {
+				assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
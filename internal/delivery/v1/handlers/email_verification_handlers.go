@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type EmailVerificationHandler struct {
+	EmailVerificationService service.EmailVerificationService
+	Router                   *chi.Mux
+}
+
+func NewEmailVerificationHandler(service service.EmailVerificationService, router *chi.Mux) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		EmailVerificationService: service,
+		Router:                   router,
+	}
+}
+
+// @Summary Send email verification
+// @Description Emails the user a signed, single-use link confirming their email address.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Success 202 {object} StatusMessage "Verification email sent"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.EmailMissing
+// @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.EmailAlreadyVerified
+// @Failure 502 {string} string "Bad Gateway: " + errors.EmailDeliveryFailed
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/email-verification [post]
+func (h *EmailVerificationHandler) SendEmailVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.EmailVerificationService.SendVerification(int32(id)); err != nil {
+		switch {
+		case err == errors.ErrUserNotFound:
+			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+		case err == errors.ErrEmailMissing:
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.EmailMissing)
+		case err == errors.ErrEmailAlreadyVerified:
+			utils.RespondWithErrorJSON(w, status.Conflict, errors.EmailAlreadyVerified)
+		case stderrors.Is(err, errors.ErrEmailDeliveryFailed):
+			utils.RespondWithErrorJSON(w, status.BadGateway, errors.EmailDeliveryFailed)
+		default:
+			slog.Error("Error sending email verification: ", utils.Err(err))
+			utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Accepted, StatusMessage{
+		Status:  status.Accepted,
+		Message: "Verification email sent",
+	})
+}
+
+// @Summary Verify email
+// @Description Confirms a user's email address with the token from a verification link. The endpoint is public, since the link is opened by the user.
+// @Tags users
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} StatusMessage "Email verified"
+// @Failure 400 {string} string "Bad Request: " + errors.VerificationTokenMissing or errors.InvalidVerificationLink
+// @Failure 410 {string} string "Gone: " + errors.VerificationLinkExpired
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /email/verify [get]
+func (h *EmailVerificationHandler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.VerificationTokenMissing)
+		return
+	}
+
+	if _, err := h.EmailVerificationService.VerifyEmail(token); err != nil {
+		switch err {
+		case errors.ErrInvalidVerificationLink:
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidVerificationLink)
+		case errors.ErrVerificationLinkExpired:
+			utils.RespondWithErrorJSON(w, status.Gone, errors.VerificationLinkExpired)
+		default:
+			slog.Error("Error verifying email: ", utils.Err(err))
+			utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "Email verified successfully",
+	})
+}
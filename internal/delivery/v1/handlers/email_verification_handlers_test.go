@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendEmailVerificationHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "sent",
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"status":202,"message":"Verification email sent"}`,
+		},
+		{
+			name:           "no email",
+			mockErr:        errors.ErrEmailMissing,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"User has no email address"}`,
+		},
+		{
+			name:           "already verified",
+			mockErr:        errors.ErrEmailAlreadyVerified,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"Email address is already verified"}`,
+		},
+		{
+			name:           "delivery failed",
+			mockErr:        fmt.Errorf("%w: connection refused", errors.ErrEmailDeliveryFailed),
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"status":502,"message":"Failed to send verification email"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emailVerificationService := &mocks.MockEmailVerificationService{}
+			emailVerificationService.On("SendVerification", int32(1)).Return(tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/1/email-verification", nil)
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewEmailVerificationHandler(emailVerificationService, router)
+			router.Post("/api/user/{id}/email-verification", handler.SendEmailVerificationHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestVerifyEmailHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "verified",
+			url:            "/email/verify?token=abc.def",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":200,"message":"Email verified successfully"}`,
+		},
+		{
+			name:           "missing token",
+			url:            "/email/verify",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Verification token is required"}`,
+		},
+		{
+			name:           "used token",
+			url:            "/email/verify?token=abc.def",
+			mockErr:        errors.ErrInvalidVerificationLink,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Invalid or already used verification link"}`,
+		},
+		{
+			name:           "expired token",
+			url:            "/email/verify?token=abc.def",
+			mockErr:        errors.ErrVerificationLinkExpired,
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"status":410,"message":"Verification link has expired"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emailVerificationService := &mocks.MockEmailVerificationService{}
+			emailVerificationService.On("VerifyEmail", "abc.def").Return(&domain.UpdateUserResponse{}, tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewEmailVerificationHandler(emailVerificationService, router)
+			router.Get("/email/verify", handler.VerifyEmailHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag sets a strong entity tag derived from the resource version.
+func setETag(w http.ResponseWriter, version int32) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(int(version))))
+}
+
+// parseIfMatch returns the resource version required by the If-Match header,
+// or nil when the header is absent or "*". It reports false when the header
+// cannot match any version, e.g. a weak or malformed entity tag.
+func parseIfMatch(r *http.Request) (*int32, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	// If-Match uses strong comparison, so weak tags never match.
+	if strings.HasPrefix(header, "W/") {
+		return nil, false
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return nil, false
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+
+	expectedVersion := int32(version)
+	return &expectedVersion, true
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var exportContentTypes = map[domain.ExportFormat]string{
+	domain.ExportFormatCSV:    "text/csv; charset=utf-8",
+	domain.ExportFormatNDJSON: "application/x-ndjson",
+	domain.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+type ExportHandler struct {
+	ExportService service.ExportService
+	Router        *chi.Mux
+}
+
+func NewExportHandler(service service.ExportService, router *chi.Mux) *ExportHandler {
+	return &ExportHandler{
+		ExportService: service,
+		Router:        router,
+	}
+}
+
+// @Summary Export users
+// @Description Streams all users matching the filters of GET /api/user, or of GET /api/user/search when query is given, as CSV, JSON Lines or XLSX. columns selects and orders the exported fields (JSON field names of a user or attr.<key>); all fields are exported by default. Errors after the download has started end the response early.
+// @Tags users
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security jwt
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param columns query string false "Comma-separated columns"
+// @Param query query string false "Search query"
+// @Param tag query []string false "Only users having all of these tags" collectionFormat(multi)
+// @Param attr.{key} query string false "Only users whose custom attribute {key} has this value"
+// @Success 200 {file} file "Export file"
+// @Failure 400 {string} string "Bad Request: " + errors.UnsupportedExportFormat + ", invalid export columns, " + errors.InvalidTagName + ", invalid attributes or invalid filter"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/export [get]
+func (h *ExportHandler) ExportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := userFilterFromRequest(r)
+	if err != nil {
+		respondWithFilterError(w, err)
+		return
+	}
+
+	request := domain.ExportRequest{
+		Format: domain.ExportFormat(r.URL.Query().Get("format")),
+		Query:  r.URL.Query().Get("query"),
+		Filter: filter,
+	}
+	if request.Format == "" {
+		request.Format = domain.ExportFormatCSV
+	}
+
+	for _, column := range strings.Split(r.URL.Query().Get("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			request.Columns = append(request.Columns, column)
+		}
+	}
+
+	download := &downloadResponseWriter{
+		ResponseWriter: w,
+		contentType:    exportContentTypes[request.Format],
+		filename:       "users." + string(request.Format),
+	}
+
+	err = h.ExportService.ExportUsers(r.Context(), &request, download)
+	if err != nil {
+		if download.started {
+			// The status line is gone, all that is left is to cut the
+			// download short.
+			if r.Context().Err() == nil {
+				slog.Error("Error exporting users: ", utils.Err(err))
+			}
+			return
+		}
+
+		if respondWithFilterError(w, err) {
+			return
+		}
+
+		switch {
+		case err == errors.ErrUnsupportedExportFormat:
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.UnsupportedExportFormat)
+		case stderrors.Is(err, errors.ErrInvalidExportColumns):
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+		default:
+			slog.Error("Error exporting users: ", utils.Err(err))
+			utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		}
+		return
+	}
+
+	// Empty JSON Lines exports write nothing.
+	download.start()
+}
+
+// downloadResponseWriter sends the download headers with the first write, so
+// that errors occurring before any output can still get an error response.
+type downloadResponseWriter struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *downloadResponseWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	w.Header().Set("Content-Type", w.contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+	w.WriteHeader(status.OK)
+}
+
+func (w *downloadResponseWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportUsersHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		url                 string
+		expectedRequest     *domain.ExportRequest
+		mockOutput          string
+		mockErr             error
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name: "CSV by default",
+			url:  "/api/user/export?columns=id,+first_name,&tag=vip&query=kem",
+			expectedRequest: &domain.ExportRequest{
+				Format:  domain.ExportFormatCSV,
+				Columns: []string{"id", "first_name"},
+				Query:   "kem",
+				Filter:  &domain.UserFilter{Tags: []string{"vip"}},
+			},
+			mockOutput:          "id,first_name\n1,Kemal\n",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,first_name\n1,Kemal\n",
+		},
+		{
+			name:                "Empty JSON Lines export",
+			url:                 "/api/user/export?format=ndjson",
+			expectedRequest:     &domain.ExportRequest{Format: domain.ExportFormatNDJSON},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+		},
+		{
+			name:                "Invalid columns",
+			url:                 "/api/user/export?columns=password",
+			expectedRequest:     &domain.ExportRequest{Format: domain.ExportFormatCSV, Columns: []string{"password"}},
+			mockErr:             fmt.Errorf("%w: %q is not an export column", errors.ErrInvalidExportColumns, "password"),
+			expectedStatus:      http.StatusBadRequest,
+			expectedContentType: "application/json",
+			expectedBody:        `{"status":400,"message":"invalid export columns: \"password\" is not an export column"}`,
+		},
+		{
+			name:                "Unsupported format",
+			url:                 "/api/user/export?format=pdf",
+			expectedRequest:     &domain.ExportRequest{Format: "pdf"},
+			mockErr:             errors.ErrUnsupportedExportFormat,
+			expectedStatus:      http.StatusBadRequest,
+			expectedContentType: "application/json",
+			expectedBody:        `{"status":400,"message":"` + errors.UnsupportedExportFormat + `"}`,
+		},
+		{
+			name:                "Failure after the download started",
+			url:                 "/api/user/export",
+			expectedRequest:     &domain.ExportRequest{Format: domain.ExportFormatCSV},
+			mockOutput:          "id\n1\n",
+			mockErr:             stderrors.New("connection reset"),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id\n1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockExportService)
+			router := chi.NewRouter()
+			handler := handlers.NewExportHandler(mockService, router)
+			router.Get("/api/user/export", handler.ExportUsersHandler)
+
+			mockService.On("ExportUsers", mock.Anything, tt.expectedRequest, mock.Anything).Return(tt.mockOutput, tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			if tt.expectedContentType != "application/json" {
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+				assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+			} else {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
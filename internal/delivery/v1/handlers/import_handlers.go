@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/spreadsheet"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportRequestSize bounds the whole multipart body. The configured import
+// file size limit is enforced by the service.
+const maxImportRequestSize = 64 << 20
+
+// maxImportMemory is the part of the upload kept in memory, the rest is
+// buffered in a temporary file.
+const maxImportMemory = 8 << 20
+
+type ImportHandler struct {
+	ImportService service.ImportService
+	Router        *chi.Mux
+}
+
+func NewImportHandler(service service.ImportService, router *chi.Mux) *ImportHandler {
+	return &ImportHandler{
+		ImportService: service,
+		Router:        router,
+	}
+}
+
+// @Summary Import users
+// @Description Imports users from a CSV or XLSX file (first sheet). Every row is validated like POST /api/user; invalid rows are reported and skipped. The optional mapping field is a JSON object from import field (first_name, last_name, phone_number, gender, date_of_birth, location, email or attr.<key>) to column header; without it headers are matched to field names. With dry_run nothing is written. With upsert_by_phone rows whose phone number is registered update that user's mapped fields.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Security jwt
+// @Param file formData file true "CSV or XLSX file"
+// @Param mapping formData string false "Column mapping as a JSON object"
+// @Param dry_run query bool false "Validate only"
+// @Param upsert_by_phone query bool false "Update users with a registered phone number"
+// @Success 200 {object} domain.ImportReport "Import report"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidURLParameters, errors.ImportFileMissing, errors.InvalidImportMapping, errors.InvalidImportFile or errors.TooManyImportRows
+// @Failure 413 {string} string "Request Entity Too Large: " + errors.ImportFileTooLarge
+// @Failure 415 {string} string "Unsupported Media Type: " + errors.MultipartRequired or errors.UnsupportedImportFormat
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/import [post]
+func (h *ImportHandler) ImportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	var options domain.ImportOptions
+	var err error
+
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		if options.DryRun, err = strconv.ParseBool(value); err != nil {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidURLParameters)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("upsert_by_phone"); value != "" {
+		if options.UpsertByPhone, err = strconv.ParseBool(value); err != nil {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidURLParameters)
+			return
+		}
+	}
+	options.ImportedBy, _ = middleware.AdminIDFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportRequestSize)
+
+	if err := r.ParseMultipartForm(maxImportMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case stderrors.As(err, &maxBytesErr):
+			utils.RespondWithErrorJSON(w, status.RequestEntityTooLarge, errors.ImportFileTooLarge)
+		case err == http.ErrNotMultipart:
+			utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.MultipartRequired)
+		default:
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidImportMapping)
+			return
+		}
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.ImportFileMissing)
+		return
+	}
+	defer file.Close()
+
+	format, err := spreadsheet.DetectFormat(header.Filename, header.Header.Get("Content-Type"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.UnsupportedImportFormat)
+		return
+	}
+
+	report, err := h.ImportService.ImportUsers(file, format, &options)
+	if err != nil {
+		switch {
+		case err == errors.ErrImportFileTooLarge:
+			utils.RespondWithErrorJSON(w, status.RequestEntityTooLarge, errors.ImportFileTooLarge)
+		case err == errors.ErrUnsupportedImportFormat:
+			utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.UnsupportedImportFormat)
+		case stderrors.Is(err, errors.ErrInvalidImportMapping),
+			stderrors.Is(err, errors.ErrInvalidImportFile),
+			stderrors.Is(err, errors.ErrTooManyImportRows):
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+		default:
+			slog.Error("Error importing users: ", utils.Err(err))
+			utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, report)
+}
@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/spreadsheet"
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func multipartImportRequest(target, filename, mapping string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if mapping != "" {
+		writer.WriteField("mapping", mapping)
+	}
+	if filename != "" {
+		part, _ := writer.CreateFormFile("file", filename)
+		part.Write([]byte("phone_number\n+99362008971\n"))
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportUsersHandler(t *testing.T) {
+	report := &domain.ImportReport{
+		DryRun:    true,
+		TotalRows: 2,
+		Valid:     1,
+		Failed:    1,
+		Errors:    []domain.ImportRowError{{Row: 3, Field: "phone_number", Message: errors.InvalidPhoneNumberFormat}},
+	}
+
+	tests := []struct {
+		name            string
+		request         func() *http.Request
+		expectedOptions *domain.ImportOptions
+		expectedFormat  spreadsheet.Format
+		mockErr         error
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{
+			name: "Dry run with mapping",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import?dry_run=true&upsert_by_phone=1", "partners.csv", `{"phone_number":"Phone"}`)
+			},
+			expectedOptions: &domain.ImportOptions{
+				Mapping:       domain.ImportMapping{"phone_number": "Phone"},
+				DryRun:        true,
+				UpsertByPhone: true,
+				ImportedBy:    1,
+			},
+			expectedFormat: spreadsheet.FormatCSV,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"dry_run":true,"total_rows":2,"valid":1,"created":0,"updated":0,"failed":1,"errors":[{"row":3,"field":"phone_number","message":"Invalid phone number format"}]}`,
+		},
+		{
+			name: "Invalid mapping reported by service",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import", "partners.xlsx", "")
+			},
+			expectedOptions: &domain.ImportOptions{ImportedBy: 1},
+			expectedFormat:  spreadsheet.FormatXLSX,
+			mockErr:         fmt.Errorf("%w: phone_number column is required", errors.ErrInvalidImportMapping),
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    `{"status":400,"message":"invalid column mapping: phone_number column is required"}`,
+		},
+		{
+			name: "File too large",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import", "partners.csv", "")
+			},
+			expectedOptions: &domain.ImportOptions{ImportedBy: 1},
+			expectedFormat:  spreadsheet.FormatCSV,
+			mockErr:         errors.ErrImportFileTooLarge,
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "Invalid dry_run",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import?dry_run=maybe", "partners.csv", "")
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidURLParameters + `"}`,
+		},
+		{
+			name: "Malformed mapping",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import", "partners.csv", `["phone_number"]`)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidImportMapping + `"}`,
+		},
+		{
+			name: "Missing file",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import", "", "")
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unsupported format",
+			request: func() *http.Request {
+				return multipartImportRequest("/api/user/import", "partners.ods", "")
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"status":415,"message":"` + errors.UnsupportedImportFormat + `"}`,
+		},
+		{
+			name: "Not multipart",
+			request: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "/api/user/import", strings.NewReader("phone_number\n"))
+				req.Header.Set("Content-Type", "text/csv")
+				return req
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockImportService)
+			router := chi.NewRouter()
+			handler := handlers.NewImportHandler(mockService, router)
+			router.Post("/api/user/import", handler.ImportUsersHandler)
+
+			if tt.expectedOptions != nil {
+				response := report
+				if tt.mockErr != nil {
+					response = nil
+				}
+				mockService.On("ImportUsers", mock.Anything, tt.expectedFormat, tt.expectedOptions).Return(response, tt.mockErr)
+			}
+
+			req := tt.request()
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "admin"}))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
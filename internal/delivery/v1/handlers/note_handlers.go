@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NoteHandler struct {
+	NoteService service.NoteService
+	Router      *chi.Mux
+}
+
+func NewNoteHandler(service service.NoteService, router *chi.Mux) *NoteHandler {
+	return &NoteHandler{
+		NoteService: service,
+		Router:      router,
+	}
+}
+
+// @Summary List user notes
+// @Description Lists the internal admin notes on a user, pinned notes first. Notes restricted to super admins are only listed for super admins.
+// @Tags notes
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} domain.NotesListResponse "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/notes [get]
+func (h *NoteHandler) GetNotesHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	notes, totalNotes, err := h.NoteService.GetNotes(int32(id), page, pageSize, actor)
+	if err != nil {
+		respondWithNoteError(w, err)
+		return
+	}
+
+	var previousPage int
+	if page > 1 {
+		previousPage = page - 1
+	} else {
+		previousPage = 1
+	}
+
+	lastPage := (totalNotes + pageSize - 1) / pageSize
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	nextPage := page + 1
+	if page >= lastPage {
+		nextPage = lastPage
+	}
+
+	utils.RespondWithJSON(w, status.OK, domain.NotesListResponse{
+		Notes:       notes,
+		CurrentPage: page,
+		PrevPage:    previousPage,
+		NextPage:    nextPage,
+		FirstPage:   1,
+		LastPage:    lastPage,
+	})
+}
+
+// @Summary Add user note
+// @Description Adds an internal markdown note to a user. Only super admins may restrict a note to super admins.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param request body domain.NoteRequest true "Note"
+// @Success 201 {object} domain.Note "Created"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID, errors.InvalidRequestBody, errors.NoteBodyRequired, errors.NoteTooLong or errors.InvalidNoteVisibility
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.InsufficientPermission
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/notes [post]
+func (h *NoteHandler) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	note, err := h.NoteService.CreateNote(int32(id), &request, actor)
+	if err != nil {
+		respondWithNoteError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Created, note)
+}
+
+// @Summary Edit user note
+// @Description Replaces the body and, optionally, the visibility of a note. Admins may only edit their own notes.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param noteID path int true "Note ID"
+// @Param request body domain.NoteRequest true "Note"
+// @Success 200 {object} domain.Note "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID, errors.InvalidRequestBody, errors.NoteBodyRequired, errors.NoteTooLong or errors.InvalidNoteVisibility
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.NoteNotAuthor or errors.InsufficientPermission
+// @Failure 404 {string} string "Not Found: " + errors.NoteNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/notes/{noteID} [put]
+func (h *NoteHandler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, noteID, ok := noteIDsFromRequest(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	note, err := h.NoteService.UpdateNote(id, noteID, &request, actor)
+	if err != nil {
+		respondWithNoteError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, note)
+}
+
+// @Summary Pin or unpin user note
+// @Description Pins a note to the top of the user's notes, or unpins it. Any admin who can read the note may pin it.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param noteID path int true "Note ID"
+// @Param request body domain.PinNoteRequest true "Pinned state"
+// @Success 200 {object} domain.Note "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 404 {string} string "Not Found: " + errors.NoteNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/notes/{noteID}/pin [put]
+func (h *NoteHandler) PinNoteHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, noteID, ok := noteIDsFromRequest(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.PinNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	note, err := h.NoteService.SetNotePinned(id, noteID, &request, actor)
+	if err != nil {
+		respondWithNoteError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, note)
+}
+
+// @Summary Delete user note
+// @Description Deletes a note. Admins may delete their own notes; super admins may delete any note.
+// @Tags notes
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param noteID path int true "Note ID"
+// @Success 200 {object} StatusMessage "Deleted"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.InsufficientPermission
+// @Failure 404 {string} string "Not Found: " + errors.NoteNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/notes/{noteID} [delete]
+func (h *NoteHandler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, noteID, ok := noteIDsFromRequest(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.NoteService.DeleteNote(id, noteID, actor); err != nil {
+		respondWithNoteError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "Note deleted successfully",
+	})
+}
+
+func noteIDsFromRequest(r *http.Request) (int32, int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "noteID"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return int32(id), int32(noteID), true
+}
+
+func respondWithNoteError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrUserNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+	case errors.ErrNoteNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.NoteNotFound)
+	case errors.ErrNoteBodyRequired:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.NoteBodyRequired)
+	case errors.ErrNoteTooLong:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.NoteTooLong)
+	case errors.ErrInvalidNoteVisibility:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidNoteVisibility)
+	case errors.ErrNoteNotAuthor:
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.NoteNotAuthor)
+	case errors.ErrInsufficientPermission:
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.InsufficientPermission)
+	default:
+		slog.Error("Error handling note: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
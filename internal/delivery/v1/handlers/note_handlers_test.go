@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNotesHandler(t *testing.T) {
+	createdAt := time.Date(2024, time.October, 3, 9, 30, 0, 0, time.UTC)
+	authorID := int32(1)
+
+	noteService := &mocks.MockNoteService{}
+	noteService.On("GetNotes", int32(5), 2, 1, domain.Actor{AdminID: 1, Role: "super_admin"}).Return(&domain.NotesList{Notes: []domain.Note{
+		{ID: 9, UserID: 5, AuthorID: &authorID, AuthorUsername: "root", Body: "Promised to unblock after ID check", Pinned: true, Visibility: domain.NoteVisibilitySuperAdmin, CreatedAt: createdAt, UpdatedAt: createdAt},
+	}}, 3, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/user/5/notes?page=2&pageSize=1", nil)
+	req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "super_admin"}))
+
+	rr := httptest.NewRecorder()
+	router := chi.NewRouter()
+	handler := handlers.NewNoteHandler(noteService, router)
+	router.Get("/api/user/{id}/notes", handler.GetNotesHandler)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"notes":{"notes":[{"id":9,"user_id":5,"author_id":1,"author_username":"root","body":"Promised to unblock after ID check","pinned":true,"visibility":"super_admin","created_at":"2024-10-03T09:30:00Z","updated_at":"2024-10-03T09:30:00Z"}]},"currentPage":2,"previousPage":1,"nextPage":3,"firstPage":1,"lastPage":3}`, strings.TrimSpace(rr.Body.String()))
+}
+
+func TestUpdateNoteHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "not the author",
+			mockErr:        errors.ErrNoteNotAuthor,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":403,"message":"` + errors.NoteNotAuthor + `"}`,
+		},
+		{
+			name:           "not found",
+			mockErr:        errors.ErrNoteNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.NoteNotFound + `"}`,
+		},
+		{
+			name:           "empty body",
+			mockErr:        errors.ErrNoteBodyRequired,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.NoteBodyRequired + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noteService := &mocks.MockNoteService{}
+			noteService.On("UpdateNote", int32(5), int32(9), &domain.NoteRequest{Body: "edited"}, domain.Actor{AdminID: 2, Role: "admin"}).Return((*domain.Note)(nil), tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodPut, "/api/user/5/notes/9", strings.NewReader(`{"body":"edited"}`))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(2), "role": "admin"}))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewNoteHandler(noteService, router)
+			router.Put("/api/user/{id}/notes/{noteID}", handler.UpdateNoteHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
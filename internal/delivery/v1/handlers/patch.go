@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/patch"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	stderrors "errors"
+	"mime"
+	"net/http"
+)
+
+const acceptPatchHeader = string(domain.MergePatchFormat) + ", " + string(domain.JSONPatchFormat)
+
+// patchFormatFromRequest determines the patch format from the Content-Type
+// header. Plain application/json is treated as a merge patch.
+func patchFormatFromRequest(r *http.Request) (domain.PatchFormat, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", false
+	}
+
+	switch mediaType {
+	case string(domain.MergePatchFormat), "application/json":
+		return domain.MergePatchFormat, true
+	case string(domain.JSONPatchFormat):
+		return domain.JSONPatchFormat, true
+	default:
+		return "", false
+	}
+}
+
+// respondWithPatchError writes the response for errors produced while
+// applying a patch document. It reports whether err was such an error.
+func respondWithPatchError(w http.ResponseWriter, err error) bool {
+	switch {
+	case stderrors.Is(err, patch.ErrTestFailed):
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.PatchTestFailed)
+	case stderrors.Is(err, patch.ErrInvalidPatch),
+		stderrors.Is(err, errors.ErrFieldNotPatchable),
+		stderrors.Is(err, errors.ErrFieldRequired):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PhoneChangeHandler struct {
+	PhoneChangeService service.PhoneChangeService
+	Router             *chi.Mux
+}
+
+func NewPhoneChangeHandler(service service.PhoneChangeService, router *chi.Mux) *PhoneChangeHandler {
+	return &PhoneChangeHandler{
+		PhoneChangeService: service,
+		Router:             router,
+	}
+}
+
+// @Summary Start phone number change
+// @Description Starts changing a user's phone number by sending a verification code to the new number. The number may be given in local or international format and is stored in E.164. The change is committed once the code is verified. A previous pending change is cancelled.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param request body domain.StartPhoneChangeRequest true "New phone number"
+// @Success 202 {object} domain.PhoneChange "Verification code sent"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody or errors.InvalidPhoneNumberFormat or errors.PhoneCountryNotAllowed or errors.PhoneNumberUnchanged
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.PhoneNumberAlreadyInUse
+// @Failure 502 {string} string "Bad Gateway: " + errors.SMSDeliveryFailed
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/phone-change [post]
+func (h *PhoneChangeHandler) StartPhoneChangeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.StartPhoneChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	adminID, ok := middleware.AdminIDFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+	request.RequestedBy = adminID
+
+	change, err := h.PhoneChangeService.StartPhoneChange(int32(id), &request)
+	if err != nil {
+		respondWithPhoneChangeError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Accepted, change)
+}
+
+// @Summary Verify phone number change
+// @Description Commits a user's pending phone number change when the code sent to the new number matches.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param request body domain.VerifyPhoneChangeRequest true "Verification code"
+// @Success 200 {object} domain.UpdateUserResponse "Phone number changed"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody or errors.InvalidVerificationCode
+// @Failure 404 {string} string "Not Found: " + errors.PhoneChangeNotFound
+// @Failure 409 {string} string "Conflict: " + errors.PhoneNumberAlreadyInUse
+// @Failure 410 {string} string "Gone: " + errors.PhoneChangeExpired
+// @Failure 429 {string} string "Too Many Requests: " + errors.TooManyVerificationAttempts
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/phone-change/verify [post]
+func (h *PhoneChangeHandler) VerifyPhoneChangeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.VerifyPhoneChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Code == "" {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	user, err := h.PhoneChangeService.VerifyPhoneChange(int32(id), &request)
+	if err != nil {
+		respondWithPhoneChangeError(w, err)
+		return
+	}
+
+	setETag(w, user.Version)
+	utils.RespondWithJSON(w, status.OK, user)
+}
+
+// @Summary Force phone number change
+// @Description Changes a user's phone number without verification. Only super admins may force a change, and a reason is required.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param request body domain.ForcePhoneChangeRequest true "New phone number and reason"
+// @Success 200 {object} domain.UpdateUserResponse "Phone number changed"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody or errors.InvalidPhoneNumberFormat or errors.PhoneCountryNotAllowed or errors.ReasonRequired or errors.PhoneNumberUnchanged
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.InsufficientPermission
+// @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.PhoneNumberAlreadyInUse
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/phone-change/force [post]
+func (h *PhoneChangeHandler) ForcePhoneChangeHandler(w http.ResponseWriter, r *http.Request) {
+	role, _ := middleware.RoleFromContext(r.Context())
+	if role != "super_admin" {
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.InsufficientPermission)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.ForcePhoneChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.ReasonRequired)
+		return
+	}
+
+	adminID, ok := middleware.AdminIDFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+	request.ForcedBy = adminID
+
+	user, err := h.PhoneChangeService.ForcePhoneChange(int32(id), &request)
+	if err != nil {
+		respondWithPhoneChangeError(w, err)
+		return
+	}
+
+	setETag(w, user.Version)
+	utils.RespondWithJSON(w, status.OK, user)
+}
+
+func respondWithPhoneChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case err == errors.ErrUserNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+	case err == errors.ErrPhoneChangeNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.PhoneChangeNotFound)
+	case err == errors.ErrInvalidPhoneNumber:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidPhoneNumberFormat)
+	case err == errors.ErrPhoneCountryNotAllowed:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.PhoneCountryNotAllowed)
+	case err == errors.ErrPhoneNumberUnchanged:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.PhoneNumberUnchanged)
+	case err == errors.ErrPhoneNumberInUse:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.PhoneNumberAlreadyInUse)
+	case err == errors.ErrInvalidVerificationCode:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidVerificationCode)
+	case err == errors.ErrPhoneChangeExpired:
+		utils.RespondWithErrorJSON(w, status.Gone, errors.PhoneChangeExpired)
+	case err == errors.ErrTooManyVerificationAttempts:
+		utils.RespondWithErrorJSON(w, status.TooManyRequests, errors.TooManyVerificationAttempts)
+	case stderrors.Is(err, errors.ErrSMSDeliveryFailed):
+		utils.RespondWithErrorJSON(w, status.BadGateway, errors.SMSDeliveryFailed)
+	default:
+		slog.Error("Error changing phone number: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
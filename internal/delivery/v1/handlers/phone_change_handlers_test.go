@@ -0,0 +1,226 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStartPhoneChangeHandler(t *testing.T) {
+	tests := []struct {
+		name                   string
+		requestBody            string
+		mockPhoneChangeService func() *mocks.MockPhoneChangeService
+		expectedStatus         int
+		expectedBody           string
+	}{
+		{
+			name:        "code sent",
+			requestBody: `{"phone_number":"+99365123456"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				phoneChangeService := &mocks.MockPhoneChangeService{}
+				phoneChangeService.On("StartPhoneChange", int32(1), &domain.StartPhoneChangeRequest{PhoneNumber: "+99365123456", RequestedBy: 7}).Return(&domain.PhoneChange{
+					ID:             3,
+					UserID:         1,
+					OldPhoneNumber: "+99362008971",
+					NewPhoneNumber: "+99365123456",
+					Status:         domain.PhoneChangePending,
+				}, nil)
+				return phoneChangeService
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"id":3,"user_id":1,"old_phone_number":"+99362008971","new_phone_number":"+99365123456","status":"pending","requested_by":null,"requested_at":"0001-01-01T00:00:00Z","attempts":0}`,
+		},
+		{
+			name:        "invalid phone number",
+			requestBody: `{"phone_number":"12345"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				phoneChangeService := &mocks.MockPhoneChangeService{}
+				phoneChangeService.On("StartPhoneChange", int32(1), mock.Anything).Return(&domain.PhoneChange{}, errors.ErrInvalidPhoneNumber)
+				return phoneChangeService
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Invalid phone number format"}`,
+		},
+		{
+			name:        "country not allowed",
+			requestBody: `{"phone_number":"+77011234567"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				phoneChangeService := &mocks.MockPhoneChangeService{}
+				phoneChangeService.On("StartPhoneChange", int32(1), mock.Anything).Return(&domain.PhoneChange{}, errors.ErrPhoneCountryNotAllowed)
+				return phoneChangeService
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Phone numbers from this country are not accepted"}`,
+		},
+		{
+			name:        "phone number in use",
+			requestBody: `{"phone_number":"+99365123456"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				phoneChangeService := &mocks.MockPhoneChangeService{}
+				phoneChangeService.On("StartPhoneChange", int32(1), mock.Anything).Return(&domain.PhoneChange{}, errors.ErrPhoneNumberInUse)
+				return phoneChangeService
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"Phone number already in use"}`,
+		},
+		{
+			name:        "sms delivery failed",
+			requestBody: `{"phone_number":"+99365123456"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				phoneChangeService := &mocks.MockPhoneChangeService{}
+				phoneChangeService.On("StartPhoneChange", int32(1), mock.Anything).Return(&domain.PhoneChange{}, fmt.Errorf("%w: timeout", errors.ErrSMSDeliveryFailed))
+				return phoneChangeService
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"status":502,"message":"Failed to send verification code"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/1/phone-change", strings.NewReader(tt.requestBody))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(7), "role": "admin"}))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewPhoneChangeHandler(tt.mockPhoneChangeService(), router)
+			router.Post("/api/user/{id}/phone-change", handler.StartPhoneChangeHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestVerifyPhoneChangeHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invalid code",
+			mockErr:        errors.ErrInvalidVerificationCode,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Invalid verification code"}`,
+		},
+		{
+			name:           "expired code",
+			mockErr:        errors.ErrPhoneChangeExpired,
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"status":410,"message":"Verification code has expired"}`,
+		},
+		{
+			name:           "too many attempts",
+			mockErr:        errors.ErrTooManyVerificationAttempts,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `{"status":429,"message":"Too many verification attempts, start a new phone number change"}`,
+		},
+		{
+			name:           "no pending change",
+			mockErr:        errors.ErrPhoneChangeNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"No pending phone number change"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phoneChangeService := &mocks.MockPhoneChangeService{}
+			phoneChangeService.On("VerifyPhoneChange", int32(1), &domain.VerifyPhoneChangeRequest{Code: "123456"}).Return(&domain.UpdateUserResponse{}, tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/1/phone-change/verify", strings.NewReader(`{"code":"123456"}`))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewPhoneChangeHandler(phoneChangeService, router)
+			router.Post("/api/user/{id}/phone-change/verify", handler.VerifyPhoneChangeHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestForcePhoneChangeHandler(t *testing.T) {
+	tests := []struct {
+		name                   string
+		role                   string
+		requestBody            string
+		mockPhoneChangeService func() *mocks.MockPhoneChangeService
+		expectedStatus         int
+		expectedBody           string
+	}{
+		{
+			name:        "forced by super admin",
+			role:        "super_admin",
+			requestBody: `{"phone_number":"+99365123456","reason":"Lost SIM card"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				phoneChangeService := &mocks.MockPhoneChangeService{}
+				phoneChangeService.On("ForcePhoneChange", int32(1), &domain.ForcePhoneChangeRequest{PhoneNumber: "+99365123456", Reason: "Lost SIM card", ForcedBy: 7}).Return(&domain.UpdateUserResponse{
+					ID:          1,
+					PhoneNumber: "+99365123456",
+					Version:     4,
+				}, nil)
+				return phoneChangeService
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1,"first_name":"","last_name":"","phone_number":"+99365123456","blocked":false,"gender":"","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"0001-01-01T00:00:00Z","location":"","email":"","profile_photo_url":"","email_verified":false}`,
+		},
+		{
+			name:        "admin cannot force",
+			role:        "admin",
+			requestBody: `{"phone_number":"+99365123456","reason":"Lost SIM card"}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				return &mocks.MockPhoneChangeService{}
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":403,"message":"Insufficient permissions"}`,
+		},
+		{
+			name:        "missing reason",
+			role:        "super_admin",
+			requestBody: `{"phone_number":"+99365123456","reason":"  "}`,
+			mockPhoneChangeService: func() *mocks.MockPhoneChangeService {
+				return &mocks.MockPhoneChangeService{}
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Reason is required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/1/phone-change/force", strings.NewReader(tt.requestBody))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(7), "role": tt.role}))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewPhoneChangeHandler(tt.mockPhoneChangeService(), router)
+			router.Post("/api/user/{id}/phone-change/force", handler.ForcePhoneChangeHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
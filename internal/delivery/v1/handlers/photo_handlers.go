@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxPhotoRequestSize bounds the whole multipart body, including any other
+// parts sent before the photo. The configured photo size limit is enforced by
+// the service.
+const maxPhotoRequestSize = 32 << 20
+
+type PhotoHandler struct {
+	PhotoService service.PhotoService
+	Router       *chi.Mux
+}
+
+func NewPhotoHandler(service service.PhotoService, router *chi.Mux) *PhotoHandler {
+	return &PhotoHandler{
+		PhotoService: service,
+		Router:       router,
+	}
+}
+
+// @Summary Upload profile photo
+// @Description Uploads a JPEG, PNG or GIF image as the user's profile photo. The image type is detected from its content. Standard renditions (large, medium, small) are stored and the large one becomes profile_photo_url.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param photo formData file true "Photo"
+// @Success 200 {object} domain.UploadPhotoResponse "Photo uploaded"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID, errors.PhotoMissing or errors.InvalidPhoto
+// @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 413 {string} string "Request Entity Too Large: " + errors.PhotoTooLarge
+// @Failure 415 {string} string "Unsupported Media Type: " + errors.MultipartRequired or errors.UnsupportedPhotoType
+// @Failure 502 {string} string "Bad Gateway: " + errors.PhotoStorageFailed
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/photo [post]
+func (h *PhotoHandler) UploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoRequestSize)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.MultipartRequired)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.PhotoMissing)
+			return
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if stderrors.As(err, &maxBytesErr) {
+				utils.RespondWithErrorJSON(w, status.RequestEntityTooLarge, errors.PhotoTooLarge)
+				return
+			}
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.PhotoMissing)
+			return
+		}
+
+		if part.FormName() != "photo" {
+			continue
+		}
+
+		photo, err := h.PhotoService.UploadPhoto(int32(id), part)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			switch {
+			case err == errors.ErrUserNotFound:
+				utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+			case err == errors.ErrPhotoTooLarge, stderrors.As(err, &maxBytesErr):
+				utils.RespondWithErrorJSON(w, status.RequestEntityTooLarge, errors.PhotoTooLarge)
+			case err == errors.ErrUnsupportedPhotoType:
+				utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.UnsupportedPhotoType)
+			case err == errors.ErrInvalidPhoto:
+				utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidPhoto)
+			case stderrors.Is(err, errors.ErrPhotoStorageFailed):
+				slog.Error("Error storing photo: ", utils.Err(err))
+				utils.RespondWithErrorJSON(w, status.BadGateway, errors.PhotoStorageFailed)
+			default:
+				slog.Error("Error uploading photo: ", utils.Err(err))
+				utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+			}
+			return
+		}
+
+		utils.RespondWithJSON(w, status.OK, photo)
+		return
+	}
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func multipartPhotoRequest(field string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("note", "ignored")
+	part, _ := writer.CreateFormFile(field, "photo.png")
+	part.Write([]byte("image bytes"))
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/user/1/photo", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadPhotoHandler(t *testing.T) {
+	uploaded := &domain.UploadPhotoResponse{
+		ProfilePhotoURL: "http://localhost:8080/media/users/1/photo/abc/large.jpg",
+		Thumbnails: map[string]string{
+			"large": "http://localhost:8080/media/users/1/photo/abc/large.jpg",
+			"small": "http://localhost:8080/media/users/1/photo/abc/small.jpg",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		request        func() *http.Request
+		mockResponse   *domain.UploadPhotoResponse
+		mockErr        error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "uploaded",
+			request:        func() *http.Request { return multipartPhotoRequest("photo") },
+			mockResponse:   uploaded,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"profile_photo_url":"http://localhost:8080/media/users/1/photo/abc/large.jpg","thumbnails":{"large":"http://localhost:8080/media/users/1/photo/abc/large.jpg","small":"http://localhost:8080/media/users/1/photo/abc/small.jpg"}}`,
+		},
+		{
+			name:           "missing photo field",
+			request:        func() *http.Request { return multipartPhotoRequest("avatar") },
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Multipart field \"photo\" is required"}`,
+		},
+		{
+			name: "not multipart",
+			request: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "/api/user/1/photo", strings.NewReader("{}"))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"status":415,"message":"Request must be multipart/form-data"}`,
+		},
+		{
+			name:           "too large",
+			request:        func() *http.Request { return multipartPhotoRequest("photo") },
+			mockErr:        errors.ErrPhotoTooLarge,
+			expectCall:     true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `{"status":413,"message":"Photo exceeds the maximum file size or dimensions"}`,
+		},
+		{
+			name:           "unsupported type",
+			request:        func() *http.Request { return multipartPhotoRequest("photo") },
+			mockErr:        errors.ErrUnsupportedPhotoType,
+			expectCall:     true,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"status":415,"message":"Photo must be a JPEG, PNG or GIF image"}`,
+		},
+		{
+			name:           "storage failed",
+			request:        func() *http.Request { return multipartPhotoRequest("photo") },
+			mockErr:        fmt.Errorf("%w: connection refused", errors.ErrPhotoStorageFailed),
+			expectCall:     true,
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"status":502,"message":"Failed to store photo"}`,
+		},
+		{
+			name:           "user not found",
+			request:        func() *http.Request { return multipartPhotoRequest("photo") },
+			mockErr:        errors.ErrUserNotFound,
+			expectCall:     true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"User not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			photoService := &mocks.MockPhotoService{}
+			if tt.expectCall {
+				photoService.On("UploadPhoto", int32(1), mock.Anything).Run(func(args mock.Arguments) {
+					content, _ := io.ReadAll(args.Get(1).(io.Reader))
+					assert.Equal(t, "image bytes", string(content))
+				}).Return(tt.mockResponse, tt.mockErr)
+			}
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewPhotoHandler(photoService, router)
+			router.Post("/api/user/{id}/photo", handler.UploadPhotoHandler)
+			router.ServeHTTP(rr, tt.request())
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+			photoService.AssertExpectations(t)
+		})
+	}
+}
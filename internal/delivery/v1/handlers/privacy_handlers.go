@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PrivacyHandler struct {
+	PrivacyService service.PrivacyService
+	Router         *chi.Mux
+}
+
+func NewPrivacyHandler(service service.PrivacyService, router *chi.Mux) *PrivacyHandler {
+	return &PrivacyHandler{
+		PrivacyService: service,
+		Router:         router,
+	}
+}
+
+// @Summary Export user data
+// @Description Downloads everything held about a user as a ZIP archive for a data subject access request: manifest.json, profile.json, blocks.json, history.json, notes.json with restricted notes included, and events.json with the blocks, phone changes, email verifications and merges of the user.
+// @Description Only super admins may export user data.
+// @Tags users
+// @Produce application/zip
+// @Security jwt
+// @Param id path int true "User ID"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.SuperAdminRequired
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/data-export [get]
+func (h *PrivacyHandler) ExportUserDataHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	data, err := h.PrivacyService.GetUserData(int32(id), actor)
+	if err != nil {
+		respondWithPrivacyError(w, err)
+		return
+	}
+
+	download := &downloadResponseWriter{
+		ResponseWriter: w,
+		contentType:    "application/zip",
+		filename:       fmt.Sprintf("user-%d-data.zip", id),
+	}
+	if err := h.PrivacyService.WriteUserDataArchive(data, actor, download); err != nil {
+		if download.started {
+			// The status line is gone, all that is left is to cut the
+			// download short.
+			slog.Error("Error exporting user data: ", utils.Err(err))
+			return
+		}
+		respondWithPrivacyError(w, err)
+	}
+}
+
+// @Summary Anonymize user
+// @Description Irreversibly removes the personal data of a user in answer to an erasure request, keeping the record as a tombstone for statistics.
+// @Description Names, phone number, email, profile photo and custom attributes are cleared on the user and on the users merged into it, and scrubbed from the field history, phone changes and email verifications. Notes are deleted, and block notes and phone change reasons cleared.
+// @Description Gender, location, dates, block reasons and tags are kept. Only super admins may anonymize users.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.UserAnonymization "Anonymized"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.SuperAdminRequired
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.UserAlreadyAnonymized
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/anonymize [post]
+func (h *PrivacyHandler) AnonymizeUserHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	result, err := h.PrivacyService.AnonymizeUser(int32(id), actor)
+	if err != nil {
+		respondWithPrivacyError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, result)
+}
+
+func respondWithPrivacyError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrSuperAdminRequired:
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.SuperAdminRequired)
+	case errors.ErrUserNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+	case errors.ErrUserAlreadyAnonymized:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.UserAlreadyAnonymized)
+	default:
+		slog.Error("Error handling data subject request: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
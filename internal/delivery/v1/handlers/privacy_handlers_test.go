@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var privacyActor = domain.Actor{AdminID: 1, Role: "super_admin"}
+
+func TestExportUserDataHandler(t *testing.T) {
+	data := &domain.UserDataExport{Profile: &domain.GetUserResponse{ID: 5}}
+
+	tests := []struct {
+		name                string
+		url                 string
+		mockData            *domain.UserDataExport
+		mockErr             error
+		expectedStatus      int
+		expectedBody        string
+		expectedDisposition string
+	}{
+		{
+			name:                "Success",
+			url:                 "/api/user/5/data-export",
+			mockData:            data,
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "PK",
+			expectedDisposition: `attachment; filename="user-5-data.zip"`,
+		},
+		{
+			name:           "Not a super admin",
+			url:            "/api/user/5/data-export",
+			mockData:       (*domain.UserDataExport)(nil),
+			mockErr:        errors.ErrSuperAdminRequired,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":403,"message":"` + errors.SuperAdminRequired + `"}`,
+		},
+		{
+			name:           "User not found",
+			url:            "/api/user/5/data-export",
+			mockData:       (*domain.UserDataExport)(nil),
+			mockErr:        errors.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.UserNotFound + `"}`,
+		},
+		{
+			name:           "Invalid ID",
+			url:            "/api/user/abc/data-export",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidID + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privacyService := new(mocks.MockPrivacyService)
+			if tt.mockData != nil || tt.mockErr != nil {
+				privacyService.On("GetUserData", int32(5), privacyActor).Return(tt.mockData, tt.mockErr)
+			}
+			privacyService.On("WriteUserDataArchive", data, privacyActor, mock.Anything).Run(func(args mock.Arguments) {
+				args.Get(2).(io.Writer).Write([]byte("PK"))
+			}).Return(nil)
+
+			router := chi.NewRouter()
+			handler := handlers.NewPrivacyHandler(privacyService, router)
+			router.Get("/api/user/{id}/data-export", handler.ExportUserDataHandler)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "super_admin"}))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedDisposition != "" {
+				assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedDisposition, rr.Header().Get("Content-Disposition"))
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+			} else {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAnonymizeUserHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockResult     *domain.UserAnonymization
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Success",
+			mockResult:     &domain.UserAnonymization{ID: 5, AnonymizedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), PhotoKeys: []string{"users/5/photo/a"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":5,"anonymized_at":"2024-03-01T10:00:00Z"}`,
+		},
+		{
+			name:           "Already anonymized",
+			mockErr:        errors.ErrUserAlreadyAnonymized,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"` + errors.UserAlreadyAnonymized + `"}`,
+		},
+		{
+			name:           "Not a super admin",
+			mockErr:        errors.ErrSuperAdminRequired,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":403,"message":"` + errors.SuperAdminRequired + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privacyService := new(mocks.MockPrivacyService)
+			privacyService.On("AnonymizeUser", int32(5), privacyActor).Return(tt.mockResult, tt.mockErr)
+
+			router := chi.NewRouter()
+			handler := handlers.NewPrivacyHandler(privacyService, router)
+			router.Post("/api/user/{id}/anonymize", handler.AnonymizeUserHandler)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/5/anonymize", nil)
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "super_admin"}))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			privacyService.AssertExpectations(t)
+		})
+	}
+}
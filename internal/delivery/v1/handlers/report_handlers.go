@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/spreadsheet"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ReportHandler struct {
+	ReportService service.ReportService
+	Router        *chi.Mux
+}
+
+func NewReportHandler(service service.ReportService, router *chi.Mux) *ReportHandler {
+	return &ReportHandler{
+		ReportService: service,
+		Router:        router,
+	}
+}
+
+// @Summary List reports
+// @Description Lists the saved reports.
+// @Tags reports
+// @Produce json
+// @Security jwt
+// @Success 200 {object} domain.ReportsList "Success"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/reports [get]
+func (h *ReportHandler) GetReportsHandler(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.ReportService.GetReports()
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, reports)
+}
+
+// @Summary Get report
+// @Description Retrieves a saved report.
+// @Tags reports
+// @Produce json
+// @Security jwt
+// @Param id path int true "Report ID"
+// @Success 200 {object} domain.Report "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.ReportNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/reports/{id} [get]
+func (h *ReportHandler) GetReportByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	report, err := h.ReportService.GetReportByID(int32(id))
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, report)
+}
+
+// @Summary Create report
+// @Description Saves a report definition: dimensions to group users by, measures to compute per group and filters, over the fields gender, location, blocked, email_verified, has_email, has_photo, registration_date, date_of_birth and age.
+// @Description Date dimensions are bucketed by day, week, month, quarter or year. Measures are count, count_distinct, min, max, avg and sum. Filter operators are eq, ne, gt, gte, lt, lte, in and not_in.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param request body domain.ReportRequest true "Report"
+// @Success 201 {object} domain.Report "Created"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody + ", invalid report or invalid sort"
+// @Failure 409 {string} string "Conflict: " + errors.ReportAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/reports [post]
+func (h *ReportHandler) CreateReportHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+	request.CreatedBy, _ = middleware.AdminIDFromContext(r.Context())
+
+	report, err := h.ReportService.CreateReport(&request)
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Created, report)
+}
+
+// @Summary Update report
+// @Description Replaces the name, description and definition of a report.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "Report ID"
+// @Param request body domain.ReportRequest true "Report"
+// @Success 200 {object} domain.Report "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID + ", " + errors.InvalidRequestBody + ", invalid report or invalid sort"
+// @Failure 404 {string} string "Not Found: " + errors.ReportNotFound
+// @Failure 409 {string} string "Conflict: " + errors.ReportAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/reports/{id} [put]
+func (h *ReportHandler) UpdateReportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	report, err := h.ReportService.UpdateReport(int32(id), &request)
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, report)
+}
+
+// @Summary Delete report
+// @Description Deletes a saved report.
+// @Tags reports
+// @Produce json
+// @Security jwt
+// @Param id path int true "Report ID"
+// @Success 200 {object} StatusMessage "Deleted"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.ReportNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/reports/{id} [delete]
+func (h *ReportHandler) DeleteReportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.ReportService.DeleteReport(int32(id)); err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "Report deleted successfully",
+	})
+}
+
+// @Summary Run report
+// @Description Runs a report definition without saving it, in the format of POST /api/reports. Rows beyond the limit are left out and the result is marked truncated; in CSV the X-Report-Truncated header is set instead.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Security jwt
+// @Param format query string false "json (default) or csv"
+// @Param request body domain.ReportDefinition true "Report definition"
+// @Success 200 {object} domain.ReportResult "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody + ", " + errors.UnsupportedReportFormat + ", invalid report or invalid sort"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Failure 504 {string} string "Gateway Timeout: " + errors.ReportTimedOut
+// @Router /api/reports/run [post]
+func (h *ReportHandler) RunReportHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := reportFormatFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var definition domain.ReportDefinition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	result, err := h.ReportService.RunReport(r.Context(), &definition)
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	respondWithReportResult(w, result, format, "report")
+}
+
+// @Summary Run saved report
+// @Description Runs a saved report against the current users.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Security jwt
+// @Param id path int true "Report ID"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} domain.ReportResult "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID + ", " + errors.UnsupportedReportFormat + " or invalid report"
+// @Failure 404 {string} string "Not Found: " + errors.ReportNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Failure 504 {string} string "Gateway Timeout: " + errors.ReportTimedOut
+// @Router /api/reports/{id}/run [get]
+func (h *ReportHandler) RunSavedReportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	format, ok := reportFormatFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.ReportService.RunSavedReport(r.Context(), int32(id))
+	if err != nil {
+		respondWithReportError(w, err)
+		return
+	}
+
+	respondWithReportResult(w, result, format, "report-"+strconv.Itoa(id))
+}
+
+func reportFormatFromRequest(w http.ResponseWriter, r *http.Request) (domain.ReportFormat, bool) {
+	format := domain.ReportFormat(r.URL.Query().Get("format"))
+	switch format {
+	case "":
+		return domain.ReportFormatJSON, true
+	case domain.ReportFormatJSON, domain.ReportFormatCSV:
+		return format, true
+	}
+
+	utils.RespondWithErrorJSON(w, status.BadRequest, errors.UnsupportedReportFormat)
+	return "", false
+}
+
+// respondWithReportResult writes result as JSON or as a CSV download named
+// after filename, with a header row of column names.
+func respondWithReportResult(w http.ResponseWriter, result *domain.ReportResult, format domain.ReportFormat, filename string) {
+	if format == domain.ReportFormatJSON {
+		utils.RespondWithJSON(w, status.OK, result)
+		return
+	}
+
+	if result.Truncated {
+		w.Header().Set("X-Report-Truncated", "true")
+	}
+
+	download := &downloadResponseWriter{
+		ResponseWriter: w,
+		contentType:    "text/csv; charset=utf-8",
+		filename:       filename + ".csv",
+	}
+	writer, _ := spreadsheet.NewWriter(download, spreadsheet.FormatCSV)
+
+	err := writer.WriteRow(result.Columns)
+	for _, row := range result.Rows {
+		if err != nil {
+			break
+		}
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = reportCell(value)
+		}
+		err = writer.WriteRow(cells)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		slog.Error("Error writing report: ", utils.Err(err))
+	}
+}
+
+func reportCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func respondWithReportError(w http.ResponseWriter, err error) {
+	if respondWithSortError(w, err) {
+		return
+	}
+
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidReport):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrReportNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.ReportNotFound)
+	case err == errors.ErrReportAlreadyExists:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.ReportAlreadyExists)
+	case err == errors.ErrReportTimedOut:
+		utils.RespondWithErrorJSON(w, status.GatewayTimeout, errors.ReportTimedOut)
+	default:
+		slog.Error("Error handling report: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunReportHandler(t *testing.T) {
+	result := &domain.ReportResult{
+		Columns:   []string{"location", "count", "avg_age"},
+		Rows:      [][]interface{}{{"ashgabat", int64(12), 31.5}, {nil, int64(1), nil}},
+		Truncated: true,
+	}
+
+	tests := []struct {
+		name            string
+		url             string
+		mockResult      *domain.ReportResult
+		mockErr         error
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "JSON",
+			url:            "/api/reports/run",
+			mockResult:     result,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"columns":["location","count","avg_age"],"rows":[["ashgabat",12,31.5],[null,1,null]],"truncated":true}`,
+		},
+		{
+			name:           "CSV",
+			url:            "/api/reports/run?format=csv",
+			mockResult:     result,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "location,count,avg_age\nashgabat,12,31.5\n,1,\n",
+			expectedHeaders: map[string]string{
+				"Content-Type":        "text/csv; charset=utf-8",
+				"Content-Disposition": `attachment; filename="report.csv"`,
+				"X-Report-Truncated":  "true",
+			},
+		},
+		{
+			name:           "Unsupported format",
+			url:            "/api/reports/run?format=xml",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.UnsupportedReportFormat + `"}`,
+		},
+		{
+			name:           "Invalid report",
+			url:            "/api/reports/run",
+			mockErr:        fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, "password"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid report: unknown field \"password\""}`,
+		},
+		{
+			name:           "Timed out",
+			url:            "/api/reports/run",
+			mockErr:        errors.ErrReportTimedOut,
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `{"status":504,"message":"` + errors.ReportTimedOut + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportService := new(mocks.MockReportService)
+			if tt.mockResult != nil || tt.mockErr != nil {
+				reportService.On("RunReport", mock.Anything, &domain.ReportDefinition{
+					Dimensions: []domain.ReportDimension{{Field: "location"}},
+				}).Return(tt.mockResult, tt.mockErr)
+			}
+
+			router := chi.NewRouter()
+			handler := handlers.NewReportHandler(reportService, router)
+			router.Post("/api/reports/run", handler.RunReportHandler)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.url, strings.NewReader(`{"dimensions":[{"field":"location"}]}`))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedHeaders != nil {
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+				for name, value := range tt.expectedHeaders {
+					assert.Equal(t, value, rr.Header().Get(name))
+				}
+			} else {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			reportService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunSavedReportHandlerNotFound(t *testing.T) {
+	reportService := new(mocks.MockReportService)
+	reportService.On("RunSavedReport", mock.Anything, int32(4)).Return((*domain.ReportResult)(nil), errors.ErrReportNotFound)
+
+	router := chi.NewRouter()
+	handler := handlers.NewReportHandler(reportService, router)
+	router.Get("/api/reports/{id}/run", handler.RunSavedReportHandler)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/reports/4/run", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"status":404,"message":"`+errors.ReportNotFound+`"}`, rr.Body.String())
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SegmentHandler struct {
+	SegmentService service.SegmentService
+	Router         *chi.Mux
+}
+
+func NewSegmentHandler(service service.SegmentService, router *chi.Mux) *SegmentHandler {
+	return &SegmentHandler{
+		SegmentService: service,
+		Router:         router,
+	}
+}
+
+// @Summary List segments
+// @Description Lists the saved user segments.
+// @Tags segments
+// @Produce json
+// @Security jwt
+// @Success 200 {object} domain.SegmentsList "Success"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments [get]
+func (h *SegmentHandler) GetSegmentsHandler(w http.ResponseWriter, r *http.Request) {
+	segments, err := h.SegmentService.GetSegments()
+	if err != nil {
+		slog.Error("Error getting segments: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, segments)
+}
+
+// @Summary Get segment
+// @Description Retrieves a saved user segment.
+// @Tags segments
+// @Produce json
+// @Security jwt
+// @Param id path int true "Segment ID"
+// @Success 200 {object} domain.Segment "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.SegmentNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments/{id} [get]
+func (h *SegmentHandler) GetSegmentByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	segment, err := h.SegmentService.GetSegmentByID(int32(id))
+	if err != nil {
+		respondWithSegmentError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, segment)
+}
+
+// @Summary Create segment
+// @Description Saves a user segment defined by a filter on tags, blocked state, location, age, registration date and custom attributes.
+// @Tags segments
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param request body domain.SegmentRequest true "Segment"
+// @Success 201 {object} domain.Segment "Created"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody + ", " + errors.InvalidTagName + ", invalid segment, invalid filter or invalid attributes"
+// @Failure 409 {string} string "Conflict: " + errors.SegmentAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments [post]
+func (h *SegmentHandler) CreateSegmentHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.SegmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+	request.CreatedBy, _ = middleware.AdminIDFromContext(r.Context())
+
+	segment, err := h.SegmentService.CreateSegment(&request)
+	if err != nil {
+		respondWithSegmentError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Created, segment)
+}
+
+// @Summary Update segment
+// @Description Replaces the name, description and filter of a segment.
+// @Tags segments
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "Segment ID"
+// @Param request body domain.SegmentRequest true "Segment"
+// @Success 200 {object} domain.Segment "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID + ", " + errors.InvalidRequestBody + ", invalid segment, invalid filter or invalid attributes"
+// @Failure 404 {string} string "Not Found: " + errors.SegmentNotFound
+// @Failure 409 {string} string "Conflict: " + errors.SegmentAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments/{id} [put]
+func (h *SegmentHandler) UpdateSegmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.SegmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	segment, err := h.SegmentService.UpdateSegment(int32(id), &request)
+	if err != nil {
+		respondWithSegmentError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, segment)
+}
+
+// @Summary Delete segment
+// @Description Deletes a saved segment. Its users are not affected.
+// @Tags segments
+// @Produce json
+// @Security jwt
+// @Param id path int true "Segment ID"
+// @Success 200 {object} StatusMessage "Deleted"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.SegmentNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments/{id} [delete]
+func (h *SegmentHandler) DeleteSegmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.SegmentService.DeleteSegment(int32(id)); err != nil {
+		respondWithSegmentError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "Segment deleted successfully",
+	})
+}
+
+// @Summary List segment users
+// @Description Lists the users currently matching a segment, with pagination.
+// @Tags segments
+// @Produce json
+// @Security jwt
+// @Param id path int true "Segment ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} domain.UsersListResponse "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.SegmentNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments/{id}/users [get]
+func (h *SegmentHandler) GetSegmentUsersHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 8 // Default page size
+	}
+
+	users, totalUsers, err := h.SegmentService.GetSegmentUsers(int32(id), page, pageSize)
+	if err != nil {
+		respondWithSegmentError(w, err)
+		return
+	}
+
+	var previousPage int
+	if page > 1 {
+		previousPage = page - 1
+	} else {
+		previousPage = 1
+	}
+
+	lastPage := (totalUsers + pageSize - 1) / pageSize
+
+	nextPage := page + 1
+	if page >= lastPage {
+		nextPage = lastPage
+	}
+
+	utils.RespondWithJSON(w, status.OK, domain.UsersListResponse{
+		Users:       users,
+		CurrentPage: page,
+		PrevPage:    previousPage,
+		NextPage:    nextPage,
+		FirstPage:   1,
+		LastPage:    lastPage,
+	})
+}
+
+// @Summary Count segment users
+// @Description Counts the users currently matching a segment.
+// @Tags segments
+// @Produce json
+// @Security jwt
+// @Param id path int true "Segment ID"
+// @Success 200 {object} domain.SegmentCount "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.SegmentNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/segments/{id}/count [get]
+func (h *SegmentHandler) CountSegmentUsersHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	count, err := h.SegmentService.CountSegmentUsers(int32(id))
+	if err != nil {
+		respondWithSegmentError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, count)
+}
+
+func respondWithSegmentError(w http.ResponseWriter, err error) {
+	if respondWithFilterError(w, err) {
+		return
+	}
+
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidSegment):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrSegmentNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.SegmentNotFound)
+	case err == errors.ErrSegmentAlreadyExists:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.SegmentAlreadyExists)
+	default:
+		slog.Error("Error handling segment: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
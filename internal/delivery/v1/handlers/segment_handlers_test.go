@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSegmentHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockResponse   *domain.Segment
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invalid filter",
+			mockErr:        fmt.Errorf("%w: min_age must not exceed max_age", errors.ErrInvalidFilter),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid filter: min_age must not exceed max_age"}`,
+		},
+		{
+			name:           "missing name",
+			mockErr:        fmt.Errorf("%w: name is required", errors.ErrInvalidSegment),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid segment: name is required"}`,
+		},
+		{
+			name:           "already exists",
+			mockErr:        errors.ErrSegmentAlreadyExists,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"` + errors.SegmentAlreadyExists + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segmentService := &mocks.MockSegmentService{}
+			segmentService.On("CreateSegment", mock.Anything).Return(tt.mockResponse, tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/segments", strings.NewReader(`{"name":"Adults","filter":{"min_age":30,"max_age":20}}`))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewSegmentHandler(segmentService, router)
+			router.Post("/api/segments", handler.CreateSegmentHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestCountSegmentUsersHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockResponse   *domain.SegmentCount
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "counted",
+			mockResponse:   &domain.SegmentCount{ID: 3, Count: 12},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":3,"count":12}`,
+		},
+		{
+			name:           "not found",
+			mockErr:        errors.ErrSegmentNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.SegmentNotFound + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segmentService := &mocks.MockSegmentService{}
+			segmentService.On("CountSegmentUsers", int32(3)).Return(tt.mockResponse, tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/segments/3/count", nil)
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewSegmentHandler(segmentService, router)
+			router.Get("/api/segments/{id}/count", handler.CountSegmentUsersHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
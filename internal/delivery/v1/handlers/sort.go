@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	stderrors "errors"
+	"net/http"
+)
+
+// sortFromRequest reads the sort order from the sort parameter, a
+// comma-separated list of fields each prefixed with - to sort descending,
+// such as sort=-registration_date,last_name. Which fields are allowed is up
+// to the service.
+func sortFromRequest(r *http.Request) domain.Sort {
+	return domain.ParseSort(r.URL.Query().Get("sort"))
+}
+
+// respondWithSortError writes the response for errors caused by an invalid
+// sort order. It reports whether err was such an error.
+func respondWithSortError(w http.ResponseWriter, err error) bool {
+	if !stderrors.Is(err, errors.ErrInvalidSort) {
+		return false
+	}
+
+	utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	return true
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type StatsHandler struct {
+	StatsService service.StatsService
+	Router       *chi.Mux
+}
+
+func NewStatsHandler(service service.StatsService, router *chi.Mux) *StatsHandler {
+	return &StatsHandler{
+		StatsService: service,
+		Router:       router,
+	}
+}
+
+// @Summary Get user statistics
+// @Description Returns dashboard statistics: registrations per day, week or month between from and to, both included, and the status, gender, location and age breakdowns of all current users.
+// @Description Dates are taken in the given IANA time zone, by default the server's configured one. Without dates the last 30 days up to today are covered. Weeks start on Monday.
+// @Description Statistics are cached for a short time, so recent changes may take up to a minute to show.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD"
+// @Param interval query string false "Bucket width: day, week or month" default(day)
+// @Param tz query string false "IANA time zone, such as Asia/Ashgabat"
+// @Param top query int false "Number of top locations, up to 100" default(10)
+// @Success 200 {object} domain.UserStats "Success"
+// @Failure 400 {string} string "Bad Request: invalid statistics request"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/stats [get]
+func (h *StatsHandler) GetUserStatsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	request := domain.UserStatsRequest{
+		Interval: domain.StatsInterval(query.Get("interval")),
+		TimeZone: query.Get("tz"),
+	}
+
+	dates := []struct {
+		name  string
+		value *time.Time
+	}{{"from", &request.From}, {"to", &request.To}}
+	for _, date := range dates {
+		if !query.Has(date.name) {
+			continue
+		}
+		value, err := time.Parse("2006-01-02", query.Get(date.name))
+		if err != nil {
+			utils.RespondWithErrorJSON(w, status.BadRequest, fmt.Sprintf("%v: %s must be a date in YYYY-MM-DD format", errors.ErrInvalidStatsRequest, date.name))
+			return
+		}
+		*date.value = value
+	}
+
+	if query.Has("top") {
+		top, err := strconv.Atoi(query.Get("top"))
+		if err != nil || top <= 0 {
+			utils.RespondWithErrorJSON(w, status.BadRequest, fmt.Sprintf("%v: top must be a positive number", errors.ErrInvalidStatsRequest))
+			return
+		}
+		request.TopLocations = top
+	}
+
+	stats, err := h.StatsService.GetUserStats(&request)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrInvalidStatsRequest) {
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+			return
+		}
+		slog.Error("Error getting user statistics: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, stats)
+}
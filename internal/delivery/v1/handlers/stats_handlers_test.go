@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserStatsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		mockRequest    *domain.UserStatsRequest
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			url:  "/api/user/stats?from=2024-01-01&to=2024-01-01&interval=week&tz=UTC&top=5",
+			mockRequest: &domain.UserStatsRequest{
+				From:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Interval:     domain.StatsIntervalWeek,
+				TimeZone:     "UTC",
+				TopLocations: 5,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"from":"2024-01-01","to":"2024-01-01","interval":"week","time_zone":"UTC","total":2,` +
+				`"registrations":[{"start":"2024-01-01T00:00:00Z","count":2}],"status":{"active":1,"blocked":1,"blocked_ratio":0.5},` +
+				`"genders":[],"top_locations":[],"age_buckets":[],"generated_at":"2024-01-02T00:00:00Z"}`,
+		},
+		{
+			name:           "Invalid time zone",
+			url:            "/api/user/stats?tz=Mars",
+			mockRequest:    &domain.UserStatsRequest{TimeZone: "Mars"},
+			mockErr:        fmt.Errorf("%w: unknown time zone %q", errors.ErrInvalidStatsRequest, "Mars"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid statistics request: unknown time zone \"Mars\""}`,
+		},
+		{
+			name:           "Invalid date",
+			url:            "/api/user/stats?from=01.01.2024",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid statistics request: from must be a date in YYYY-MM-DD format"}`,
+		},
+		{
+			name:           "Invalid top",
+			url:            "/api/user/stats?top=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid statistics request: top must be a positive number"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statsService := new(mocks.MockStatsService)
+			if tt.mockRequest != nil {
+				var stats *domain.UserStats
+				if tt.mockErr == nil {
+					stats = &domain.UserStats{
+						From:          "2024-01-01",
+						To:            "2024-01-01",
+						Interval:      domain.StatsIntervalWeek,
+						TimeZone:      "UTC",
+						Total:         2,
+						Registrations: []domain.RegistrationCount{{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Count: 2}},
+						Status:        domain.UserStatusStats{Active: 1, Blocked: 1, BlockedRatio: 0.5},
+						Genders:       []domain.StatsGroup{},
+						TopLocations:  []domain.StatsGroup{},
+						AgeBuckets:    []domain.AgeBucket{},
+						GeneratedAt:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					}
+				}
+				statsService.On("GetUserStats", tt.mockRequest).Return(stats, tt.mockErr)
+			}
+
+			router := chi.NewRouter()
+			handler := handlers.NewStatsHandler(statsService, router)
+			router.Get("/api/user/stats", handler.GetUserStatsHandler)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			statsService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TagHandler struct {
+	TagService service.TagService
+	Router     *chi.Mux
+}
+
+func NewTagHandler(service service.TagService, router *chi.Mux) *TagHandler {
+	return &TagHandler{
+		TagService: service,
+		Router:     router,
+	}
+}
+
+// @Summary List tags
+// @Description Lists all tags with the number of users carrying each.
+// @Tags tags
+// @Produce json
+// @Security jwt
+// @Success 200 {object} domain.TagsList "Success"
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/tags [get]
+func (h *TagHandler) GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.TagService.GetTags()
+	if err != nil {
+		slog.Error("Error getting tags: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, tags)
+}
+
+// @Summary Create tag
+// @Description Creates a tag that can be attached to users. Names are stored lowercased.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param request body domain.TagRequest true "Tag"
+// @Success 201 {object} domain.Tag "Created"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody or errors.InvalidTagName
+// @Failure 409 {string} string "Conflict: " + errors.TagAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/tags [post]
+func (h *TagHandler) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	tag, err := h.TagService.CreateTag(&request)
+	if err != nil {
+		respondWithTagError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Created, tag)
+}
+
+// @Summary Update tag
+// @Description Renames a tag or changes its description.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "Tag ID"
+// @Param request body domain.TagRequest true "Tag"
+// @Success 200 {object} domain.Tag "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID, errors.InvalidRequestBody or errors.InvalidTagName
+// @Failure 404 {string} string "Not Found: " + errors.TagNotFound
+// @Failure 409 {string} string "Conflict: " + errors.TagAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/tags/{id} [put]
+func (h *TagHandler) UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	tag, err := h.TagService.UpdateTag(int32(id), &request)
+	if err != nil {
+		respondWithTagError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, tag)
+}
+
+// @Summary Delete tag
+// @Description Deletes a tag and detaches it from all users.
+// @Tags tags
+// @Produce json
+// @Security jwt
+// @Param id path int true "Tag ID"
+// @Success 200 {object} StatusMessage "Deleted"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.TagNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/tags/{id} [delete]
+func (h *TagHandler) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.TagService.DeleteTag(int32(id)); err != nil {
+		respondWithTagError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "Tag deleted successfully",
+	})
+}
+
+// @Summary Tag user
+// @Description Attaches existing tags to a user. Tags the user already has are ignored.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param request body domain.TagUserRequest true "Tag names"
+// @Success 200 {object} StatusMessage "Tagged"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID, errors.InvalidRequestBody, errors.TagsRequired or errors.InvalidTagName
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound or errors.TagNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/tags [post]
+func (h *TagHandler) TagUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.TagUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+	request.TaggedBy, _ = middleware.AdminIDFromContext(r.Context())
+
+	if err := h.TagService.TagUser(int32(id), &request); err != nil {
+		respondWithTagError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "User tagged successfully",
+	})
+}
+
+// @Summary Untag user
+// @Description Removes a tag from a user.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param tag path string true "Tag name"
+// @Success 200 {object} StatusMessage "Untagged"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidTagName
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound or errors.TagNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/tags/{tag} [delete]
+func (h *TagHandler) UntagUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.TagService.UntagUser(int32(id), chi.URLParam(r, "tag")); err != nil {
+		respondWithTagError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "User untagged successfully",
+	})
+}
+
+func respondWithTagError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrInvalidTagName:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidTagName)
+	case errors.ErrTagsRequired:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.TagsRequired)
+	case errors.ErrTagNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.TagNotFound)
+	case errors.ErrUserNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+	case errors.ErrTagAlreadyExists:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.TagAlreadyExists)
+	default:
+		slog.Error("Error changing tags: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagUserHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "tagged",
+			body:           `{"tags":["vip","beta"]}`,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":200,"message":"User tagged successfully"}`,
+		},
+		{
+			name:           "invalid body",
+			body:           `{"tags":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Invalid request body"}`,
+		},
+		{
+			name:           "unknown tag",
+			body:           `{"tags":["vip","beta"]}`,
+			mockErr:        errors.ErrTagNotFound,
+			expectCall:     true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.TagNotFound + `"}`,
+		},
+		{
+			name:           "no tags",
+			body:           `{"tags":["vip","beta"]}`,
+			mockErr:        errors.ErrTagsRequired,
+			expectCall:     true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.TagsRequired + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagService := &mocks.MockTagService{}
+			if tt.expectCall {
+				tagService.On("TagUser", int32(5), &domain.TagUserRequest{Tags: []string{"vip", "beta"}, TaggedBy: 1}).Return(tt.mockErr)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/user/5/tags", strings.NewReader(tt.body))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "admin"}))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewTagHandler(tagService, router)
+			router.Post("/api/user/{id}/tags", handler.TagUserHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+			tagService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateTagHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockResponse   *domain.Tag
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "created",
+			mockResponse:   &domain.Tag{ID: 1, Name: "vip"},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1,"name":"vip","description":"","user_count":0,"created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:           "already exists",
+			mockErr:        errors.ErrTagAlreadyExists,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"` + errors.TagAlreadyExists + `"}`,
+		},
+		{
+			name:           "invalid name",
+			mockErr:        errors.ErrInvalidTagName,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidTagName + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagService := &mocks.MockTagService{}
+			tagService.On("CreateTag", &domain.TagRequest{Name: "VIP"}).Return(tt.mockResponse, tt.mockErr)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/tags", strings.NewReader(`{"name":"VIP"}`))
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewTagHandler(tagService, router)
+			router.Post("/api/tags", handler.CreateTagHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"admin-panel/internal/delivery/v1/middleware"
 	"admin-panel/internal/domain"
 	repository "admin-panel/internal/repository/interfaces"
 	service "admin-panel/internal/service/interfaces"
@@ -8,10 +9,16 @@ import (
 	"admin-panel/pkg/lib/status"
 	"admin-panel/pkg/lib/utils"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -31,14 +38,32 @@ func NewUserHandler(repository repository.UserRepository, service service.UserSe
 }
 
 // @Summary Get all users
-// @Description Retrieves a list of all users with pagination.
+// @Description Retrieves a page of the users matching all of the given filters. The pagination fields count the matching users only.
+// @Description With the cursor parameter, pages are selected by cursor instead of number and the response is a domain.UsersCursorResponse: pass an empty cursor for the first page, then nextCursor or previousCursor together with the same filters and sort. Cursors keep their position when users are added or removed, and no total is counted unless requested with count.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security jwt
 // @Param page query int false "Page number"
 // @Param pageSize query int false "Page size"
+// @Param tag query []string false "Only users having all of these tags" collectionFormat(multi)
+// @Param blocked query bool false "Only blocked (true) or unblocked (false) users"
+// @Param gender query string false "Only users of this gender, case-insensitive"
+// @Param location query string false "Only users at this location, case-insensitive"
+// @Param registered_after query string false "Only users registered at or after this date (YYYY-MM-DD) or RFC 3339 time"
+// @Param registered_before query string false "Only users registered before this date (YYYY-MM-DD) or RFC 3339 time"
+// @Param born_after query string false "Only users born on or after this date (YYYY-MM-DD)"
+// @Param born_before query string false "Only users born before this date (YYYY-MM-DD)"
+// @Param min_age query int false "Only users at least this many years old"
+// @Param max_age query int false "Only users at most this many years old"
+// @Param has_email query bool false "Only users with (true) or without (false) an email address"
+// @Param has_photo query bool false "Only users with (true) or without (false) a profile photo"
+// @Param attr.{key} query string false "Only users whose custom attribute {key} has this value"
+// @Param sort query string false "Comma-separated fields to sort by, each prefixed with - for descending order, e.g. -registration_date,last_name. Sortable: id, first_name, last_name, registration_date, date_of_birth, location, gender, blocked. Ties are broken by id; defaults to id"
+// @Param cursor query string false "Cursor of the page to get; empty for the first page. Switches to cursor pagination, with pageSize as the page size"
+// @Param count query string false "In cursor mode, exact to count the matching users, or estimated for the planner's estimate of all users, which is exact when filtering"
 // @Success 200 {object} domain.UsersListResponse "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidTagName + ", invalid attributes, invalid filter, invalid sort, invalid cursor or invalid count"
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user [get]
 func (h *UserHandler) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
@@ -59,17 +84,31 @@ func (h *UserHandler) GetAllUsersHandler(w http.ResponseWriter, r *http.Request)
 		previousPage = 1
 	}
 
-	users, err := h.UserService.GetAllUsers(page, pageSize)
+	filter, err := userFilterFromRequest(r)
 	if err != nil {
+		respondWithFilterError(w, err)
+		return
+	}
+
+	if r.URL.Query().Has("cursor") {
+		h.getUsersByCursor(w, r, pageSize, filter)
+		return
+	}
+
+	users, err := h.UserService.GetAllUsers(page, pageSize, filter, sortFromRequest(r))
+	if err != nil {
+		if respondWithFilterError(w, err) || respondWithSortError(w, err) {
+			return
+		}
 		slog.Error("Error getting users: ", utils.Err(err))
-		http.Error(w, errors.InternalServerError, status.InternalServerError)
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
 		return
 	}
 
-	totalUsers, err := h.UserRepository.GetTotalUsersCount()
+	totalUsers, err := h.UserService.GetTotalUsersCount(filter)
 	if err != nil {
 		slog.Error("Error getting total users count: ", utils.Err(err))
-		http.Error(w, errors.InternalServerError, status.InternalServerError)
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
 		return
 	}
 
@@ -95,15 +134,17 @@ func (h *UserHandler) GetAllUsersHandler(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary Get user by ID
-// @Description Retrieves a user by ID.
+// @Description Retrieves a user by ID. With as_of, returns the user as it was at that time; historical views carry only the fields tracked by the change history and no ETag.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security jwt
 // @Param id path int true "User ID"
+// @Param as_of query string false "RFC 3339 timestamp to view the user at"
 // @Success 200 {object} domain.GetUserResponse "Success"
-// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
-// @Failure 404 {string} string "User not found" + errors.UserNotFound
+// @Header 200 {string} ETag "Current version of the user, for use in If-Match"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidAsOf
+// @Failure 404 {string} string "User not found" + errors.UserNotFound or errors.UserVersionNotFound
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user/{id} [get]
 func (h *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -114,6 +155,23 @@ func (h *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if asOfParam := r.URL.Query().Get("as_of"); asOfParam != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfParam)
+		if err != nil {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidAsOf)
+			return
+		}
+
+		user, err := h.UserService.GetUserAsOf(int32(id), asOf.UTC())
+		if err != nil {
+			respondWithHistoryError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, status.OK, user)
+		return
+	}
+
 	user, err := h.UserService.GetUserByID(int32(id))
 	if err != nil {
 		if err.Error() == "user not found" {
@@ -126,6 +184,7 @@ func (h *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
@@ -138,7 +197,7 @@ func (h *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request)
 // @Security jwt
 // @Param request body domain.CreateUserRequest true "User creation request"
 // @Success 201 {object} domain.CreateUserResponse "Created"
-// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody or errors.InvalidPhoneNumberFormat or errors.PhoneCountryNotAllowed or errors.InvalidEmailFormat or invalid attributes
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user [post]
 func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -148,18 +207,26 @@ func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
 		return
 	}
-
-	if !utils.IsValidPhoneNumber(createUserRequest.PhoneNumber) {
-		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidPhoneNumberFormat)
-		return
-	}
+	createUserRequest.CreatedBy, _ = middleware.AdminIDFromContext(r.Context())
 
 	user, err := h.UserService.CreateUser(&createUserRequest)
 	if err != nil {
 		slog.Error("Error creating user: ", utils.Err(err))
-		if err.Error() == errors.ErrPhoneNumberInUse.Error() {
+		if err == errors.ErrInvalidPhoneNumber {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidPhoneNumberFormat)
+			return
+		} else if err == errors.ErrPhoneCountryNotAllowed {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.PhoneCountryNotAllowed)
+			return
+		} else if err.Error() == errors.ErrPhoneNumberInUse.Error() {
 			utils.RespondWithErrorJSON(w, status.Conflict, errors.PhoneNumberAlreadyInUse)
 			return
+		} else if err == errors.ErrInvalidEmail {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidEmailFormat)
+			return
+		} else if stderrors.Is(err, errors.ErrInvalidAttributes) {
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+			return
 		} else if err.Error() == errors.ErrEmailInUse.Error() {
 			utils.RespondWithErrorJSON(w, status.Conflict, errors.EmailAlreadyInUse)
 			return
@@ -180,10 +247,15 @@ func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Security jwt
 // @Param id path int true "User ID"
+// @Param If-Match header string false "ETag returned by GET /api/user/{id}; required when strict concurrency is enabled"
 // @Param request body domain.UpdateUserRequest true "User update request"
 // @Success 200 {object} domain.UpdateUserResponse "Updated"
-// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody or errors.InvalidEmailFormat or invalid attributes
 // @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.EmailAlreadyInUse or errors.UserAnonymized
+// @Failure 412 {string} string "Precondition Failed: " + errors.PreconditionFailed
+// @Failure 428 {string} string "Precondition Required: " + errors.PreconditionRequired
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user/{id} [put]
 func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -194,6 +266,12 @@ func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
 	var updateUserRequest domain.UpdateUserRequest
 
 	err = json.NewDecoder(r.Body).Decode(&updateUserRequest)
@@ -201,26 +279,122 @@ func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
 		return
 	}
+	updateUserRequest.ExpectedVersion = expectedVersion
+	updateUserRequest.UpdatedBy, _ = middleware.AdminIDFromContext(r.Context())
 
 	user, err := h.UserService.UpdateUser(int32(id), &updateUserRequest)
 	if err != nil {
 		if err == errors.ErrUserNotFound {
 			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
 			return
+		} else if err == errors.ErrPreconditionFailed {
+			utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+			return
+		} else if err == errors.ErrInvalidEmail {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidEmailFormat)
+			return
+		} else if stderrors.Is(err, errors.ErrInvalidAttributes) {
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+			return
 		} else if err == errors.ErrEmailInUse {
 			utils.RespondWithErrorJSON(w, status.Conflict, errors.EmailAlreadyInUse)
 			return
+		} else if err == errors.ErrUserAnonymized {
+			utils.RespondWithErrorJSON(w, status.Conflict, errors.UserAnonymized)
+			return
 		}
 		slog.Error("Error updating user: ", utils.Err(err))
 		utils.RespondWithErrorJSON(w, status.InternalServerError, fmt.Sprintf("error updating user: %v", err))
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status.OK)
 	json.NewEncoder(w).Encode(user)
 }
 
+// @Summary Patch user
+// @Description Partially updates a user. Send a JSON Merge Patch (RFC 7396) with Content-Type application/merge-patch+json, or a JSON Patch (RFC 6902) with Content-Type application/json-patch+json. Only the supplied fields are changed; null clears location, email and profile_photo_url and is rejected for required fields.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param If-Match header string false "ETag returned by GET /api/user/{id}; required when strict concurrency is enabled"
+// @Param request body domain.PatchUserRequest true "Merge patch or JSON Patch document"
+// @Success 200 {object} domain.UpdateUserResponse "Updated"
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody or errors.InvalidEmailFormat or invalid attributes
+// @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 409 {string} string "Conflict: " + errors.EmailAlreadyInUse, errors.PatchTestFailed or errors.UserAnonymized
+// @Failure 412 {string} string "Precondition Failed: " + errors.PreconditionFailed
+// @Failure 415 {string} string "Unsupported Media Type: " + errors.UnsupportedPatchFormat
+// @Failure 428 {string} string "Precondition Required: " + errors.PreconditionRequired
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id} [patch]
+func (h *UserHandler) PatchUserHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
+	format, ok := patchFormatFromRequest(r)
+	if !ok {
+		w.Header().Set("Accept-Patch", acceptPatchHeader)
+		utils.RespondWithErrorJSON(w, status.UnsupportedMediaType, errors.UnsupportedPatchFormat)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	adminID, _ := middleware.AdminIDFromContext(r.Context())
+
+	user, err := h.UserService.PatchUser(int32(id), format, body, expectedVersion, adminID)
+	if err != nil {
+		if respondWithPatchError(w, err) {
+			return
+		}
+		if err == errors.ErrUserNotFound {
+			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+			return
+		} else if err == errors.ErrPreconditionFailed {
+			utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+			return
+		} else if err == errors.ErrInvalidEmail {
+			utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidEmailFormat)
+			return
+		} else if stderrors.Is(err, errors.ErrInvalidAttributes) {
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+			return
+		} else if err == errors.ErrEmailInUse {
+			utils.RespondWithErrorJSON(w, status.Conflict, errors.EmailAlreadyInUse)
+			return
+		} else if err == errors.ErrUserAnonymized {
+			utils.RespondWithErrorJSON(w, status.Conflict, errors.UserAnonymized)
+			return
+		}
+		slog.Error("Error patching user: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, fmt.Sprintf("error patching user: %v", err))
+		return
+	}
+
+	setETag(w, user.Version)
+	utils.RespondWithJSON(w, status.OK, user)
+}
+
 // @Summary Delete user by ID
 // @Description Deletes a user by their unique ID.
 // @Tags users
@@ -228,9 +402,12 @@ func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Security jwt
 // @Param id path int true "User ID"
+// @Param If-Match header string false "ETag returned by GET /api/user/{id}; required when strict concurrency is enabled"
 // @Success 200 {object} StatusMessage "Deleted"
 // @Failure 400 {string} string "Bad Request: " + errors.InvalidID
 // @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 412 {string} string "Precondition Failed: " + errors.PreconditionFailed
+// @Failure 428 {string} string "Precondition Required: " + errors.PreconditionRequired
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user/{id} [delete]
 func (h *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -241,10 +418,19 @@ func (h *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.UserService.DeleteUser(int32(id)); err != nil {
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
+	if err := h.UserService.DeleteUser(int32(id), expectedVersion); err != nil {
 		if err == errors.ErrUserNotFound {
 			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
 			return
+		} else if err == errors.ErrPreconditionFailed {
+			utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+			return
 		}
 
 		slog.Error("Error deleting user: ", utils.Err(err))
@@ -259,14 +445,16 @@ func (h *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary Block user by ID
-// @Description Blocks a user by their unique ID.
+// @Description Blocks a user by their unique ID, recording the reason, an optional note and an optional expiry after which the user is unblocked automatically. An existing active block is superseded.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security jwt
 // @Param id path int true "User ID"
+// @Param request body domain.BlockUserRequest true "Block details"
 // @Success 200 {object} StatusMessage "Blocked"
-// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID or errors.InvalidRequestBody or errors.InvalidBlockReason or errors.InvalidBlockExpiry
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
 // @Failure 404 {string} string "User not found: " + errors.UserNotFound
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user/{id}/block [post]
@@ -278,7 +466,30 @@ func (h *UserHandler) BlockUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.UserService.BlockUser(int32(id)); err != nil {
+	var blockUserRequest domain.BlockUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&blockUserRequest); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	if !utils.IsValidBlockReason(blockUserRequest.Reason) {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidBlockReason)
+		return
+	}
+
+	if blockUserRequest.ExpiresAt != nil && !blockUserRequest.ExpiresAt.After(time.Now()) {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidBlockExpiry)
+		return
+	}
+
+	adminID, ok := middleware.AdminIDFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+	blockUserRequest.BlockedBy = adminID
+
+	if err := h.UserService.BlockUser(int32(id), &blockUserRequest); err != nil {
 		if err == errors.ErrUserNotFound {
 			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
 			return
@@ -296,7 +507,7 @@ func (h *UserHandler) BlockUserHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Unblock user by ID
-// @Description Unblocks a user by their unique ID.
+// @Description Unblocks a user by their unique ID and closes their active block record.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -304,6 +515,7 @@ func (h *UserHandler) BlockUserHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "User ID"
 // @Success 200 {object} StatusMessage "Unblocked"
 // @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
 // @Failure 404 {string} string "User not found: " + errors.UserNotFound
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user/{id}/unblock [post]
@@ -315,7 +527,13 @@ func (h *UserHandler) UnblockUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.UserService.UnblockUser(int32(id)); err != nil {
+	adminID, ok := middleware.AdminIDFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	if err := h.UserService.UnblockUser(int32(id), adminID); err != nil {
 		if err == errors.ErrUserNotFound {
 			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
 			return
@@ -332,8 +550,44 @@ func (h *UserHandler) UnblockUserHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// @Summary Get user block history
+// @Description Retrieves every block applied to a user, newest first.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.UserBlocksList "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "User not found: " + errors.UserNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/blocks [get]
+func (h *UserHandler) GetUserBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	blocks, err := h.UserService.GetUserBlocks(int32(id))
+	if err != nil {
+		if err == errors.ErrUserNotFound {
+			utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+			return
+		}
+
+		slog.Error("Error retrieving user blocks: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, blocks)
+}
+
 // @Summary Search users
-// @Description Search users by query with pagination
+// @Description Search users by query with pagination. Every word of the query must match the start of a word of the name or location, or be a likely typo of one, with names matching whether written in Latin or Cyrillic. Phone numbers and email addresses are stored encrypted and only match exactly: a query that is a phone number matches it whatever its formatting, and one that is an email address matches it regardless of case. Results are ordered by relevance unless sort is given, and carry highlights: the matching fields, HTML-escaped, with the matching words in <mark> tags.
+// @Description Accepts the filters of GET /api/user; the pagination fields count the users matching both the query and the filters.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -341,8 +595,22 @@ func (h *UserHandler) UnblockUserHandler(w http.ResponseWriter, r *http.Request)
 // @Param query query string true "Search query"
 // @Param page query int false "Page number"
 // @Param pageSize query int false "Page size"
+// @Param tag query []string false "Only users having all of these tags" collectionFormat(multi)
+// @Param blocked query bool false "Only blocked (true) or unblocked (false) users"
+// @Param gender query string false "Only users of this gender, case-insensitive"
+// @Param location query string false "Only users at this location, case-insensitive"
+// @Param registered_after query string false "Only users registered at or after this date (YYYY-MM-DD) or RFC 3339 time"
+// @Param registered_before query string false "Only users registered before this date (YYYY-MM-DD) or RFC 3339 time"
+// @Param born_after query string false "Only users born on or after this date (YYYY-MM-DD)"
+// @Param born_before query string false "Only users born before this date (YYYY-MM-DD)"
+// @Param min_age query int false "Only users at least this many years old"
+// @Param max_age query int false "Only users at most this many years old"
+// @Param has_email query bool false "Only users with (true) or without (false) an email address"
+// @Param has_photo query bool false "Only users with (true) or without (false) a profile photo"
+// @Param attr.{key} query string false "Only users whose custom attribute {key} has this value"
+// @Param sort query string false "Comma-separated fields to sort by, each prefixed with - for descending order, e.g. -registration_date,last_name. Sortable: id, first_name, last_name, registration_date, date_of_birth, location, gender, blocked. Ties are broken by id; defaults to relevance"
 // @Success 200 {object} domain.UsersListResponse "Success"
-// @Failure 400 {string} string "Bad Request: " + errors.SearchQueryRequired
+// @Failure 400 {string} string "Bad Request: " + errors.SearchQueryRequired + ", " + errors.InvalidTagName + ", invalid attributes, invalid filter or invalid sort"
 // @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
 // @Router /api/user/search [get]
 func (h *UserHandler) SearchUsersHandler(w http.ResponseWriter, r *http.Request) {
@@ -362,17 +630,26 @@ func (h *UserHandler) SearchUsersHandler(w http.ResponseWriter, r *http.Request)
 		pageSize = 8 // Default page size
 	}
 
-	users, err := h.UserService.SearchUsers(query, page, pageSize)
+	filter, err := userFilterFromRequest(r)
+	if err != nil {
+		respondWithFilterError(w, err)
+		return
+	}
+
+	users, err := h.UserService.SearchUsers(query, page, pageSize, filter, sortFromRequest(r))
 	if err != nil {
+		if respondWithFilterError(w, err) || respondWithSortError(w, err) {
+			return
+		}
 		slog.Error("Error searching users: ", utils.Err(err))
 		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
 		return
 	}
 
-	totalUsers, err := h.UserRepository.GetTotalUsersCount()
+	totalUsers, err := h.UserService.GetSearchUsersCount(query, filter)
 	if err != nil {
 		slog.Error("Error getting total users count: ", utils.Err(err))
-		http.Error(w, errors.InternalServerError, status.InternalServerError)
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
 		return
 	}
 
@@ -403,3 +680,279 @@ func (h *UserHandler) SearchUsersHandler(w http.ResponseWriter, r *http.Request)
 
 	utils.RespondWithJSON(w, status.OK, response)
 }
+
+// getUsersByCursor serves GET /api/user in cursor mode.
+func (h *UserHandler) getUsersByCursor(w http.ResponseWriter, r *http.Request, pageSize int, filter *domain.UserFilter) {
+	response, err := h.UserService.GetUsersByCursor(&domain.UsersCursorRequest{
+		Cursor: r.URL.Query().Get("cursor"),
+		Limit:  pageSize,
+		Filter: filter,
+		Sort:   sortFromRequest(r),
+		Count:  domain.CountMode(r.URL.Query().Get("count")),
+	})
+	if err != nil {
+		if respondWithFilterError(w, err) || respondWithSortError(w, err) {
+			return
+		}
+
+		switch {
+		case err == errors.ErrInvalidCursor, err == errors.ErrInvalidCountMode:
+			utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+		default:
+			slog.Error("Error getting users by cursor: ", utils.Err(err))
+			utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, response)
+}
+
+// userFilterFromRequest reads the user filter from the query string: tag
+// filters given as repeated tag=<name> parameters, custom attribute filters
+// given as attr.<key>=<value> parameters and the typed filters listed in the
+// swagger documentation of GetAllUsersHandler. It returns nil when there are
+// none, and an error wrapping errors.ErrInvalidFilter for malformed values.
+func userFilterFromRequest(r *http.Request) (*domain.UserFilter, error) {
+	query := r.URL.Query()
+	filter := domain.UserFilter{
+		Tags:     query["tag"],
+		Gender:   query.Get("gender"),
+		Location: query.Get("location"),
+	}
+
+	for name, values := range query {
+		key, ok := strings.CutPrefix(name, "attr.")
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+
+		if filter.Attributes == nil {
+			filter.Attributes = make(map[string]interface{})
+		}
+		filter.Attributes[key] = values[0]
+	}
+
+	var err error
+	for name, target := range map[string]**bool{
+		"blocked":   &filter.Blocked,
+		"has_email": &filter.HasEmail,
+		"has_photo": &filter.HasPhoto,
+	} {
+		if *target, err = boolQueryParam(query, name); err != nil {
+			return nil, err
+		}
+	}
+	for name, target := range map[string]**int{
+		"min_age": &filter.MinAge,
+		"max_age": &filter.MaxAge,
+	} {
+		if *target, err = intQueryParam(query, name); err != nil {
+			return nil, err
+		}
+	}
+	for name, target := range map[string]**time.Time{
+		"born_after":        &filter.BornAfter,
+		"born_before":       &filter.BornBefore,
+		"registered_after":  &filter.RegisteredAfter,
+		"registered_before": &filter.RegisteredBefore,
+	} {
+		if *target, err = timeQueryParam(query, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if reflect.ValueOf(filter).IsZero() {
+		return nil, nil
+	}
+
+	return &filter, nil
+}
+
+func boolQueryParam(query url.Values, name string) (*bool, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(query.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be true or false", errors.ErrInvalidFilter, name)
+	}
+	return &value, nil
+}
+
+func intQueryParam(query url.Values, name string) (*int, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an integer", errors.ErrInvalidFilter, name)
+	}
+	return &value, nil
+}
+
+// timeQueryParam accepts a date in YYYY-MM-DD format, taken as midnight UTC,
+// or an RFC 3339 timestamp.
+func timeQueryParam(query url.Values, name string) (*time.Time, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	str := query.Get(name)
+	value, err := time.Parse("2006-01-02", str)
+	if err != nil {
+		if value, err = time.Parse(time.RFC3339, str); err != nil {
+			return nil, fmt.Errorf("%w: %s must be a date in YYYY-MM-DD format or an RFC 3339 timestamp", errors.ErrInvalidFilter, name)
+		}
+	}
+	return &value, nil
+}
+
+// respondWithFilterError writes the response for errors caused by an invalid
+// user filter. It reports whether err was such an error.
+func respondWithFilterError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == errors.ErrInvalidTagName:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidTagName)
+	case stderrors.Is(err, errors.ErrInvalidAttributes), stderrors.Is(err, errors.ErrInvalidFilter):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// @Summary Get user change history
+// @Description Lists the recorded versions of a user, newest first, with the fields changed by each version, who changed them and when. Entries of users merged into this one keep their own versions and carry merged_from.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} domain.UserHistoryResponse "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/history [get]
+func (h *UserHandler) GetUserHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	history, totalVersions, err := h.UserService.GetUserHistory(int32(id), page, pageSize)
+	if err != nil {
+		respondWithHistoryError(w, err)
+		return
+	}
+
+	var previousPage int
+	if page > 1 {
+		previousPage = page - 1
+	} else {
+		previousPage = 1
+	}
+
+	lastPage := (totalVersions + pageSize - 1) / pageSize
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	nextPage := page + 1
+	if page >= lastPage {
+		nextPage = lastPage
+	}
+
+	utils.RespondWithJSON(w, status.OK, domain.UserHistoryResponse{
+		History:     history,
+		CurrentPage: page,
+		PrevPage:    previousPage,
+		NextPage:    nextPage,
+		FirstPage:   1,
+		LastPage:    lastPage,
+	})
+}
+
+// @Summary Revert user to a previous version
+// @Description Restores the name, gender, date of birth, location, email and custom attributes of a user to their values at a previous version. The phone number, blocked state and profile photo are not reverted. The revert is recorded as a new version.
+// @Tags users
+// @Produce json
+// @Security jwt
+// @Param id path int true "User ID"
+// @Param version path int true "Version to revert to"
+// @Param If-Match header string false "ETag returned by GET /api/user/{id}"
+// @Success 200 {object} domain.UpdateUserResponse "Reverted"
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID, errors.InvalidEmailFormat or invalid attributes
+// @Failure 404 {string} string "Not Found: " + errors.UserNotFound or errors.UserVersionNotFound
+// @Failure 409 {string} string "Conflict: " + errors.EmailAlreadyInUse or errors.UserAnonymized
+// @Failure 412 {string} string "Precondition Failed: " + errors.PreconditionFailed
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/{id}/history/{version}/revert [post]
+func (h *UserHandler) RevertUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+		return
+	}
+
+	request := domain.RevertUserRequest{ExpectedVersion: expectedVersion}
+	request.RevertedBy, _ = middleware.AdminIDFromContext(r.Context())
+
+	user, err := h.UserService.RevertUser(int32(id), int32(version), &request)
+	if err != nil {
+		respondWithHistoryError(w, err)
+		return
+	}
+
+	setETag(w, user.Version)
+	utils.RespondWithJSON(w, status.OK, user)
+}
+
+func respondWithHistoryError(w http.ResponseWriter, err error) {
+	switch {
+	case err == errors.ErrUserNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserNotFound)
+	case err == errors.ErrUserVersionNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.UserVersionNotFound)
+	case err == errors.ErrPreconditionFailed:
+		utils.RespondWithErrorJSON(w, status.PreconditionFailed, errors.PreconditionFailed)
+	case err == errors.ErrInvalidEmail:
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidEmailFormat)
+	case stderrors.Is(err, errors.ErrInvalidAttributes):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrEmailInUse:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.EmailAlreadyInUse)
+	case err == errors.ErrUserAnonymized:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.UserAnonymized)
+	default:
+		slog.Error("Error handling user history: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
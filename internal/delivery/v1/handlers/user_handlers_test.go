@@ -2,10 +2,12 @@ package handlers_test
 
 import (
 	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
 	"admin-panel/internal/domain"
 	repoMocks "admin-panel/internal/mocks/repository"
 	mocks "admin-panel/internal/mocks/service"
 	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/patch"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -15,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -57,7 +60,7 @@ func TestGetAllUsersHandler(t *testing.T) {
 			},
 			mockReturnErr:  nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"users":{"users":[{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"2022-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg"}]},"currentPage":1,"previousPage":1,"nextPage":2,"firstPage":1,"lastPage":2}`,
+			expectedBody:   `{"users":{"users":[{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"2022-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg","email_verified":false}]},"currentPage":1,"previousPage":1,"nextPage":2,"firstPage":1,"lastPage":2}`,
 		},
 		{
 			name:     "Success - Second Page",
@@ -87,10 +90,10 @@ func TestGetAllUsersHandler(t *testing.T) {
 			mockUserRepository := new(repoMocks.MockUserRepository)
 			router := chi.NewRouter()
 
-			handler := handlers.NewUserHandler(mockUserService, mockUserRepository, router)
+			handler := handlers.NewUserHandler(mockUserRepository, mockUserService, router)
 
-			mockUserService.On("GetAllUsers", tc.page, tc.pageSize).Return(tc.mockReturnUser, tc.mockReturnErr)
-			mockUserRepository.On("GetTotalUsersCount").Return(10, nil)
+			mockUserService.On("GetAllUsers", tc.page, tc.pageSize, (*domain.UserFilter)(nil), domain.Sort(nil)).Return(tc.mockReturnUser, tc.mockReturnErr)
+			mockUserService.On("GetTotalUsersCount", (*domain.UserFilter)(nil)).Return(10, nil)
 
 			rr := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", fmt.Sprintf("/api/users?page=%d&pageSize=%d", tc.page, tc.pageSize), nil)
@@ -106,6 +109,248 @@ func TestGetAllUsersHandler(t *testing.T) {
 
 }
 
+func TestGetAllUsersHandlerAttributeFilter(t *testing.T) {
+	filter := &domain.UserFilter{Attributes: map[string]interface{}{"tier": "gold"}}
+
+	testCases := []struct {
+		name           string
+		mockReturnErr  error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Filtered",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"users":{"users":[]},"currentPage":1,"previousPage":1,"nextPage":1,"firstPage":1,"lastPage":1}`,
+		},
+		{
+			name:           "Undefined attribute",
+			mockReturnErr:  fmt.Errorf("%w: %q is not defined", errors.ErrInvalidAttributes, "tier"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid attributes: \"tier\" is not defined"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.MockUserService)
+			router := chi.NewRouter()
+
+			handler := handlers.NewUserHandler(new(repoMocks.MockUserRepository), mockUserService, router)
+
+			mockUserService.On("GetAllUsers", 1, 8, filter, domain.Sort(nil)).Return(&domain.UsersList{Users: []domain.GetUserResponse{}}, tc.mockReturnErr)
+			mockUserService.On("GetTotalUsersCount", filter).Return(3, nil)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/users?attr.tier=gold", nil)
+
+			router.Get("/api/users", handler.GetAllUsersHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestGetAllUsersHandlerTagFilter(t *testing.T) {
+	filter := &domain.UserFilter{Tags: []string{"vip", "beta"}}
+
+	mockUserService := new(mocks.MockUserService)
+	router := chi.NewRouter()
+
+	handler := handlers.NewUserHandler(new(repoMocks.MockUserRepository), mockUserService, router)
+
+	mockUserService.On("GetAllUsers", 1, 8, filter, domain.Sort(nil)).Return((*domain.UsersList)(nil), errors.ErrInvalidTagName)
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/users?tag=vip&tag=beta", nil)
+
+	router.Get("/api/users", handler.GetAllUsersHandler)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, `{"status":400,"message":"`+errors.InvalidTagName+`"}`, strings.TrimSpace(rr.Body.String()))
+	mockUserService.AssertExpectations(t)
+}
+
+func TestGetAllUsersHandlerTypedFilters(t *testing.T) {
+	blocked := true
+	hasPhoto := false
+	minAge := 18
+	bornAfter := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	registeredBefore := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedFilter *domain.UserFilter
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Filtered",
+			url:  "/api/users?blocked=true&gender=Female&location=Mary&born_after=1990-01-01&registered_before=2024-03-01T12:00:00Z&min_age=18&has_photo=false&tag=vip",
+			expectedFilter: &domain.UserFilter{
+				Tags:             []string{"vip"},
+				Blocked:          &blocked,
+				Gender:           "Female",
+				Location:         "Mary",
+				MinAge:           &minAge,
+				BornAfter:        &bornAfter,
+				RegisteredBefore: &registeredBefore,
+				HasPhoto:         &hasPhoto,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"users":{"users":[]},"currentPage":1,"previousPage":1,"nextPage":1,"firstPage":1,"lastPage":1}`,
+		},
+		{
+			name:           "Invalid boolean",
+			url:            "/api/users?has_email=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid filter: has_email must be true or false"}`,
+		},
+		{
+			name:           "Invalid age",
+			url:            "/api/users?max_age=old",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid filter: max_age must be an integer"}`,
+		},
+		{
+			name:           "Invalid date",
+			url:            "/api/users?born_before=01.01.2000",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid filter: born_before must be a date in YYYY-MM-DD format or an RFC 3339 timestamp"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.MockUserService)
+			router := chi.NewRouter()
+
+			handler := handlers.NewUserHandler(new(repoMocks.MockUserRepository), mockUserService, router)
+
+			if tc.expectedFilter != nil {
+				mockUserService.On("GetAllUsers", 1, 8, tc.expectedFilter, domain.Sort(nil)).Return(&domain.UsersList{Users: []domain.GetUserResponse{}}, nil)
+				mockUserService.On("GetTotalUsersCount", tc.expectedFilter).Return(3, nil)
+			}
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+
+			router.Get("/api/users", handler.GetAllUsersHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+			mockUserService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetAllUsersHandlerSort(t *testing.T) {
+	sort := domain.Sort{{Field: "registration_date", Descending: true}, {Field: "last_name"}}
+
+	testCases := []struct {
+		name           string
+		mockReturnErr  error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Sorted",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"users":{"users":[]},"currentPage":1,"previousPage":1,"nextPage":1,"firstPage":1,"lastPage":1}`,
+		},
+		{
+			name:           "Unsortable field",
+			mockReturnErr:  fmt.Errorf("%w: %q is not sortable", errors.ErrInvalidSort, "last_name"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid sort: \"last_name\" is not sortable"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.MockUserService)
+			router := chi.NewRouter()
+
+			handler := handlers.NewUserHandler(new(repoMocks.MockUserRepository), mockUserService, router)
+
+			mockUserService.On("GetAllUsers", 1, 8, (*domain.UserFilter)(nil), sort).Return(&domain.UsersList{Users: []domain.GetUserResponse{}}, tc.mockReturnErr)
+			mockUserService.On("GetTotalUsersCount", (*domain.UserFilter)(nil)).Return(3, nil)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/users?sort=-registration_date,%20last_name,", nil)
+
+			router.Get("/api/users", handler.GetAllUsersHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestGetAllUsersHandlerCursor(t *testing.T) {
+	total := 118
+
+	testCases := []struct {
+		name            string
+		url             string
+		expectedRequest *domain.UsersCursorRequest
+		mockResponse    *domain.UsersCursorResponse
+		mockErr         error
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{
+			name:            "First page",
+			url:             "/api/users?cursor=&pageSize=2&count=estimated",
+			expectedRequest: &domain.UsersCursorRequest{Limit: 2, Count: domain.CountEstimated},
+			mockResponse: &domain.UsersCursorResponse{
+				Users:          &domain.UsersList{Users: []domain.GetUserResponse{}},
+				NextCursor:     "next",
+				Total:          &total,
+				TotalEstimated: true,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"users":{"users":[]},"nextCursor":"next","total":118,"totalEstimated":true}`,
+		},
+		{
+			name:            "Invalid cursor",
+			url:             "/api/users?cursor=abc&sort=last_name",
+			expectedRequest: &domain.UsersCursorRequest{Cursor: "abc", Limit: 8, Sort: domain.Sort{{Field: "last_name"}}},
+			mockResponse:    (*domain.UsersCursorResponse)(nil),
+			mockErr:         errors.ErrInvalidCursor,
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    `{"status":400,"message":"invalid cursor"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.MockUserService)
+			router := chi.NewRouter()
+
+			handler := handlers.NewUserHandler(new(repoMocks.MockUserRepository), mockUserService, router)
+
+			mockUserService.On("GetUsersByCursor", tc.expectedRequest).Return(tc.mockResponse, tc.mockErr)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+
+			router.Get("/api/users", handler.GetAllUsersHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+			mockUserService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetUserByIDHandler(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -128,7 +373,7 @@ func TestGetUserByIDHandler(t *testing.T) {
 				ProfilePhotoURL: "https://example.com/profile.jpg"},
 			mockReturnErr:  nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg"}`,
+			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg","email_verified":false}`,
 		},
 		{
 			name:           "NotFound",
@@ -150,7 +395,7 @@ func TestGetUserByIDHandler(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserService := new(mocks.MockUserService)
 			router := chi.NewRouter()
-			handler := handlers.NewUserHandler(mockUserService, nil, router)
+			handler := handlers.NewUserHandler(nil, mockUserService, router)
 
 			mockUserService.On("GetUserByID", mock.AnythingOfType("int32")).Return(tc.mockReturnUser, tc.mockReturnErr)
 
@@ -201,7 +446,7 @@ func TestCreateUserHandler(t *testing.T) {
 				return userService
 			},
 			expectedStatus: http.StatusCreated,
-			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg"}`,
+			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg","email_verified":false}`,
 		},
 		{
 			name: "phone number already in use",
@@ -251,7 +496,7 @@ func TestCreateUserHandler(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodPost, "/api/user", bytes.NewBuffer(body))
 			rr := httptest.NewRecorder()
 			router := chi.NewRouter()
-			handler := handlers.NewUserHandler(tt.mockUserService(), nil, router)
+			handler := handlers.NewUserHandler(nil, tt.mockUserService(), router)
 			router.Post("/api/user", handler.CreateUserHandler)
 			router.ServeHTTP(rr, req)
 			assert.Equal(t, tt.expectedStatus, rr.Code)
@@ -294,7 +539,7 @@ func TestUpdateUserHandler(t *testing.T) {
 				return userService
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg"}`,
+			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg","email_verified":false}`,
 		},
 		{
 			name: "user not found",
@@ -334,6 +579,22 @@ func TestUpdateUserHandler(t *testing.T) {
 			expectedStatus: http.StatusConflict,
 			expectedBody:   `{"status":409,"message":"Email already in use"}`,
 		},
+		{
+			name: "user anonymized",
+			requestBody: &domain.UpdateUserRequest{
+				FirstName:   "Kemal",
+				LastName:    "Atdayew",
+				Gender:      "Male",
+				DateOfBirth: dateOfBirth,
+			},
+			mockUserService: func() *mocks.MockUserService {
+				userService := &mocks.MockUserService{}
+				userService.On("UpdateUser", mock.Anything, mock.Anything).Return(&domain.UpdateUserResponse{}, errors.ErrUserAnonymized)
+				return userService
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"` + errors.UserAnonymized + `"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -373,10 +634,96 @@ func TestUpdateUserHandler(t *testing.T) {
 	}
 }
 
+func TestPatchUserHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		requestBody     string
+		mockUserService func() *mocks.MockUserService
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{
+			name:        "merge patch",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"location":"Mary"}`,
+			mockUserService: func() *mocks.MockUserService {
+				userService := &mocks.MockUserService{}
+				userService.On("PatchUser", int32(1), domain.MergePatchFormat, []byte(`{"location":"Mary"}`), mock.Anything, mock.Anything).Return(&domain.UpdateUserResponse{
+					ID:          1,
+					FirstName:   "Kemal",
+					LastName:    "Atdayew",
+					PhoneNumber: "+99362008971",
+					Gender:      "Male",
+					DateOfBirth: dateOfBirth,
+					Location:    "Mary",
+				}, nil)
+				return userService
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Mary","email":"","profile_photo_url":"","email_verified":false}`,
+		},
+		{
+			name:        "failed json patch test",
+			contentType: "application/json-patch+json",
+			requestBody: `[{"op":"test","path":"/first_name","value":"Aman"}]`,
+			mockUserService: func() *mocks.MockUserService {
+				userService := &mocks.MockUserService{}
+				userService.On("PatchUser", int32(1), domain.JSONPatchFormat, mock.Anything, mock.Anything, mock.Anything).Return(&domain.UpdateUserResponse{}, fmt.Errorf("operation 0 (test /first_name): %w", patch.ErrTestFailed))
+				return userService
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"Patch test operation failed"}`,
+		},
+		{
+			name:        "required field set to null",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"first_name":null}`,
+			mockUserService: func() *mocks.MockUserService {
+				userService := &mocks.MockUserService{}
+				userService.On("PatchUser", int32(1), domain.MergePatchFormat, mock.Anything, mock.Anything, mock.Anything).Return(&domain.UpdateUserResponse{}, fmt.Errorf("%w: %q", errors.ErrFieldRequired, "first_name"))
+				return userService
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"field cannot be null or empty: \"first_name\""}`,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			requestBody: `location=Mary`,
+			mockUserService: func() *mocks.MockUserService {
+				return &mocks.MockUserService{}
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"status":415,"message":"Unsupported patch format, use application/merge-patch+json or application/json-patch+json"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/api/user/1", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := handlers.NewUserHandler(nil, tt.mockUserService(), router)
+			router.Patch("/api/user/{id}", handler.PatchUserHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rr.Body.String()))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, `"0"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
 func TestDeleteUserHandler(t *testing.T) {
 	tests := []struct {
 		name            string
 		id              int
+		ifMatch         string
 		mockUserService func() *mocks.MockUserService
 		expectedStatus  int
 		expectedBody    string
@@ -386,7 +733,7 @@ func TestDeleteUserHandler(t *testing.T) {
 			id:   1,
 			mockUserService: func() *mocks.MockUserService {
 				userService := &mocks.MockUserService{}
-				userService.On("DeleteUser", mock.Anything).Return(nil)
+				userService.On("DeleteUser", mock.Anything, mock.Anything).Return(nil)
 				return userService
 			},
 			expectedStatus: http.StatusOK,
@@ -397,12 +744,46 @@ func TestDeleteUserHandler(t *testing.T) {
 			id:   1,
 			mockUserService: func() *mocks.MockUserService {
 				userService := &mocks.MockUserService{}
-				userService.On("DeleteUser", mock.Anything).Return(errors.ErrUserNotFound)
+				userService.On("DeleteUser", mock.Anything, mock.Anything).Return(errors.ErrUserNotFound)
 				return userService
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"status":404,"message":"User not found"}`,
 		},
+		{
+			name:    "matching version",
+			id:      1,
+			ifMatch: `"3"`,
+			mockUserService: func() *mocks.MockUserService {
+				userService := &mocks.MockUserService{}
+				userService.On("DeleteUser", int32(1), mock.MatchedBy(func(v *int32) bool { return v != nil && *v == 3 })).Return(nil)
+				return userService
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":200,"message":"User deleted successfully"}`,
+		},
+		{
+			name:    "stale version",
+			id:      1,
+			ifMatch: `"2"`,
+			mockUserService: func() *mocks.MockUserService {
+				userService := &mocks.MockUserService{}
+				userService.On("DeleteUser", mock.Anything, mock.Anything).Return(errors.ErrPreconditionFailed)
+				return userService
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"status":412,"message":"Precondition failed: the resource has been modified"}`,
+		},
+		{
+			name:    "weak entity tag",
+			id:      1,
+			ifMatch: `W/"3"`,
+			mockUserService: func() *mocks.MockUserService {
+				return &mocks.MockUserService{}
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"status":412,"message":"Precondition failed: the resource has been modified"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -411,6 +792,9 @@ func TestDeleteUserHandler(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			rr := httptest.NewRecorder()
 			router := chi.NewRouter()
@@ -440,40 +824,86 @@ func TestBlockUserHandler(t *testing.T) {
 	tests := []struct {
 		name            string
 		id              int
+		requestBody     string
+		claims          jwt.MapClaims
 		mockUserService func() *mocks.MockUserService
 		expectedStatus  int
 		expectedBody    string
 	}{
 		{
-			name: "successful request",
-			id:   1,
+			name:        "successful request",
+			id:          1,
+			requestBody: `{"reason":"spam","note":"Sending promotional messages"}`,
+			claims:      jwt.MapClaims{"id": float64(7), "role": "admin"},
 			mockUserService: func() *mocks.MockUserService {
 				userService := &mocks.MockUserService{}
-				userService.On("BlockUser", mock.Anything).Return(nil)
+				userService.On("BlockUser", int32(1), &domain.BlockUserRequest{
+					Reason:    domain.BlockReasonSpam,
+					Note:      "Sending promotional messages",
+					BlockedBy: 7,
+				}).Return(nil)
 				return userService
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"status":200,"message":"User blocked successfully"}`,
 		},
 		{
-			name: "user not found",
-			id:   1,
+			name:        "user not found",
+			id:          1,
+			requestBody: `{"reason":"fraud"}`,
+			claims:      jwt.MapClaims{"id": float64(7), "role": "admin"},
 			mockUserService: func() *mocks.MockUserService {
 				userService := &mocks.MockUserService{}
-				userService.On("BlockUser", mock.Anything).Return(errors.ErrUserNotFound)
+				userService.On("BlockUser", mock.Anything, mock.Anything).Return(errors.ErrUserNotFound)
 				return userService
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"status":404,"message":"User not found"}`,
 		},
+		{
+			name:        "invalid reason",
+			id:          1,
+			requestBody: `{"reason":"bored"}`,
+			claims:      jwt.MapClaims{"id": float64(7), "role": "admin"},
+			mockUserService: func() *mocks.MockUserService {
+				return &mocks.MockUserService{}
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Invalid block reason"}`,
+		},
+		{
+			name:        "expiry in the past",
+			id:          1,
+			requestBody: `{"reason":"abuse","expires_at":"2020-01-01T00:00:00Z"}`,
+			claims:      jwt.MapClaims{"id": float64(7), "role": "admin"},
+			mockUserService: func() *mocks.MockUserService {
+				return &mocks.MockUserService{}
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Block expiry must be in the future"}`,
+		},
+		{
+			name:        "missing token claims",
+			id:          1,
+			requestBody: `{"reason":"spam"}`,
+			claims:      nil,
+			mockUserService: func() *mocks.MockUserService {
+				return &mocks.MockUserService{}
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":401,"message":"Token claims not found"}`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, err := http.NewRequest("PUT", fmt.Sprintf("/users/%d/block", tt.id), nil)
+			req, err := http.NewRequest("PUT", fmt.Sprintf("/users/%d/block", tt.id), strings.NewReader(tt.requestBody))
 			if err != nil {
 				t.Fatal(err)
 			}
+			if tt.claims != nil {
+				req = req.WithContext(middleware.ContextWithClaims(req.Context(), tt.claims))
+			}
 
 			rr := httptest.NewRecorder()
 			router := chi.NewRouter()
@@ -512,7 +942,7 @@ func TestUnblockUserHandler(t *testing.T) {
 			id:   1,
 			mockUserService: func() *mocks.MockUserService {
 				userService := &mocks.MockUserService{}
-				userService.On("UnblockUser", mock.Anything).Return(nil)
+				userService.On("UnblockUser", int32(1), int32(7)).Return(nil)
 				return userService
 			},
 			expectedStatus: http.StatusOK,
@@ -523,7 +953,7 @@ func TestUnblockUserHandler(t *testing.T) {
 			id:   1,
 			mockUserService: func() *mocks.MockUserService {
 				userService := &mocks.MockUserService{}
-				userService.On("UnblockUser", mock.Anything).Return(errors.ErrUserNotFound)
+				userService.On("UnblockUser", mock.Anything, mock.Anything).Return(errors.ErrUserNotFound)
 				return userService
 			},
 			expectedStatus: http.StatusNotFound,
@@ -537,6 +967,7 @@ func TestUnblockUserHandler(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(7), "role": "admin"}))
 
 			rr := httptest.NewRecorder()
 			router := chi.NewRouter()
@@ -562,6 +993,64 @@ func TestUnblockUserHandler(t *testing.T) {
 	}
 }
 
+func TestGetUserBlocksHandler(t *testing.T) {
+	blockedAt, _ := time.Parse(time.RFC3339, "2024-10-03T09:00:00Z")
+	blockedBy := int32(7)
+
+	testCases := []struct {
+		name             string
+		mockReturnBlocks *domain.UserBlocksList
+		mockReturnErr    error
+		expectedStatus   int
+		expectedBody     string
+	}{
+		{
+			name: "Success",
+			mockReturnBlocks: &domain.UserBlocksList{
+				Blocks: []domain.UserBlock{
+					{
+						ID:        1,
+						UserID:    1,
+						Reason:    domain.BlockReasonSpam,
+						Note:      "Sending promotional messages",
+						BlockedBy: &blockedBy,
+						BlockedAt: blockedAt,
+					},
+				},
+			},
+			mockReturnErr:  nil,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"blocks":[{"id":1,"user_id":1,"reason":"spam","note":"Sending promotional messages","blocked_by":7,"blocked_at":"2024-10-03T09:00:00Z","expires_at":null,"unblocked_by":null,"unblocked_at":null}]}`,
+		},
+		{
+			name:             "NotFound",
+			mockReturnBlocks: nil,
+			mockReturnErr:    errors.ErrUserNotFound,
+			expectedStatus:   http.StatusNotFound,
+			expectedBody:     `{"status":404,"message":"User not found"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.MockUserService)
+			router := chi.NewRouter()
+			handler := handlers.NewUserHandler(nil, mockUserService, router)
+
+			mockUserService.On("GetUserBlocks", int32(1)).Return(tc.mockReturnBlocks, tc.mockReturnErr)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/user/1/blocks", nil)
+
+			router.Get("/api/user/{id}/blocks", handler.GetUserBlocksHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
 func TestSearchUsersHandler(t *testing.T) {
 	dateOfBirth, _ := time.Parse(time.RFC3339, "2000-01-01T00:00:00Z")
 	registrationDate, _ := time.Parse(time.RFC3339, "2022-01-01T00:00:00Z")
@@ -599,7 +1088,7 @@ func TestSearchUsersHandler(t *testing.T) {
 			},
 			mockReturnErr:  nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"users":{"users":[{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"2022-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg"}]},"currentPage":1,"previousPage":1,"nextPage":2,"firstPage":1,"lastPage":2}`,
+			expectedBody:   `{"users":{"users":[{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"2022-01-01T00:00:00Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"atdayewkemal@gmail.com","profile_photo_url":"https://example.com/profile.jpg","email_verified":false}]},"currentPage":1,"previousPage":1,"nextPage":2,"firstPage":1,"lastPage":2}`,
 		},
 		{
 			name:           "Internal server error",
@@ -629,10 +1118,10 @@ func TestSearchUsersHandler(t *testing.T) {
 			mockUserRepository := new(repoMocks.MockUserRepository)
 			router := chi.NewRouter()
 
-			handler := handlers.NewUserHandler(mockUserService, mockUserRepository, router)
+			handler := handlers.NewUserHandler(mockUserRepository, mockUserService, router)
 
-			mockUserService.On("SearchUsers", tc.query, tc.page, tc.pageSize).Return(tc.mockReturnUser, tc.mockReturnErr)
-			mockUserRepository.On("GetTotalUsersCount").Return(10, nil)
+			mockUserService.On("SearchUsers", tc.query, tc.page, tc.pageSize, (*domain.UserFilter)(nil), domain.Sort(nil)).Return(tc.mockReturnUser, tc.mockReturnErr)
+			mockUserService.On("GetSearchUsersCount", tc.query, (*domain.UserFilter)(nil)).Return(10, nil)
 
 			rr := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", fmt.Sprintf("/api/user?query=%s&page=%d&pageSize=%d", tc.query, tc.page, tc.pageSize), nil)
@@ -645,3 +1134,117 @@ func TestSearchUsersHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserByIDHandlerAsOf(t *testing.T) {
+	asOf := time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		asOf           string
+		mockReturnUser *domain.GetUserResponse
+		mockReturnErr  error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Historical view",
+			asOf:           "2024-09-01T03:00:00%2B03:00",
+			mockReturnUser: &domain.GetUserResponse{ID: 1, FirstName: "Kemal", Email: "old@example.com", Version: 4},
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"","phone_number":"","blocked":false,"gender":"","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"0001-01-01T00:00:00Z","location":"","email":"old@example.com","profile_photo_url":"","email_verified":false}`,
+		},
+		{
+			name:           "Before the first recorded version",
+			asOf:           "2024-09-01T00:00:00Z",
+			mockReturnErr:  errors.ErrUserVersionNotFound,
+			expectCall:     true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.UserVersionNotFound + `"}`,
+		},
+		{
+			name:           "Invalid timestamp",
+			asOf:           "last-month",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidAsOf + `"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserService := new(mocks.MockUserService)
+			if tc.expectCall {
+				mockUserService.On("GetUserAsOf", int32(1), asOf).Return(tc.mockReturnUser, tc.mockReturnErr)
+			}
+
+			router := chi.NewRouter()
+			handler := handlers.NewUserHandler(nil, mockUserService, router)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/user/1?as_of="+tc.asOf, nil)
+
+			router.Get("/api/user/{id}", handler.GetUserByIDHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+			assert.Empty(t, rr.Header().Get("ETag"))
+			mockUserService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevertUserHandler(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockReturnErr  error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Reverted",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1,"first_name":"Kemal","last_name":"","phone_number":"","blocked":false,"gender":"","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"0001-01-01T00:00:00Z","location":"","email":"","profile_photo_url":"","email_verified":false}`,
+		},
+		{
+			name:           "Unknown version",
+			mockReturnErr:  errors.ErrUserVersionNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.UserVersionNotFound + `"}`,
+		},
+		{
+			name:           "Modified meanwhile",
+			mockReturnErr:  errors.ErrPreconditionFailed,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"status":412,"message":"` + errors.PreconditionFailed + `"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectedVersion := int32(5)
+
+			mockUserService := new(mocks.MockUserService)
+			mockUserService.On("RevertUser", int32(1), int32(2), &domain.RevertUserRequest{ExpectedVersion: &expectedVersion, RevertedBy: 7}).
+				Return(&domain.UpdateUserResponse{ID: 1, FirstName: "Kemal", Version: 6}, tc.mockReturnErr)
+
+			router := chi.NewRouter()
+			handler := handlers.NewUserHandler(nil, mockUserService, router)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/user/1/history/2/revert", nil)
+			req.Header.Set("If-Match", `"5"`)
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(7), "role": "admin"}))
+
+			router.Post("/api/user/{id}/history/{version}/revert", handler.RevertUserHandler)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(rr.Body.String()))
+			if tc.mockReturnErr == nil {
+				assert.Equal(t, `"6"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/status"
+	"admin-panel/pkg/lib/utils"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ViewHandler struct {
+	ViewService service.ViewService
+	Router      *chi.Mux
+}
+
+func NewViewHandler(service service.ViewService, router *chi.Mux) *ViewHandler {
+	return &ViewHandler{
+		ViewService: service,
+		Router:      router,
+	}
+}
+
+// @Summary List saved views
+// @Description Lists the saved user views visible to the admin: their own and those shared with them or their role.
+// @Tags views
+// @Produce json
+// @Security jwt
+// @Success 200 {object} domain.ViewsList "Success"
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/views [get]
+func (h *ViewHandler) GetViewsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	views, err := h.ViewService.GetViews(actor)
+	if err != nil {
+		respondWithViewError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, views)
+}
+
+// @Summary Save view
+// @Description Saves a named user search owned by the admin: search text, filter, sort in the format of the sort parameter and the columns to display, named as in exports.
+// @Description The view can be shared with other admins by ID and with roles.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param request body domain.ViewRequest true "View"
+// @Success 201 {object} domain.View "Created"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidRequestBody + ", invalid view, invalid filter, invalid sort or invalid attributes"
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 409 {string} string "Conflict: " + errors.ViewAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/views [post]
+func (h *ViewHandler) CreateViewHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	var request domain.ViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	view, err := h.ViewService.CreateView(&request, actor)
+	if err != nil {
+		respondWithViewError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.Created, view)
+}
+
+// @Summary Get view results
+// @Description Returns a saved view together with a page of the users currently matching it.
+// @Description The view is checked against the current attribute schema: filters, sort fields and columns that no longer apply are left out of the returned view and described in warnings.
+// @Tags views
+// @Produce json
+// @Security jwt
+// @Param id path int true "View ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} domain.ViewResultsResponse "Success"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 404 {string} string "Not Found: " + errors.ViewNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/views/{id} [get]
+func (h *ViewHandler) GetViewResultsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 8 // Default page size
+	}
+
+	results, totalUsers, err := h.ViewService.GetViewResults(int32(id), page, pageSize, actor)
+	if err != nil {
+		respondWithViewError(w, err)
+		return
+	}
+
+	var previousPage int
+	if page > 1 {
+		previousPage = page - 1
+	} else {
+		previousPage = 1
+	}
+
+	lastPage := (totalUsers + pageSize - 1) / pageSize
+
+	nextPage := page + 1
+	if page >= lastPage {
+		nextPage = lastPage
+	}
+
+	utils.RespondWithJSON(w, status.OK, domain.ViewResultsResponse{
+		View:        results.View,
+		Warnings:    results.Warnings,
+		Users:       results.Users,
+		CurrentPage: page,
+		PrevPage:    previousPage,
+		NextPage:    nextPage,
+		FirstPage:   1,
+		LastPage:    lastPage,
+	})
+}
+
+// @Summary Update view
+// @Description Replaces the definition and sharing of a saved view. Only the owner may change a view.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security jwt
+// @Param id path int true "View ID"
+// @Param request body domain.ViewRequest true "View"
+// @Success 200 {object} domain.View "Updated"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID + ", " + errors.InvalidRequestBody + ", invalid view, invalid filter, invalid sort or invalid attributes"
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.ViewNotOwner
+// @Failure 404 {string} string "Not Found: " + errors.ViewNotFound
+// @Failure 409 {string} string "Conflict: " + errors.ViewAlreadyExists
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/views/{id} [put]
+func (h *ViewHandler) UpdateViewHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	var request domain.ViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidRequestBody)
+		return
+	}
+
+	view, err := h.ViewService.UpdateView(int32(id), &request, actor)
+	if err != nil {
+		respondWithViewError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, view)
+}
+
+// @Summary Delete view
+// @Description Deletes a saved view. Admins may delete their own views; super admins may delete any view shared with them.
+// @Tags views
+// @Produce json
+// @Security jwt
+// @Param id path int true "View ID"
+// @Success 200 {object} StatusMessage "Deleted"
+// @Failure 400 {string} string "Bad Request: " + errors.InvalidID
+// @Failure 401 {string} string "Unauthorized: " + errors.TokenClaimsNotFound
+// @Failure 403 {string} string "Forbidden: " + errors.ViewNotOwner
+// @Failure 404 {string} string "Not Found: " + errors.ViewNotFound
+// @Failure 500 {string} string "Internal Server Error: " + errors.InternalServerError
+// @Router /api/user/views/{id} [delete]
+func (h *ViewHandler) DeleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		utils.RespondWithErrorJSON(w, status.Unauthorized, errors.TokenClaimsNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithErrorJSON(w, status.BadRequest, errors.InvalidID)
+		return
+	}
+
+	if err := h.ViewService.DeleteView(int32(id), actor); err != nil {
+		respondWithViewError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, status.OK, StatusMessage{
+		Status:  status.OK,
+		Message: "View deleted successfully",
+	})
+}
+
+func respondWithViewError(w http.ResponseWriter, err error) {
+	if respondWithFilterError(w, err) || respondWithSortError(w, err) {
+		return
+	}
+
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidView):
+		utils.RespondWithErrorJSON(w, status.BadRequest, err.Error())
+	case err == errors.ErrViewNotFound:
+		utils.RespondWithErrorJSON(w, status.NotFound, errors.ViewNotFound)
+	case err == errors.ErrViewNotOwner:
+		utils.RespondWithErrorJSON(w, status.Forbidden, errors.ViewNotOwner)
+	case err == errors.ErrViewAlreadyExists:
+		utils.RespondWithErrorJSON(w, status.Conflict, errors.ViewAlreadyExists)
+	default:
+		slog.Error("Error handling view: ", utils.Err(err))
+		utils.RespondWithErrorJSON(w, status.InternalServerError, errors.InternalServerError)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	"admin-panel/internal/delivery/v1/middleware"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/service"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var viewActor = domain.Actor{AdminID: 1, Role: "admin"}
+
+func TestGetViewResultsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		mockResults    *domain.ViewResults
+		mockTotal      int
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			url:  "/api/user/views/7?page=2&pageSize=1",
+			mockResults: &domain.ViewResults{
+				View:     &domain.View{ID: 7, Name: "VIPs", Sort: "last_name", Columns: []string{"first_name"}},
+				Warnings: []string{`filter on attribute "tier" ignored: it is no longer defined`},
+				Users:    &domain.UsersList{Users: []domain.GetUserResponse{{ID: 3, FirstName: "Aman"}}},
+			},
+			mockTotal:      3,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"view":{"id":7,"name":"VIPs","query":"","filter":{},"sort":"last_name","columns":["first_name"],"shared_with":{"admin_ids":null,"roles":null},"owner_id":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"},` +
+				`"warnings":["filter on attribute \"tier\" ignored: it is no longer defined"],` +
+				`"users":{"users":[{"id":3,"first_name":"Aman","last_name":"","phone_number":"","blocked":false,"gender":"","registration_date":"0001-01-01T00:00:00Z","date_of_birth":"0001-01-01T00:00:00Z","location":"","email":"","profile_photo_url":"","email_verified":false}]},` +
+				`"currentPage":2,"previousPage":1,"nextPage":3,"firstPage":1,"lastPage":3}`,
+		},
+		{
+			name:           "Not visible",
+			url:            "/api/user/views/7",
+			mockResults:    (*domain.ViewResults)(nil),
+			mockErr:        errors.ErrViewNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"` + errors.ViewNotFound + `"}`,
+		},
+		{
+			name:           "Invalid ID",
+			url:            "/api/user/views/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"` + errors.InvalidID + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viewService := new(mocks.MockViewService)
+			if tt.mockResults != nil || tt.mockErr != nil {
+				viewService.On("GetViewResults", int32(7), mock.Anything, mock.Anything, viewActor).Return(tt.mockResults, tt.mockTotal, tt.mockErr)
+			}
+
+			router := chi.NewRouter()
+			handler := handlers.NewViewHandler(viewService, router)
+			router.Get("/api/user/views/{id}", handler.GetViewResultsHandler)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "admin"}))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			viewService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSaveViewHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Created",
+			method:         http.MethodPost,
+			url:            "/api/user/views",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":9,"name":"VIPs","query":"","filter":{},"sort":"","columns":null,"shared_with":{"admin_ids":null,"roles":null},"owner_id":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:           "Invalid view",
+			method:         http.MethodPost,
+			url:            "/api/user/views",
+			mockErr:        fmt.Errorf("%w: unknown role %q", errors.ErrInvalidView, "moderator"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid view: unknown role \"moderator\""}`,
+		},
+		{
+			name:           "Invalid sort",
+			method:         http.MethodPost,
+			url:            "/api/user/views",
+			mockErr:        fmt.Errorf("%w: %q is not sortable", errors.ErrInvalidSort, "phone_number"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid sort: \"phone_number\" is not sortable"}`,
+		},
+		{
+			name:           "Name taken",
+			method:         http.MethodPost,
+			url:            "/api/user/views",
+			mockErr:        errors.ErrViewAlreadyExists,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":409,"message":"` + errors.ViewAlreadyExists + `"}`,
+		},
+		{
+			name:           "Not the owner",
+			method:         http.MethodPut,
+			url:            "/api/user/views/9",
+			mockErr:        errors.ErrViewNotOwner,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":403,"message":"` + errors.ViewNotOwner + `"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var view *domain.View
+			if tt.mockErr == nil {
+				view = &domain.View{ID: 9, Name: "VIPs"}
+			}
+
+			viewService := new(mocks.MockViewService)
+			viewService.On("CreateView", &domain.ViewRequest{Name: "VIPs"}, viewActor).Return(view, tt.mockErr)
+			viewService.On("UpdateView", int32(9), &domain.ViewRequest{Name: "VIPs"}, viewActor).Return(view, tt.mockErr)
+
+			router := chi.NewRouter()
+			handler := handlers.NewViewHandler(viewService, router)
+			router.Post("/api/user/views", handler.CreateViewHandler)
+			router.Put("/api/user/views/{id}", handler.UpdateViewHandler)
+
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(`{"name":"VIPs"}`))
+			req = req.WithContext(middleware.ContextWithClaims(req.Context(), jwt.MapClaims{"id": float64(1), "role": "admin"}))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
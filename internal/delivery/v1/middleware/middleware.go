@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
 	"admin-panel/pkg/lib/errors"
 	"admin-panel/pkg/lib/status"
 	"admin-panel/pkg/lib/utils"
@@ -38,8 +39,7 @@ func AuthMiddleware(cfg *config.Config, allowedRoles []string) func(http.Handler
 				return
 			}
 
-			ctx := r.Context()
-			ctx = context.WithValue(ctx, tokenKey, claims)
+			ctx := ContextWithClaims(r.Context(), claims)
 
 			if hasRequiredRole(claims["role"].(string), []string{"super_admin"}) {
 				next.ServeHTTP(w, r.WithContext(ctx))
@@ -56,6 +56,30 @@ func AuthMiddleware(cfg *config.Config, allowedRoles []string) func(http.Handler
 	}
 }
 
+// RequireIfMatch rejects unconditional PUT, PATCH and DELETE requests with
+// 428 Precondition Required when strict optimistic concurrency is enabled.
+// Apply it only to routes whose handlers compare the If-Match version.
+func RequireIfMatch(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.Concurrency.RequireIfMatch {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch r.Method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				if r.Header.Get("If-Match") == "" {
+					utils.RespondWithErrorJSON(w, status.PreconditionRequired, errors.PreconditionRequired)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func validateToken(tokenString string, cfg *config.Config, isRefreshToken bool) (jwt.MapClaims, error) {
 	var secretKey string
 
@@ -114,3 +138,54 @@ func hasRequiredRole(adminRole string, allowedRoles []string) bool {
 	}
 	return false
 }
+
+// ContextWithClaims returns a copy of ctx carrying the given JWT claims,
+// the same way AuthMiddleware stores them for downstream handlers.
+func ContextWithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, tokenKey, claims)
+}
+
+// AdminIDFromContext returns the ID of the authenticated admin taken from the
+// access token claims stored in the request context.
+func AdminIDFromContext(ctx context.Context) (int32, bool) {
+	claims, ok := ctx.Value(tokenKey).(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+
+	// JSON numbers are decoded as float64 by jwt-go.
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return 0, false
+	}
+
+	return int32(id), true
+}
+
+// RoleFromContext returns the role of the authenticated admin taken from the
+// access token claims stored in the request context.
+func RoleFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ctx.Value(tokenKey).(jwt.MapClaims)
+	if !ok {
+		return "", false
+	}
+
+	role, ok := claims["role"].(string)
+	return role, ok
+}
+
+// ActorFromContext returns the authenticated admin taken from the access
+// token claims stored in the request context.
+func ActorFromContext(ctx context.Context) (domain.Actor, bool) {
+	id, ok := AdminIDFromContext(ctx)
+	if !ok {
+		return domain.Actor{}, false
+	}
+
+	role, ok := RoleFromContext(ctx)
+	if !ok {
+		return domain.Actor{}, false
+	}
+
+	return domain.Actor{AdminID: id, Role: role}, true
+}
@@ -4,17 +4,19 @@ import (
 	"admin-panel/internal/delivery/v1/handlers"
 	repository "admin-panel/internal/repository/interfaces"
 	service "admin-panel/internal/service/interfaces"
+	"net/http"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func SetupAdminRoutes(adminRepository repository.AdminRepository, adminService service.AdminService, adminRouter *chi.Mux) {
+func SetupAdminRoutes(adminRepository repository.AdminRepository, adminService service.AdminService, adminRouter *chi.Mux, requireIfMatch func(http.Handler) http.Handler) {
 	adminHandler := handlers.NewAdminHandler(adminRepository, adminService, adminRouter)
 
 	adminRouter.Get("/", adminHandler.GetAllAdminsHandler)
 	adminRouter.Get("/{id}", adminHandler.GetAdminByID)
 	adminRouter.Post("/", adminHandler.CreateAdminHandler)
-	adminRouter.Put("/{id}", adminHandler.UpdateAdminHandler)
-	adminRouter.Delete("/{id}", adminHandler.DeleteAdminHandler)
+	adminRouter.With(requireIfMatch).Put("/{id}", adminHandler.UpdateAdminHandler)
+	adminRouter.With(requireIfMatch).Patch("/{id}", adminHandler.PatchAdminHandler)
+	adminRouter.With(requireIfMatch).Delete("/{id}", adminHandler.DeleteAdminHandler)
 	adminRouter.Get("/search", adminHandler.SearchAdminsHandler)
 }
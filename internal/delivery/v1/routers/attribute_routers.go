@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupAttributeRoutes(attributeService service.AttributeService, attributeRouter *chi.Mux) {
+	attributeHandler := handlers.NewAttributeHandler(attributeService, attributeRouter)
+
+	attributeRouter.Get("/", attributeHandler.GetAttributeDefinitionsHandler)
+	attributeRouter.Post("/", attributeHandler.CreateAttributeDefinitionHandler)
+	attributeRouter.Put("/{key}", attributeHandler.UpdateAttributeDefinitionHandler)
+	attributeRouter.Delete("/{key}", attributeHandler.DeleteAttributeDefinitionHandler)
+}
@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupBulkRoutes(bulkService service.BulkService, userRouter *chi.Mux) {
+	bulkHandler := handlers.NewBulkHandler(bulkService, userRouter)
+
+	userRouter.Post("/bulk", bulkHandler.RunBulkActionHandler)
+}
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupDuplicateRoutes(duplicateService service.DuplicateService, userRouter *chi.Mux) {
+	duplicateHandler := handlers.NewDuplicateHandler(duplicateService, userRouter)
+
+	userRouter.Get("/duplicates", duplicateHandler.GetDuplicatesHandler)
+	userRouter.Post("/{id}/merge", duplicateHandler.MergeUsersHandler)
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupEmailVerificationRoutes registers the admin action sending a
+// verification link on userRouter and the public endpoint the link points to
+// on emailRouter.
+func SetupEmailVerificationRoutes(emailVerificationService service.EmailVerificationService, userRouter, emailRouter *chi.Mux) {
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService, userRouter)
+
+	userRouter.Post("/{id}/email-verification", emailVerificationHandler.SendEmailVerificationHandler)
+	emailRouter.Get("/verify", emailVerificationHandler.VerifyEmailHandler)
+}
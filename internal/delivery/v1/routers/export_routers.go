@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupExportRoutes(exportService service.ExportService, userRouter *chi.Mux) {
+	exportHandler := handlers.NewExportHandler(exportService, userRouter)
+
+	userRouter.Get("/export", exportHandler.ExportUsersHandler)
+}
@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupImportRoutes(importService service.ImportService, userRouter *chi.Mux) {
+	importHandler := handlers.NewImportHandler(importService, userRouter)
+
+	userRouter.Post("/import", importHandler.ImportUsersHandler)
+}
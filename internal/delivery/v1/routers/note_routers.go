@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupNoteRoutes(noteService service.NoteService, userRouter *chi.Mux) {
+	noteHandler := handlers.NewNoteHandler(noteService, userRouter)
+
+	userRouter.Get("/{id}/notes", noteHandler.GetNotesHandler)
+	userRouter.Post("/{id}/notes", noteHandler.CreateNoteHandler)
+	userRouter.Put("/{id}/notes/{noteID}", noteHandler.UpdateNoteHandler)
+	userRouter.Put("/{id}/notes/{noteID}/pin", noteHandler.PinNoteHandler)
+	userRouter.Delete("/{id}/notes/{noteID}", noteHandler.DeleteNoteHandler)
+}
@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupPhoneChangeRoutes(phoneChangeService service.PhoneChangeService, userRouter *chi.Mux) {
+	phoneChangeHandler := handlers.NewPhoneChangeHandler(phoneChangeService, userRouter)
+
+	userRouter.Post("/{id}/phone-change", phoneChangeHandler.StartPhoneChangeHandler)
+	userRouter.Post("/{id}/phone-change/verify", phoneChangeHandler.VerifyPhoneChangeHandler)
+	userRouter.Post("/{id}/phone-change/force", phoneChangeHandler.ForcePhoneChangeHandler)
+}
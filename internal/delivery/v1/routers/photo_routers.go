@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupPhotoRoutes(photoService service.PhotoService, userRouter *chi.Mux) {
+	photoHandler := handlers.NewPhotoHandler(photoService, userRouter)
+
+	userRouter.Post("/{id}/photo", photoHandler.UploadPhotoHandler)
+}
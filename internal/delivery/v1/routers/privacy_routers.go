@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupPrivacyRoutes(privacyService service.PrivacyService, userRouter *chi.Mux) {
+	privacyHandler := handlers.NewPrivacyHandler(privacyService, userRouter)
+
+	userRouter.Get("/{id}/data-export", privacyHandler.ExportUserDataHandler)
+	userRouter.Post("/{id}/anonymize", privacyHandler.AnonymizeUserHandler)
+}
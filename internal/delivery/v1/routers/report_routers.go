@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupReportRoutes(reportService service.ReportService, reportRouter *chi.Mux) {
+	reportHandler := handlers.NewReportHandler(reportService, reportRouter)
+
+	reportRouter.Get("/", reportHandler.GetReportsHandler)
+	reportRouter.Post("/", reportHandler.CreateReportHandler)
+	reportRouter.Post("/run", reportHandler.RunReportHandler)
+	reportRouter.Get("/{id}", reportHandler.GetReportByIDHandler)
+	reportRouter.Put("/{id}", reportHandler.UpdateReportHandler)
+	reportRouter.Delete("/{id}", reportHandler.DeleteReportHandler)
+	reportRouter.Get("/{id}/run", reportHandler.RunSavedReportHandler)
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupSegmentRoutes(segmentService service.SegmentService, segmentRouter *chi.Mux) {
+	segmentHandler := handlers.NewSegmentHandler(segmentService, segmentRouter)
+
+	segmentRouter.Get("/", segmentHandler.GetSegmentsHandler)
+	segmentRouter.Post("/", segmentHandler.CreateSegmentHandler)
+	segmentRouter.Get("/{id}", segmentHandler.GetSegmentByIDHandler)
+	segmentRouter.Put("/{id}", segmentHandler.UpdateSegmentHandler)
+	segmentRouter.Delete("/{id}", segmentHandler.DeleteSegmentHandler)
+	segmentRouter.Get("/{id}/users", segmentHandler.GetSegmentUsersHandler)
+	segmentRouter.Get("/{id}/count", segmentHandler.CountSegmentUsersHandler)
+}
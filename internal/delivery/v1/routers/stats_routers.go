@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupStatsRoutes(statsService service.StatsService, userRouter *chi.Mux) {
+	statsHandler := handlers.NewStatsHandler(statsService, userRouter)
+
+	userRouter.Get("/stats", statsHandler.GetUserStatsHandler)
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupTagRoutes registers tag management on tagRouter and tagging of
+// individual users on userRouter.
+func SetupTagRoutes(tagService service.TagService, tagRouter, userRouter *chi.Mux) {
+	tagHandler := handlers.NewTagHandler(tagService, tagRouter)
+
+	tagRouter.Get("/", tagHandler.GetTagsHandler)
+	tagRouter.Post("/", tagHandler.CreateTagHandler)
+	tagRouter.Put("/{id}", tagHandler.UpdateTagHandler)
+	tagRouter.Delete("/{id}", tagHandler.DeleteTagHandler)
+
+	userRouter.Post("/{id}/tags", tagHandler.TagUserHandler)
+	userRouter.Delete("/{id}/tags/{tag}", tagHandler.UntagUserHandler)
+}
@@ -4,19 +4,24 @@ import (
 	"admin-panel/internal/delivery/v1/handlers"
 	repository "admin-panel/internal/repository/interfaces"
 	service "admin-panel/internal/service/interfaces"
+	"net/http"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func SetupUserRoutes(userRepository repository.UserRepository, userService service.UserService, userRouter *chi.Mux) {
+func SetupUserRoutes(userRepository repository.UserRepository, userService service.UserService, userRouter *chi.Mux, requireIfMatch func(http.Handler) http.Handler) {
 	userHandler := handlers.NewUserHandler(userRepository, userService, userRouter)
 
 	userRouter.Get("/", userHandler.GetAllUsersHandler)
 	userRouter.Get("/{id}", userHandler.GetUserByIDHandler)
 	userRouter.Post("/", userHandler.CreateUserHandler)
-	userRouter.Put("/{id}", userHandler.UpdateUserHandler)
-	userRouter.Delete("/{id}", userHandler.DeleteUserHandler)
+	userRouter.With(requireIfMatch).Put("/{id}", userHandler.UpdateUserHandler)
+	userRouter.With(requireIfMatch).Patch("/{id}", userHandler.PatchUserHandler)
+	userRouter.With(requireIfMatch).Delete("/{id}", userHandler.DeleteUserHandler)
 	userRouter.Post("/{id}/block", userHandler.BlockUserHandler)
 	userRouter.Post("/{id}/unblock", userHandler.UnblockUserHandler)
+	userRouter.Get("/{id}/blocks", userHandler.GetUserBlocksHandler)
+	userRouter.Get("/{id}/history", userHandler.GetUserHistoryHandler)
+	userRouter.Post("/{id}/history/{version}/revert", userHandler.RevertUserHandler)
 	userRouter.Get("/search", userHandler.SearchUsersHandler)
 }
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"admin-panel/internal/delivery/v1/handlers"
+	service "admin-panel/internal/service/interfaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupViewRoutes(viewService service.ViewService, userRouter *chi.Mux) {
+	viewHandler := handlers.NewViewHandler(viewService, userRouter)
+
+	userRouter.Get("/views", viewHandler.GetViewsHandler)
+	userRouter.Post("/views", viewHandler.CreateViewHandler)
+	userRouter.Get("/views/{id}", viewHandler.GetViewResultsHandler)
+	userRouter.Put("/views/{id}", viewHandler.UpdateViewHandler)
+	userRouter.Delete("/views/{id}", viewHandler.DeleteViewHandler)
+}
@@ -4,6 +4,20 @@ import (
 	"time"
 )
 
+// Actor is the authenticated admin on whose behalf a request is made, for
+// operations whose outcome depends on who is asking.
+type Actor struct {
+	AdminID int32
+	Role    string
+}
+
+// AdminRoles are the roles an admin can have.
+var AdminRoles = []string{"admin", "super_admin"}
+
+func (a Actor) IsSuperAdmin() bool {
+	return a.Role == "super_admin"
+}
+
 type AdminsList struct {
 	Admins []GetAdminResponse `json:"admins"`
 }
@@ -37,10 +51,21 @@ type CommonAdminRequest struct {
 	Role     string `json:"role"`
 }
 
+// PatchAdminRequest holds the fields changed by a PATCH request. Nil fields
+// are left untouched.
+type PatchAdminRequest struct {
+	Username *string `json:"username,omitempty"`
+	Password *string `json:"password,omitempty"`
+	Role     *string `json:"role,omitempty"`
+
+	ExpectedVersion *int32 `json:"-"`
+}
+
 type CommonAdminResponse struct {
 	ID       int32  `json:"id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	Version  int32  `json:"-"`
 }
 
 type GetAdminResponse CommonAdminResponse
@@ -49,6 +74,11 @@ type CreateAdminRequest CommonAdminRequest
 
 type CreateAdminResponse CommonAdminResponse
 
-type UpdateAdminRequest CommonAdminRequest
+type UpdateAdminRequest struct {
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	Role            string `json:"role"`
+	ExpectedVersion *int32 `json:"-"`
+}
 
 type UpdateAdminResponse CommonAdminResponse
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AttributeType string
+
+const (
+	AttributeTypeString  AttributeType = "string"
+	AttributeTypeNumber  AttributeType = "number"
+	AttributeTypeInteger AttributeType = "integer"
+	AttributeTypeBoolean AttributeType = "boolean"
+	// AttributeTypeDate values are strings in YYYY-MM-DD format.
+	AttributeTypeDate AttributeType = "date"
+)
+
+var AttributeTypes = []AttributeType{
+	AttributeTypeString,
+	AttributeTypeNumber,
+	AttributeTypeInteger,
+	AttributeTypeBoolean,
+	AttributeTypeDate,
+}
+
+// AttributeDefinition describes a custom attribute users may carry. Enum
+// restricts string attributes to a fixed set of values.
+type AttributeDefinition struct {
+	Key         string        `json:"key"`
+	Type        AttributeType `json:"type"`
+	Enum        []string      `json:"enum,omitempty"`
+	Required    bool          `json:"required"`
+	Description string        `json:"description"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+type AttributeDefinitionRequest struct {
+	Key         string        `json:"key"`
+	Type        AttributeType `json:"type"`
+	Enum        []string      `json:"enum"`
+	Required    bool          `json:"required"`
+	Description string        `json:"description"`
+}
+
+type AttributeDefinitionsList struct {
+	Attributes []AttributeDefinition `json:"attributes"`
+}
+
+// UserAttributes holds a user's custom attribute values, stored as a JSONB
+// object.
+type UserAttributes map[string]interface{}
+
+func (a UserAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(map[string]interface{}(a))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (a *UserAttributes) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = UserAttributes{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into UserAttributes", src)
+	}
+
+	attributes := UserAttributes{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+	*a = attributes
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package domain
+
+import "time"
+
+type BulkAction string
+
+const (
+	BulkActionBlock   BulkAction = "block"
+	BulkActionUnblock BulkAction = "unblock"
+	BulkActionDelete  BulkAction = "delete"
+)
+
+var BulkActions = []BulkAction{BulkActionBlock, BulkActionUnblock, BulkActionDelete}
+
+type BulkMode string
+
+const (
+	// BulkModeAtomic applies the action to all users in one transaction, or
+	// to none of them if any user is missing.
+	BulkModeAtomic BulkMode = "atomic"
+	// BulkModeBestEffort applies the action to each user separately.
+	BulkModeBestEffort BulkMode = "best_effort"
+)
+
+// BulkRequest targets users either by IDs, by a search query and filter, as
+// used by the list and search endpoints, or by a saved segment.
+type BulkRequest struct {
+	Action    BulkAction  `json:"action"`
+	Mode      BulkMode    `json:"mode"`
+	IDs       []int32     `json:"ids"`
+	Query     string      `json:"query"`
+	Filter    *UserFilter `json:"filter"`
+	SegmentID *int32      `json:"segment_id"`
+	// Block holds the reason, note and expiry of the block action.
+	Block BlockUserRequest `json:"block"`
+	// ConfirmationToken confirms an operation over the confirmation
+	// threshold once. It is returned by the first, unconfirmed attempt.
+	ConfirmationToken string `json:"confirmation_token"`
+	ActorID           int32  `json:"-"`
+}
+
+// BulkBatch is applied by the repository in a single transaction.
+type BulkBatch struct {
+	Action  BulkAction
+	IDs     []int32
+	Block   BlockUserRequest
+	ActorID int32
+	Atomic  bool
+}
+
+type BulkResultStatus string
+
+const (
+	BulkStatusOK       BulkResultStatus = "ok"
+	BulkStatusNotFound BulkResultStatus = "not_found"
+	BulkStatusFailed   BulkResultStatus = "failed"
+	// BulkStatusRolledBack marks users left unchanged because another user
+	// of an atomic operation failed.
+	BulkStatusRolledBack BulkResultStatus = "rolled_back"
+)
+
+type BulkResult struct {
+	ID     int32            `json:"id"`
+	Status BulkResultStatus `json:"status"`
+	Error  string           `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Action    BulkAction   `json:"action"`
+	Mode      BulkMode     `json:"mode"`
+	Total     int          `json:"total"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// BulkConfirmation is returned instead of running an operation that affects
+// more users than the confirmation threshold. Repeating the request with
+// Token runs it, provided it still targets the same users.
+type BulkConfirmation struct {
+	Status    int       `json:"status"`
+	Message   string    `json:"message"`
+	Token     string    `json:"confirmation_token"`
+	Affected  int       `json:"affected"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
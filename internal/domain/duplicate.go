@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// DuplicateProfile holds the fields of a user compared when looking for
+// duplicates.
+type DuplicateProfile struct {
+	ID               int32     `json:"id"`
+	FirstName        string    `json:"first_name"`
+	LastName         string    `json:"last_name"`
+	PhoneNumber      string    `json:"phone_number"`
+	Email            string    `json:"email"`
+	DateOfBirth      time.Time `json:"date_of_birth"`
+	Location         string    `json:"location"`
+	RegistrationDate time.Time `json:"registration_date"`
+}
+
+// DuplicateSignals holds the similarity of each compared field, from 0 to 1.
+// Fields missing on either user are not compared and left nil.
+type DuplicateSignals struct {
+	Name        float64  `json:"name"`
+	Email       *float64 `json:"email,omitempty"`
+	DateOfBirth *float64 `json:"date_of_birth,omitempty"`
+	Location    *float64 `json:"location,omitempty"`
+}
+
+// DuplicatePair is a pair of users likely registered by the same person,
+// ordered by ID. Score is the weighted mean of the compared signals.
+type DuplicatePair struct {
+	Score   float64            `json:"score"`
+	Signals DuplicateSignals   `json:"signals"`
+	Users   []DuplicateProfile `json:"users"`
+}
+
+type DuplicatesList struct {
+	Pairs []DuplicatePair `json:"pairs"`
+}
+
+type DuplicatesListResponse struct {
+	Duplicates  *DuplicatesList `json:"duplicates"`
+	CurrentPage int             `json:"currentPage"`
+	PrevPage    int             `json:"previousPage"`
+	NextPage    int             `json:"nextPage"`
+	FirstPage   int             `json:"firstPage"`
+	LastPage    int             `json:"lastPage"`
+}
+
+type MergeSource string
+
+const (
+	MergeSourceSurvivor  MergeSource = "survivor"
+	MergeSourceDuplicate MergeSource = "duplicate"
+)
+
+// MergeFields are the fields whose value can be taken from the duplicate
+// when merging. The phone number always stays with the surviving user.
+var MergeFields = []string{"first_name", "last_name", "gender", "date_of_birth", "location", "email", "profile_photo_url"}
+
+// MergeUsersRequest merges the duplicate into the surviving user. Fields
+// picks the source of each field in MergeFields; unlisted fields keep the
+// survivor's value. Custom attributes the survivor lacks are taken from the
+// duplicate.
+type MergeUsersRequest struct {
+	DuplicateID int32                  `json:"duplicate_id"`
+	Fields      map[string]MergeSource `json:"fields"`
+	MergedBy    int32                  `json:"-"`
+}
+
+// UserMerge is a merge ready to be written. Survivor holds the changes to the
+// surviving user; notes, tags and history of the duplicate move to it and the
+// duplicate is soft-deleted.
+type UserMerge struct {
+	SurvivorID  int32
+	DuplicateID int32
+	Survivor    PatchUserRequest
+	// ReleaseEmail clears the duplicate's email address, which the survivor
+	// takes over.
+	ReleaseEmail bool
+	MergedBy     int32
+}
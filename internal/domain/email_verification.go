@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// EmailVerification is a single-use link sent to a user's email address to
+// confirm they own it.
+type EmailVerification struct {
+	ID        int32      `json:"id"`
+	UserID    int32      `json:"user_id"`
+	Email     string     `json:"email"`
+	TokenHash string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	ExportFormatXLSX   ExportFormat = "xlsx"
+)
+
+var ExportFormats = []ExportFormat{ExportFormatCSV, ExportFormatNDJSON, ExportFormatXLSX}
+
+// ExportColumns are the user fields an export can select, named after the
+// JSON fields of GetUserResponse. Custom attributes are selected as
+// "attr.<key>". Without a selection all of ExportColumns are exported.
+var ExportColumns = []string{
+	"id",
+	"first_name",
+	"last_name",
+	"phone_number",
+	"blocked",
+	"gender",
+	"registration_date",
+	"date_of_birth",
+	"location",
+	"email",
+	"profile_photo_url",
+	"email_verified",
+	"email_verified_at",
+	"attributes",
+	"tags",
+	"block_reason",
+	"block_expires_at",
+}
+
+// ExportRequest selects the users of an export with the filters of the
+// list and search endpoints. An empty Query exports without searching.
+type ExportRequest struct {
+	Format  ExportFormat
+	Columns []string
+	Query   string
+	Filter  *UserFilter
+}
@@ -0,0 +1,80 @@
+package domain
+
+// ImportFields are the user fields an import column can be mapped to.
+// Custom attributes are mapped as "attr.<key>".
+var ImportFields = []string{
+	"first_name",
+	"last_name",
+	"phone_number",
+	"gender",
+	"date_of_birth",
+	"location",
+	"email",
+}
+
+// AttributeColumnPrefix names custom attribute columns in imports and
+// exports, as in "attr.tier".
+const AttributeColumnPrefix = "attr."
+
+// ImportMapping maps an import field to the header of the column holding it.
+// Without a mapping columns are matched to fields by header name.
+type ImportMapping map[string]string
+
+type ImportOptions struct {
+	Mapping ImportMapping
+	DryRun  bool
+	// UpsertByPhone updates users whose phone number is already registered
+	// instead of reporting the row as a duplicate. Only mapped fields are
+	// overwritten.
+	UpsertByPhone bool
+	ImportedBy    int32
+}
+
+// ImportUser is a validated import row. Row is its line in the file.
+type ImportUser struct {
+	Row  int
+	User CreateUserRequest
+}
+
+// ImportBatch is written in one transaction. Fields lists the mapped fields
+// an upsert overwrites.
+type ImportBatch struct {
+	Users         []ImportUser
+	Fields        []string
+	UpsertByPhone bool
+	ImportedBy    int32
+}
+
+type ImportBatchResult struct {
+	Created int
+	Updated int
+}
+
+// UserContact identifies the user holding a phone number or email address.
+// Users merged into another one keep their phone number but are deleted, and
+// MergedInto names the user they were merged into.
+type UserContact struct {
+	ID          int32
+	PhoneNumber string
+	Email       string
+	Deleted     bool
+	MergedInto  *int32
+}
+
+// ImportRowError reports why a row was rejected. Row is the 1-based line in
+// the file, the header being line 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	Valid     int              `json:"valid"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Failed    int              `json:"failed"`
+	Errors    []ImportRowError `json:"errors"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+type NoteVisibility string
+
+const (
+	NoteVisibilityAll        NoteVisibility = "all"
+	NoteVisibilitySuperAdmin NoteVisibility = "super_admin"
+)
+
+// Note is an internal remark left by an admin on a user record. The body is
+// markdown and is stored as written; rendering is up to the client.
+type Note struct {
+	ID             int32          `json:"id"`
+	UserID         int32          `json:"user_id"`
+	AuthorID       *int32         `json:"author_id"`
+	AuthorUsername string         `json:"author_username,omitempty"`
+	Body           string         `json:"body"`
+	Pinned         bool           `json:"pinned"`
+	Visibility     NoteVisibility `json:"visibility"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type NoteRequest struct {
+	Body       string         `json:"body"`
+	Visibility NoteVisibility `json:"visibility,omitempty"`
+	AuthorID   int32          `json:"-"`
+}
+
+type PinNoteRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
+type NotesList struct {
+	Notes []Note `json:"notes"`
+}
+
+type NotesListResponse struct {
+	Notes       *NotesList `json:"notes"`
+	CurrentPage int        `json:"currentPage"`
+	PrevPage    int        `json:"previousPage"`
+	NextPage    int        `json:"nextPage"`
+	FirstPage   int        `json:"firstPage"`
+	LastPage    int        `json:"lastPage"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// PatchFormat identifies the media type of a PATCH request body.
+type PatchFormat string
+
+const (
+	MergePatchFormat PatchFormat = "application/merge-patch+json"
+	JSONPatchFormat  PatchFormat = "application/json-patch+json"
+)
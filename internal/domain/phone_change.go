@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+type PhoneChangeStatus string
+
+const (
+	PhoneChangePending   PhoneChangeStatus = "pending"
+	PhoneChangeVerified  PhoneChangeStatus = "verified"
+	PhoneChangeForced    PhoneChangeStatus = "forced"
+	PhoneChangeCancelled PhoneChangeStatus = "cancelled"
+)
+
+// PhoneChange is a request to replace a user's phone number. A pending change
+// is committed once the code sent to the new number is verified.
+type PhoneChange struct {
+	ID             int32             `json:"id"`
+	UserID         int32             `json:"user_id"`
+	OldPhoneNumber string            `json:"old_phone_number"`
+	NewPhoneNumber string            `json:"new_phone_number"`
+	Status         PhoneChangeStatus `json:"status"`
+	Reason         string            `json:"reason,omitempty"`
+	RequestedBy    *int32            `json:"requested_by"`
+	RequestedAt    time.Time         `json:"requested_at"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	Attempts       int               `json:"attempts"`
+	CompletedAt    *time.Time        `json:"completed_at,omitempty"`
+	CodeHash       string            `json:"-"`
+}
+
+type StartPhoneChangeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	RequestedBy int32  `json:"-"`
+}
+
+type VerifyPhoneChangeRequest struct {
+	Code string `json:"code"`
+}
+
+type ForcePhoneChangeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Reason      string `json:"reason"`
+	ForcedBy    int32  `json:"-"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+// ProfilePhoto points at an uploaded profile photo. Key is the storage prefix
+// below which every rendition of the photo is stored.
+type ProfilePhoto struct {
+	URL string
+	Key string
+}
+
+type UploadPhotoResponse struct {
+	ProfilePhotoURL string            `json:"profile_photo_url"`
+	Thumbnails      map[string]string `json:"thumbnails"`
+}
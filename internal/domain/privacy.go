@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// UserEvent is an entry of the audit trail kept about a user outside the
+// field history: blocks, phone changes, email verifications and merges.
+type UserEvent struct {
+	Type    string                 `json:"type"`
+	At      time.Time              `json:"at"`
+	ActorID *int32                 `json:"actor_id"`
+	Details map[string]interface{} `json:"details"`
+}
+
+// UserDataExport is everything held about a user, as exported in answer to
+// a data subject access request.
+type UserDataExport struct {
+	Profile *GetUserResponse   `json:"profile"`
+	Blocks  []UserBlock        `json:"blocks"`
+	History []UserHistoryEntry `json:"history"`
+	Notes   []Note             `json:"notes"`
+	Events  []UserEvent        `json:"events"`
+}
+
+// UserDataExportManifest describes the files of a data export archive.
+type UserDataExportManifest struct {
+	UserID      int32     `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	GeneratedBy int32     `json:"generated_by"`
+	Files       []string  `json:"files"`
+}
+
+// UserAnonymization is the result of anonymizing a user. PhotoKeys are the
+// storage keys of the profile photos that were removed from the user and
+// the users merged into it.
+type UserAnonymization struct {
+	ID           int32     `json:"id"`
+	AnonymizedAt time.Time `json:"anonymized_at"`
+	PhotoKeys    []string  `json:"-"`
+}
@@ -0,0 +1,178 @@
+package domain
+
+import "time"
+
+// ReportFieldType is the type of a report field, which decides the
+// measures and filter operators it supports.
+type ReportFieldType string
+
+const (
+	ReportFieldString  ReportFieldType = "string"
+	ReportFieldBoolean ReportFieldType = "boolean"
+	ReportFieldDate    ReportFieldType = "date"
+	ReportFieldNumber  ReportFieldType = "number"
+)
+
+// ReportFields are the user fields reports can group by, aggregate and
+// filter on. String fields are compared in lowercase and age is in whole
+// years.
+var ReportFields = map[string]ReportFieldType{
+	"gender":            ReportFieldString,
+	"location":          ReportFieldString,
+	"blocked":           ReportFieldBoolean,
+	"email_verified":    ReportFieldBoolean,
+	"has_email":         ReportFieldBoolean,
+	"has_photo":         ReportFieldBoolean,
+	"registration_date": ReportFieldDate,
+	"date_of_birth":     ReportFieldDate,
+	"age":               ReportFieldNumber,
+}
+
+// ReportBucket truncates a date dimension to the start of its day, week
+// (Monday), month, quarter or year.
+type ReportBucket string
+
+const (
+	ReportBucketDay     ReportBucket = "day"
+	ReportBucketWeek    ReportBucket = "week"
+	ReportBucketMonth   ReportBucket = "month"
+	ReportBucketQuarter ReportBucket = "quarter"
+	ReportBucketYear    ReportBucket = "year"
+)
+
+var ReportBuckets = []ReportBucket{ReportBucketDay, ReportBucketWeek, ReportBucketMonth, ReportBucketQuarter, ReportBucketYear}
+
+type ReportFunction string
+
+const (
+	ReportCount         ReportFunction = "count"
+	ReportCountDistinct ReportFunction = "count_distinct"
+	ReportMin           ReportFunction = "min"
+	ReportMax           ReportFunction = "max"
+	ReportAvg           ReportFunction = "avg"
+	ReportSum           ReportFunction = "sum"
+)
+
+type ReportOperator string
+
+const (
+	ReportEq    ReportOperator = "eq"
+	ReportNe    ReportOperator = "ne"
+	ReportGt    ReportOperator = "gt"
+	ReportGte   ReportOperator = "gte"
+	ReportLt    ReportOperator = "lt"
+	ReportLte   ReportOperator = "lte"
+	ReportIn    ReportOperator = "in"
+	ReportNotIn ReportOperator = "not_in"
+)
+
+// ReportOperators are the filter operators supported by each field type.
+var ReportOperators = map[ReportFieldType][]ReportOperator{
+	ReportFieldString:  {ReportEq, ReportNe, ReportIn, ReportNotIn},
+	ReportFieldBoolean: {ReportEq, ReportNe},
+	ReportFieldDate:    {ReportEq, ReportNe, ReportGt, ReportGte, ReportLt, ReportLte},
+	ReportFieldNumber:  {ReportEq, ReportNe, ReportGt, ReportGte, ReportLt, ReportLte, ReportIn, ReportNotIn},
+}
+
+// ReportDimension groups the report rows by a field. Date fields are
+// grouped by Bucket, a day by default.
+type ReportDimension struct {
+	Field  string       `json:"field"`
+	Bucket ReportBucket `json:"bucket,omitempty"`
+}
+
+// Name is the column of the dimension in the report, such as "location" or
+// "registration_date_month".
+func (d ReportDimension) Name() string {
+	if d.Bucket == "" {
+		return d.Field
+	}
+	return d.Field + "_" + string(d.Bucket)
+}
+
+// ReportMeasure aggregates the users of each row. Count takes no field.
+type ReportMeasure struct {
+	Function ReportFunction `json:"function"`
+	Field    string         `json:"field,omitempty"`
+}
+
+// Name is the column of the measure in the report, such as "count" or
+// "avg_age".
+func (m ReportMeasure) Name() string {
+	if m.Field == "" {
+		return string(m.Function)
+	}
+	return string(m.Function) + "_" + m.Field
+}
+
+// ReportFilter restricts the users a report covers. Value is a single value
+// of the field's type, dates as YYYY-MM-DD or RFC 3339, or a list of values
+// for in and not_in.
+type ReportFilter struct {
+	Field    string         `json:"field"`
+	Operator ReportOperator `json:"op"`
+	Value    interface{}    `json:"value"`
+}
+
+// ReportDefinition describes an aggregate report over users: one row per
+// combination of dimension values, with a column per dimension followed by a
+// column per measure. All filters must match. Sort orders the rows by
+// columns in the format of the sort parameter; rows are ordered by the
+// dimensions otherwise. Limit caps the number of rows, at the configured
+// maximum when zero.
+type ReportDefinition struct {
+	Dimensions []ReportDimension `json:"dimensions"`
+	Measures   []ReportMeasure   `json:"measures"`
+	Filters    []ReportFilter    `json:"filters"`
+	Sort       string            `json:"sort"`
+	Limit      int               `json:"limit"`
+}
+
+// Columns returns the names of the report columns in order.
+func (d *ReportDefinition) Columns() []string {
+	columns := make([]string, 0, len(d.Dimensions)+len(d.Measures))
+	for _, dimension := range d.Dimensions {
+		columns = append(columns, dimension.Name())
+	}
+	for _, measure := range d.Measures {
+		columns = append(columns, measure.Name())
+	}
+	return columns
+}
+
+// Report is a saved report definition.
+type Report struct {
+	ID          int32            `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Definition  ReportDefinition `json:"definition"`
+	CreatedBy   *int32           `json:"created_by"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type ReportRequest struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Definition  ReportDefinition `json:"definition"`
+	CreatedBy   int32            `json:"-"`
+}
+
+type ReportsList struct {
+	Reports []Report `json:"reports"`
+}
+
+type ReportFormat string
+
+const (
+	ReportFormatJSON ReportFormat = "json"
+	ReportFormatCSV  ReportFormat = "csv"
+)
+
+// ReportResult holds the rows of a report run. Dates of date dimensions are
+// YYYY-MM-DD strings. Truncated reports had more rows than their limit.
+type ReportResult struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// Segment is a saved user filter. Its members are evaluated whenever the
+// segment is used, so they change as users change.
+type Segment struct {
+	ID          int32      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Filter      UserFilter `json:"filter"`
+	CreatedBy   *int32     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type SegmentRequest struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Filter      UserFilter `json:"filter"`
+	CreatedBy   int32      `json:"-"`
+}
+
+type SegmentsList struct {
+	Segments []Segment `json:"segments"`
+}
+
+type SegmentCount struct {
+	ID    int32 `json:"id"`
+	Count int   `json:"count"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "strings"
+
+// SortField orders a listing by one field, ascending unless Descending.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// Sort orders a listing by its fields in turn. An empty Sort orders by ID.
+type Sort []SortField
+
+// String returns sort in the format of the sort query parameter.
+func (s Sort) String() string {
+	fields := make([]string, len(s))
+	for i, field := range s {
+		fields[i] = field.Field
+		if field.Descending {
+			fields[i] = "-" + fields[i]
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// ParseSort reads a sort order in the format of the sort query parameter, a
+// comma-separated list of fields each prefixed with - to sort descending.
+func ParseSort(s string) Sort {
+	var sort Sort
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, descending := strings.CutPrefix(field, "-")
+		sort = append(sort, SortField{Field: name, Descending: descending})
+	}
+
+	return sort
+}
+
+// UserSortFields are the fields users can be sorted by.
+var UserSortFields = []string{"id", "first_name", "last_name", "registration_date", "date_of_birth", "location", "gender", "blocked"}
+
+// AdminSortFields are the fields admins can be sorted by.
+var AdminSortFields = []string{"id", "username", "role"}
@@ -0,0 +1,91 @@
+package domain
+
+import "time"
+
+// StatsInterval is the width of the time buckets registrations are counted
+// in.
+type StatsInterval string
+
+const (
+	StatsIntervalDay   StatsInterval = "day"
+	StatsIntervalWeek  StatsInterval = "week"
+	StatsIntervalMonth StatsInterval = "month"
+)
+
+var StatsIntervals = []StatsInterval{StatsIntervalDay, StatsIntervalWeek, StatsIntervalMonth}
+
+// UserStatsRequest selects the statistics of GET /api/user/stats. From and
+// To are dates, both included, in TimeZone. Zero values select the
+// defaults.
+type UserStatsRequest struct {
+	From         time.Time
+	To           time.Time
+	Interval     StatsInterval
+	TimeZone     string
+	TopLocations int
+}
+
+// UserStatsQuery is a UserStatsRequest resolved for the repository: Start
+// and End are the instants bounding the registrations counted, End excluded,
+// and Today is the current date in TimeZone, which ages are computed at.
+type UserStatsQuery struct {
+	Start        time.Time
+	End          time.Time
+	Interval     StatsInterval
+	TimeZone     string
+	Today        time.Time
+	TopLocations int
+}
+
+// UserStatsCounts are the aggregates read from the users table.
+type UserStatsCounts struct {
+	// Registrations holds the buckets with at least one registration, each
+	// identified by the date it starts on.
+	Registrations []RegistrationCount
+	Active        int
+	Blocked       int
+	Genders       []StatsGroup
+	Locations     []StatsGroup
+	// Ages counts users by age in whole years.
+	Ages map[int]int
+}
+
+type RegistrationCount struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// StatsGroup counts the users sharing a value of a field.
+type StatsGroup struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type UserStatusStats struct {
+	Active       int     `json:"active"`
+	Blocked      int     `json:"blocked"`
+	BlockedRatio float64 `json:"blocked_ratio"`
+}
+
+type AgeBucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// UserStats are the statistics of GET /api/user/stats. Registrations cover
+// the requested range; the other figures describe all current users.
+// Registration buckets start on the dates they are labeled with, in
+// TimeZone, and include empty buckets.
+type UserStats struct {
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Interval      StatsInterval       `json:"interval"`
+	TimeZone      string              `json:"time_zone"`
+	Total         int                 `json:"total"`
+	Registrations []RegistrationCount `json:"registrations"`
+	Status        UserStatusStats     `json:"status"`
+	Genders       []StatsGroup        `json:"genders"`
+	TopLocations  []StatsGroup        `json:"top_locations"`
+	AgeBuckets    []AgeBucket         `json:"age_buckets"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+}
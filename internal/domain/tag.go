@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type Tag struct {
+	ID          int32     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UserCount   int       `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TagRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type TagsList struct {
+	Tags []Tag `json:"tags"`
+}
+
+type TagUserRequest struct {
+	Tags     []string `json:"tags"`
+	TaggedBy int32    `json:"-"`
+}
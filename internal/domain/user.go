@@ -12,6 +12,25 @@ type UsersList struct {
 	Users []GetUserResponse `json:"users"`
 }
 
+// UserFilter narrows user listings; users must match every criterion that is
+// set. Attributes holds custom attribute values a user must have; values read
+// from a query string are converted to the attribute's type by the service.
+type UserFilter struct {
+	Tags             []string               `json:"tags,omitempty"`
+	Blocked          *bool                  `json:"blocked,omitempty"`
+	Gender           string                 `json:"gender,omitempty"`
+	Location         string                 `json:"location,omitempty"`
+	MinAge           *int                   `json:"min_age,omitempty"`
+	MaxAge           *int                   `json:"max_age,omitempty"`
+	BornAfter        *time.Time             `json:"born_after,omitempty"`
+	BornBefore       *time.Time             `json:"born_before,omitempty"`
+	RegisteredAfter  *time.Time             `json:"registered_after,omitempty"`
+	RegisteredBefore *time.Time             `json:"registered_before,omitempty"`
+	HasEmail         *bool                  `json:"has_email,omitempty"`
+	HasPhoto         *bool                  `json:"has_photo,omitempty"`
+	Attributes       map[string]interface{} `json:"attributes,omitempty"`
+}
+
 type UsersListResponse struct {
 	Users       *UsersList `json:"users"`
 	CurrentPage int        `json:"currentPage"`
@@ -21,39 +40,110 @@ type UsersListResponse struct {
 	LastPage    int        `json:"lastPage"`
 }
 
+// CountMode selects how the total of a cursor-paginated listing is counted.
+type CountMode string
+
+const (
+	CountNone  CountMode = ""
+	CountExact CountMode = "exact"
+	// CountEstimated uses the planner's estimate of the number of users, which
+	// is cheap but ignores filters; filtered listings are counted exactly.
+	CountEstimated CountMode = "estimated"
+)
+
+// UsersCursorRequest asks for the page of users after, or before, the
+// position encoded in Cursor. An empty Cursor starts at the first user.
+type UsersCursorRequest struct {
+	Cursor string
+	Limit  int
+	Filter *UserFilter
+	Sort   Sort
+	Count  CountMode
+}
+
+type UsersCursorResponse struct {
+	Users          *UsersList `json:"users"`
+	NextCursor     string     `json:"nextCursor,omitempty"`
+	PrevCursor     string     `json:"previousCursor,omitempty"`
+	Total          *int       `json:"total,omitempty"`
+	TotalEstimated bool       `json:"totalEstimated,omitempty"`
+}
+
+// UsersKeyset selects a page of users by the sort key of the user it follows
+// or, if Backward, precedes. Key holds the values of the sort fields followed
+// by the ID, as text; a nil Key selects the first page.
+type UsersKeyset struct {
+	Sort     Sort
+	Key      []string
+	Backward bool
+	Limit    int
+}
+
 type CreateUserRequest struct {
-	FirstName       string    `json:"first_name"`
-	LastName        string    `json:"last_name"`
-	PhoneNumber     string    `json:"phone_number"`
-	Gender          string    `json:"gender"`
-	DateOfBirth     time.Time `json:"date_of_birth"`
-	Location        string    `json:"location"`
-	Email           string    `json:"email"`
-	ProfilePhotoURL string    `json:"profile_photo_url"`
+	FirstName       string         `json:"first_name"`
+	LastName        string         `json:"last_name"`
+	PhoneNumber     string         `json:"phone_number"`
+	Gender          string         `json:"gender"`
+	DateOfBirth     time.Time      `json:"date_of_birth"`
+	Location        string         `json:"location"`
+	Email           string         `json:"email"`
+	ProfilePhotoURL string         `json:"profile_photo_url"`
+	Attributes      UserAttributes `json:"attributes"`
+	CreatedBy       int32          `json:"-"`
 }
 
 type UpdateUserRequest struct {
-	FirstName       string    `json:"first_name"`
-	LastName        string    `json:"last_name"`
-	Gender          string    `json:"gender"`
-	DateOfBirth     time.Time `json:"date_of_birth"`
-	Location        string    `json:"location"`
-	Email           string    `json:"email"`
-	ProfilePhotoURL string    `json:"profile_photo_url"`
+	FirstName       string         `json:"first_name"`
+	LastName        string         `json:"last_name"`
+	Gender          string         `json:"gender"`
+	DateOfBirth     time.Time      `json:"date_of_birth"`
+	Location        string         `json:"location"`
+	Email           string         `json:"email"`
+	ProfilePhotoURL string         `json:"profile_photo_url"`
+	Attributes      UserAttributes `json:"attributes"`
+	ExpectedVersion *int32         `json:"-"`
+	UpdatedBy       int32          `json:"-"`
+}
+
+// PatchUserRequest holds the fields changed by a PATCH request. Nil fields
+// are left untouched.
+type PatchUserRequest struct {
+	FirstName       *string    `json:"first_name,omitempty"`
+	LastName        *string    `json:"last_name,omitempty"`
+	Gender          *string    `json:"gender,omitempty"`
+	DateOfBirth     *time.Time `json:"date_of_birth,omitempty"`
+	Location        *string    `json:"location,omitempty"`
+	Email           *string    `json:"email,omitempty"`
+	ProfilePhotoURL *string    `json:"profile_photo_url,omitempty"`
+	// Attributes replaces all custom attributes when non-nil.
+	Attributes UserAttributes `json:"attributes,omitempty"`
+
+	ExpectedVersion *int32 `json:"-"`
+	UpdatedBy       int32  `json:"-"`
 }
 
 type CommonUserResponse struct {
-	ID               int32     `json:"id"`
-	FirstName        string    `json:"first_name"`
-	LastName         string    `json:"last_name"`
-	PhoneNumber      string    `json:"phone_number"`
-	Blocked          bool      `json:"blocked"`
-	Gender           string    `json:"gender"`
-	RegistrationDate time.Time `json:"registration_date"`
-	DateOfBirth      time.Time `json:"date_of_birth"`
-	Location         string    `json:"location"`
-	Email            string    `json:"email"`
-	ProfilePhotoURL  string    `json:"profile_photo_url"`
+	ID               int32          `json:"id"`
+	FirstName        string         `json:"first_name"`
+	LastName         string         `json:"last_name"`
+	PhoneNumber      string         `json:"phone_number"`
+	Blocked          bool           `json:"blocked"`
+	Gender           string         `json:"gender"`
+	RegistrationDate time.Time      `json:"registration_date"`
+	DateOfBirth      time.Time      `json:"date_of_birth"`
+	Location         string         `json:"location"`
+	Email            string         `json:"email"`
+	ProfilePhotoURL  string         `json:"profile_photo_url"`
+	EmailVerified    bool           `json:"email_verified"`
+	EmailVerifiedAt  *time.Time     `json:"email_verified_at,omitempty"`
+	Attributes       UserAttributes `json:"attributes,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+	BlockReason      *string        `json:"block_reason,omitempty"`
+	BlockExpiresAt   *time.Time     `json:"block_expires_at,omitempty"`
+	// Highlights holds, for search results, the fields matching the query
+	// by JSON name, HTML-escaped with the matching words in <mark> tags.
+	Highlights map[string]string `json:"highlights,omitempty"`
+	Version    int32             `json:"-"`
 }
 
 type GetUserResponse CommonUserResponse
@@ -61,3 +151,80 @@ type GetUserResponse CommonUserResponse
 type CreateUserResponse CommonUserResponse
 
 type UpdateUserResponse CommonUserResponse
+
+type BlockReason string
+
+const (
+	BlockReasonSpam           BlockReason = "spam"
+	BlockReasonFraud          BlockReason = "fraud"
+	BlockReasonAbuse          BlockReason = "abuse"
+	BlockReasonTermsViolation BlockReason = "terms_violation"
+	BlockReasonOther          BlockReason = "other"
+)
+
+var BlockReasons = []BlockReason{
+	BlockReasonSpam,
+	BlockReasonFraud,
+	BlockReasonAbuse,
+	BlockReasonTermsViolation,
+	BlockReasonOther,
+}
+
+type BlockUserRequest struct {
+	Reason    BlockReason `json:"reason"`
+	Note      string      `json:"note"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+	BlockedBy int32       `json:"-"`
+}
+
+type UserBlock struct {
+	ID          int32       `json:"id"`
+	UserID      int32       `json:"user_id"`
+	Reason      BlockReason `json:"reason"`
+	Note        string      `json:"note"`
+	BlockedBy   *int32      `json:"blocked_by"`
+	BlockedAt   time.Time   `json:"blocked_at"`
+	ExpiresAt   *time.Time  `json:"expires_at"`
+	UnblockedBy *int32      `json:"unblocked_by"`
+	UnblockedAt *time.Time  `json:"unblocked_at"`
+}
+
+type UserBlocksList struct {
+	Blocks []UserBlock `json:"blocks"`
+}
+
+// FieldChange holds the value of a user field before and after a change. Old
+// is null for fields set when the user was created.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type UserHistoryEntry struct {
+	Version           int32                  `json:"version"`
+	Changes           map[string]FieldChange `json:"changes"`
+	ChangedBy         *int32                 `json:"changed_by"`
+	ChangedByUsername string                 `json:"changed_by_username,omitempty"`
+	ChangedAt         time.Time              `json:"changed_at"`
+	// MergedFrom is the ID of the user the entry was recorded for, if that
+	// user was merged into this one.
+	MergedFrom *int32 `json:"merged_from,omitempty"`
+}
+
+type UserHistory struct {
+	Entries []UserHistoryEntry `json:"entries"`
+}
+
+type UserHistoryResponse struct {
+	History     *UserHistory `json:"history"`
+	CurrentPage int          `json:"currentPage"`
+	PrevPage    int          `json:"previousPage"`
+	NextPage    int          `json:"nextPage"`
+	FirstPage   int          `json:"firstPage"`
+	LastPage    int          `json:"lastPage"`
+}
+
+type RevertUserRequest struct {
+	ExpectedVersion *int32 `json:"-"`
+	RevertedBy      int32  `json:"-"`
+}
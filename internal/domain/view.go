@@ -0,0 +1,90 @@
+package domain
+
+import "time"
+
+// View is a saved user search that admins rerun: the search text, filter and
+// sort order, and the columns to display. Its users are evaluated whenever
+// the view is loaded. A view is visible to its owner and to the admins and
+// roles it is shared with.
+type View struct {
+	ID         int32       `json:"id"`
+	Name       string      `json:"name"`
+	Query      string      `json:"query"`
+	Filter     UserFilter  `json:"filter"`
+	Sort       string      `json:"sort"`
+	Columns    []string    `json:"columns"`
+	SharedWith ViewSharing `json:"shared_with"`
+	OwnerID    *int32      `json:"owner_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// ViewSharing lists the admins, and the roles of admins, a view is shared
+// with besides its owner.
+type ViewSharing struct {
+	AdminIDs []int32  `json:"admin_ids"`
+	Roles    []string `json:"roles"`
+}
+
+// VisibleTo reports whether actor owns the view or it is shared with them.
+func (v *View) VisibleTo(actor Actor) bool {
+	if v.OwnedBy(actor) {
+		return true
+	}
+	for _, id := range v.SharedWith.AdminIDs {
+		if id == actor.AdminID {
+			return true
+		}
+	}
+	for _, role := range v.SharedWith.Roles {
+		if role == actor.Role {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnedBy reports whether actor owns the view.
+func (v *View) OwnedBy(actor Actor) bool {
+	return v.OwnerID != nil && *v.OwnerID == actor.AdminID
+}
+
+// ViewColumns are the columns a view can display, named as in exports.
+// Custom attributes are displayed as "attr.<key>".
+var ViewColumns = ExportColumns
+
+// ViewRequest holds a view as saved by an admin. Sort is in the format of
+// the sort query parameter, such as "-registration_date,last_name".
+type ViewRequest struct {
+	Name       string      `json:"name"`
+	Query      string      `json:"query"`
+	Filter     UserFilter  `json:"filter"`
+	Sort       string      `json:"sort"`
+	Columns    []string    `json:"columns"`
+	SharedWith ViewSharing `json:"shared_with"`
+	OwnerID    int32       `json:"-"`
+}
+
+type ViewsList struct {
+	Views []View `json:"views"`
+}
+
+// ViewResults is a page of the users of a view. Parts of the view that no
+// longer apply, such as a filter on an attribute that has since been
+// deleted, are left out and described by Warnings.
+type ViewResults struct {
+	View     *View
+	Warnings []string
+	Users    *UsersList
+}
+
+type ViewResultsResponse struct {
+	View        *View      `json:"view"`
+	Warnings    []string   `json:"warnings,omitempty"`
+	Users       *UsersList `json:"users"`
+	CurrentPage int        `json:"currentPage"`
+	PrevPage    int        `json:"previousPage"`
+	NextPage    int        `json:"nextPage"`
+	FirstPage   int        `json:"firstPage"`
+	LastPage    int        `json:"lastPage"`
+}
@@ -10,8 +10,8 @@ type MockAdminRepository struct {
 	mock.Mock
 }
 
-func (m *MockAdminRepository) GetAllAdmins(page, pageSize int) (*domain.AdminsList, error) {
-	args := m.Called(page, pageSize)
+func (m *MockAdminRepository) GetAllAdmins(page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
+	args := m.Called(page, pageSize, sort)
 	return args.Get(0).(*domain.AdminsList), args.Error(1)
 }
 
@@ -35,8 +35,13 @@ func (m *MockAdminRepository) UpdateAdmin(id int32, request *domain.UpdateAdminR
 	return args.Get(0).(*domain.UpdateAdminResponse), args.Error(1)
 }
 
-func (m *MockAdminRepository) DeleteAdmin(id int32) error {
-	args := m.Called(id)
+func (m *MockAdminRepository) PatchAdmin(id int32, request *domain.PatchAdminRequest) (*domain.UpdateAdminResponse, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.UpdateAdminResponse), args.Error(1)
+}
+
+func (m *MockAdminRepository) DeleteAdmin(id int32, expectedVersion *int32) error {
+	args := m.Called(id, expectedVersion)
 	return args.Error(0)
 }
 
@@ -50,7 +55,7 @@ func (m *MockAdminRepository) UnblockAdmin(id int32) error {
 	return args.Error(0)
 }
 
-func (m *MockAdminRepository) SearchAdmins(query string, page, pageSize int) (*domain.AdminsList, error) {
-	args := m.Called(query, page, pageSize)
+func (m *MockAdminRepository) SearchAdmins(query string, page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
+	args := m.Called(query, page, pageSize, sort)
 	return args.Get(0).(*domain.AdminsList), args.Error(1)
 }
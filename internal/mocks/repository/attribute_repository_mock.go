@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAttributeRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttributeRepository) GetAttributeDefinitions() ([]domain.AttributeDefinition, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) CreateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) UpdateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) DeleteAttributeDefinition(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) CreateEmailVerification(verification *domain.EmailVerification) error {
+	args := m.Called(verification)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) ConsumeEmailVerification(tokenHash string) (*domain.UpdateUserResponse, error) {
+	args := m.Called(tokenHash)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNoteRepository struct {
+	mock.Mock
+}
+
+func (m *MockNoteRepository) GetNotes(userID int32, page, pageSize int, includeRestricted bool) (*domain.NotesList, error) {
+	args := m.Called(userID, page, pageSize, includeRestricted)
+	return args.Get(0).(*domain.NotesList), args.Error(1)
+}
+
+func (m *MockNoteRepository) CountNotes(userID int32, includeRestricted bool) (int, error) {
+	args := m.Called(userID, includeRestricted)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetNoteByID(userID, noteID int32) (*domain.Note, error) {
+	args := m.Called(userID, noteID)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) CreateNote(userID int32, request *domain.NoteRequest) (*domain.Note, error) {
+	args := m.Called(userID, request)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) UpdateNote(userID, noteID int32, request *domain.NoteRequest) (*domain.Note, error) {
+	args := m.Called(userID, noteID, request)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) SetNotePinned(userID, noteID int32, pinned bool) (*domain.Note, error) {
+	args := m.Called(userID, noteID, pinned)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) DeleteNote(userID, noteID int32) error {
+	args := m.Called(userID, noteID)
+	return args.Error(0)
+}
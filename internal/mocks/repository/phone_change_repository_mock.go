@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPhoneChangeRepository struct {
+	mock.Mock
+}
+
+func (m *MockPhoneChangeRepository) IsPhoneNumberInUse(phoneNumber string) (bool, error) {
+	args := m.Called(phoneNumber)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPhoneChangeRepository) CreatePhoneChange(change *domain.PhoneChange) (*domain.PhoneChange, error) {
+	args := m.Called(change)
+	return args.Get(0).(*domain.PhoneChange), args.Error(1)
+}
+
+func (m *MockPhoneChangeRepository) GetPendingPhoneChange(userID int32) (*domain.PhoneChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*domain.PhoneChange), args.Error(1)
+}
+
+func (m *MockPhoneChangeRepository) IncrementPhoneChangeAttempts(id int32, maxAttempts int) error {
+	args := m.Called(id, maxAttempts)
+	return args.Error(0)
+}
+
+func (m *MockPhoneChangeRepository) VerifyPhoneChange(change *domain.PhoneChange) (*domain.UpdateUserResponse, error) {
+	args := m.Called(change)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
+
+func (m *MockPhoneChangeRepository) ForcePhoneChange(change *domain.PhoneChange) (*domain.UpdateUserResponse, error) {
+	args := m.Called(change)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPrivacyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPrivacyRepository) GetUserEvents(id int32) ([]domain.UserEvent, error) {
+	args := m.Called(id)
+	return args.Get(0).([]domain.UserEvent), args.Error(1)
+}
+
+func (m *MockPrivacyRepository) AnonymizeUser(id, actorID int32) (*domain.UserAnonymization, error) {
+	args := m.Called(id, actorID)
+	return args.Get(0).(*domain.UserAnonymization), args.Error(1)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) GetReports() ([]domain.Report, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) GetReportByID(id int32) (*domain.Report, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) CreateReport(request *domain.ReportRequest) (*domain.Report, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) UpdateReport(id int32, request *domain.ReportRequest) (*domain.Report, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) DeleteReport(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) RunReport(ctx context.Context, definition *domain.ReportDefinition) (*domain.ReportResult, error) {
+	args := m.Called(ctx, definition)
+	return args.Get(0).(*domain.ReportResult), args.Error(1)
+}
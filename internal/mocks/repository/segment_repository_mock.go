@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockSegmentRepository) GetSegments() ([]domain.Segment, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentRepository) GetSegmentByID(id int32) (*domain.Segment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentRepository) CreateSegment(request *domain.SegmentRequest) (*domain.Segment, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentRepository) UpdateSegment(id int32, request *domain.SegmentRequest) (*domain.Segment, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentRepository) DeleteSegment(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) GetUserStatsCounts(query *domain.UserStatsQuery) (*domain.UserStatsCounts, error) {
+	args := m.Called(query)
+	return args.Get(0).(*domain.UserStatsCounts), args.Error(1)
+}
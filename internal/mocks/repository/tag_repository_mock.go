@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) GetTags() ([]domain.Tag, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) CreateTag(request *domain.TagRequest) (*domain.Tag, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) UpdateTag(id int32, request *domain.TagRequest) (*domain.Tag, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) DeleteTag(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) TagUser(userID int32, request *domain.TagUserRequest) error {
+	args := m.Called(userID, request)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) UntagUser(userID int32, tag string) error {
+	args := m.Called(userID, tag)
+	return args.Error(0)
+}
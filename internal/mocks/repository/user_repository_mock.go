@@ -2,6 +2,8 @@ package mocks
 
 import (
 	"admin-panel/internal/domain"
+	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,16 +12,31 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) GetAllUsers(page, pageSize int) (*domain.UsersList, error) {
-	args := m.Called(page, pageSize)
+func (m *MockUserRepository) GetAllUsers(page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	args := m.Called(page, pageSize, filter, sort)
 	return args.Get(0).(*domain.UsersList), args.Error(1)
 }
 
-func (m *MockUserRepository) GetTotalUsersCount() (int, error) {
+func (m *MockUserRepository) GetTotalUsersCount(filter *domain.UserFilter) (int, error) {
+	args := m.Called(filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUsersByKeyset(filter *domain.UserFilter, keyset *domain.UsersKeyset) (*domain.UsersList, bool, error) {
+	args := m.Called(filter, keyset)
+	return args.Get(0).(*domain.UsersList), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserRepository) GetEstimatedUsersCount() (int, error) {
 	args := m.Called()
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockUserRepository) GetSearchUsersCount(query string, filter *domain.UserFilter) (int, error) {
+	args := m.Called(query, filter)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockUserRepository) GetUserByID(id int32) (*domain.GetUserResponse, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domain.GetUserResponse), args.Error(1)
@@ -35,22 +52,127 @@ func (m *MockUserRepository) UpdateUser(id int32, request *domain.UpdateUserRequ
 	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
 }
 
-func (m *MockUserRepository) DeleteUser(id int32) error {
-	args := m.Called(id)
+func (m *MockUserRepository) PatchUser(id int32, request *domain.PatchUserRequest) (*domain.UpdateUserResponse, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
+
+func (m *MockUserRepository) DeleteUser(id int32, expectedVersion *int32) error {
+	args := m.Called(id, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) BlockUser(id int32) error {
-	args := m.Called(id)
+func (m *MockUserRepository) BlockUser(id int32, request *domain.BlockUserRequest) error {
+	args := m.Called(id, request)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) UnblockUser(id int32) error {
-	args := m.Called(id)
+func (m *MockUserRepository) UnblockUser(id, unblockedBy int32) error {
+	args := m.Called(id, unblockedBy)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) SearchUsers(query string, page, pageSize int) (*domain.UsersList, error) {
-	args := m.Called(query, page, pageSize)
+func (m *MockUserRepository) GetUserBlocks(id int32) (*domain.UserBlocksList, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.UserBlocksList), args.Error(1)
+}
+
+func (m *MockUserRepository) UnblockExpiredUsers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) BackfillNameKeys(batchSize int) (int, error) {
+	args := m.Called(batchSize)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) BackfillContactIndexes(batchSize int) (int, error) {
+	args := m.Called(batchSize)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SearchUsers(query string, page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	args := m.Called(query, page, pageSize, filter, sort)
 	return args.Get(0).(*domain.UsersList), args.Error(1)
 }
+
+func (m *MockUserRepository) SetProfilePhoto(id int32, photo *domain.ProfilePhoto) (string, error) {
+	args := m.Called(id, photo)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserHistory(id int32, page, pageSize int) (*domain.UserHistory, error) {
+	args := m.Called(id, page, pageSize)
+	return args.Get(0).(*domain.UserHistory), args.Error(1)
+}
+
+func (m *MockUserRepository) CountUserHistory(id int32) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserVersion(id, version int32) (*domain.GetUserResponse, error) {
+	args := m.Called(id, version)
+	return args.Get(0).(*domain.GetUserResponse), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserAsOf(id int32, asOf time.Time) (*domain.GetUserResponse, error) {
+	args := m.Called(id, asOf)
+	return args.Get(0).(*domain.GetUserResponse), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserContacts(phoneNumbers, emails []string) ([]domain.UserContact, error) {
+	args := m.Called(phoneNumbers, emails)
+	return args.Get(0).([]domain.UserContact), args.Error(1)
+}
+
+func (m *MockUserRepository) ImportUsers(batch *domain.ImportBatch) (*domain.ImportBatchResult, error) {
+	args := m.Called(batch)
+	return args.Get(0).(*domain.ImportBatchResult), args.Error(1)
+}
+
+// ExportUsers passes the users given to Return to fn, stopping at the first
+// error fn returns.
+func (m *MockUserRepository) ExportUsers(ctx context.Context, query string, filter *domain.UserFilter, fn func(user *domain.GetUserResponse) error) error {
+	args := m.Called(ctx, query, filter, fn)
+	for _, user := range args.Get(0).([]domain.GetUserResponse) {
+		user := user
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserIDs(query string, filter *domain.UserFilter, limit int) ([]int32, error) {
+	args := m.Called(query, filter, limit)
+	return args.Get(0).([]int32), args.Error(1)
+}
+
+func (m *MockUserRepository) BulkUserAction(batch *domain.BulkBatch) ([]int32, error) {
+	args := m.Called(batch)
+	return args.Get(0).([]int32), args.Error(1)
+}
+
+func (m *MockUserRepository) UseBulkConfirmation(tokenHash string, expiresAt time.Time) (bool, error) {
+	args := m.Called(tokenHash, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+// ForEachDuplicateBlock passes the blocks given to Return to fn, stopping at
+// the first error fn returns.
+func (m *MockUserRepository) ForEachDuplicateBlock(maxBlockSize int, fn func(block []domain.DuplicateProfile) error) error {
+	args := m.Called(maxBlockSize, fn)
+	for _, block := range args.Get(0).([][]domain.DuplicateProfile) {
+		if err := fn(block); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserRepository) MergeUsers(merge *domain.UserMerge) (*domain.UpdateUserResponse, error) {
+	args := m.Called(merge)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockViewRepository struct {
+	mock.Mock
+}
+
+func (m *MockViewRepository) GetViews(actor domain.Actor) ([]domain.View, error) {
+	args := m.Called(actor)
+	return args.Get(0).([]domain.View), args.Error(1)
+}
+
+func (m *MockViewRepository) GetViewByID(id int32) (*domain.View, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.View), args.Error(1)
+}
+
+func (m *MockViewRepository) CreateView(request *domain.ViewRequest) (*domain.View, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.View), args.Error(1)
+}
+
+func (m *MockViewRepository) UpdateView(id int32, request *domain.ViewRequest) (*domain.View, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.View), args.Error(1)
+}
+
+func (m *MockViewRepository) DeleteView(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -10,8 +10,8 @@ type MockAdminService struct {
 	mock.Mock
 }
 
-func (m *MockAdminService) GetAllAdmins(page, pageSize int) (*domain.AdminsList, error) {
-	args := m.Called(page, pageSize)
+func (m *MockAdminService) GetAllAdmins(page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
+	args := m.Called(page, pageSize, sort)
 	return args.Get(0).(*domain.AdminsList), args.Error(1)
 }
 
@@ -35,8 +35,13 @@ func (m *MockAdminService) UpdateAdmin(id int32, request *domain.UpdateAdminRequ
 	return args.Get(0).(*domain.UpdateAdminResponse), args.Error(1)
 }
 
-func (m *MockAdminService) DeleteAdmin(id int32) error {
-	args := m.Called(id)
+func (m *MockAdminService) PatchAdmin(id int32, format domain.PatchFormat, body []byte, expectedVersion *int32) (*domain.UpdateAdminResponse, error) {
+	args := m.Called(id, format, body, expectedVersion)
+	return args.Get(0).(*domain.UpdateAdminResponse), args.Error(1)
+}
+
+func (m *MockAdminService) DeleteAdmin(id int32, expectedVersion *int32) error {
+	args := m.Called(id, expectedVersion)
 	return args.Error(0)
 }
 
@@ -50,7 +55,7 @@ func (m *MockAdminService) UnblockAdmin(id int32) error {
 	return args.Error(0)
 }
 
-func (m *MockAdminService) SearchAdmins(query string, page, pageSize int) (*domain.AdminsList, error) {
-	args := m.Called(query, page, pageSize)
+func (m *MockAdminService) SearchAdmins(query string, page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
+	args := m.Called(query, page, pageSize, sort)
 	return args.Get(0).(*domain.AdminsList), args.Error(1)
 }
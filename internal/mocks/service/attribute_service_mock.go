@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAttributeService struct {
+	mock.Mock
+}
+
+func (m *MockAttributeService) GetAttributeDefinitions() (*domain.AttributeDefinitionsList, error) {
+	args := m.Called()
+	return args.Get(0).(*domain.AttributeDefinitionsList), args.Error(1)
+}
+
+func (m *MockAttributeService) CreateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) UpdateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) DeleteAttributeDefinition(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
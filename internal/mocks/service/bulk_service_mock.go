@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBulkService struct {
+	mock.Mock
+}
+
+func (m *MockBulkService) RunBulkAction(request *domain.BulkRequest) (*domain.BulkResponse, *domain.BulkConfirmation, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.BulkResponse), args.Get(1).(*domain.BulkConfirmation), args.Error(2)
+}
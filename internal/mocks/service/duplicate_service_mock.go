@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDuplicateService struct {
+	mock.Mock
+}
+
+func (m *MockDuplicateService) FindDuplicates(minScore float64, page, pageSize int) (*domain.DuplicatesList, int, error) {
+	args := m.Called(minScore, page, pageSize)
+	return args.Get(0).(*domain.DuplicatesList), args.Int(1), args.Error(2)
+}
+
+func (m *MockDuplicateService) MergeUsers(survivorID int32, request *domain.MergeUsersRequest) (*domain.UpdateUserResponse, error) {
+	args := m.Called(survivorID, request)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) SendVerification(userID int32) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) VerifyEmail(token string) (*domain.UpdateUserResponse, error) {
+	args := m.Called(token)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
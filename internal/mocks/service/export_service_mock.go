@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+	"context"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExportService struct {
+	mock.Mock
+}
+
+// ExportUsers writes the string given as the first Return value to w before
+// returning the error given as the second.
+func (m *MockExportService) ExportUsers(ctx context.Context, request *domain.ExportRequest, w io.Writer) error {
+	args := m.Called(ctx, request, w)
+	if output := args.String(0); output != "" {
+		io.WriteString(w, output)
+	}
+	return args.Error(1)
+}
@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/spreadsheet"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockImportService struct {
+	mock.Mock
+}
+
+func (m *MockImportService) ImportUsers(content io.Reader, format spreadsheet.Format, options *domain.ImportOptions) (*domain.ImportReport, error) {
+	args := m.Called(content, format, options)
+	return args.Get(0).(*domain.ImportReport), args.Error(1)
+}
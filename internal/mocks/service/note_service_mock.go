@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNoteService struct {
+	mock.Mock
+}
+
+func (m *MockNoteService) GetNotes(userID int32, page, pageSize int, actor domain.Actor) (*domain.NotesList, int, error) {
+	args := m.Called(userID, page, pageSize, actor)
+	return args.Get(0).(*domain.NotesList), args.Int(1), args.Error(2)
+}
+
+func (m *MockNoteService) CreateNote(userID int32, request *domain.NoteRequest, actor domain.Actor) (*domain.Note, error) {
+	args := m.Called(userID, request, actor)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteService) UpdateNote(userID, noteID int32, request *domain.NoteRequest, actor domain.Actor) (*domain.Note, error) {
+	args := m.Called(userID, noteID, request, actor)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteService) SetNotePinned(userID, noteID int32, request *domain.PinNoteRequest, actor domain.Actor) (*domain.Note, error) {
+	args := m.Called(userID, noteID, request, actor)
+	return args.Get(0).(*domain.Note), args.Error(1)
+}
+
+func (m *MockNoteService) DeleteNote(userID, noteID int32, actor domain.Actor) error {
+	args := m.Called(userID, noteID, actor)
+	return args.Error(0)
+}
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPhoneChangeService struct {
+	mock.Mock
+}
+
+func (m *MockPhoneChangeService) StartPhoneChange(userID int32, request *domain.StartPhoneChangeRequest) (*domain.PhoneChange, error) {
+	args := m.Called(userID, request)
+	return args.Get(0).(*domain.PhoneChange), args.Error(1)
+}
+
+func (m *MockPhoneChangeService) VerifyPhoneChange(userID int32, request *domain.VerifyPhoneChangeRequest) (*domain.UpdateUserResponse, error) {
+	args := m.Called(userID, request)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
+
+func (m *MockPhoneChangeService) ForcePhoneChange(userID int32, request *domain.ForcePhoneChangeRequest) (*domain.UpdateUserResponse, error) {
+	args := m.Called(userID, request)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
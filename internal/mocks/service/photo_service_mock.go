@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPhotoService struct {
+	mock.Mock
+}
+
+func (m *MockPhotoService) UploadPhoto(userID int32, content io.Reader) (*domain.UploadPhotoResponse, error) {
+	args := m.Called(userID, content)
+	return args.Get(0).(*domain.UploadPhotoResponse), args.Error(1)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPrivacyService struct {
+	mock.Mock
+}
+
+func (m *MockPrivacyService) GetUserData(id int32, actor domain.Actor) (*domain.UserDataExport, error) {
+	args := m.Called(id, actor)
+	return args.Get(0).(*domain.UserDataExport), args.Error(1)
+}
+
+func (m *MockPrivacyService) WriteUserDataArchive(data *domain.UserDataExport, actor domain.Actor, w io.Writer) error {
+	args := m.Called(data, actor, w)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyService) AnonymizeUser(id int32, actor domain.Actor) (*domain.UserAnonymization, error) {
+	args := m.Called(id, actor)
+	return args.Get(0).(*domain.UserAnonymization), args.Error(1)
+}
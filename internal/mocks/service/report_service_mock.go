@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReportService struct {
+	mock.Mock
+}
+
+func (m *MockReportService) GetReports() (*domain.ReportsList, error) {
+	args := m.Called()
+	return args.Get(0).(*domain.ReportsList), args.Error(1)
+}
+
+func (m *MockReportService) GetReportByID(id int32) (*domain.Report, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.Report), args.Error(1)
+}
+
+func (m *MockReportService) CreateReport(request *domain.ReportRequest) (*domain.Report, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.Report), args.Error(1)
+}
+
+func (m *MockReportService) UpdateReport(id int32, request *domain.ReportRequest) (*domain.Report, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.Report), args.Error(1)
+}
+
+func (m *MockReportService) DeleteReport(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockReportService) RunReport(ctx context.Context, definition *domain.ReportDefinition) (*domain.ReportResult, error) {
+	args := m.Called(ctx, definition)
+	return args.Get(0).(*domain.ReportResult), args.Error(1)
+}
+
+func (m *MockReportService) RunSavedReport(ctx context.Context, id int32) (*domain.ReportResult, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.ReportResult), args.Error(1)
+}
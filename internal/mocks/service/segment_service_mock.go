@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSegmentService struct {
+	mock.Mock
+}
+
+func (m *MockSegmentService) GetSegments() (*domain.SegmentsList, error) {
+	args := m.Called()
+	return args.Get(0).(*domain.SegmentsList), args.Error(1)
+}
+
+func (m *MockSegmentService) GetSegmentByID(id int32) (*domain.Segment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentService) CreateSegment(request *domain.SegmentRequest) (*domain.Segment, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentService) UpdateSegment(id int32, request *domain.SegmentRequest) (*domain.Segment, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.Segment), args.Error(1)
+}
+
+func (m *MockSegmentService) DeleteSegment(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockSegmentService) GetSegmentUsers(id int32, page, pageSize int) (*domain.UsersList, int, error) {
+	args := m.Called(id, page, pageSize)
+	return args.Get(0).(*domain.UsersList), args.Int(1), args.Error(2)
+}
+
+func (m *MockSegmentService) CountSegmentUsers(id int32) (*domain.SegmentCount, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.SegmentCount), args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStatsService struct {
+	mock.Mock
+}
+
+func (m *MockStatsService) GetUserStats(request *domain.UserStatsRequest) (*domain.UserStats, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.UserStats), args.Error(1)
+}
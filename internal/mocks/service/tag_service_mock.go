@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) GetTags() (*domain.TagsList, error) {
+	args := m.Called()
+	return args.Get(0).(*domain.TagsList), args.Error(1)
+}
+
+func (m *MockTagService) CreateTag(request *domain.TagRequest) (*domain.Tag, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.Tag), args.Error(1)
+}
+
+func (m *MockTagService) UpdateTag(id int32, request *domain.TagRequest) (*domain.Tag, error) {
+	args := m.Called(id, request)
+	return args.Get(0).(*domain.Tag), args.Error(1)
+}
+
+func (m *MockTagService) DeleteTag(id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTagService) TagUser(userID int32, request *domain.TagUserRequest) error {
+	args := m.Called(userID, request)
+	return args.Error(0)
+}
+
+func (m *MockTagService) UntagUser(userID int32, tag string) error {
+	args := m.Called(userID, tag)
+	return args.Error(0)
+}
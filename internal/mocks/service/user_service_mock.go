@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"admin-panel/internal/domain"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,13 +11,23 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) GetAllUsers(page, pageSize int) (*domain.UsersList, error) {
-	args := m.Called(page, pageSize)
+func (m *MockUserService) GetAllUsers(page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	args := m.Called(page, pageSize, filter, sort)
 	return args.Get(0).(*domain.UsersList), args.Error(1)
 }
 
-func (m *MockUserService) GetTotalUsersCount() (int, error) {
-	args := m.Called()
+func (m *MockUserService) GetTotalUsersCount(filter *domain.UserFilter) (int, error) {
+	args := m.Called(filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserService) GetUsersByCursor(request *domain.UsersCursorRequest) (*domain.UsersCursorResponse, error) {
+	args := m.Called(request)
+	return args.Get(0).(*domain.UsersCursorResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetSearchUsersCount(query string, filter *domain.UserFilter) (int, error) {
+	args := m.Called(query, filter)
 	return args.Int(0), args.Error(1)
 }
 
@@ -35,22 +46,52 @@ func (m *MockUserService) UpdateUser(id int32, request *domain.UpdateUserRequest
 	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
 }
 
-func (m *MockUserService) DeleteUser(id int32) error {
-	args := m.Called(id)
+func (m *MockUserService) PatchUser(id int32, format domain.PatchFormat, body []byte, expectedVersion *int32, updatedBy int32) (*domain.UpdateUserResponse, error) {
+	args := m.Called(id, format, body, expectedVersion, updatedBy)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
+
+func (m *MockUserService) DeleteUser(id int32, expectedVersion *int32) error {
+	args := m.Called(id, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockUserService) BlockUser(id int32) error {
-	args := m.Called(id)
+func (m *MockUserService) BlockUser(id int32, request *domain.BlockUserRequest) error {
+	args := m.Called(id, request)
 	return args.Error(0)
 }
 
-func (m *MockUserService) UnblockUser(id int32) error {
-	args := m.Called(id)
+func (m *MockUserService) UnblockUser(id, unblockedBy int32) error {
+	args := m.Called(id, unblockedBy)
 	return args.Error(0)
 }
 
-func (m *MockUserService) SearchUsers(query string, page, pageSize int) (*domain.UsersList, error) {
-	args := m.Called(query, page, pageSize)
+func (m *MockUserService) GetUserBlocks(id int32) (*domain.UserBlocksList, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.UserBlocksList), args.Error(1)
+}
+
+func (m *MockUserService) UnblockExpiredUsers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) SearchUsers(query string, page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	args := m.Called(query, page, pageSize, filter, sort)
 	return args.Get(0).(*domain.UsersList), args.Error(1)
 }
+
+func (m *MockUserService) GetUserHistory(id int32, page, pageSize int) (*domain.UserHistory, int, error) {
+	args := m.Called(id, page, pageSize)
+	return args.Get(0).(*domain.UserHistory), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserService) GetUserAsOf(id int32, asOf time.Time) (*domain.GetUserResponse, error) {
+	args := m.Called(id, asOf)
+	return args.Get(0).(*domain.GetUserResponse), args.Error(1)
+}
+
+func (m *MockUserService) RevertUser(id, version int32, request *domain.RevertUserRequest) (*domain.UpdateUserResponse, error) {
+	args := m.Called(id, version, request)
+	return args.Get(0).(*domain.UpdateUserResponse), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"admin-panel/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockViewService struct {
+	mock.Mock
+}
+
+func (m *MockViewService) GetViews(actor domain.Actor) (*domain.ViewsList, error) {
+	args := m.Called(actor)
+	return args.Get(0).(*domain.ViewsList), args.Error(1)
+}
+
+func (m *MockViewService) CreateView(request *domain.ViewRequest, actor domain.Actor) (*domain.View, error) {
+	args := m.Called(request, actor)
+	return args.Get(0).(*domain.View), args.Error(1)
+}
+
+func (m *MockViewService) UpdateView(id int32, request *domain.ViewRequest, actor domain.Actor) (*domain.View, error) {
+	args := m.Called(id, request, actor)
+	return args.Get(0).(*domain.View), args.Error(1)
+}
+
+func (m *MockViewService) DeleteView(id int32, actor domain.Actor) error {
+	args := m.Called(id, actor)
+	return args.Error(0)
+}
+
+func (m *MockViewService) GetViewResults(id int32, page, pageSize int, actor domain.Actor) (*domain.ViewResults, int, error) {
+	args := m.Called(id, page, pageSize, actor)
+	return args.Get(0).(*domain.ViewResults), args.Int(1), args.Error(2)
+}
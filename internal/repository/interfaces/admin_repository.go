@@ -3,11 +3,12 @@ package repository
 import "admin-panel/internal/domain"
 
 type AdminRepository interface {
-	GetAllAdmins(page, pageSize int) (*domain.AdminsList, error)
+	GetAllAdmins(page, pageSize int, sort domain.Sort) (*domain.AdminsList, error)
 	GetTotalAdminsCount() (int, error)
 	GetAdminByID(id int32) (*domain.GetAdminResponse, error)
 	CreateAdmin(request *domain.CreateAdminRequest) (*domain.CreateAdminResponse, error)
 	UpdateAdmin(id int32, request *domain.UpdateAdminRequest) (*domain.UpdateAdminResponse, error)
-	DeleteAdmin(id int32) error
-	SearchAdmins(query string, page, pageSize int) (*domain.AdminsList, error)
+	PatchAdmin(id int32, request *domain.PatchAdminRequest) (*domain.UpdateAdminResponse, error)
+	DeleteAdmin(id int32, expectedVersion *int32) error
+	SearchAdmins(query string, page, pageSize int, sort domain.Sort) (*domain.AdminsList, error)
 }
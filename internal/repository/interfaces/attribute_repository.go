@@ -0,0 +1,10 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type AttributeRepository interface {
+	GetAttributeDefinitions() ([]domain.AttributeDefinition, error)
+	CreateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error)
+	UpdateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error)
+	DeleteAttributeDefinition(key string) error
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type EmailVerificationRepository interface {
+	CreateEmailVerification(verification *domain.EmailVerification) error
+	ConsumeEmailVerification(tokenHash string) (*domain.UpdateUserResponse, error)
+}
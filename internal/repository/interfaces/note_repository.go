@@ -0,0 +1,13 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type NoteRepository interface {
+	GetNotes(userID int32, page, pageSize int, includeRestricted bool) (*domain.NotesList, error)
+	CountNotes(userID int32, includeRestricted bool) (int, error)
+	GetNoteByID(userID, noteID int32) (*domain.Note, error)
+	CreateNote(userID int32, request *domain.NoteRequest) (*domain.Note, error)
+	UpdateNote(userID, noteID int32, request *domain.NoteRequest) (*domain.Note, error)
+	SetNotePinned(userID, noteID int32, pinned bool) (*domain.Note, error)
+	DeleteNote(userID, noteID int32) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type PhoneChangeRepository interface {
+	IsPhoneNumberInUse(phoneNumber string) (bool, error)
+	CreatePhoneChange(change *domain.PhoneChange) (*domain.PhoneChange, error)
+	GetPendingPhoneChange(userID int32) (*domain.PhoneChange, error)
+	IncrementPhoneChangeAttempts(id int32, maxAttempts int) error
+	VerifyPhoneChange(change *domain.PhoneChange) (*domain.UpdateUserResponse, error)
+	ForcePhoneChange(change *domain.PhoneChange) (*domain.UpdateUserResponse, error)
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type PrivacyRepository interface {
+	GetUserEvents(id int32) ([]domain.UserEvent, error)
+	AnonymizeUser(id, actorID int32) (*domain.UserAnonymization, error)
+}
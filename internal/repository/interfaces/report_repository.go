@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	"context"
+)
+
+type ReportRepository interface {
+	GetReports() ([]domain.Report, error)
+	GetReportByID(id int32) (*domain.Report, error)
+	CreateReport(request *domain.ReportRequest) (*domain.Report, error)
+	UpdateReport(id int32, request *domain.ReportRequest) (*domain.Report, error)
+	DeleteReport(id int32) error
+	RunReport(ctx context.Context, definition *domain.ReportDefinition) (*domain.ReportResult, error)
+}
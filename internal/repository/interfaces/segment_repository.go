@@ -0,0 +1,11 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type SegmentRepository interface {
+	GetSegments() ([]domain.Segment, error)
+	GetSegmentByID(id int32) (*domain.Segment, error)
+	CreateSegment(request *domain.SegmentRequest) (*domain.Segment, error)
+	UpdateSegment(id int32, request *domain.SegmentRequest) (*domain.Segment, error)
+	DeleteSegment(id int32) error
+}
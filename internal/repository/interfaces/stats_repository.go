@@ -0,0 +1,7 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type StatsRepository interface {
+	GetUserStatsCounts(query *domain.UserStatsQuery) (*domain.UserStatsCounts, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type TagRepository interface {
+	GetTags() ([]domain.Tag, error)
+	CreateTag(request *domain.TagRequest) (*domain.Tag, error)
+	UpdateTag(id int32, request *domain.TagRequest) (*domain.Tag, error)
+	DeleteTag(id int32) error
+	TagUser(userID int32, request *domain.TagUserRequest) error
+	UntagUser(userID int32, tag string) error
+}
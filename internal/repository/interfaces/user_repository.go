@@ -1,15 +1,40 @@
 package repository
 
-import "admin-panel/internal/domain"
+import (
+	"admin-panel/internal/domain"
+	"context"
+	"time"
+)
 
 type UserRepository interface {
-	GetAllUsers(page, pageSize int) (*domain.UsersList, error)
-	GetTotalUsersCount() (int, error)
+	GetAllUsers(page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error)
+	GetTotalUsersCount(filter *domain.UserFilter) (int, error)
+	GetUsersByKeyset(filter *domain.UserFilter, keyset *domain.UsersKeyset) (*domain.UsersList, bool, error)
+	GetEstimatedUsersCount() (int, error)
 	GetUserByID(id int32) (*domain.GetUserResponse, error)
 	CreateUser(request *domain.CreateUserRequest) (*domain.CreateUserResponse, error)
 	UpdateUser(id int32, request *domain.UpdateUserRequest) (*domain.UpdateUserResponse, error)
-	DeleteUser(id int32) error
-	BlockUser(id int32) error
-	UnblockUser(id int32) error
-	SearchUsers(query string, page, pageSize int) (*domain.UsersList, error)
+	PatchUser(id int32, request *domain.PatchUserRequest) (*domain.UpdateUserResponse, error)
+	DeleteUser(id int32, expectedVersion *int32) error
+	BlockUser(id int32, request *domain.BlockUserRequest) error
+	UnblockUser(id, unblockedBy int32) error
+	GetUserBlocks(id int32) (*domain.UserBlocksList, error)
+	UnblockExpiredUsers() (int64, error)
+	BackfillNameKeys(batchSize int) (int, error)
+	BackfillContactIndexes(batchSize int) (int, error)
+	SearchUsers(query string, page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error)
+	GetSearchUsersCount(query string, filter *domain.UserFilter) (int, error)
+	SetProfilePhoto(id int32, photo *domain.ProfilePhoto) (string, error)
+	GetUserHistory(id int32, page, pageSize int) (*domain.UserHistory, error)
+	CountUserHistory(id int32) (int, error)
+	GetUserVersion(id, version int32) (*domain.GetUserResponse, error)
+	GetUserAsOf(id int32, asOf time.Time) (*domain.GetUserResponse, error)
+	GetUserContacts(phoneNumbers, emails []string) ([]domain.UserContact, error)
+	ImportUsers(batch *domain.ImportBatch) (*domain.ImportBatchResult, error)
+	GetUserIDs(query string, filter *domain.UserFilter, limit int) ([]int32, error)
+	BulkUserAction(batch *domain.BulkBatch) ([]int32, error)
+	UseBulkConfirmation(tokenHash string, expiresAt time.Time) (bool, error)
+	ForEachDuplicateBlock(maxBlockSize int, fn func(block []domain.DuplicateProfile) error) error
+	MergeUsers(merge *domain.UserMerge) (*domain.UpdateUserResponse, error)
+	ExportUsers(ctx context.Context, query string, filter *domain.UserFilter, fn func(user *domain.GetUserResponse) error) error
 }
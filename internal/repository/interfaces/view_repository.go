@@ -0,0 +1,11 @@
+package repository
+
+import "admin-panel/internal/domain"
+
+type ViewRepository interface {
+	GetViews(actor domain.Actor) ([]domain.View, error)
+	GetViewByID(id int32) (*domain.View, error)
+	CreateView(request *domain.ViewRequest) (*domain.View, error)
+	UpdateView(id int32, request *domain.ViewRequest) (*domain.View, error)
+	DeleteView(id int32) error
+}
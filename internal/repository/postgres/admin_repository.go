@@ -6,7 +6,9 @@ import (
 	"admin-panel/pkg/lib/utils"
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,13 +21,25 @@ func NewPostgresAdminRepository(db *sql.DB) *PostgresAdminRepository {
 	return &PostgresAdminRepository{DB: db}
 }
 
-func (r *PostgresAdminRepository) GetAllAdmins(page, pageSize int) (*domain.AdminsList, error) {
+// adminSortColumns maps the fields in domain.AdminSortFields to their columns.
+var adminSortColumns = map[string]string{
+	"id":       "id",
+	"username": "username",
+	"role":     "role",
+}
+
+func (r *PostgresAdminRepository) GetAllAdmins(page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
 	offset := (page - 1) * pageSize
 
+	orderBy, err := orderByClause(sort, adminSortColumns, "id")
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
         SELECT id, username, role
         FROM admins
-        ORDER BY id
+        ` + orderBy + `
         LIMIT $1 OFFSET $2
     `
 
@@ -74,7 +88,7 @@ func (r *PostgresAdminRepository) GetTotalAdminsCount() (int, error) {
 
 func (r *PostgresAdminRepository) GetAdminByID(id int32) (*domain.GetAdminResponse, error) {
 	stmt, err := r.DB.Prepare(`
-		SELECT id, username, role
+		SELECT id, username, role, version
 		FROM admins
 		WHERE id = $1
 	`)
@@ -92,6 +106,7 @@ func (r *PostgresAdminRepository) GetAdminByID(id int32) (*domain.GetAdminRespon
 		&admin.ID,
 		&admin.Username,
 		&admin.Role,
+		&admin.Version,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -165,9 +180,10 @@ func (r *PostgresAdminRepository) UpdateAdmin(id int32, request *domain.UpdateAd
 	updateQuery := `UPDATE admins SET
                     username = $1,
                     password = $2,
-                    role = $3
-                    WHERE id = $4
-                    RETURNING id, username, role`
+                    role = $3,
+                    version = version + 1
+                    WHERE id = $4 AND ($5::integer IS NULL OR version = $5)
+                    RETURNING id, username, role, version`
 
 	stmt, err := r.DB.Prepare(updateQuery)
 	if err != nil {
@@ -183,14 +199,16 @@ func (r *PostgresAdminRepository) UpdateAdmin(id int32, request *domain.UpdateAd
 		request.Password,
 		request.Role,
 		id,
+		request.ExpectedVersion,
 	).Scan(
 		&admin.ID,
 		&admin.Username,
 		&admin.Role,
+		&admin.Version,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.ErrAdminNotFound
+			return nil, r.writeConflict(id)
 		}
 
 		slog.Error("error executing  query: %v", utils.Err(err))
@@ -200,7 +218,82 @@ func (r *PostgresAdminRepository) UpdateAdmin(id int32, request *domain.UpdateAd
 	return &admin, nil
 }
 
-func (r *PostgresAdminRepository) DeleteAdmin(id int32) error {
+// PatchAdmin updates only the non-nil fields of request, hashing a new
+// password before it is stored.
+func (r *PostgresAdminRepository) PatchAdmin(id int32, request *domain.PatchAdminRequest) (*domain.UpdateAdminResponse, error) {
+	var setClauses []string
+	var args []interface{}
+
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if request.Username != nil {
+		var existingID int32
+		err := r.DB.QueryRow("SELECT id FROM admins WHERE username = $1 LIMIT 1", *request.Username).Scan(&existingID)
+		if err == sql.ErrNoRows {
+		} else if err != nil {
+			slog.Error("error checking admin existence: %v", utils.Err(err))
+			return nil, err
+		} else if existingID != id {
+			return nil, errors.ErrAdminAlreadyExists
+		}
+
+		set("username", *request.Username)
+	}
+	if request.Password != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*request.Password), bcrypt.DefaultCost)
+		if err != nil {
+			slog.Error("error hashing password: %v", utils.Err(err))
+			return nil, err
+		}
+
+		set("password", hashedPassword)
+	}
+	if request.Role != nil {
+		set("role", *request.Role)
+	}
+
+	if len(setClauses) == 0 {
+		admin, err := r.GetAdminByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if request.ExpectedVersion != nil && *request.ExpectedVersion != admin.Version {
+			return nil, errors.ErrPreconditionFailed
+		}
+		unchanged := domain.UpdateAdminResponse(*admin)
+		return &unchanged, nil
+	}
+
+	setClauses = append(setClauses, "version = version + 1")
+	args = append(args, id, request.ExpectedVersion)
+	patchQuery := fmt.Sprintf(`UPDATE admins SET %s
+                    WHERE id = $%d AND ($%d::integer IS NULL OR version = $%d)
+                    RETURNING id, username, role, version`, strings.Join(setClauses, ", "), len(args)-1, len(args), len(args))
+
+	var admin domain.UpdateAdminResponse
+
+	err := r.DB.QueryRowContext(context.TODO(), patchQuery, args...).Scan(
+		&admin.ID,
+		&admin.Username,
+		&admin.Role,
+		&admin.Version,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.writeConflict(id)
+		}
+
+		slog.Error("error executing query: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &admin, nil
+}
+
+func (r *PostgresAdminRepository) DeleteAdmin(id int32, expectedVersion *int32) error {
 	var exists bool
 	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM admins WHERE id = $1)`, id).Scan(&exists)
 	if err != nil {
@@ -212,30 +305,62 @@ func (r *PostgresAdminRepository) DeleteAdmin(id int32) error {
 		return errors.ErrAdminNotFound
 	}
 
-	stmt, err := r.DB.Prepare(`DELETE FROM admins WHERE id = $1`)
+	stmt, err := r.DB.Prepare(`DELETE FROM admins WHERE id = $1 AND ($2::integer IS NULL OR version = $2)`)
 	if err != nil {
-		slog.Error("error preparing query: %v", err)
+		slog.Error("error preparing query: %v", utils.Err(err))
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
+	result, err := stmt.Exec(id, expectedVersion)
 	if err != nil {
 		slog.Error("error executing query: %v", utils.Err(err))
 		return err
 	}
 
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return r.writeConflict(id)
+	}
+
 	return nil
 }
 
-func (r *PostgresAdminRepository) SearchAdmins(query string, page, pageSize int) (*domain.AdminsList, error) {
+// writeConflict reports why a conditional write on an admin matched no rows:
+// either the admin does not exist or its version no longer matches If-Match.
+func (r *PostgresAdminRepository) writeConflict(id int32) error {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM admins WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		slog.Error("error checking admin existence: %v", utils.Err(err))
+		return err
+	}
+
+	if !exists {
+		return errors.ErrAdminNotFound
+	}
+
+	return errors.ErrPreconditionFailed
+}
+
+func (r *PostgresAdminRepository) SearchAdmins(query string, page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
 	offset := (page - 1) * pageSize
 
+	orderBy, err := orderByClause(sort, adminSortColumns, "id")
+	if err != nil {
+		return nil, err
+	}
+
 	searchQuery := `
         SELECT id, username, role
         FROM admins
         WHERE username ILIKE $1 OR role ILIKE $1
-        ORDER BY id
+        ` + orderBy + `
         LIMIT $2 OFFSET $3
     `
 
@@ -61,13 +61,30 @@ func TestGetAllAdmins(t *testing.T) {
 			mock.ExpectPrepare(query)
 			mock.ExpectQuery(query).WithArgs(tc.pageSize, (tc.page-1)*tc.pageSize).WillReturnRows(rows)
 
-			admins, _ := repo.GetAllAdmins(tc.page, tc.pageSize)
+			admins, _ := repo.GetAllAdmins(tc.page, tc.pageSize, nil)
 
 			assert.Equal(t, tc.expectedLength, len(admins.Admins))
 		})
 	}
 }
 
+func TestSearchAdminsSorted(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresAdminRepository(db)
+
+	query := `SELECT id, username, role FROM admins WHERE username ILIKE \$1 OR role ILIKE \$1 ORDER BY role, username DESC, id DESC LIMIT \$2 OFFSET \$3`
+	mock.ExpectPrepare(query)
+	mock.ExpectQuery(query).WithArgs("%adm%", 8, 0).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "role"}).AddRow(2, "bob", "admin"))
+
+	admins, err := repo.SearchAdmins("adm", 1, 8, domain.Sort{{Field: "role"}, {Field: "username", Descending: true}})
+
+	assert.NoError(t, err)
+	assert.Len(t, admins.Admins, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetTotalAdminsCount(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
@@ -317,9 +334,9 @@ func TestDeleteAdmin(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockAdminRepository := new(mocks.MockAdminRepository)
 
-			mockAdminRepository.On("DeleteAdmin", tc.id).Return(tc.mockReturnErr)
+			mockAdminRepository.On("DeleteAdmin", tc.id, (*int32)(nil)).Return(tc.mockReturnErr)
 
-			err := mockAdminRepository.DeleteAdmin(tc.id)
+			err := mockAdminRepository.DeleteAdmin(tc.id, nil)
 
 			if err != nil {
 				assert.Equal(t, tc.expectedErr, err)
@@ -384,7 +401,7 @@ func TestSearchAdmins(t *testing.T) {
 			mock.ExpectPrepare(searchQuery)
 			mock.ExpectQuery(searchQuery).WithArgs("%"+tc.query+"%", tc.pageSize, (tc.page-1)*tc.pageSize).WillReturnRows(rows)
 
-			admins, _ := repo.SearchAdmins(tc.query, tc.page, tc.pageSize)
+			admins, _ := repo.SearchAdmins(tc.query, tc.page, tc.pageSize, nil)
 
 			assert.Equal(t, tc.expectedLength, len(admins.Admins))
 		})
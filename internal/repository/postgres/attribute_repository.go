@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+const attributeDefinitionColumns = `key, type, enum_values, required, description, created_at, updated_at`
+
+type PostgresAttributeRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresAttributeRepository(db *sql.DB) *PostgresAttributeRepository {
+	return &PostgresAttributeRepository{DB: db}
+}
+
+func scanAttributeDefinition(row utils.RowScanner) (domain.AttributeDefinition, error) {
+	var definition domain.AttributeDefinition
+	var enum []string
+
+	err := row.Scan(
+		&definition.Key,
+		&definition.Type,
+		pq.Array(&enum),
+		&definition.Required,
+		&definition.Description,
+		&definition.CreatedAt,
+		&definition.UpdatedAt,
+	)
+	if len(enum) > 0 {
+		definition.Enum = enum
+	}
+
+	return definition, err
+}
+
+func (r *PostgresAttributeRepository) GetAttributeDefinitions() ([]domain.AttributeDefinition, error) {
+	rows, err := r.DB.Query(`SELECT ` + attributeDefinitionColumns + ` FROM user_attribute_definitions ORDER BY key`)
+	if err != nil {
+		slog.Error("error querying attribute definitions: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := make([]domain.AttributeDefinition, 0)
+	for rows.Next() {
+		definition, err := scanAttributeDefinition(rows)
+		if err != nil {
+			slog.Error("error scanning attribute definition: %v", utils.Err(err))
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over attribute definitions: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+func (r *PostgresAttributeRepository) CreateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	row := r.DB.QueryRow(`
+		INSERT INTO user_attribute_definitions (key, type, enum_values, required, description)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+attributeDefinitionColumns,
+		request.Key, request.Type, pq.Array(request.Enum), request.Required, request.Description)
+
+	definition, err := scanAttributeDefinition(row)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrAttributeAlreadyExists
+		}
+		slog.Error("error inserting attribute definition: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// UpdateAttributeDefinition replaces the definition of an existing attribute.
+// Values users already carry are not revalidated; they have to satisfy the
+// new definition the next time the user is written.
+func (r *PostgresAttributeRepository) UpdateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	row := r.DB.QueryRow(`
+		UPDATE user_attribute_definitions
+		SET type = $2, enum_values = $3, required = $4, description = $5, updated_at = NOW()
+		WHERE key = $1
+		RETURNING `+attributeDefinitionColumns,
+		request.Key, request.Type, pq.Array(request.Enum), request.Required, request.Description)
+
+	definition, err := scanAttributeDefinition(row)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrAttributeNotFound
+	}
+	if err != nil {
+		slog.Error("error updating attribute definition: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// DeleteAttributeDefinition removes the attribute and strips its value from
+// every user that has one.
+func (r *PostgresAttributeRepository) DeleteAttributeDefinition(key string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM user_attribute_definitions WHERE key = $1`, key)
+	if err != nil {
+		slog.Error("error deleting attribute definition: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrAttributeNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET attributes = attributes - $1, version = version + 1
+		WHERE attributes ? $1
+	`, key)
+	if err != nil {
+		slog.Error("error removing attribute from users: %v", utils.Err(err))
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	repository "admin-panel/internal/repository/postgres"
+	errors "admin-panel/pkg/lib/errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteAttributeDefinition(t *testing.T) {
+	testCases := []struct {
+		name        string
+		deleted     int64
+		expectedErr error
+	}{
+		{
+			name:    "Removes values from users",
+			deleted: 1,
+		},
+		{
+			name:        "Attribute not found",
+			deleted:     0,
+			expectedErr: errors.ErrAttributeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresAttributeRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM user_attribute_definitions WHERE key = \$1`).
+				WithArgs("tier").
+				WillReturnResult(sqlmock.NewResult(0, tc.deleted))
+
+			if tc.deleted > 0 {
+				mock.ExpectExec(`UPDATE users SET attributes = attributes - \$1, version = version \+ 1 WHERE attributes \? \$1`).
+					WithArgs("tier").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := repo.DeleteAttributeDefinition("tier")
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
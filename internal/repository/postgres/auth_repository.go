@@ -114,7 +114,7 @@ func (r *PostgresAuthRepository) GetAdminByUsername(username string) (*domain.Ad
 			return nil, errors.ErrAdminNotFound
 		}
 
-		slog.Error("Error getting admin by username: %v", err)
+		slog.Error("Error getting admin by username: %v", utils.Err(err))
 		return nil, err
 	}
 
@@ -140,7 +140,7 @@ func (r *PostgresAuthRepository) GetAdminByID(adminID int) (*domain.Admin, error
 			return nil, errors.ErrAdminNotFound
 		}
 
-		slog.Error("Error getting admin by ID: %v", err)
+		slog.Error("Error getting admin by ID: %v", utils.Err(err))
 		return nil, err
 	}
 
@@ -212,7 +212,7 @@ func (r *PostgresAuthRepository) validateRefreshToken(refreshToken string) (map[
 				return nil, errors.ErrRefreshTokenExpired
 			}
 		}
-		slog.Error("Refresh token validation error: %v !BADKEY=\"%s\"", err, r.JWTConfig.RefreshSecretKey)
+		slog.Error("Refresh token validation error: %v", utils.Err(err))
 		return nil, fmt.Errorf("refresh token validation error: %v", err)
 	}
 
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/encryption"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"log/slog"
+)
+
+type PostgresEmailVerificationRepository struct {
+	DB     *sql.DB
+	Cipher *encryption.Cipher
+}
+
+func NewPostgresEmailVerificationRepository(db *sql.DB, cipher *encryption.Cipher) *PostgresEmailVerificationRepository {
+	return &PostgresEmailVerificationRepository{DB: db, Cipher: cipher}
+}
+
+func (r *PostgresEmailVerificationRepository) CreateEmailVerification(verification *domain.EmailVerification) error {
+	email := verification.Email
+	if err := encryptValues(r.Cipher, &email); err != nil {
+		return err
+	}
+
+	_, err := r.DB.Exec(`
+		INSERT INTO email_verifications (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, verification.UserID, email, verification.TokenHash, verification.ExpiresAt)
+	if err != nil {
+		slog.Error("error inserting email verification: %v", utils.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeEmailVerification marks the unused, unexpired verification with the
+// given token hash as used and flags the user's email as verified. It fails
+// when the user's email has changed since the link was sent.
+func (r *PostgresEmailVerificationRepository) ConsumeEmailVerification(tokenHash string) (*domain.UpdateUserResponse, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userID int32
+	var email string
+	err = tx.QueryRow(`
+		UPDATE email_verifications
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, email
+	`, tokenHash).Scan(&userID, &email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrInvalidVerificationLink
+		}
+		slog.Error("error consuming email verification: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := decryptValues(r.Cipher, &email); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow(`
+		WITH u AS (
+			UPDATE users SET email_verified = true, email_verified_at = NOW(), version = version + 1
+			WHERE id = $1 AND email_index = $2
+			RETURNING *
+		)
+		SELECT `+userColumns+`
+		FROM u
+		`+userCurrentBlockJoin, userID, blindIndex(r.Cipher, emailIndexField, email))
+
+	verifiedUser, err := scanUser(row, r.Cipher)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrInvalidVerificationLink
+		}
+		slog.Error("error marking email as verified: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	user := domain.UpdateUserResponse(verifiedUser)
+
+	return &user, nil
+}
@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/postgres"
+	errors "admin-panel/pkg/lib/errors"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeEmailVerification(t *testing.T) {
+	testCases := []struct {
+		name           string
+		consumed       bool
+		emailUnchanged bool
+		expectedErr    error
+	}{
+		{
+			name:           "Success",
+			consumed:       true,
+			emailUnchanged: true,
+		},
+		{
+			name:        "Used or expired token",
+			consumed:    false,
+			expectedErr: errors.ErrInvalidVerificationLink,
+		},
+		{
+			name:           "Email changed since the link was sent",
+			consumed:       true,
+			emailUnchanged: false,
+			expectedErr:    errors.ErrInvalidVerificationLink,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresEmailVerificationRepository(db, testCipher)
+
+			mock.ExpectBegin()
+			consume := mock.ExpectQuery(`UPDATE email_verifications SET used_at = NOW\(\) WHERE token_hash = \$1 AND used_at IS NULL AND expires_at > NOW\(\) RETURNING user_id, email`).
+				WithArgs("hash")
+			if !tc.consumed {
+				consume.WillReturnError(sql.ErrNoRows)
+			} else {
+				consume.WillReturnRows(sqlmock.NewRows([]string{"user_id", "email"}).AddRow(1, mustEncrypt("atdayewkemal@gmail.com")))
+
+				// The user is matched by the blind index of the address.
+				verify := mock.ExpectQuery(`WITH u AS \( UPDATE users SET email_verified = true, email_verified_at = NOW\(\), version = version \+ 1 WHERE id = \$1 AND email_index = \$2 RETURNING \* \)`).
+					WithArgs(int32(1), testCipher.BlindIndex("email", "atdayewkemal@gmail.com"))
+				if tc.emailUnchanged {
+					verify.WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+						AddRow(1, "Kemal", "Atdayew", mustEncrypt("+99362008971"), false, time.Now(), "Male", time.Now(), "Ashgabat", mustEncrypt("atdayewkemal@gmail.com"), "", true, time.Now(), 3, []byte(`{}`), "{}", nil, nil))
+				} else {
+					verify.WillReturnError(sql.ErrNoRows)
+				}
+			}
+
+			if tc.expectedErr == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			user, err := repo.ConsumeEmailVerification("hash")
+
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr == nil {
+				assert.True(t, user.EmailVerified)
+				assert.Equal(t, "atdayewkemal@gmail.com", user.Email)
+				assert.Equal(t, "+99362008971", user.PhoneNumber)
+				assert.NotNil(t, user.EmailVerifiedAt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateEmailVerificationStoresCiphertext(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEmailVerificationRepository(db, testCipher)
+
+	// The longest address the column held before it was encrypted.
+	email := strings.Repeat("k", 64) + "@" + strings.Repeat("e", 185) + ".com"
+	expiresAt := time.Now().Add(time.Hour)
+	var stored driver.Value
+
+	mock.ExpectExec(`INSERT INTO email_verifications \(user_id, email, token_hash, expires_at\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs(int32(1), storedArg{&stored}, "hash", expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.CreateEmailVerification(&domain.EmailVerification{UserID: 1, Email: email, TokenHash: "hash", ExpiresAt: expiresAt})
+
+	assert.NoError(t, err)
+	assert.True(t, encrypted(email).Match(stored))
+	assert.Greater(t, len(stored.(string)), 254)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/encryption"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"log/slog"
+)
+
+// Phone numbers and email addresses are stored encrypted, together with
+// blind indexes that they are looked up and kept unique by. These are the
+// fields the indexes are computed for.
+const (
+	phoneNumberIndexField = encryption.PhoneNumberField
+	emailIndexField       = encryption.EmailField
+)
+
+// encryptContact encrypts a phone number or email address and returns it
+// with its blind index, which is NULL for empty values so that they never
+// collide.
+func encryptContact(cipher *encryption.Cipher, field, value string) (string, sql.NullString, error) {
+	encrypted, err := cipher.Encrypt(value)
+	if err != nil {
+		slog.Error("error encrypting contact: %v", utils.Err(err))
+		return "", sql.NullString{}, err
+	}
+
+	return encrypted, blindIndex(cipher, field, value), nil
+}
+
+func blindIndex(cipher *encryption.Cipher, field, value string) sql.NullString {
+	index := cipher.BlindIndex(field, value)
+	return sql.NullString{String: index, Valid: index != ""}
+}
+
+// blindIndexes returns the blind indexes of values, leaving out empty ones.
+func blindIndexes(cipher *encryption.Cipher, field string, values []string) []string {
+	var indexes []string
+	for _, value := range values {
+		if index := cipher.BlindIndex(field, value); index != "" {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// encryptValues replaces each of values with its encryption.
+func encryptValues(cipher *encryption.Cipher, values ...*string) error {
+	for _, value := range values {
+		encrypted, err := cipher.Encrypt(*value)
+		if err != nil {
+			slog.Error("error encrypting contact: %v", utils.Err(err))
+			return err
+		}
+		*value = encrypted
+	}
+	return nil
+}
+
+// decryptValues replaces each of values with its plaintext.
+func decryptValues(cipher *encryption.Cipher, values ...*string) error {
+	for _, value := range values {
+		plaintext, err := cipher.Decrypt(*value)
+		if err != nil {
+			slog.Error("error decrypting contact: %v", utils.Err(err))
+			return err
+		}
+		*value = plaintext
+	}
+	return nil
+}
+
+// scanUser scans a row selected with userColumns, like utils.ScanUserRow,
+// and decrypts the user's phone number and email address.
+func scanUser(row utils.RowScanner, cipher *encryption.Cipher) (domain.GetUserResponse, error) {
+	user, err := utils.ScanUserRow(row)
+	if err != nil {
+		return user, err
+	}
+
+	if err := decryptValues(cipher, &user.PhoneNumber, &user.Email); err != nil {
+		return domain.GetUserResponse{}, err
+	}
+
+	return user, nil
+}
+
+// decryptHistoryChanges decrypts the phone number and email address changes
+// of a history entry. Changes that only re-encrypted a value, as when a value
+// stored before encryption was enabled is written again, are dropped.
+func decryptHistoryChanges(cipher *encryption.Cipher, changes map[string]domain.FieldChange) error {
+	for _, field := range []string{"phone_number", "email"} {
+		change, ok := changes[field]
+		if !ok {
+			continue
+		}
+
+		for _, value := range []*interface{}{&change.Old, &change.New} {
+			if text, ok := (*value).(string); ok {
+				if err := decryptValues(cipher, &text); err != nil {
+					return err
+				}
+				*value = text
+			}
+		}
+
+		if change.Old == change.New {
+			delete(changes, field)
+		} else {
+			changes[field] = change
+		}
+	}
+
+	return nil
+}
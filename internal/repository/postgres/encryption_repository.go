@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"admin-panel/pkg/encryption"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// historyEncryptedFields are the fields of the history snapshots and changes
+// holding encrypted values.
+var historyEncryptedFields = []string{"phone_number", "email"}
+
+// PostgresEncryptionRepository re-encrypts stored personal data with the
+// current key of its Cipher and recomputes the blind indexes, for key
+// rotation. Every method handles one batch of rows with IDs above afterID, in
+// ID order, and returns the last ID it read, 0 when no rows were left, and
+// the number of rows it rewrote. Anonymized users keep their placeholders in
+// plaintext and have no blind indexes, and their records are skipped.
+type PostgresEncryptionRepository struct {
+	DB     *sql.DB
+	Cipher *encryption.Cipher
+	// Decrypt makes the methods store the plaintext instead, before
+	// encryption is rolled back. The blind indexes are still computed.
+	Decrypt bool
+}
+
+func NewPostgresEncryptionRepository(db *sql.DB, cipher *encryption.Cipher) *PostgresEncryptionRepository {
+	return &PostgresEncryptionRepository{DB: db, Cipher: cipher}
+}
+
+// userContacts holds the encrypted columns of a user.
+type userContacts struct {
+	id                           int64
+	phoneNumber, email           string
+	phoneNumberIndex, emailIndex sql.NullString
+	anonymized                   bool
+}
+
+// ReencryptUsers re-encrypts the phone numbers and email addresses of a batch
+// of users and recomputes their blind indexes, which also encrypts and
+// indexes values stored before encryption was enabled. The placeholders of
+// anonymized users are stored in plaintext without blind indexes instead.
+// Users keep their version and no history is recorded, as nothing visible
+// changes.
+func (r *PostgresEncryptionRepository) ReencryptUsers(afterID int64, batchSize int) (int64, int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT set_config('admin_panel.skip_history', 'on', true)`); err != nil {
+		slog.Error("error disabling user history: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, phone_number, COALESCE(email, ''), phone_number_index, email_index, anonymized_at IS NOT NULL
+		FROM users
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE
+	`, afterID, batchSize)
+	if err != nil {
+		slog.Error("error reading users to re-encrypt: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	var users []userContacts
+	err = scanRows(rows, func() error {
+		var user userContacts
+		if err := rows.Scan(&user.id, &user.phoneNumber, &user.email, &user.phoneNumberIndex, &user.emailIndex, &user.anonymized); err != nil {
+			return err
+		}
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading users to re-encrypt: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	var lastID int64
+	var updated int
+	for _, user := range users {
+		lastID = user.id
+
+		reencryptContact := r.reencryptContact
+		if user.anonymized {
+			reencryptContact = r.anonymizedContact
+		}
+
+		phoneNumber, phoneNumberIndex, err := reencryptContact(phoneNumberIndexField, user.phoneNumber)
+		if err != nil {
+			return 0, 0, fmt.Errorf("user %d: %w", user.id, err)
+		}
+		email, emailIndex, err := reencryptContact(emailIndexField, user.email)
+		if err != nil {
+			return 0, 0, fmt.Errorf("user %d: %w", user.id, err)
+		}
+
+		if phoneNumber == user.phoneNumber && email == user.email &&
+			phoneNumberIndex == user.phoneNumberIndex && emailIndex == user.emailIndex {
+			continue
+		}
+
+		_, err = tx.Exec(`
+			UPDATE users
+			SET phone_number = $2, phone_number_index = $3, email = $4, email_index = $5
+			WHERE id = $1
+		`, user.id, phoneNumber, phoneNumberIndex, email, emailIndex)
+		if err != nil {
+			slog.Error("error re-encrypting user: %v", utils.Err(err))
+			return 0, 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	return lastID, updated, nil
+}
+
+// reencryptContact returns value re-encrypted with the current key together
+// with the blind index of its plaintext.
+func (r *PostgresEncryptionRepository) reencryptContact(field, value string) (string, sql.NullString, error) {
+	plaintext, err := r.Cipher.Decrypt(value)
+	if err != nil {
+		return "", sql.NullString{}, err
+	}
+
+	reencrypted, err := r.reencrypt(value)
+	if err != nil {
+		return "", sql.NullString{}, err
+	}
+
+	return reencrypted, blindIndex(r.Cipher, field, plaintext), nil
+}
+
+// anonymizedContact returns the plaintext of value without a blind index, as
+// the contacts of anonymized users are placeholders that must not be looked
+// up.
+func (r *PostgresEncryptionRepository) anonymizedContact(field, value string) (string, sql.NullString, error) {
+	plaintext, err := r.Cipher.Decrypt(value)
+	return plaintext, sql.NullString{}, err
+}
+
+// reencrypt returns value encrypted with the current key, or its plaintext
+// when r.Decrypt is set. Values that are already so are returned unchanged.
+func (r *PostgresEncryptionRepository) reencrypt(value string) (string, error) {
+	if r.Decrypt {
+		return r.Cipher.Decrypt(value)
+	}
+	return r.Cipher.Reencrypt(value)
+}
+
+// ReencryptUserHistory re-encrypts the phone numbers and email addresses in
+// the snapshots and changes of a batch of history entries. The other fields
+// are left as they are.
+func (r *PostgresEncryptionRepository) ReencryptUserHistory(afterID int64, batchSize int) (int64, int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, snapshot, changes
+		FROM user_history h
+		WHERE id > $1 AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = h.user_id AND u.anonymized_at IS NOT NULL)
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE
+	`, afterID, batchSize)
+	if err != nil {
+		slog.Error("error reading user history to re-encrypt: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	type historyEntry struct {
+		id                int64
+		snapshot, changes []byte
+	}
+	var entries []historyEntry
+	err = scanRows(rows, func() error {
+		var entry historyEntry
+		if err := rows.Scan(&entry.id, &entry.snapshot, &entry.changes); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading user history to re-encrypt: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	var lastID int64
+	var updated int
+	for _, entry := range entries {
+		lastID = entry.id
+
+		snapshot, snapshotChanged, err := r.reencryptFields(entry.snapshot, historyEncryptedFields, r.reencryptJSONString)
+		if err != nil {
+			return 0, 0, fmt.Errorf("history entry %d: %w", entry.id, err)
+		}
+		changes, changesChanged, err := r.reencryptFields(entry.changes, historyEncryptedFields, func(change json.RawMessage) (json.RawMessage, bool, error) {
+			// Changes hold the old and new value of each field.
+			return r.reencryptFields(change, []string{"old", "new"}, r.reencryptJSONString)
+		})
+		if err != nil {
+			return 0, 0, fmt.Errorf("history entry %d: %w", entry.id, err)
+		}
+
+		if !snapshotChanged && !changesChanged {
+			continue
+		}
+
+		if _, err := tx.Exec(`UPDATE user_history SET snapshot = $2, changes = $3 WHERE id = $1`, entry.id, []byte(snapshot), []byte(changes)); err != nil {
+			slog.Error("error re-encrypting user history: %v", utils.Err(err))
+			return 0, 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	return lastID, updated, nil
+}
+
+// reencryptFields applies reencrypt to fields of the JSON object document
+// and reports whether any of them changed. Other fields are kept verbatim.
+func (r *PostgresEncryptionRepository) reencryptFields(document json.RawMessage, fields []string, reencrypt func(json.RawMessage) (json.RawMessage, bool, error)) (json.RawMessage, bool, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(document, &object); err != nil || object == nil {
+		return document, false, err
+	}
+
+	changed := false
+	for _, field := range fields {
+		value, ok := object[field]
+		if !ok {
+			continue
+		}
+
+		reencrypted, fieldChanged, err := reencrypt(value)
+		if err != nil {
+			return nil, false, err
+		}
+		if fieldChanged {
+			object[field] = reencrypted
+			changed = true
+		}
+	}
+
+	if !changed {
+		return document, false, nil
+	}
+
+	reencrypted, err := json.Marshal(object)
+	return reencrypted, true, err
+}
+
+// reencryptJSONString re-encrypts the JSON string value with the current key.
+// Other JSON values are left alone.
+func (r *PostgresEncryptionRepository) reencryptJSONString(value json.RawMessage) (json.RawMessage, bool, error) {
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return value, false, nil
+	}
+
+	reencrypted, err := r.reencrypt(text)
+	if err != nil {
+		return nil, false, err
+	}
+	if reencrypted == text {
+		return value, false, nil
+	}
+
+	encoded, err := json.Marshal(reencrypted)
+	return encoded, true, err
+}
+
+// ReencryptPhoneChanges re-encrypts the old and new phone numbers of a batch
+// of phone changes.
+func (r *PostgresEncryptionRepository) ReencryptPhoneChanges(afterID int64, batchSize int) (int64, int, error) {
+	return r.reencryptColumns("phone_changes", []string{"old_phone_number", "new_phone_number"}, afterID, batchSize)
+}
+
+// ReencryptEmailVerifications re-encrypts the email addresses of a batch of
+// email verifications.
+func (r *PostgresEncryptionRepository) ReencryptEmailVerifications(afterID int64, batchSize int) (int64, int, error) {
+	return r.reencryptColumns("email_verifications", []string{"email"}, afterID, batchSize)
+}
+
+// reencryptColumns re-encrypts the text columns of a batch of rows of table,
+// which references users by user_id.
+func (r *PostgresEncryptionRepository) reencryptColumns(table string, columns []string, afterID int64, batchSize int) (int64, int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT id, %s
+		FROM %s t
+		WHERE id > $1 AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.user_id AND u.anonymized_at IS NOT NULL)
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE
+	`, strings.Join(columns, ", "), table), afterID, batchSize)
+	if err != nil {
+		slog.Error("error reading rows to re-encrypt: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	type encryptedRow struct {
+		id     int64
+		values []string
+	}
+	var batch []encryptedRow
+	err = scanRows(rows, func() error {
+		row := encryptedRow{values: make([]string, len(columns))}
+		dest := []interface{}{&row.id}
+		for i := range row.values {
+			dest = append(dest, &row.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		batch = append(batch, row)
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading rows to re-encrypt: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+2)
+	}
+	update := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $1`, table, strings.Join(assignments, ", "))
+
+	var lastID int64
+	var updated int
+	for _, row := range batch {
+		lastID = row.id
+
+		args := []interface{}{row.id}
+		changed := false
+		for _, value := range row.values {
+			reencrypted, err := r.reencrypt(value)
+			if err != nil {
+				return 0, 0, fmt.Errorf("%s %d: %w", table, row.id, err)
+			}
+			changed = changed || reencrypted != value
+			args = append(args, reencrypted)
+		}
+
+		if !changed {
+			continue
+		}
+
+		if _, err := tx.Exec(update, args...); err != nil {
+			slog.Error("error re-encrypting row: %v", utils.Err(err))
+			return 0, 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return 0, 0, err
+	}
+
+	return lastID, updated, nil
+}
@@ -0,0 +1,308 @@
+package repository_test
+
+import (
+	repository "admin-panel/internal/repository/postgres"
+	"admin-panel/pkg/encryption"
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKey1     = bytes.Repeat([]byte{1}, 32)
+	testKey2     = bytes.Repeat([]byte{2}, 32)
+	testIndexKey = bytes.Repeat([]byte{9}, 32)
+
+	// testCipher encrypts the contacts written by the repositories under test.
+	testCipher = newTestCipher(map[int][]byte{1: testKey1}, 1)
+)
+
+func newTestCipher(keys map[int][]byte, current int) *encryption.Cipher {
+	cipher, err := encryption.NewCipher(keys, current, testIndexKey)
+	if err != nil {
+		panic(err)
+	}
+	return cipher
+}
+
+// mustEncrypt encrypts value as stored by the repositories under test.
+func mustEncrypt(value string) string {
+	encrypted, err := testCipher.Encrypt(value)
+	if err != nil {
+		panic(err)
+	}
+	return encrypted
+}
+
+// encryptedArg matches an argument holding plaintext encrypted with the
+// current key of cipher.
+type encryptedArg struct {
+	cipher    *encryption.Cipher
+	plaintext string
+}
+
+func encrypted(plaintext string) encryptedArg {
+	return encryptedArg{cipher: testCipher, plaintext: plaintext}
+}
+
+func (a encryptedArg) Match(value driver.Value) bool {
+	text, ok := value.(string)
+	if !ok || !a.cipher.Current(text) {
+		return false
+	}
+	plaintext, err := a.cipher.Decrypt(text)
+	return err == nil && plaintext == a.plaintext
+}
+
+// storedArg matches any argument and keeps it, to inspect the value a
+// repository writes.
+type storedArg struct {
+	value *driver.Value
+}
+
+func (a storedArg) Match(value driver.Value) bool {
+	*a.value = value
+	return true
+}
+
+// jsonArg matches a JSON argument whose decoded value satisfies match.
+type jsonArg func(document map[string]interface{}) bool
+
+func (a jsonArg) Match(value driver.Value) bool {
+	raw, ok := value.([]byte)
+	if !ok {
+		return false
+	}
+	var document map[string]interface{}
+	return json.Unmarshal(raw, &document) == nil && a(document)
+}
+
+func TestReencryptUsers(t *testing.T) {
+	rotated := newTestCipher(map[int][]byte{1: testKey1, 2: testKey2}, 2)
+	current, err := rotated.Encrypt("+99362008973")
+	assert.NoError(t, err)
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEncryptionRepository(db, rotated)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('admin_panel.skip_history', 'on', true\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, phone_number, COALESCE\(email, ''\), phone_number_index, email_index, anonymized_at IS NOT NULL FROM users WHERE id > \$1 ORDER BY id LIMIT \$2 FOR UPDATE`).
+		WithArgs(int64(10), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone_number", "email", "phone_number_index", "email_index", "anonymized"}).
+			// Stored before encryption was enabled.
+			AddRow(11, "+99362008971", "", nil, nil, false).
+			// Encrypted with the previous key.
+			AddRow(12, mustEncrypt("+99362008972"), mustEncrypt("kemal@example.com"), rotated.BlindIndex("phone_number", "+99362008972"), rotated.BlindIndex("email", "kemal@example.com"), false).
+			// Up to date.
+			AddRow(13, current, "", rotated.BlindIndex("phone_number", "+99362008973"), nil, false).
+			// Anonymized after its placeholder was encrypted and indexed.
+			AddRow(14, mustEncrypt("anon-14"), "", rotated.BlindIndex("phone_number", "anon-14"), nil, true).
+			// Anonymized and up to date.
+			AddRow(15, "anon-15", "", nil, nil, true))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2, phone_number_index = \$3, email = \$4, email_index = \$5 WHERE id = \$1`).
+		WithArgs(int64(11), encryptedArg{rotated, "+99362008971"}, rotated.BlindIndex("phone_number", "+99362008971"), "", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2, phone_number_index = \$3, email = \$4, email_index = \$5 WHERE id = \$1`).
+		WithArgs(int64(12), encryptedArg{rotated, "+99362008972"}, rotated.BlindIndex("phone_number", "+99362008972"), encryptedArg{rotated, "kemal@example.com"}, rotated.BlindIndex("email", "kemal@example.com")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2, phone_number_index = \$3, email = \$4, email_index = \$5 WHERE id = \$1`).
+		WithArgs(int64(14), "anon-14", nil, "", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	lastID, updated, err := repo.ReencryptUsers(10, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), lastID)
+	assert.Equal(t, 3, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptUsersDone(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEncryptionRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, phone_number.* FROM users`).
+		WithArgs(int64(13), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone_number", "email", "phone_number_index", "email_index", "anonymized"}))
+	mock.ExpectCommit()
+
+	lastID, updated, err := repo.ReencryptUsers(13, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), lastID)
+	assert.Equal(t, 0, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptUserHistory(t *testing.T) {
+	rotated := newTestCipher(map[int][]byte{1: testKey1, 2: testKey2}, 2)
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEncryptionRepository(db, rotated)
+
+	snapshot := `{"first_name":"Kemal","phone_number":"` + mustEncrypt("+99362008971") + `","email":""}`
+	changes := `{"first_name":{"old":null,"new":"Kemal"},"email":{"old":null,"new":"kemal@example.com"}}`
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, snapshot, changes FROM user_history h WHERE id > \$1 AND NOT EXISTS \(SELECT 1 FROM users u WHERE u.id = h.user_id AND u.anonymized_at IS NOT NULL\) ORDER BY id LIMIT \$2 FOR UPDATE`).
+		WithArgs(int64(0), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "snapshot", "changes"}).
+			AddRow(1, []byte(snapshot), []byte(changes)))
+	mock.ExpectExec(`UPDATE user_history SET snapshot = \$2, changes = \$3 WHERE id = \$1`).
+		WithArgs(int64(1),
+			jsonArg(func(document map[string]interface{}) bool {
+				return document["first_name"] == "Kemal" && document["email"] == "" &&
+					encryptedArg{rotated, "+99362008971"}.Match(document["phone_number"])
+			}),
+			jsonArg(func(document map[string]interface{}) bool {
+				email, _ := document["email"].(map[string]interface{})
+				return document["first_name"] != nil && email["old"] == nil &&
+					encryptedArg{rotated, "kemal@example.com"}.Match(email["new"])
+			})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	lastID, updated, err := repo.ReencryptUserHistory(0, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), lastID)
+	assert.Equal(t, 1, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptPhoneChanges(t *testing.T) {
+	rotated := newTestCipher(map[int][]byte{1: testKey1, 2: testKey2}, 2)
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEncryptionRepository(db, rotated)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, old_phone_number, new_phone_number FROM phone_changes t WHERE id > \$1 AND NOT EXISTS \(SELECT 1 FROM users u WHERE u.id = t.user_id AND u.anonymized_at IS NOT NULL\) ORDER BY id LIMIT \$2 FOR UPDATE`).
+		WithArgs(int64(0), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "old_phone_number", "new_phone_number"}).
+			AddRow(1, mustEncrypt("+99362008971"), "+99362008972").
+			// Blank values are left alone.
+			AddRow(2, "", ""))
+	mock.ExpectExec(`UPDATE phone_changes SET old_phone_number = \$2, new_phone_number = \$3 WHERE id = \$1`).
+		WithArgs(int64(1), encryptedArg{rotated, "+99362008971"}, encryptedArg{rotated, "+99362008972"}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	lastID, updated, err := repo.ReencryptPhoneChanges(0, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), lastID)
+	assert.Equal(t, 1, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDecryptUsers(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEncryptionRepository(db, testCipher)
+	repo.Decrypt = true
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, phone_number.* FROM users`).
+		WithArgs(int64(0), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone_number", "email", "phone_number_index", "email_index", "anonymized"}).
+			AddRow(1, mustEncrypt("+99362008971"), mustEncrypt("kemal@example.com"), testCipher.BlindIndex("phone_number", "+99362008971"), testCipher.BlindIndex("email", "kemal@example.com"), false).
+			// Already decrypted.
+			AddRow(2, "+99362008972", "", testCipher.BlindIndex("phone_number", "+99362008972"), nil, false))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2, phone_number_index = \$3, email = \$4, email_index = \$5 WHERE id = \$1`).
+		WithArgs(int64(1), "+99362008971", testCipher.BlindIndex("phone_number", "+99362008971"), "kemal@example.com", testCipher.BlindIndex("email", "kemal@example.com")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	lastID, updated, err := repo.ReencryptUsers(0, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), lastID)
+	assert.Equal(t, 1, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDecryptUserHistoryAndPhoneChanges(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresEncryptionRepository(db, testCipher)
+	repo.Decrypt = true
+
+	snapshot := `{"first_name":"Kemal","phone_number":"` + mustEncrypt("+99362008971") + `","email":""}`
+	changes := `{"email":{"old":null,"new":"` + mustEncrypt("kemal@example.com") + `"}}`
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, snapshot, changes FROM user_history`).
+		WithArgs(int64(0), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "snapshot", "changes"}).AddRow(1, []byte(snapshot), []byte(changes)))
+	mock.ExpectExec(`UPDATE user_history SET snapshot = \$2, changes = \$3 WHERE id = \$1`).
+		WithArgs(int64(1),
+			jsonArg(func(document map[string]interface{}) bool {
+				return document["phone_number"] == "+99362008971" && document["email"] == ""
+			}),
+			jsonArg(func(document map[string]interface{}) bool {
+				email, _ := document["email"].(map[string]interface{})
+				return email["old"] == nil && email["new"] == "kemal@example.com"
+			})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, updated, err := repo.ReencryptUserHistory(0, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, old_phone_number, new_phone_number FROM phone_changes`).
+		WithArgs(int64(0), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "old_phone_number", "new_phone_number"}).
+			AddRow(1, mustEncrypt("+99362008971"), "+99362008972"))
+	mock.ExpectExec(`UPDATE phone_changes SET old_phone_number = \$2, new_phone_number = \$3 WHERE id = \$1`).
+		WithArgs(int64(1), "+99362008971", "+99362008972").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, updated, err = repo.ReencryptPhoneChanges(0, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptUnknownKey(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	// The previous key is no longer configured.
+	rotated := newTestCipher(map[int][]byte{2: testKey2}, 2)
+	repo := repository.NewPostgresEncryptionRepository(db, rotated)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, email FROM email_verifications`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, mustEncrypt("kemal@example.com")))
+	mock.ExpectRollback()
+
+	_, _, err := repo.ReencryptEmailVerifications(0, 100)
+
+	assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+// noteColumns selects a note from n joined with its author from a, so that
+// the same column list serves plain selects and CTEs over RETURNING clauses.
+const noteColumns = `n.id, n.user_id, n.author_id, COALESCE(a.username, ''), n.body, n.pinned, n.visibility, n.created_at, n.updated_at`
+
+const noteAuthorJoin = `LEFT JOIN admins a ON a.id = n.author_id`
+
+type PostgresNoteRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresNoteRepository(db *sql.DB) *PostgresNoteRepository {
+	return &PostgresNoteRepository{DB: db}
+}
+
+func scanNote(row utils.RowScanner) (domain.Note, error) {
+	var note domain.Note
+	err := row.Scan(
+		&note.ID,
+		&note.UserID,
+		&note.AuthorID,
+		&note.AuthorUsername,
+		&note.Body,
+		&note.Pinned,
+		&note.Visibility,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+	)
+	return note, err
+}
+
+// GetNotes returns a page of the user's notes, pinned notes first and newest
+// first within each group. Notes restricted to super admins are skipped unless
+// includeRestricted is set.
+func (r *PostgresNoteRepository) GetNotes(userID int32, page, pageSize int, includeRestricted bool) (*domain.NotesList, error) {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, userID).Scan(&exists)
+	if err != nil {
+		slog.Error("error checking user existence: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.ErrUserNotFound
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT `+noteColumns+`
+		FROM user_notes n
+		`+noteAuthorJoin+`
+		WHERE n.user_id = $1 AND ($2 OR n.visibility = 'all')
+		ORDER BY n.pinned DESC, n.created_at DESC, n.id DESC
+		LIMIT $3 OFFSET $4
+	`, userID, includeRestricted, pageSize, (page-1)*pageSize)
+	if err != nil {
+		slog.Error("error querying notes: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	notesList := domain.NotesList{Notes: make([]domain.Note, 0)}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			slog.Error("error scanning note: %v", utils.Err(err))
+			return nil, err
+		}
+		notesList.Notes = append(notesList.Notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over notes: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &notesList, nil
+}
+
+func (r *PostgresNoteRepository) CountNotes(userID int32, includeRestricted bool) (int, error) {
+	var total int
+	err := r.DB.QueryRow(`
+		SELECT COUNT(*) FROM user_notes n
+		WHERE n.user_id = $1 AND ($2 OR n.visibility = 'all')
+	`, userID, includeRestricted).Scan(&total)
+	if err != nil {
+		slog.Error("error counting notes: %v", utils.Err(err))
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *PostgresNoteRepository) GetNoteByID(userID, noteID int32) (*domain.Note, error) {
+	note, err := scanNote(r.DB.QueryRow(`
+		SELECT `+noteColumns+`
+		FROM user_notes n
+		`+noteAuthorJoin+`
+		WHERE n.id = $1 AND n.user_id = $2
+	`, noteID, userID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		slog.Error("error getting note: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func (r *PostgresNoteRepository) CreateNote(userID int32, request *domain.NoteRequest) (*domain.Note, error) {
+	note, err := scanNote(r.DB.QueryRow(`
+		WITH n AS (
+			INSERT INTO user_notes (user_id, author_id, body, visibility)
+			VALUES ($1, $2, $3, $4)
+			RETURNING *
+		)
+		SELECT `+noteColumns+` FROM n `+noteAuthorJoin,
+		userID, request.AuthorID, request.Body, request.Visibility))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" && pqErr.Constraint == "user_notes_user_id_fkey" {
+			return nil, errors.ErrUserNotFound
+		}
+		slog.Error("error inserting note: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func (r *PostgresNoteRepository) UpdateNote(userID, noteID int32, request *domain.NoteRequest) (*domain.Note, error) {
+	note, err := scanNote(r.DB.QueryRow(`
+		WITH n AS (
+			UPDATE user_notes SET body = $3, visibility = $4, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+			RETURNING *
+		)
+		SELECT `+noteColumns+` FROM n `+noteAuthorJoin,
+		noteID, userID, request.Body, request.Visibility))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		slog.Error("error updating note: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// SetNotePinned pins or unpins a note. Pinning is not an edit of the note, so
+// updated_at is left alone.
+func (r *PostgresNoteRepository) SetNotePinned(userID, noteID int32, pinned bool) (*domain.Note, error) {
+	note, err := scanNote(r.DB.QueryRow(`
+		WITH n AS (
+			UPDATE user_notes SET pinned = $3
+			WHERE id = $1 AND user_id = $2
+			RETURNING *
+		)
+		SELECT `+noteColumns+` FROM n `+noteAuthorJoin,
+		noteID, userID, pinned))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		slog.Error("error pinning note: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func (r *PostgresNoteRepository) DeleteNote(userID, noteID int32) error {
+	result, err := r.DB.Exec(`DELETE FROM user_notes WHERE id = $1 AND user_id = $2`, noteID, userID)
+	if err != nil {
+		slog.Error("error deleting note: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrNoteNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	repository "admin-panel/internal/repository/postgres"
+	errors "admin-panel/pkg/lib/errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNotes(t *testing.T) {
+	testCases := []struct {
+		name              string
+		userExists        bool
+		includeRestricted bool
+		expectedLength    int
+		expectedErr       error
+	}{
+		{
+			name:              "Super admin sees restricted notes",
+			userExists:        true,
+			includeRestricted: true,
+			expectedLength:    2,
+		},
+		{
+			name:        "User not found",
+			userExists:  false,
+			expectedErr: errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresNoteRepository(db)
+
+			mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE id = \$1 AND deleted_at IS NULL\)`).
+				WithArgs(int32(1)).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.userExists))
+
+			if tc.userExists {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "author_id", "username", "body", "pinned", "visibility", "created_at", "updated_at"}).
+					AddRow(2, 1, 7, "alice", "Promised to unblock after ID check", true, "super_admin", time.Now(), time.Now()).
+					AddRow(1, 1, nil, "", "Called on 3 Oct", false, "all", time.Now(), time.Now())
+
+				mock.ExpectQuery(`FROM user_notes n LEFT JOIN admins a ON a.id = n.author_id WHERE n.user_id = \$1 AND \(\$2 OR n.visibility = 'all'\) ORDER BY n.pinned DESC, n.created_at DESC, n.id DESC LIMIT \$3 OFFSET \$4`).
+					WithArgs(int32(1), tc.includeRestricted, 20, 0).
+					WillReturnRows(rows)
+			}
+
+			notes, err := repo.GetNotes(1, 1, 20, tc.includeRestricted)
+
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr == nil {
+				assert.Len(t, notes.Notes, tc.expectedLength)
+				assert.Equal(t, "alice", notes.Notes[0].AuthorUsername)
+				assert.Nil(t, notes.Notes[1].AuthorID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
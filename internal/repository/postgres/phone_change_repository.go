@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/encryption"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"log/slog"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type PostgresPhoneChangeRepository struct {
+	DB     *sql.DB
+	Cipher *encryption.Cipher
+}
+
+func NewPostgresPhoneChangeRepository(db *sql.DB, cipher *encryption.Cipher) *PostgresPhoneChangeRepository {
+	return &PostgresPhoneChangeRepository{DB: db, Cipher: cipher}
+}
+
+func (r *PostgresPhoneChangeRepository) IsPhoneNumberInUse(phoneNumber string) (bool, error) {
+	var inUse bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE phone_number_index = $1)`, blindIndex(r.Cipher, phoneNumberIndexField, phoneNumber)).Scan(&inUse)
+	if err != nil {
+		slog.Error("error checking phone number usage: %v", utils.Err(err))
+		return false, err
+	}
+
+	return inUse, nil
+}
+
+// CreatePhoneChange stores a new pending phone change, cancelling the user's
+// previous pending change, if any.
+func (r *PostgresPhoneChangeRepository) CreatePhoneChange(change *domain.PhoneChange) (*domain.PhoneChange, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := cancelPendingPhoneChange(tx, change.UserID); err != nil {
+		return nil, err
+	}
+
+	oldPhoneNumber, newPhoneNumber := change.OldPhoneNumber, change.NewPhoneNumber
+	if err := encryptValues(r.Cipher, &oldPhoneNumber, &newPhoneNumber); err != nil {
+		return nil, err
+	}
+
+	created := *change
+	err = tx.QueryRow(`
+		INSERT INTO phone_changes (user_id, old_phone_number, new_phone_number, status, code_hash, requested_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, requested_at
+	`, change.UserID, oldPhoneNumber, newPhoneNumber, domain.PhoneChangePending, change.CodeHash, change.RequestedBy, change.ExpiresAt).
+		Scan(&created.ID, &created.RequestedAt)
+	if err != nil {
+		slog.Error("error inserting phone change: %v", utils.Err(err))
+		return nil, err
+	}
+	created.Status = domain.PhoneChangePending
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *PostgresPhoneChangeRepository) GetPendingPhoneChange(userID int32) (*domain.PhoneChange, error) {
+	var change domain.PhoneChange
+
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, old_phone_number, new_phone_number, status, reason, code_hash, attempts, requested_by, requested_at, expires_at, completed_at
+		FROM phone_changes
+		WHERE user_id = $1 AND status = $2
+	`, userID, domain.PhoneChangePending).Scan(
+		&change.ID,
+		&change.UserID,
+		&change.OldPhoneNumber,
+		&change.NewPhoneNumber,
+		&change.Status,
+		&change.Reason,
+		&change.CodeHash,
+		&change.Attempts,
+		&change.RequestedBy,
+		&change.RequestedAt,
+		&change.ExpiresAt,
+		&change.CompletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrPhoneChangeNotFound
+		}
+		slog.Error("error getting pending phone change: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := decryptValues(r.Cipher, &change.OldPhoneNumber, &change.NewPhoneNumber); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// IncrementPhoneChangeAttempts counts a verification attempt for the change
+// unless it already had maxAttempts, so concurrent guesses cannot exceed it.
+func (r *PostgresPhoneChangeRepository) IncrementPhoneChangeAttempts(id int32, maxAttempts int) error {
+	var attempts int
+	err := r.DB.QueryRow(`
+		UPDATE phone_changes SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2
+		RETURNING attempts
+	`, id, maxAttempts).Scan(&attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrTooManyVerificationAttempts
+		}
+		slog.Error("error incrementing phone change attempts: %v", utils.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// VerifyPhoneChange marks a pending change as verified and assigns its new
+// phone number to the user.
+func (r *PostgresPhoneChangeRepository) VerifyPhoneChange(change *domain.PhoneChange) (*domain.UpdateUserResponse, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if change.RequestedBy != nil {
+		if err := setChangeActor(tx, *change.RequestedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE phone_changes
+		SET status = $2, completed_at = NOW()
+		WHERE id = $1 AND status = $3
+	`, change.ID, domain.PhoneChangeVerified, domain.PhoneChangePending)
+	if err != nil {
+		slog.Error("error completing phone change: %v", utils.Err(err))
+		return nil, err
+	}
+
+	completed, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return nil, err
+	}
+
+	// Another request verified or replaced the change in the meantime.
+	if completed == 0 {
+		return nil, errors.ErrPhoneChangeNotFound
+	}
+
+	user, err := r.updatePhoneNumber(tx, change.UserID, change.NewPhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ForcePhoneChange assigns a new phone number to the user without
+// verification and records the change together with its reason.
+func (r *PostgresPhoneChangeRepository) ForcePhoneChange(change *domain.PhoneChange) (*domain.UpdateUserResponse, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if change.RequestedBy != nil {
+		if err := setChangeActor(tx, *change.RequestedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cancelPendingPhoneChange(tx, change.UserID); err != nil {
+		return nil, err
+	}
+
+	user, err := r.updatePhoneNumber(tx, change.UserID, change.NewPhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPhoneNumber, newPhoneNumber := change.OldPhoneNumber, change.NewPhoneNumber
+	if err := encryptValues(r.Cipher, &oldPhoneNumber, &newPhoneNumber); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO phone_changes (user_id, old_phone_number, new_phone_number, status, reason, requested_by, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, change.UserID, oldPhoneNumber, newPhoneNumber, domain.PhoneChangeForced, change.Reason, change.RequestedBy)
+	if err != nil {
+		slog.Error("error inserting phone change: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func cancelPendingPhoneChange(tx *sql.Tx, userID int32) error {
+	_, err := tx.Exec(`
+		UPDATE phone_changes
+		SET status = $2, completed_at = NOW()
+		WHERE user_id = $1 AND status = $3
+	`, userID, domain.PhoneChangeCancelled, domain.PhoneChangePending)
+	if err != nil {
+		slog.Error("error cancelling pending phone change: %v", utils.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresPhoneChangeRepository) updatePhoneNumber(tx *sql.Tx, userID int32, phoneNumber string) (*domain.UpdateUserResponse, error) {
+	encrypted, index, err := encryptContact(r.Cipher, phoneNumberIndexField, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow(`
+		WITH u AS (
+			UPDATE users SET phone_number = $2, phone_number_index = $3, version = version + 1
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+userColumns+`
+		FROM u
+		`+userCurrentBlockJoin, userID, encrypted, index)
+
+	updatedUser, err := scanUser(row, r.Cipher)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			if strings.Contains(pqErr.Error(), "phone_number") {
+				return nil, errors.ErrPhoneNumberInUse
+			}
+		}
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		slog.Error("error updating phone number: %v", utils.Err(err))
+		return nil, err
+	}
+
+	user := domain.UpdateUserResponse(updatedUser)
+
+	return &user, nil
+}
@@ -0,0 +1,169 @@
+package repository_test
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/postgres"
+	errors "admin-panel/pkg/lib/errors"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPhoneChange(t *testing.T) {
+	change := &domain.PhoneChange{ID: 3, UserID: 1, OldPhoneNumber: "+99362008971", NewPhoneNumber: "+99365123456"}
+
+	testCases := []struct {
+		name          string
+		completed     int64
+		updateErr     error
+		expectedPhone string
+		expectedErr   error
+	}{
+		{
+			name:          "Success",
+			completed:     1,
+			expectedPhone: "+99365123456",
+		},
+		{
+			name:        "Change no longer pending",
+			completed:   0,
+			expectedErr: errors.ErrPhoneChangeNotFound,
+		},
+		{
+			name:        "Phone number taken meanwhile",
+			completed:   1,
+			updateErr:   &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_phone_number_index_key"`},
+			expectedErr: errors.ErrPhoneNumberInUse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresPhoneChangeRepository(db, testCipher)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE phone_changes SET status = \$2, completed_at = NOW\(\) WHERE id = \$1 AND status = \$3`).
+				WithArgs(change.ID, domain.PhoneChangeVerified, domain.PhoneChangePending).
+				WillReturnResult(sqlmock.NewResult(0, tc.completed))
+
+			if tc.completed > 0 {
+				query := mock.ExpectQuery(`WITH u AS \( UPDATE users SET phone_number = \$2, phone_number_index = \$3, version = version \+ 1 WHERE id = \$1 RETURNING \* \)`).
+					WithArgs(change.UserID, encrypted(change.NewPhoneNumber), testCipher.BlindIndex("phone_number", change.NewPhoneNumber))
+				if tc.updateErr != nil {
+					query.WillReturnError(tc.updateErr)
+				} else {
+					query.WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+						AddRow(1, "Kemal", "Atdayew", mustEncrypt(change.NewPhoneNumber), false, time.Now(), "Male", time.Now(), "Ashgabat", "atdayewkemal@gmail.com", "", false, nil, 2, []byte(`{}`), "{}", nil, nil))
+				}
+			}
+
+			if tc.expectedErr == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			user, err := repo.VerifyPhoneChange(change)
+
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expectedPhone, user.PhoneNumber)
+				assert.Equal(t, int32(2), user.Version)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIncrementPhoneChangeAttempts(t *testing.T) {
+	testCases := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		expectedErr error
+	}{
+		{
+			name: "Attempt counted",
+			rows: sqlmock.NewRows([]string{"attempts"}).AddRow(2),
+		},
+		{
+			name:        "No attempts left",
+			rows:        sqlmock.NewRows([]string{"attempts"}),
+			expectedErr: errors.ErrTooManyVerificationAttempts,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresPhoneChangeRepository(db, testCipher)
+
+			mock.ExpectQuery(`UPDATE phone_changes SET attempts = attempts \+ 1 WHERE id = \$1 AND attempts < \$2 RETURNING attempts`).
+				WithArgs(int32(3), 5).
+				WillReturnRows(tc.rows)
+
+			err := repo.IncrementPhoneChangeAttempts(3, 5)
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsPhoneNumberInUse(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresPhoneChangeRepository(db, testCipher)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE phone_number_index = \$1\)`).
+		WithArgs(testCipher.BlindIndex("phone_number", "+99365123456")).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	inUse, err := repo.IsPhoneNumberInUse("+99365123456")
+
+	assert.NoError(t, err)
+	assert.True(t, inUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePhoneChangeStoresCiphertext(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresPhoneChangeRepository(db, testCipher)
+
+	requestedAt := time.Now()
+	var oldPhoneNumber, newPhoneNumber driver.Value
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE phone_changes SET status = \$2, completed_at = NOW\(\) WHERE user_id = \$1 AND status = \$3`).
+		WithArgs(int32(1), domain.PhoneChangeCancelled, domain.PhoneChangePending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO phone_changes \(user_id, old_phone_number, new_phone_number, status, code_hash, requested_by, expires_at\)`).
+		WithArgs(int32(1), storedArg{&oldPhoneNumber}, storedArg{&newPhoneNumber}, domain.PhoneChangePending, "hash", nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "requested_at"}).AddRow(3, requestedAt))
+	mock.ExpectCommit()
+
+	created, err := repo.CreatePhoneChange(&domain.PhoneChange{UserID: 1, OldPhoneNumber: "+99362008971", NewPhoneNumber: "+99365123456", CodeHash: "hash"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), created.ID)
+	assert.Equal(t, "+99365123456", created.NewPhoneNumber)
+
+	// The stored values are real ciphertexts, far longer than the 20
+	// characters the columns held before they were encrypted.
+	for plaintext, stored := range map[string]driver.Value{"+99362008971": oldPhoneNumber, "+99365123456": newPhoneNumber} {
+		assert.True(t, encrypted(plaintext).Match(stored))
+		assert.Greater(t, len(stored.(string)), 20)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/encryption"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// anonymizedFields are the personal fields of the history snapshots and
+// changes that anonymization removes.
+var anonymizedFields = []string{"first_name", "last_name", "phone_number", "email", "profile_photo_url", "attributes"}
+
+// encryptedEventDetails are the event details holding encrypted values.
+var encryptedEventDetails = []string{"old_phone_number", "new_phone_number", "email"}
+
+type PostgresPrivacyRepository struct {
+	DB     *sql.DB
+	Cipher *encryption.Cipher
+}
+
+func NewPostgresPrivacyRepository(db *sql.DB, cipher *encryption.Cipher) *PostgresPrivacyRepository {
+	return &PostgresPrivacyRepository{DB: db, Cipher: cipher}
+}
+
+// GetUserEvents returns the blocks, phone changes, email verifications and
+// merges recorded for a user, oldest first.
+func (r *PostgresPrivacyRepository) GetUserEvents(id int32) ([]domain.UserEvent, error) {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		slog.Error("error checking user existence: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.ErrUserNotFound
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT type, at, actor_id, details FROM (
+			SELECT 'blocked' AS type, blocked_at AS at, blocked_by AS actor_id,
+				jsonb_build_object('reason', reason, 'note', note, 'expires_at', expires_at) AS details
+			FROM user_blocks WHERE user_id = $1
+			UNION ALL
+			SELECT 'unblocked', unblocked_at, unblocked_by, '{}'
+			FROM user_blocks WHERE user_id = $1 AND unblocked_at IS NOT NULL
+			UNION ALL
+			SELECT 'phone_change_requested', requested_at, requested_by,
+				jsonb_build_object('old_phone_number', old_phone_number, 'new_phone_number', new_phone_number, 'status', status, 'reason', reason)
+			FROM phone_changes WHERE user_id = $1
+			UNION ALL
+			SELECT 'phone_change_completed', completed_at, NULL, jsonb_build_object('new_phone_number', new_phone_number)
+			FROM phone_changes WHERE user_id = $1 AND completed_at IS NOT NULL
+			UNION ALL
+			SELECT 'email_verification_sent', created_at, NULL, jsonb_build_object('email', email)
+			FROM email_verifications WHERE user_id = $1
+			UNION ALL
+			SELECT 'email_verified', used_at, NULL, jsonb_build_object('email', email)
+			FROM email_verifications WHERE user_id = $1 AND used_at IS NOT NULL
+			UNION ALL
+			SELECT 'merged', deleted_at, NULL, jsonb_build_object('merged_from', id)
+			FROM users WHERE merged_into = $1
+		) events
+		ORDER BY at, type
+	`, id)
+	if err != nil {
+		slog.Error("error querying user events: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]domain.UserEvent, 0)
+	for rows.Next() {
+		var event domain.UserEvent
+		var details []byte
+		if err := rows.Scan(&event.Type, &event.At, &event.ActorID, &details); err != nil {
+			slog.Error("error scanning user event: %v", utils.Err(err))
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			slog.Error("error decoding user event details: %v", utils.Err(err))
+			return nil, err
+		}
+		for _, key := range encryptedEventDetails {
+			if value, ok := event.Details[key].(string); ok {
+				if err := decryptValues(r.Cipher, &value); err != nil {
+					return nil, err
+				}
+				event.Details[key] = value
+			}
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over user events: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// AnonymizeUser irreversibly removes the personal data of a user and of the
+// users merged into it, in one transaction. The rows stay as tombstones with
+// their IDs, gender, location, birth and registration dates, blocks and tags,
+// so that statistics and references to them still add up. Names, email
+// addresses, photos and custom attributes are cleared, phone numbers replaced
+// by a unique placeholder, and the same fields are removed from the history,
+// phone changes and email verifications. Free text is deleted too: notes,
+// block notes and phone change reasons. Block reasons are kept, as they are
+// one of a fixed set of categories. The photo files are left to the caller.
+func (r *PostgresPrivacyRepository) AnonymizeUser(id, actorID int32) (*domain.UserAnonymization, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, actorID); err != nil {
+		return nil, err
+	}
+
+	var anonymizedAt *time.Time
+	err = tx.QueryRow(`SELECT anonymized_at FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&anonymizedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrUserNotFound
+	}
+	if err != nil {
+		slog.Error("error locking user: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if anonymizedAt != nil {
+		return nil, errors.ErrUserAlreadyAnonymized
+	}
+
+	// Users merged into this one, directly or through other merges, hold
+	// earlier copies of the same person's data.
+	rows, err := tx.Query(`
+		WITH RECURSIVE subject AS (
+			SELECT id FROM users WHERE id = $1
+			UNION
+			SELECT u.id FROM users u JOIN subject s ON u.merged_into = s.id
+		)
+		SELECT u.id, u.profile_photo_key FROM users u JOIN subject s ON s.id = u.id
+		ORDER BY u.id
+		FOR UPDATE OF u
+	`, id)
+	if err != nil {
+		slog.Error("error querying merged users: %v", utils.Err(err))
+		return nil, err
+	}
+
+	result := &domain.UserAnonymization{ID: id}
+	var ids []int32
+	err = scanRows(rows, func() error {
+		var userID int32
+		var photoKey string
+		if err := rows.Scan(&userID, &photoKey); err != nil {
+			return err
+		}
+		ids = append(ids, userID)
+		if photoKey != "" {
+			result.PhotoKeys = append(result.PhotoKeys, photoKey)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading merged users: %v", utils.Err(err))
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET first_name = '', last_name = '', first_name_key = '', last_name_key = '',
+			phone_number = 'anon-' || id, email = '', email_verified = false, email_verified_at = NULL,
+			phone_number_index = NULL, email_index = NULL,
+			profile_photo_url = '', profile_photo_key = '', attributes = '{}',
+			anonymized_at = NOW(), version = version + 1
+		WHERE id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		slog.Error("error anonymizing users: %v", utils.Err(err))
+		return nil, err
+	}
+
+	// The update above was recorded in the history with the old values, so
+	// the history is scrubbed after it.
+	_, err = tx.Exec(`
+		UPDATE user_history
+		SET snapshot = snapshot || jsonb_build_object('first_name', '', 'last_name', '', 'phone_number', 'anon-' || COALESCE(merged_from, user_id), 'email', '', 'profile_photo_url', '', 'attributes', '{}'::jsonb),
+			changes = changes - $2::text[]
+		WHERE user_id = ANY($1)
+	`, pq.Array(ids), pq.Array(anonymizedFields))
+	if err != nil {
+		slog.Error("error scrubbing user history: %v", utils.Err(err))
+		return nil, err
+	}
+
+	for _, statement := range []string{
+		`UPDATE phone_changes SET old_phone_number = '', new_phone_number = '', code_hash = '', reason = '' WHERE user_id = ANY($1)`,
+		`UPDATE email_verifications SET email = '' WHERE user_id = ANY($1)`,
+		`UPDATE user_blocks SET note = '' WHERE user_id = ANY($1)`,
+		`DELETE FROM user_notes WHERE user_id = ANY($1)`,
+	} {
+		if _, err := tx.Exec(statement, pq.Array(ids)); err != nil {
+			slog.Error("error scrubbing user records: %v", utils.Err(err))
+			return nil, err
+		}
+	}
+
+	if err := tx.QueryRow(`SELECT anonymized_at FROM users WHERE id = $1`, id).Scan(&result.AnonymizedAt); err != nil {
+		slog.Error("error reading anonymization time: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return result, nil
+}
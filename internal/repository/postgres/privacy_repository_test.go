@@ -0,0 +1,127 @@
+package repository_test
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/postgres"
+	"admin-panel/pkg/lib/errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserEvents(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresPrivacyRepository(db, testCipher)
+
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	actorID := int32(2)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE id = \$1 AND deleted_at IS NULL\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`SELECT type, at, actor_id, details FROM \(.+FROM user_blocks WHERE user_id = \$1.+FROM users WHERE merged_into = \$1\s+\) events ORDER BY at, type`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "at", "actor_id", "details"}).
+			AddRow("blocked", at, actorID, []byte(`{"reason":"spam","note":"","expires_at":null}`)).
+			AddRow("merged", at.Add(time.Hour), nil, []byte(`{"merged_from":7}`)))
+
+	events, err := repo.GetUserEvents(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.UserEvent{
+		{Type: "blocked", At: at, ActorID: &actorID, Details: map[string]interface{}{"reason": "spam", "note": "", "expires_at": nil}},
+		{Type: "merged", At: at.Add(time.Hour), Details: map[string]interface{}{"merged_from": float64(7)}},
+	}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserEventsUserNotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresPrivacyRepository(db, testCipher)
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	_, err := repo.GetUserEvents(1)
+
+	assert.Equal(t, errors.ErrUserNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresPrivacyRepository(db, testCipher)
+
+	anonymizedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	ids := pq.Array([]int32{1, 7})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('admin_panel.actor_id', \$1, true\)`).
+		WithArgs("3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT anonymized_at FROM users WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"anonymized_at"}).AddRow(nil))
+	mock.ExpectQuery(`WITH RECURSIVE subject AS .+ SELECT u.id, u.profile_photo_key FROM users u JOIN subject s ON s.id = u.id ORDER BY u.id FOR UPDATE OF u`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "profile_photo_key"}).AddRow(1, "users/1/photo/a").AddRow(7, ""))
+	mock.ExpectExec(`UPDATE users SET first_name = '', last_name = '', .+ phone_number = 'anon-' \|\| id, email = '', .+ profile_photo_key = '', attributes = '\{\}', anonymized_at = NOW\(\), version = version \+ 1 WHERE id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE user_history SET snapshot = snapshot \|\| jsonb_build_object\(.+, 'attributes', '\{\}'::jsonb\), changes = changes - \$2::text\[\] WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids, pq.Array([]string{"first_name", "last_name", "phone_number", "email", "profile_photo_url", "attributes"})).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec(`UPDATE phone_changes SET old_phone_number = '', new_phone_number = '', code_hash = '', reason = '' WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE email_verifications SET email = '' WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Block reasons are categories, only the notes are free text.
+	mock.ExpectExec(`UPDATE user_blocks SET note = '' WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM user_notes WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`SELECT anonymized_at FROM users WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"anonymized_at"}).AddRow(anonymizedAt))
+	mock.ExpectCommit()
+
+	result, err := repo.AnonymizeUser(1, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, &domain.UserAnonymization{ID: 1, AnonymizedAt: anonymizedAt, PhotoKeys: []string{"users/1/photo/a"}}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnonymizeUserAlreadyAnonymized(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresPrivacyRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT anonymized_at FROM users WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"anonymized_at"}).AddRow(time.Now()))
+	mock.ExpectRollback()
+
+	_, err := repo.AnonymizeUser(1, 3)
+
+	assert.Equal(t, errors.ErrUserAlreadyAnonymized, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
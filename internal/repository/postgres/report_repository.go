@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const reportColumns = `id, name, description, definition, created_by, created_at, updated_at`
+
+// reportFieldColumns maps domain.ReportFields to their SQL expressions.
+var reportFieldColumns = map[string]string{
+	"gender":            "LOWER(u.gender)",
+	"location":          "LOWER(u.location)",
+	"blocked":           "u.blocked",
+	"email_verified":    "u.email_verified",
+	"has_email":         "(u.email <> '')",
+	"has_photo":         "(u.profile_photo_url <> '')",
+	"registration_date": "u.registration_date",
+	"date_of_birth":     "u.date_of_birth",
+	"age":               "date_part('year', age(u.date_of_birth))::integer",
+}
+
+var reportBuckets = map[domain.ReportBucket]string{
+	domain.ReportBucketDay:     "day",
+	domain.ReportBucketWeek:    "week",
+	domain.ReportBucketMonth:   "month",
+	domain.ReportBucketQuarter: "quarter",
+	domain.ReportBucketYear:    "year",
+}
+
+// reportMeasures are the SQL templates of the measures, with %s standing for
+// the field's expression.
+var reportMeasures = map[domain.ReportFunction]string{
+	domain.ReportCount:         "COUNT(*)",
+	domain.ReportCountDistinct: "COUNT(DISTINCT %s)",
+	domain.ReportMin:           "MIN(%s)",
+	domain.ReportMax:           "MAX(%s)",
+	domain.ReportAvg:           "ROUND(AVG(%s)::numeric, 2)::float8",
+	domain.ReportSum:           "SUM(%s)",
+}
+
+// reportOperators are the SQL templates of the filter operators, with %s
+// standing for the field's expression and %d for the value's placeholder.
+var reportOperators = map[domain.ReportOperator]string{
+	domain.ReportEq:    "%s = $%d",
+	domain.ReportNe:    "%s <> $%d",
+	domain.ReportGt:    "%s > $%d",
+	domain.ReportGte:   "%s >= $%d",
+	domain.ReportLt:    "%s < $%d",
+	domain.ReportLte:   "%s <= $%d",
+	domain.ReportIn:    "%s = ANY($%d)",
+	domain.ReportNotIn: "%s <> ALL($%d)",
+}
+
+type PostgresReportRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresReportRepository(db *sql.DB) *PostgresReportRepository {
+	return &PostgresReportRepository{DB: db}
+}
+
+func scanReport(row utils.RowScanner) (domain.Report, error) {
+	var report domain.Report
+	var definition []byte
+
+	if err := row.Scan(
+		&report.ID,
+		&report.Name,
+		&report.Description,
+		&definition,
+		&report.CreatedBy,
+		&report.CreatedAt,
+		&report.UpdatedAt,
+	); err != nil {
+		return domain.Report{}, err
+	}
+
+	if err := json.Unmarshal(definition, &report.Definition); err != nil {
+		return domain.Report{}, err
+	}
+
+	return report, nil
+}
+
+func (r *PostgresReportRepository) GetReports() ([]domain.Report, error) {
+	rows, err := r.DB.Query(`SELECT ` + reportColumns + ` FROM reports ORDER BY name`)
+	if err != nil {
+		slog.Error("error querying reports: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]domain.Report, 0)
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			slog.Error("error scanning report: %v", utils.Err(err))
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over reports: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (r *PostgresReportRepository) GetReportByID(id int32) (*domain.Report, error) {
+	report, err := scanReport(r.DB.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrReportNotFound
+	}
+	if err != nil {
+		slog.Error("error getting report: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *PostgresReportRepository) CreateReport(request *domain.ReportRequest) (*domain.Report, error) {
+	definition, err := json.Marshal(request.Definition)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := scanReport(r.DB.QueryRow(`
+		INSERT INTO reports (name, description, definition, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+reportColumns,
+		request.Name, request.Description, string(definition), request.CreatedBy))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrReportAlreadyExists
+		}
+		slog.Error("error inserting report: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *PostgresReportRepository) UpdateReport(id int32, request *domain.ReportRequest) (*domain.Report, error) {
+	definition, err := json.Marshal(request.Definition)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := scanReport(r.DB.QueryRow(`
+		UPDATE reports SET name = $2, description = $3, definition = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+reportColumns,
+		id, request.Name, request.Description, string(definition)))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrReportAlreadyExists
+		}
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrReportNotFound
+		}
+		slog.Error("error updating report: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *PostgresReportRepository) DeleteReport(id int32) error {
+	result, err := r.DB.Exec(`DELETE FROM reports WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("error deleting report: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrReportNotFound
+	}
+
+	return nil
+}
+
+// RunReport compiles definition to SQL and returns its rows. The definition
+// is expected to be validated; anything not in the whitelists above is
+// rejected rather than reaching the query.
+func (r *PostgresReportRepository) RunReport(ctx context.Context, definition *domain.ReportDefinition) (*domain.ReportResult, error) {
+	query, args, err := compileReport(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("error running report: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &domain.ReportResult{Columns: definition.Columns(), Rows: make([][]interface{}, 0)}
+	for rows.Next() {
+		if len(result.Rows) == definition.Limit {
+			result.Truncated = true
+			break
+		}
+
+		row := make([]interface{}, len(result.Columns))
+		pointers := make([]interface{}, len(row))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			slog.Error("error scanning report row: %v", utils.Err(err))
+			return nil, err
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over report rows: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// compileReport returns the query of a report. Field names, functions and
+// operators only select from fixed SQL fragments and all values are passed as
+// arguments. One row more than the limit is selected to detect truncation.
+func compileReport(definition *domain.ReportDefinition) (string, []interface{}, error) {
+	var selected []string
+	for _, dimension := range definition.Dimensions {
+		column, ok := reportFieldColumns[dimension.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, dimension.Field)
+		}
+		if dimension.Bucket != "" {
+			bucket, ok := reportBuckets[dimension.Bucket]
+			if !ok {
+				return "", nil, fmt.Errorf("%w: unknown bucket %q", errors.ErrInvalidReport, dimension.Bucket)
+			}
+			column = fmt.Sprintf("to_char(date_trunc('%s', %s), 'YYYY-MM-DD')", bucket, column)
+		}
+		selected = append(selected, column)
+	}
+
+	for _, measure := range definition.Measures {
+		template, ok := reportMeasures[measure.Function]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown function %q", errors.ErrInvalidReport, measure.Function)
+		}
+		if measure.Function == domain.ReportCount {
+			selected = append(selected, template)
+			continue
+		}
+		column, ok := reportFieldColumns[measure.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, measure.Field)
+		}
+		selected = append(selected, fmt.Sprintf(template, column))
+	}
+
+	conditions := []string{activeUserCondition}
+	var args []interface{}
+	for _, filter := range definition.Filters {
+		column, ok := reportFieldColumns[filter.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, filter.Field)
+		}
+		template, ok := reportOperators[filter.Operator]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown operator %q", errors.ErrInvalidReport, filter.Operator)
+		}
+
+		value := filter.Value
+		switch values := value.(type) {
+		case []string:
+			value = pq.StringArray(values)
+		case []float64:
+			value = pq.Float64Array(values)
+		}
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(template, column, len(args)))
+	}
+
+	query := "SELECT " + strings.Join(selected, ", ") + " FROM users u " + whereClause(conditions)
+
+	if len(definition.Dimensions) > 0 {
+		positions := make([]string, len(definition.Dimensions))
+		for i := range positions {
+			positions[i] = strconv.Itoa(i + 1)
+		}
+		query += " GROUP BY " + strings.Join(positions, ", ")
+	}
+
+	order, err := reportOrderBy(definition)
+	if err != nil {
+		return "", nil, err
+	}
+	query += order
+
+	args = append(args, definition.Limit+1)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	return query, args, nil
+}
+
+// reportOrderBy orders by the sorted columns, referenced by position, and
+// then by the remaining dimensions so that rows come in a stable order.
+func reportOrderBy(definition *domain.ReportDefinition) (string, error) {
+	columns := definition.Columns()
+	position := func(name string) int {
+		for i, column := range columns {
+			if column == name {
+				return i + 1
+			}
+		}
+		return 0
+	}
+
+	var terms []string
+	used := make(map[int]bool)
+	for _, field := range domain.ParseSort(definition.Sort) {
+		i := position(field.Field)
+		if i == 0 {
+			return "", fmt.Errorf("%w: %q is not a report column", errors.ErrInvalidSort, field.Field)
+		}
+		term := strconv.Itoa(i)
+		if field.Descending {
+			term += " DESC"
+		}
+		terms = append(terms, term)
+		used[i] = true
+	}
+	for i := range definition.Dimensions {
+		if !used[i+1] {
+			terms = append(terms, strconv.Itoa(i+1))
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", nil
+	}
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
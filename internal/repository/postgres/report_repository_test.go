@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/postgres"
+	errors "admin-panel/pkg/lib/errors"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReport(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresReportRepository(db)
+
+	q2Start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	q2End := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	definition := &domain.ReportDefinition{
+		Dimensions: []domain.ReportDimension{{Field: "location"}, {Field: "gender"}},
+		Measures:   []domain.ReportMeasure{{Function: domain.ReportCount}, {Function: domain.ReportAvg, Field: "age"}},
+		Filters: []domain.ReportFilter{
+			{Field: "blocked", Operator: domain.ReportEq, Value: true},
+			{Field: "registration_date", Operator: domain.ReportGte, Value: q2Start},
+			{Field: "registration_date", Operator: domain.ReportLt, Value: q2End},
+			{Field: "location", Operator: domain.ReportNotIn, Value: []string{"mary"}},
+		},
+		Sort:  "-count",
+		Limit: 2,
+	}
+
+	mock.ExpectQuery(`SELECT LOWER\(u.location\), LOWER\(u.gender\), COUNT\(\*\), ROUND\(AVG\(date_part\('year', age\(u.date_of_birth\)\)::integer\)::numeric, 2\)::float8 `+
+		`FROM users u WHERE u.deleted_at IS NULL AND u.blocked = \$1 AND u.registration_date >= \$2 AND u.registration_date < \$3 AND LOWER\(u.location\) <> ALL\(\$4\) `+
+		`GROUP BY 1, 2 ORDER BY 3 DESC, 1, 2 LIMIT \$5`).
+		WithArgs(true, q2Start, q2End, pq.StringArray{"mary"}, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"location", "gender", "count", "avg"}).
+			AddRow("ashgabat", "female", int64(12), 31.5).
+			AddRow("ashgabat", "male", int64(9), 28.25).
+			AddRow("dashoguz", nil, int64(1), nil))
+
+	result, err := repo.RunReport(context.Background(), definition)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"location", "gender", "count", "avg_age"}, result.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"ashgabat", "female", int64(12), 31.5},
+		{"ashgabat", "male", int64(9), 28.25},
+	}, result.Rows)
+	assert.True(t, result.Truncated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunReportBucketsDates(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresReportRepository(db)
+
+	mock.ExpectQuery(`SELECT to_char\(date_trunc\('quarter', u.registration_date\), 'YYYY-MM-DD'\), COUNT\(DISTINCT LOWER\(u.location\)\) `+
+		`FROM users u WHERE u.deleted_at IS NULL AND date_part\('year', age\(u.date_of_birth\)\)::integer = ANY\(\$1\) GROUP BY 1 ORDER BY 1 LIMIT \$2`).
+		WithArgs(pq.Float64Array{18, 19}, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"quarter", "count"}).AddRow("2024-04-01", int64(3)))
+
+	result, err := repo.RunReport(context.Background(), &domain.ReportDefinition{
+		Dimensions: []domain.ReportDimension{{Field: "registration_date", Bucket: domain.ReportBucketQuarter}},
+		Measures:   []domain.ReportMeasure{{Function: domain.ReportCountDistinct, Field: "location"}},
+		Filters:    []domain.ReportFilter{{Field: "age", Operator: domain.ReportIn, Value: []float64{18, 19}}},
+		Limit:      10,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"registration_date_quarter", "count_distinct_location"}, result.Columns)
+	assert.Equal(t, [][]interface{}{{"2024-04-01", int64(3)}}, result.Rows)
+	assert.False(t, result.Truncated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunReportRejectsUnknownFields(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresReportRepository(db)
+
+	_, err := repo.RunReport(context.Background(), &domain.ReportDefinition{
+		Dimensions: []domain.ReportDimension{{Field: "password; DROP TABLE users"}},
+		Limit:      10,
+	})
+
+	assert.ErrorIs(t, err, errors.ErrInvalidReport)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
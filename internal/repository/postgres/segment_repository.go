@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+const segmentColumns = `id, name, description, filter, created_by, created_at, updated_at`
+
+type PostgresSegmentRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresSegmentRepository(db *sql.DB) *PostgresSegmentRepository {
+	return &PostgresSegmentRepository{DB: db}
+}
+
+func scanSegment(row utils.RowScanner) (domain.Segment, error) {
+	var segment domain.Segment
+	var filter []byte
+
+	if err := row.Scan(
+		&segment.ID,
+		&segment.Name,
+		&segment.Description,
+		&filter,
+		&segment.CreatedBy,
+		&segment.CreatedAt,
+		&segment.UpdatedAt,
+	); err != nil {
+		return domain.Segment{}, err
+	}
+
+	if err := json.Unmarshal(filter, &segment.Filter); err != nil {
+		return domain.Segment{}, err
+	}
+
+	return segment, nil
+}
+
+func (r *PostgresSegmentRepository) GetSegments() ([]domain.Segment, error) {
+	rows, err := r.DB.Query(`SELECT ` + segmentColumns + ` FROM segments ORDER BY name`)
+	if err != nil {
+		slog.Error("error querying segments: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	segments := make([]domain.Segment, 0)
+	for rows.Next() {
+		segment, err := scanSegment(rows)
+		if err != nil {
+			slog.Error("error scanning segment: %v", utils.Err(err))
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over segments: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func (r *PostgresSegmentRepository) GetSegmentByID(id int32) (*domain.Segment, error) {
+	segment, err := scanSegment(r.DB.QueryRow(`SELECT `+segmentColumns+` FROM segments WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrSegmentNotFound
+	}
+	if err != nil {
+		slog.Error("error getting segment: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &segment, nil
+}
+
+func (r *PostgresSegmentRepository) CreateSegment(request *domain.SegmentRequest) (*domain.Segment, error) {
+	filter, err := json.Marshal(request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	segment, err := scanSegment(r.DB.QueryRow(`
+		INSERT INTO segments (name, description, filter, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+segmentColumns,
+		request.Name, request.Description, string(filter), request.CreatedBy))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrSegmentAlreadyExists
+		}
+		slog.Error("error inserting segment: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &segment, nil
+}
+
+func (r *PostgresSegmentRepository) UpdateSegment(id int32, request *domain.SegmentRequest) (*domain.Segment, error) {
+	filter, err := json.Marshal(request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	segment, err := scanSegment(r.DB.QueryRow(`
+		UPDATE segments SET name = $2, description = $3, filter = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+segmentColumns,
+		id, request.Name, request.Description, string(filter)))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrSegmentAlreadyExists
+		}
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrSegmentNotFound
+		}
+		slog.Error("error updating segment: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &segment, nil
+}
+
+func (r *PostgresSegmentRepository) DeleteSegment(id int32) error {
+	result, err := r.DB.Exec(`DELETE FROM segments WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("error deleting segment: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrSegmentNotFound
+	}
+
+	return nil
+}
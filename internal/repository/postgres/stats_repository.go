@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/lib/utils"
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+type PostgresStatsRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresStatsRepository(db *sql.DB) *PostgresStatsRepository {
+	return &PostgresStatsRepository{DB: db}
+}
+
+// GetUserStatsCounts aggregates the users table for query. All aggregates
+// are read from the same snapshot, so they add up.
+func (r *PostgresStatsRepository) GetUserStatsCounts(query *domain.UserStatsQuery) (*domain.UserStatsCounts, error) {
+	tx, err := r.DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	counts := domain.UserStatsCounts{Ages: make(map[int]int)}
+
+	// Registration dates are stored in UTC and bucketed in the requested
+	// time zone.
+	rows, err := tx.Query(`
+		SELECT date_trunc($1, u.registration_date AT TIME ZONE 'UTC' AT TIME ZONE $2)::date AS bucket, COUNT(*)
+		FROM users u
+		WHERE u.deleted_at IS NULL AND u.registration_date >= $3 AND u.registration_date < $4
+		GROUP BY bucket
+		ORDER BY bucket`,
+		string(query.Interval), query.TimeZone, query.Start.UTC(), query.End.UTC())
+	if err != nil {
+		slog.Error("error counting registrations: %v", utils.Err(err))
+		return nil, err
+	}
+	err = scanRows(rows, func() error {
+		var count domain.RegistrationCount
+		if err := rows.Scan(&count.Start, &count.Count); err != nil {
+			return err
+		}
+		counts.Registrations = append(counts.Registrations, count)
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading registration counts: %v", utils.Err(err))
+		return nil, err
+	}
+
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE NOT u.blocked), COUNT(*) FILTER (WHERE u.blocked)
+		FROM users u
+		WHERE u.deleted_at IS NULL`).Scan(&counts.Active, &counts.Blocked)
+	if err != nil {
+		slog.Error("error counting blocked users: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if counts.Genders, err = queryStatsGroups(tx, `
+		SELECT LOWER(u.gender), COUNT(*)
+		FROM users u
+		WHERE u.deleted_at IS NULL
+		GROUP BY 1
+		ORDER BY 2 DESC, 1`); err != nil {
+		slog.Error("error counting genders: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if counts.Locations, err = queryStatsGroups(tx, `
+		SELECT u.location, COUNT(*)
+		FROM users u
+		WHERE u.deleted_at IS NULL AND u.location <> ''
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT $1`, query.TopLocations); err != nil {
+		slog.Error("error counting locations: %v", utils.Err(err))
+		return nil, err
+	}
+
+	rows, err = tx.Query(`
+		SELECT EXTRACT(YEAR FROM age($1::date, u.date_of_birth::date))::integer AS years, COUNT(*)
+		FROM users u
+		WHERE u.deleted_at IS NULL
+		GROUP BY years`,
+		query.Today.Format("2006-01-02"))
+	if err != nil {
+		slog.Error("error counting ages: %v", utils.Err(err))
+		return nil, err
+	}
+	err = scanRows(rows, func() error {
+		var years, count int
+		if err := rows.Scan(&years, &count); err != nil {
+			return err
+		}
+		counts.Ages[years] = count
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading age counts: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+func queryStatsGroups(tx *sql.Tx, query string, args ...interface{}) ([]domain.StatsGroup, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]domain.StatsGroup, 0)
+	err = scanRows(rows, func() error {
+		var group domain.StatsGroup
+		if err := rows.Scan(&group.Value, &group.Count); err != nil {
+			return err
+		}
+		groups = append(groups, group)
+		return nil
+	})
+
+	return groups, err
+}
+
+// scanRows calls scan for each of rows and closes them.
+func scanRows(rows *sql.Rows, scan func() error) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,57 @@
+package repository_test
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserStatsCounts(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresStatsRepository(db)
+
+	ashgabat, _ := time.LoadLocation("Asia/Ashgabat")
+	query := &domain.UserStatsQuery{
+		Start:        time.Date(2024, 1, 1, 0, 0, 0, 0, ashgabat),
+		End:          time.Date(2024, 2, 1, 0, 0, 0, 0, ashgabat),
+		Interval:     domain.StatsIntervalDay,
+		TimeZone:     "Asia/Ashgabat",
+		Today:        time.Date(2024, 2, 10, 0, 0, 0, 0, ashgabat),
+		TopLocations: 5,
+	}
+	day := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT date_trunc\(\$1, u.registration_date AT TIME ZONE 'UTC' AT TIME ZONE \$2\)::date AS bucket, COUNT\(\*\) FROM users u WHERE u.deleted_at IS NULL AND u.registration_date >= \$3 AND u.registration_date < \$4 GROUP BY bucket ORDER BY bucket`).
+		WithArgs("day", "Asia/Ashgabat", query.Start.UTC(), query.End.UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(day, 2))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FILTER \(WHERE NOT u.blocked\), COUNT\(\*\) FILTER \(WHERE u.blocked\) FROM users u WHERE u.deleted_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"active", "blocked"}).AddRow(9, 1))
+	mock.ExpectQuery(`SELECT LOWER\(u.gender\), COUNT\(\*\) FROM users u WHERE u.deleted_at IS NULL GROUP BY 1 ORDER BY 2 DESC, 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"gender", "count"}).AddRow("female", 6).AddRow("male", 4))
+	mock.ExpectQuery(`SELECT u.location, COUNT\(\*\) FROM users u WHERE u.deleted_at IS NULL AND u.location <> '' GROUP BY 1 ORDER BY 2 DESC, 1 LIMIT \$1`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"location", "count"}).AddRow("Ashgabat", 10))
+	mock.ExpectQuery(`SELECT EXTRACT\(YEAR FROM age\(\$1::date, u.date_of_birth::date\)\)::integer AS years, COUNT\(\*\) FROM users u WHERE u.deleted_at IS NULL GROUP BY years`).
+		WithArgs("2024-02-10").
+		WillReturnRows(sqlmock.NewRows([]string{"years", "count"}).AddRow(30, 7).AddRow(41, 3))
+	mock.ExpectRollback()
+
+	counts, err := repo.GetUserStatsCounts(query)
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.RegistrationCount{{Start: day, Count: 2}}, counts.Registrations)
+	assert.Equal(t, 9, counts.Active)
+	assert.Equal(t, 1, counts.Blocked)
+	assert.Equal(t, []domain.StatsGroup{{Value: "female", Count: 6}, {Value: "male", Count: 4}}, counts.Genders)
+	assert.Equal(t, []domain.StatsGroup{{Value: "Ashgabat", Count: 10}}, counts.Locations)
+	assert.Equal(t, map[int]int{30: 7, 41: 3}, counts.Ages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
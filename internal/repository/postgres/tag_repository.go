@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+type PostgresTagRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresTagRepository(db *sql.DB) *PostgresTagRepository {
+	return &PostgresTagRepository{DB: db}
+}
+
+func (r *PostgresTagRepository) GetTags() ([]domain.Tag, error) {
+	rows, err := r.DB.Query(`
+		SELECT t.id, t.name, t.description, COUNT(ut.user_id), t.created_at
+		FROM tags t
+		LEFT JOIN user_tags ut ON ut.tag_id = t.id
+		GROUP BY t.id
+		ORDER BY t.name
+	`)
+	if err != nil {
+		slog.Error("error querying tags: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]domain.Tag, 0)
+	for rows.Next() {
+		var tag domain.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Description, &tag.UserCount, &tag.CreatedAt); err != nil {
+			slog.Error("error scanning tag: %v", utils.Err(err))
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over tags: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *PostgresTagRepository) CreateTag(request *domain.TagRequest) (*domain.Tag, error) {
+	var tag domain.Tag
+	err := r.DB.QueryRow(`
+		INSERT INTO tags (name, description) VALUES ($1, $2)
+		RETURNING id, name, description, created_at
+	`, request.Name, request.Description).Scan(&tag.ID, &tag.Name, &tag.Description, &tag.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrTagAlreadyExists
+		}
+		slog.Error("error inserting tag: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (r *PostgresTagRepository) UpdateTag(id int32, request *domain.TagRequest) (*domain.Tag, error) {
+	var tag domain.Tag
+	err := r.DB.QueryRow(`
+		UPDATE tags SET name = $2, description = $3 WHERE id = $1
+		RETURNING id, name, description, (SELECT COUNT(*) FROM user_tags WHERE tag_id = $1), created_at
+	`, id, request.Name, request.Description).Scan(&tag.ID, &tag.Name, &tag.Description, &tag.UserCount, &tag.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrTagAlreadyExists
+		}
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrTagNotFound
+		}
+		slog.Error("error updating tag: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (r *PostgresTagRepository) DeleteTag(id int32) error {
+	result, err := r.DB.Exec(`DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("error deleting tag: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// TagUser attaches the named tags to the user. Tags the user already has are
+// left untouched; the user's version is bumped since tags are part of the
+// user representation.
+func (r *PostgresTagRepository) TagUser(userID int32, request *domain.TagUserRequest) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := bumpUserVersion(tx, userID); err != nil {
+		return err
+	}
+
+	var found int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM tags WHERE name = ANY($1)`, pq.Array(request.Tags)).Scan(&found)
+	if err != nil {
+		slog.Error("error looking up tags: %v", utils.Err(err))
+		return err
+	}
+
+	if found != len(request.Tags) {
+		return errors.ErrTagNotFound
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_tags (user_id, tag_id, tagged_by)
+		SELECT $1, t.id, $3 FROM tags t WHERE t.name = ANY($2)
+		ON CONFLICT (user_id, tag_id) DO NOTHING
+	`, userID, pq.Array(request.Tags), request.TaggedBy)
+	if err != nil {
+		slog.Error("error tagging user: %v", utils.Err(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UntagUser removes the named tag from the user. It returns ErrTagNotFound
+// when the user does not have the tag.
+func (r *PostgresTagRepository) UntagUser(userID int32, tag string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := bumpUserVersion(tx, userID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM user_tags ut USING tags t
+		WHERE ut.tag_id = t.id AND ut.user_id = $1 AND t.name = $2
+	`, userID, tag)
+	if err != nil {
+		slog.Error("error untagging user: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrTagNotFound
+	}
+
+	return tx.Commit()
+}
+
+// bumpUserVersion increments the version of a user whose representation
+// changes outside the users table, locking the row for the rest of tx.
+func bumpUserVersion(tx *sql.Tx, userID int32) error {
+	result, err := tx.Exec(`UPDATE users SET version = version + 1 WHERE id = $1 AND deleted_at IS NULL`, userID)
+	if err != nil {
+		slog.Error("error updating user version: %v", utils.Err(err))
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if updated == 0 {
+		return errors.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/postgres"
+	errors "admin-panel/pkg/lib/errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagUser(t *testing.T) {
+	testCases := []struct {
+		name        string
+		userExists  bool
+		tagsFound   int
+		expectedErr error
+	}{
+		{
+			name:       "Tags attached",
+			userExists: true,
+			tagsFound:  2,
+		},
+		{
+			name:        "User not found",
+			userExists:  false,
+			expectedErr: errors.ErrUserNotFound,
+		},
+		{
+			name:        "Tag not found",
+			userExists:  true,
+			tagsFound:   1,
+			expectedErr: errors.ErrTagNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresTagRepository(db)
+
+			mock.ExpectBegin()
+
+			var updated int64
+			if tc.userExists {
+				updated = 1
+			}
+			mock.ExpectExec(`UPDATE users SET version = version \+ 1 WHERE id = \$1`).
+				WithArgs(int32(1)).
+				WillReturnResult(sqlmock.NewResult(0, updated))
+
+			if tc.userExists {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tags WHERE name = ANY\(\$1\)`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tc.tagsFound))
+			}
+
+			if tc.expectedErr == nil {
+				mock.ExpectExec(`INSERT INTO user_tags \(user_id, tag_id, tagged_by\)`).
+					WithArgs(int32(1), sqlmock.AnyArg(), int32(7)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := repo.TagUser(1, &domain.TagUserRequest{Tags: []string{"vip", "beta"}, TaggedBy: 7})
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -2,31 +2,195 @@ package repository
 
 import (
 	"admin-panel/internal/domain"
+	"admin-panel/pkg/encryption"
 	errors "admin-panel/pkg/lib/errors"
 	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/search"
+	"admin-panel/pkg/translit"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
 
+// userColumns is the column list every user query selects, in the order
+// expected by utils.ScanUserRow. It must be used together with
+// userCurrentBlockJoin, which exposes the user's active block as "b".
+const userColumns = `u.id, u.first_name, u.last_name, u.phone_number, u.blocked, u.registration_date, u.gender, u.date_of_birth, u.location, u.email, u.profile_photo_url, u.email_verified, u.email_verified_at, u.version, u.attributes, ` + userTagsColumn + `, b.reason, b.expires_at`
+
+// userTagsColumn selects the names of the user's tags as a text array.
+const userTagsColumn = `ARRAY(SELECT t.name FROM user_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.user_id = u.id ORDER BY t.name) AS tags`
+
+const userCurrentBlockJoin = `LEFT JOIN user_blocks b ON b.user_id = u.id AND b.unblocked_at IS NULL`
+
 type PostgresUserRepository struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Cipher *encryption.Cipher
+}
+
+func NewPostgresUserRepository(db *sql.DB, cipher *encryption.Cipher) *PostgresUserRepository {
+	return &PostgresUserRepository{DB: db, Cipher: cipher}
+}
+
+// activeUserCondition excludes users that were merged into another user.
+const activeUserCondition = "u.deleted_at IS NULL"
+
+// userFilterConditions returns the SQL conditions implementing filter, with
+// placeholders numbered after the len(args) arguments already in use. Users
+// merged into others never match.
+func userFilterConditions(filter *domain.UserFilter, args []interface{}) ([]string, []interface{}, error) {
+	conditions := []string{activeUserCondition}
+	if filter == nil {
+		return conditions, args, nil
+	}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	for _, tag := range filter.Tags {
+		add("EXISTS (SELECT 1 FROM user_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.user_id = u.id AND t.name = $%d)", tag)
+	}
+	if filter.Blocked != nil {
+		add("u.blocked = $%d", *filter.Blocked)
+	}
+	if filter.Gender != "" {
+		add("LOWER(u.gender) = LOWER($%d)", filter.Gender)
+	}
+	if filter.Location != "" {
+		add("LOWER(u.location) = LOWER($%d)", filter.Location)
+	}
+	if filter.MinAge != nil {
+		add("u.date_of_birth <= CURRENT_DATE - make_interval(years => $%d)", *filter.MinAge)
+	}
+	if filter.MaxAge != nil {
+		// Users stay MaxAge years old until the day before their next birthday.
+		add("u.date_of_birth > CURRENT_DATE - make_interval(years => $%d + 1)", *filter.MaxAge)
+	}
+	if filter.BornAfter != nil {
+		add("u.date_of_birth >= $%d", *filter.BornAfter)
+	}
+	if filter.BornBefore != nil {
+		add("u.date_of_birth < $%d", *filter.BornBefore)
+	}
+	if filter.RegisteredAfter != nil {
+		add("u.registration_date >= $%d", *filter.RegisteredAfter)
+	}
+	if filter.RegisteredBefore != nil {
+		add("u.registration_date < $%d", *filter.RegisteredBefore)
+	}
+	if filter.HasEmail != nil {
+		add("(u.email <> '') = $%d", *filter.HasEmail)
+	}
+	if filter.HasPhoto != nil {
+		add("(u.profile_photo_url <> '') = $%d", *filter.HasPhoto)
+	}
+	if len(filter.Attributes) > 0 {
+		attributes, err := domain.UserAttributes(filter.Attributes).Value()
+		if err != nil {
+			return nil, nil, err
+		}
+		add("u.attributes @> $%d::jsonb", attributes)
+	}
+
+	return conditions, args, nil
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// userSortColumns maps the fields in domain.UserSortFields to their columns.
+var userSortColumns = map[string]string{
+	"id":                "u.id",
+	"first_name":        "u.first_name",
+	"last_name":         "u.last_name",
+	"registration_date": "u.registration_date",
+	"date_of_birth":     "u.date_of_birth",
+	"location":          "u.location",
+	"gender":            "u.gender",
+	"blocked":           "u.blocked",
+}
+
+// sortTerm is a column of an ORDER BY clause.
+type sortTerm struct {
+	column     string
+	descending bool
+}
+
+// sortTerms returns the columns to order by for sort. Ties are broken by
+// idColumn, in the direction of the last field so that an index on the sort
+// columns followed by the ID serves the query, which keeps pages stable.
+// Fields missing from columns are rejected.
+func sortTerms(sort domain.Sort, columns map[string]string, idColumn string) ([]sortTerm, error) {
+	terms := make([]sortTerm, 0, len(sort)+1)
+	descending := false
+	for _, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not sortable", errors.ErrInvalidSort, field.Field)
+		}
+
+		terms = append(terms, sortTerm{column: column, descending: field.Descending})
+		descending = field.Descending
+
+		if column == idColumn {
+			return terms, nil
+		}
+	}
+
+	return append(terms, sortTerm{column: idColumn, descending: descending}), nil
+}
+
+func orderByTerms(terms []sortTerm) string {
+	columns := make([]string, len(terms))
+	for i, term := range terms {
+		columns[i] = term.column
+		if term.descending {
+			columns[i] += " DESC"
+		}
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
 }
 
-func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
-	return &PostgresUserRepository{DB: db}
+// orderByClause returns the ORDER BY clause for sort, see sortTerms.
+func orderByClause(sort domain.Sort, columns map[string]string, idColumn string) (string, error) {
+	terms, err := sortTerms(sort, columns, idColumn)
+	if err != nil {
+		return "", err
+	}
+	return orderByTerms(terms), nil
 }
 
-func (r *PostgresUserRepository) GetAllUsers(page, pageSize int) (*domain.UsersList, error) {
+func (r *PostgresUserRepository) GetAllUsers(page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
 	offset := (page - 1) * pageSize
 
+	conditions, args, err := userFilterConditions(filter, []interface{}{pageSize, offset})
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := orderByClause(sort, userSortColumns, "u.id")
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-        SELECT id, first_name, last_name, phone_number, blocked, registration_date, gender, date_of_birth, location, email, profile_photo_url
-        FROM users
-        ORDER BY id
+        SELECT ` + userColumns + `
+        FROM users u
+        ` + userCurrentBlockJoin + `
+        ` + whereClause(conditions) + `
+        ` + orderBy + `
         LIMIT $1 OFFSET $2
     `
 	stmt, err := r.DB.Prepare(query)
@@ -36,7 +200,7 @@ func (r *PostgresUserRepository) GetAllUsers(page, pageSize int) (*domain.UsersL
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(context.TODO(), pageSize, offset)
+	rows, err := stmt.QueryContext(context.TODO(), args...)
 	if err != nil {
 		slog.Error("Error executing query: %v", utils.Err(err))
 		return nil, err
@@ -46,20 +210,8 @@ func (r *PostgresUserRepository) GetAllUsers(page, pageSize int) (*domain.UsersL
 	var usersList domain.UsersList
 
 	for rows.Next() {
-		var user domain.GetUserResponse
-		if err := rows.Scan(
-			&user.ID,
-			&user.FirstName,
-			&user.LastName,
-			&user.PhoneNumber,
-			&user.Blocked,
-			&user.RegistrationDate,
-			&user.Gender,
-			&user.DateOfBirth,
-			&user.Location,
-			&user.Email,
-			&user.ProfilePhotoURL,
-		); err != nil {
+		user, err := scanUser(rows, r.Cipher)
+		if err != nil {
 			slog.Error("Error scanning user row: %v", utils.Err(err))
 			return nil, err
 		}
@@ -75,9 +227,145 @@ func (r *PostgresUserRepository) GetAllUsers(page, pageSize int) (*domain.UsersL
 	return &usersList, nil
 }
 
-func (r *PostgresUserRepository) GetTotalUsersCount() (int, error) {
+// GetUsersByKeyset returns up to keyset.Limit users matching filter, in the
+// order of keyset.Sort, that follow or precede keyset.Key, and reports
+// whether there are more users beyond them in the direction of the page.
+func (r *PostgresUserRepository) GetUsersByKeyset(filter *domain.UserFilter, keyset *domain.UsersKeyset) (*domain.UsersList, bool, error) {
+	terms, err := sortTerms(keyset.Sort, userSortColumns, "u.id")
+	if err != nil {
+		return nil, false, err
+	}
+	if keyset.Backward {
+		// Walk back from the key and restore the order afterwards.
+		for i := range terms {
+			terms[i].descending = !terms[i].descending
+		}
+	}
+
+	conditions, args, err := userFilterConditions(filter, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if keyset.Key != nil {
+		if len(keyset.Key) != len(terms) {
+			return nil, false, errors.ErrInvalidCursor
+		}
+
+		var condition string
+		condition, args = keysetCondition(terms, keyset.Key, args)
+		conditions = append(conditions, condition)
+	}
+
+	args = append(args, keyset.Limit+1)
+
+	rows, err := r.DB.Query(`
+		SELECT `+userColumns+`
+		FROM users u
+		`+userCurrentBlockJoin+`
+		`+whereClause(conditions)+`
+		`+orderByTerms(terms)+`
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		slog.Error("error getting users by keyset: %v", utils.Err(err))
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	users := make([]domain.GetUserResponse, 0, keyset.Limit+1)
+	for rows.Next() {
+		user, err := scanUser(rows, r.Cipher)
+		if err != nil {
+			slog.Error("error scanning user row: %v", utils.Err(err))
+			return nil, false, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over user rows: %v", utils.Err(err))
+		return nil, false, err
+	}
+
+	more := len(users) > keyset.Limit
+	if more {
+		users = users[:keyset.Limit]
+	}
+	if keyset.Backward {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	return &domain.UsersList{Users: users}, more, nil
+}
+
+// keysetCondition returns the condition selecting the rows ordered after key
+// by terms, with placeholders numbered after the len(args) arguments already
+// in use. Key values are passed as text and converted by PostgreSQL to the
+// types of their columns.
+func keysetCondition(terms []sortTerm, key []string, args []interface{}) (string, []interface{}) {
+	placeholders := make([]string, len(key))
+	for i, value := range key {
+		args = append(args, value)
+		placeholders[i] = "$" + strconv.Itoa(len(args))
+	}
+
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		comparisons := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			comparisons = append(comparisons, terms[j].column+" = "+placeholders[j])
+		}
+
+		operator := " > "
+		if term.descending {
+			operator = " < "
+		}
+		comparisons = append(comparisons, term.column+operator+placeholders[i])
+
+		alternatives[i] = "(" + strings.Join(comparisons, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// GetEstimatedUsersCount returns the planner's estimate of the number of rows
+// in the users table, or -1 if the table has not been analyzed yet.
+func (r *PostgresUserRepository) GetEstimatedUsersCount() (int, error) {
+	var estimate int
+	err := r.DB.QueryRow("SELECT reltuples::bigint FROM pg_class WHERE oid = 'users'::regclass").Scan(&estimate)
+	if err != nil {
+		slog.Error("error getting estimated users count", utils.Err(err))
+		return 0, err
+	}
+
+	return estimate, nil
+}
+
+func (r *PostgresUserRepository) GetTotalUsersCount(filter *domain.UserFilter) (int, error) {
+	conditions, args, err := userFilterConditions(filter, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.countUsers(conditions, args)
+}
+
+// GetSearchUsersCount returns the number of users SearchUsers pages through
+// for query and filter.
+func (r *PostgresUserRepository) GetSearchUsersCount(query string, filter *domain.UserFilter) (int, error) {
+	conditions, args, err := userSelectionConditions(query, filter, r.Cipher)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.countUsers(conditions, args)
+}
+
+func (r *PostgresUserRepository) countUsers(conditions []string, args []interface{}) (int, error) {
 	var totalUsers int
-	err := r.DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&totalUsers)
+	err := r.DB.QueryRow(strings.TrimSpace("SELECT COUNT(*) FROM users u "+whereClause(conditions)), args...).Scan(&totalUsers)
 	if err != nil {
 		slog.Error("error getting total users count", utils.Err(err))
 		return 0, err
@@ -88,9 +376,10 @@ func (r *PostgresUserRepository) GetTotalUsersCount() (int, error) {
 
 func (r *PostgresUserRepository) GetUserByID(id int32) (*domain.GetUserResponse, error) {
 	stmt, err := r.DB.Prepare(`
-		SELECT id, first_name, last_name, phone_number, blocked, registration_date, gender, date_of_birth, location, email, profile_photo_url
-		FROM users 
-		WHERE id = $1
+		SELECT ` + userColumns + `
+		FROM users u
+		` + userCurrentBlockJoin + `
+		WHERE u.id = $1 AND ` + activeUserCondition + `
 	`)
 
 	if err != nil {
@@ -101,21 +390,7 @@ func (r *PostgresUserRepository) GetUserByID(id int32) (*domain.GetUserResponse,
 
 	row := stmt.QueryRowContext(context.TODO(), id)
 
-	var user domain.GetUserResponse
-
-	err = row.Scan(
-		&user.ID,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Blocked,
-		&user.RegistrationDate,
-		&user.Gender,
-		&user.DateOfBirth,
-		&user.Location,
-		&user.Email,
-		&user.ProfilePhotoURL,
-	)
+	user, err := scanUser(row, r.Cipher)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.ErrUserNotFound
@@ -128,14 +403,35 @@ func (r *PostgresUserRepository) GetUserByID(id int32) (*domain.GetUserResponse,
 }
 
 func (r *PostgresUserRepository) CreateUser(request *domain.CreateUserRequest) (*domain.CreateUserResponse, error) {
-	if !utils.IsValidPhoneNumber(request.PhoneNumber) {
-		return nil, errors.ErrInvalidPhoneNumber
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	stmt, err := r.DB.Prepare(`
-		INSERT INTO users (first_name, last_name, phone_number,	gender, date_of_birth, location, email, profile_photo_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, first_name, last_name, phone_number, blocked,	registration_date, gender, date_of_birth, location,	email, profile_photo_url
+	if err := setChangeActor(tx, request.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	phoneNumber, phoneNumberIndex, err := encryptContact(r.Cipher, phoneNumberIndexField, request.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	email, emailIndex, err := encryptContact(r.Cipher, emailIndexField, request.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare(`
+		WITH u AS (
+			INSERT INTO users (first_name, last_name, phone_number,	gender, date_of_birth, location, email, profile_photo_url, attributes, first_name_key, last_name_key, phone_number_index, email_index)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING *
+		)
+		SELECT ` + userColumns + `
+		FROM u
+		` + userCurrentBlockJoin + `
 	`)
 	if err != nil {
 		slog.Error("error preparing query: %v", utils.Err(err))
@@ -143,30 +439,23 @@ func (r *PostgresUserRepository) CreateUser(request *domain.CreateUserRequest) (
 	}
 	defer stmt.Close()
 
-	var user domain.CreateUserResponse
-
-	err = stmt.QueryRow(
+	row := stmt.QueryRow(
 		request.FirstName,
 		request.LastName,
-		request.PhoneNumber,
+		phoneNumber,
 		request.Gender,
 		request.DateOfBirth,
 		request.Location,
-		request.Email,
+		email,
 		request.ProfilePhotoURL,
-	).Scan(
-		&user.ID,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Blocked,
-		&user.RegistrationDate,
-		&user.Gender,
-		&user.DateOfBirth,
-		&user.Location,
-		&user.Email,
-		&user.ProfilePhotoURL,
+		request.Attributes,
+		translit.Key(request.FirstName),
+		translit.Key(request.LastName),
+		phoneNumberIndex,
+		emailIndex,
 	)
+
+	createdUser, err := scanUser(row, r.Cipher)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" {
@@ -181,51 +470,80 @@ func (r *PostgresUserRepository) CreateUser(request *domain.CreateUserRequest) (
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	user := domain.CreateUserResponse(createdUser)
+
 	return &user, nil
 }
 
 func (r PostgresUserRepository) UpdateUser(id int32, request *domain.UpdateUserRequest) (*domain.UpdateUserResponse, error) {
-	updateQuery := `UPDATE users SET
-                    first_name = $1,
-                    last_name = $2,
-                    gender = $3,
-                    date_of_birth = $4,
-                    location = $5,
-                    email = $6,
-                    profile_photo_url = $7
-                    WHERE id = $8
-                    RETURNING id, first_name, last_name, phone_number, blocked, gender, registration_date, date_of_birth, location, email, profile_photo_url`
-
-	stmt, err := r.DB.Prepare(updateQuery)
+	updateQuery := `WITH u AS (
+                        UPDATE users SET
+                        first_name = $1,
+                        last_name = $2,
+                        gender = $3,
+                        date_of_birth = $4,
+                        location = $5,
+                        email = CASE WHEN email_index IS NOT DISTINCT FROM $13 THEN email ELSE $6 END,
+                        email_index = $13,
+                        email_verified = (email_verified AND email_index IS NOT DISTINCT FROM $13),
+                        email_verified_at = CASE WHEN email_index IS NOT DISTINCT FROM $13 THEN email_verified_at END,
+                        profile_photo_url = $7,
+                        attributes = $8,
+                        first_name_key = $11,
+                        last_name_key = $12,
+                        version = version + 1
+                        WHERE id = $9 AND deleted_at IS NULL AND anonymized_at IS NULL AND ($10::integer IS NULL OR version = $10)
+                        RETURNING *
+                    )
+                    SELECT ` + userColumns + `
+                    FROM u
+                    ` + userCurrentBlockJoin
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, request.UpdatedBy); err != nil {
+		return nil, err
+	}
+
+	email, emailIndex, err := encryptContact(r.Cipher, emailIndexField, request.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare(updateQuery)
 	if err != nil {
 		slog.Error("error preparing query: %v", utils.Err(err))
 		return nil, err
 	}
 	defer stmt.Close()
 
-	var user domain.UpdateUserResponse
-	err = stmt.QueryRow(
+	row := stmt.QueryRow(
 		request.FirstName,
 		request.LastName,
 		request.Gender,
 		request.DateOfBirth,
 		request.Location,
-		request.Email,
+		email,
 		request.ProfilePhotoURL,
+		request.Attributes,
 		id,
-	).Scan(
-		&user.ID,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Blocked,
-		&user.Gender,
-		&user.RegistrationDate,
-		&user.DateOfBirth,
-		&user.Location,
-		&user.Email,
-		&user.ProfilePhotoURL,
+		request.ExpectedVersion,
+		translit.Key(request.FirstName),
+		translit.Key(request.LastName),
+		emailIndex,
 	)
+
+	updatedUser, err := scanUser(row, r.Cipher)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" {
@@ -235,18 +553,148 @@ func (r PostgresUserRepository) UpdateUser(id int32, request *domain.UpdateUserR
 			}
 		}
 		if err == sql.ErrNoRows {
-			return nil, errors.ErrUserNotFound
+			return nil, r.updateConflict(id)
+		}
+		slog.Error("error executing query: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	user := domain.UpdateUserResponse(updatedUser)
+
+	return &user, nil
+}
+
+// patchAssignments returns the SET assignments for the non-nil fields of
+// request, with placeholders numbered from $1.
+func patchAssignments(request *domain.PatchUserRequest, cipher *encryption.Cipher) ([]string, []interface{}, error) {
+	var setClauses []string
+	var args []interface{}
+
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if request.FirstName != nil {
+		set("first_name", *request.FirstName)
+		set("first_name_key", translit.Key(*request.FirstName))
+	}
+	if request.LastName != nil {
+		set("last_name", *request.LastName)
+		set("last_name_key", translit.Key(*request.LastName))
+	}
+	if request.Gender != nil {
+		set("gender", *request.Gender)
+	}
+	if request.DateOfBirth != nil {
+		set("date_of_birth", *request.DateOfBirth)
+	}
+	if request.Location != nil {
+		set("location", *request.Location)
+	}
+	if request.Email != nil {
+		email, emailIndex, err := encryptContact(cipher, emailIndexField, *request.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Addresses are compared by their blind index, as no two encryptions
+		// are alike. An unchanged address keeps its ciphertext; a new one has
+		// to be verified again.
+		args = append(args, email, emailIndex)
+		setClauses = append(setClauses,
+			fmt.Sprintf("email = CASE WHEN email_index IS NOT DISTINCT FROM $%d THEN email ELSE $%d END", len(args), len(args)-1),
+			fmt.Sprintf("email_index = $%d", len(args)),
+			fmt.Sprintf("email_verified = (email_verified AND email_index IS NOT DISTINCT FROM $%d)", len(args)),
+			fmt.Sprintf("email_verified_at = CASE WHEN email_index IS NOT DISTINCT FROM $%d THEN email_verified_at END", len(args)),
+		)
+	}
+	if request.ProfilePhotoURL != nil {
+		set("profile_photo_url", *request.ProfilePhotoURL)
+	}
+	if request.Attributes != nil {
+		set("attributes", request.Attributes)
+	}
+
+	return setClauses, args, nil
+}
+
+// PatchUser updates only the non-nil fields of request. When nothing changed
+// the current user is returned without issuing an UPDATE.
+func (r *PostgresUserRepository) PatchUser(id int32, request *domain.PatchUserRequest) (*domain.UpdateUserResponse, error) {
+	setClauses, args, err := patchAssignments(request, r.Cipher)
+	if err != nil {
+		return nil, err
+	}
+	if len(setClauses) == 0 {
+		user, err := r.GetUserByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if request.ExpectedVersion != nil && *request.ExpectedVersion != user.Version {
+			return nil, errors.ErrPreconditionFailed
+		}
+		unchanged := domain.UpdateUserResponse(*user)
+		return &unchanged, nil
+	}
+
+	setClauses = append(setClauses, "version = version + 1")
+	args = append(args, id, request.ExpectedVersion)
+	patchQuery := fmt.Sprintf(`WITH u AS (
+                        UPDATE users SET %s
+                        WHERE id = $%d AND deleted_at IS NULL AND anonymized_at IS NULL AND ($%d::integer IS NULL OR version = $%d)
+                        RETURNING *
+                    )
+                    SELECT `+userColumns+`
+                    FROM u
+                    `+userCurrentBlockJoin, strings.Join(setClauses, ", "), len(args)-1, len(args), len(args))
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, request.UpdatedBy); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(context.TODO(), patchQuery, args...)
+
+	patchedUser, err := scanUser(row, r.Cipher)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				if strings.Contains(pqErr.Error(), "email") {
+					return nil, errors.ErrEmailInUse
+				}
+			}
+		}
+		if err == sql.ErrNoRows {
+			return nil, r.updateConflict(id)
 		}
 		slog.Error("error executing query: %v", utils.Err(err))
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	user := domain.UpdateUserResponse(patchedUser)
+
 	return &user, nil
 }
 
-func (r PostgresUserRepository) DeleteUser(id int32) error {
+func (r PostgresUserRepository) DeleteUser(id int32, expectedVersion *int32) error {
 	var exists bool
-	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
 	if err != nil {
 		slog.Error("error checking user existence: %v", utils.Err(err))
 		return err
@@ -256,25 +704,37 @@ func (r PostgresUserRepository) DeleteUser(id int32) error {
 		return errors.ErrUserNotFound
 	}
 
-	stmt, err := r.DB.Prepare(`DELETE FROM users WHERE id = $1`)
+	stmt, err := r.DB.Prepare(`DELETE FROM users WHERE id = $1 AND ($2::integer IS NULL OR version = $2)`)
 	if err != nil {
-		slog.Error("error preparing query: %v", err)
+		slog.Error("error preparing query: %v", utils.Err(err))
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
+	result, err := stmt.Exec(id, expectedVersion)
 	if err != nil {
 		slog.Error("error executing query: %v", utils.Err(err))
 		return err
 	}
 
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return r.writeConflict(id)
+	}
+
 	return nil
 }
 
-func (r *PostgresUserRepository) BlockUser(id int32) error {
+// writeConflict reports why a conditional write on a user matched no rows:
+// either the user does not exist or its version no longer matches If-Match.
+func (r *PostgresUserRepository) writeConflict(id int32) error {
 	var exists bool
-	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
 	if err != nil {
 		slog.Error("error checking user existence: %v", utils.Err(err))
 		return err
@@ -284,25 +744,33 @@ func (r *PostgresUserRepository) BlockUser(id int32) error {
 		return errors.ErrUserNotFound
 	}
 
-	stmt, err := r.DB.Prepare("UPDATE users SET blocked = true WHERE id = $1")
+	return errors.ErrPreconditionFailed
+}
+
+// updateConflict reports why an update of a user matched no rows: the user
+// does not exist, was anonymized or changed since version expected. Erased
+// personal data must not be written back.
+func (r *PostgresUserRepository) updateConflict(id int32) error {
+	var anonymized bool
+	err := r.DB.QueryRow(`SELECT anonymized_at IS NOT NULL FROM users WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&anonymized)
+	if err == sql.ErrNoRows {
+		return errors.ErrUserNotFound
+	}
 	if err != nil {
-		slog.Error("error preparing query: %v", utils.Err(err))
+		slog.Error("error checking user existence: %v", utils.Err(err))
 		return err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
-	if err != nil {
-		slog.Error("error executing query: %v", utils.Err(err))
-		return err
+	if anonymized {
+		return errors.ErrUserAnonymized
 	}
 
-	return nil
+	return errors.ErrPreconditionFailed
 }
 
-func (r *PostgresUserRepository) UnblockUser(id int32) error {
+func (r *PostgresUserRepository) BlockUser(id int32, request *domain.BlockUserRequest) error {
 	var exists bool
-	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
 	if err != nil {
 		slog.Error("error checking user existence: %v", utils.Err(err))
 		return err
@@ -312,53 +780,450 @@ func (r *PostgresUserRepository) UnblockUser(id int32) error {
 		return errors.ErrUserNotFound
 	}
 
-	stmt, err := r.DB.Prepare("UPDATE users SET blocked = false WHERE id = $1")
+	tx, err := r.DB.Begin()
 	if err != nil {
-		slog.Error("error preparing query: %v", utils.Err(err))
+		slog.Error("error starting transaction: %v", utils.Err(err))
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(id)
-	if err != nil {
-		slog.Error("error executing query: %v", utils.Err(err))
+	if err := setChangeActor(tx, request.BlockedBy); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (r *PostgresUserRepository) SearchUsers(query string, page, pageSize int) (*domain.UsersList, error) {
-	offset := (page - 1) * pageSize
-
-	searchQuery := `
-        SELECT id, first_name, last_name, phone_number, blocked,
-        registration_date, gender, date_of_birth, location,
-        email, profile_photo_url
-        FROM users
-        WHERE first_name ILIKE $1 OR last_name ILIKE $1 OR phone_number ILIKE $1 OR email ILIKE $1
-        ORDER BY id
-        LIMIT $2 OFFSET $3
-    `
+	// A new block supersedes the currently active one, if any.
+	_, err = tx.Exec(`
+		UPDATE user_blocks
+		SET unblocked_at = NOW(), unblocked_by = $2
+		WHERE user_id = $1 AND unblocked_at IS NULL
+	`, id, request.BlockedBy)
+	if err != nil {
+		slog.Error("error closing active block: %v", utils.Err(err))
+		return err
+	}
 
-	stmt, err := r.DB.Prepare(searchQuery)
+	_, err = tx.Exec(`
+		INSERT INTO user_blocks (user_id, reason, note, blocked_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, request.Reason, request.Note, request.BlockedBy, request.ExpiresAt)
 	if err != nil {
-		slog.Error("Error preparing search query: %v", utils.Err(err))
-		return nil, err
+		slog.Error("error inserting block record: %v", utils.Err(err))
+		return err
 	}
-	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(context.TODO(), "%"+query+"%", pageSize, offset)
+	_, err = tx.Exec("UPDATE users SET blocked = true, version = version + 1 WHERE id = $1", id)
 	if err != nil {
-		slog.Error("Error executing search query: %v", utils.Err(err))
-		return nil, err
+		slog.Error("error executing query: %v", utils.Err(err))
+		return err
 	}
-	defer rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresUserRepository) UnblockUser(id, unblockedBy int32) error {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		slog.Error("error checking user existence: %v", utils.Err(err))
+		return err
+	}
+
+	if !exists {
+		return errors.ErrUserNotFound
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, unblockedBy); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_blocks
+		SET unblocked_at = NOW(), unblocked_by = $2
+		WHERE user_id = $1 AND unblocked_at IS NULL
+	`, id, unblockedBy)
+	if err != nil {
+		slog.Error("error closing active block: %v", utils.Err(err))
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET blocked = false, version = version + 1 WHERE id = $1", id)
+	if err != nil {
+		slog.Error("error executing query: %v", utils.Err(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresUserRepository) GetUserBlocks(id int32) (*domain.UserBlocksList, error) {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		slog.Error("error checking user existence: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.ErrUserNotFound
+	}
+
+	rows, err := r.DB.QueryContext(context.TODO(), `
+		SELECT id, user_id, reason, note, blocked_by, blocked_at, expires_at, unblocked_by, unblocked_at
+		FROM user_blocks
+		WHERE user_id = $1
+		ORDER BY blocked_at DESC, id DESC
+	`, id)
+	if err != nil {
+		slog.Error("error executing query: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocksList := domain.UserBlocksList{Blocks: make([]domain.UserBlock, 0)}
+	for rows.Next() {
+		var block domain.UserBlock
+		if err := rows.Scan(
+			&block.ID,
+			&block.UserID,
+			&block.Reason,
+			&block.Note,
+			&block.BlockedBy,
+			&block.BlockedAt,
+			&block.ExpiresAt,
+			&block.UnblockedBy,
+			&block.UnblockedAt,
+		); err != nil {
+			slog.Error("error scanning block row: %v", utils.Err(err))
+			return nil, err
+		}
+		blocksList.Blocks = append(blocksList.Blocks, block)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over block rows: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &blocksList, nil
+}
+
+// UnblockExpiredUsers closes every active block whose expiry has passed and
+// unblocks the affected users. It returns the number of users unblocked.
+func (r *PostgresUserRepository) UnblockExpiredUsers() (int64, error) {
+	result, err := r.DB.Exec(`
+		WITH expired AS (
+			UPDATE user_blocks
+			SET unblocked_at = NOW()
+			WHERE unblocked_at IS NULL AND expires_at IS NOT NULL AND expires_at <= NOW()
+			RETURNING user_id
+		)
+		UPDATE users SET blocked = false, version = version + 1
+		WHERE id IN (SELECT user_id FROM expired)
+	`)
+	if err != nil {
+		slog.Error("error unblocking expired users: %v", utils.Err(err))
+		return 0, err
+	}
+
+	unblocked, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return 0, err
+	}
+
+	return unblocked, nil
+}
+
+// BackfillNameKeys computes the name search keys of up to batchSize users
+// that have none yet, such as users created before the keys were introduced.
+// It returns the number of users updated, 0 once all users have keys.
+func (r *PostgresUserRepository) BackfillNameKeys(batchSize int) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, first_name, last_name FROM users
+		WHERE first_name_key IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, batchSize)
+	if err != nil {
+		slog.Error("error querying users without name keys: %v", utils.Err(err))
+		return 0, err
+	}
+
+	type userName struct {
+		id                  int32
+		firstName, lastName string
+	}
+	var names []userName
+	for rows.Next() {
+		var name userName
+		if err := rows.Scan(&name.id, &name.firstName, &name.lastName); err != nil {
+			rows.Close()
+			slog.Error("error scanning user name: %v", utils.Err(err))
+			return 0, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating user names: %v", utils.Err(err))
+		return 0, err
+	}
+
+	for _, name := range names {
+		// The keys are not tracked fields, so no history entry is written.
+		_, err := tx.Exec(`UPDATE users SET first_name_key = $2, last_name_key = $3 WHERE id = $1`,
+			name.id, translit.Key(name.firstName), translit.Key(name.lastName))
+		if err != nil {
+			slog.Error("error setting name keys: %v", utils.Err(err))
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return 0, err
+	}
+
+	return len(names), nil
+}
+
+// BackfillContactIndexes encrypts the phone numbers and email addresses of up
+// to batchSize users that lack their blind indexes, such as users created
+// before encryption was introduced, and computes the indexes. It returns the
+// number of users updated, 0 once all users have indexes. A user whose phone
+// number or email address is taken by another user fails the batch.
+func (r *PostgresUserRepository) BackfillContactIndexes(batchSize int) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Encrypting changes the stored text but not the values, so no history
+	// entry is written.
+	if _, err := tx.Exec(`SELECT set_config('admin_panel.skip_history', 'on', true)`); err != nil {
+		slog.Error("error disabling user history: %v", utils.Err(err))
+		return 0, err
+	}
+
+	// Anonymized users have no indexes on purpose.
+	rows, err := tx.Query(`
+		SELECT id, phone_number, COALESCE(email, '') FROM users
+		WHERE anonymized_at IS NULL
+			AND ((phone_number_index IS NULL AND phone_number <> '') OR (email_index IS NULL AND COALESCE(email, '') <> ''))
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE
+	`, batchSize)
+	if err != nil {
+		slog.Error("error querying users without contact indexes: %v", utils.Err(err))
+		return 0, err
+	}
+
+	type unindexedUser struct {
+		id                 int32
+		phoneNumber, email string
+	}
+	var users []unindexedUser
+	err = scanRows(rows, func() error {
+		var user unindexedUser
+		if err := rows.Scan(&user.id, &user.phoneNumber, &user.email); err != nil {
+			return err
+		}
+		if err := decryptValues(r.Cipher, &user.phoneNumber, &user.email); err != nil {
+			return err
+		}
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		slog.Error("error reading users without contact indexes: %v", utils.Err(err))
+		return 0, err
+	}
+
+	for _, user := range users {
+		phoneNumber, phoneNumberIndex, err := encryptContact(r.Cipher, phoneNumberIndexField, user.phoneNumber)
+		if err != nil {
+			return 0, err
+		}
+		email, emailIndex, err := encryptContact(r.Cipher, emailIndexField, user.email)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE users
+			SET phone_number = $2, phone_number_index = $3, email = $4, email_index = $5
+			WHERE id = $1
+		`, user.id, phoneNumber, phoneNumberIndex, email, emailIndex)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				if strings.Contains(pqErr.Error(), "phone_number") {
+					return 0, fmt.Errorf("user %d: %w", user.id, errors.ErrPhoneNumberInUse)
+				} else if strings.Contains(pqErr.Error(), "email") {
+					return 0, fmt.Errorf("user %d: %w", user.id, errors.ErrEmailInUse)
+				}
+			}
+			slog.Error("error setting contact indexes: %v", utils.Err(err))
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return 0, err
+	}
+
+	return len(users), nil
+}
+
+// userSearchConditions returns the conditions matching users against the
+// search query, with placeholders numbered after the len(args) arguments
+// already in use. Every term must match a word of the user's name or
+// location by prefix, through the full-text index, or by trigram similarity,
+// which catches typos. A term also matches through its transliteration key,
+// so that names match whether written in Latin or Cyrillic. Phone numbers and
+// email addresses are encrypted: a query that is one matches it exactly,
+// through its blind index, and nothing else.
+func userSearchConditions(query string, cipher *encryption.Cipher, args []interface{}) ([]string, []interface{}) {
+	parsed := search.Parse(query)
+	if parsed.Empty() {
+		return []string{"FALSE"}, args
+	}
+
+	if parsed.Phone != "" {
+		args = append(args, blindIndex(cipher, phoneNumberIndexField, "+"+parsed.Phone))
+		return []string{fmt.Sprintf("u.phone_number_index = $%d", len(args))}, args
+	}
+	if parsed.Email != "" {
+		args = append(args, blindIndex(cipher, emailIndexField, parsed.Email))
+		return []string{fmt.Sprintf("u.email_index = $%d", len(args))}, args
+	}
+
+	conditions := make([]string, 0, len(parsed.Terms))
+	for _, term := range parsed.Terms {
+		var alternatives []string
+		for _, variant := range termVariants(term) {
+			args = append(args, variant)
+			n := len(args)
+
+			alternatives = append(alternatives, fmt.Sprintf("u.search_vector @@ to_tsquery('simple', $%d || ':*') OR $%d <%% u.search_text", n, n))
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	return conditions, args
+}
+
+// termVariants returns term followed by its transliteration key, unless
+// that is the term itself.
+func termVariants(term string) []string {
+	if key := translit.Key(term); key != "" && key != term {
+		return []string{term, key}
+	}
+	return []string{term}
+}
+
+// userSearchRank returns the expression ranking the users matching query by
+// relevance, highest first, with placeholders numbered after the len(args)
+// arguments already in use. Phone number and email address queries match
+// exactly and rank all users alike.
+func userSearchRank(query string, args []interface{}) (string, []interface{}) {
+	parsed := search.Parse(query)
+	if len(parsed.Terms) == 0 {
+		return "0", args
+	}
+
+	var prefixes, keys []string
+	for _, term := range parsed.Terms {
+		variants := termVariants(term)
+		for _, variant := range variants {
+			prefixes = append(prefixes, variant+":*")
+		}
+		keys = append(keys, variants[len(variants)-1])
+	}
+	args = append(args, strings.Join(prefixes, " | "), strings.Join(keys, " "))
+
+	return fmt.Sprintf("ts_rank(u.search_vector, to_tsquery('simple', $%d)) + word_similarity($%d, u.search_text)", len(args)-1, len(args)), args
+}
+
+// SearchUsers returns a page of the users matching query and filter, most
+// relevant first unless sort is given.
+func (r *PostgresUserRepository) SearchUsers(query string, page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	offset := (page - 1) * pageSize
+
+	conditions, args, err := userSelectionConditions(query, filter, r.Cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderBy string
+	if len(sort) > 0 {
+		if orderBy, err = orderByClause(sort, userSortColumns, "u.id"); err != nil {
+			return nil, err
+		}
+	} else {
+		var rank string
+		rank, args = userSearchRank(query, args)
+		orderBy = "ORDER BY " + rank + " DESC, u.id"
+	}
+
+	args = append(args, pageSize, offset)
+
+	searchQuery := `
+        SELECT ` + userColumns + `
+        FROM users u
+        ` + userCurrentBlockJoin + `
+        ` + whereClause(conditions) + `
+        ` + orderBy + `
+        LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `
+    `
+
+	stmt, err := r.DB.Prepare(searchQuery)
+	if err != nil {
+		slog.Error("Error preparing search query: %v", utils.Err(err))
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(context.TODO(), args...)
+	if err != nil {
+		slog.Error("Error executing search query: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
 
 	userList := domain.UsersList{Users: make([]domain.GetUserResponse, 0)}
 	for rows.Next() {
-		user, err := utils.ScanUserRow(rows)
+		user, err := scanUser(rows, r.Cipher)
 		if err != nil {
+			slog.Error("Error scanning user row: %v", utils.Err(err))
 			return nil, err
 		}
 		userList.Users = append(userList.Users, user)
@@ -371,3 +1236,767 @@ func (r *PostgresUserRepository) SearchUsers(query string, page, pageSize int) (
 
 	return &userList, nil
 }
+
+// userSelectionConditions returns the conditions selecting the users that
+// match the search query, if not empty, and filter.
+func userSelectionConditions(query string, filter *domain.UserFilter, cipher *encryption.Cipher) ([]string, []interface{}, error) {
+	var searchConditions []string
+	var args []interface{}
+	if query != "" {
+		searchConditions, args = userSearchConditions(query, cipher, args)
+	}
+
+	conditions, args, err := userFilterConditions(filter, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(searchConditions, conditions...), args, nil
+}
+
+// GetUserIDs returns the IDs of at most limit users matching query and
+// filter, in ID order.
+func (r *PostgresUserRepository) GetUserIDs(query string, filter *domain.UserFilter, limit int) ([]int32, error) {
+	conditions, args, err := userSelectionConditions(query, filter, r.Cipher)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, limit)
+
+	rows, err := r.DB.Query(`
+		SELECT u.id
+		FROM users u
+		`+whereClause(conditions)+`
+		ORDER BY u.id
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		slog.Error("error getting user ids: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int32, 0)
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("error scanning user id: %v", utils.Err(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over user ids: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// BulkUserAction applies batch.Action to all users of batch in a single
+// transaction and returns the IDs of the users it affected. IDs without a
+// user are skipped, unless batch.Atomic is set, in which case nothing is
+// written and errors.ErrUserNotFound is returned with the affected IDs.
+func (r *PostgresUserRepository) BulkUserAction(batch *domain.BulkBatch) ([]int32, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, batch.ActorID); err != nil {
+		return nil, err
+	}
+
+	ids := pq.Array(batch.IDs)
+
+	var query string
+	switch batch.Action {
+	case domain.BulkActionBlock, domain.BulkActionUnblock:
+		// A new block supersedes the currently active one, if any.
+		_, err = tx.Exec(`
+			UPDATE user_blocks
+			SET unblocked_at = NOW(), unblocked_by = $2
+			WHERE user_id = ANY($1) AND unblocked_at IS NULL
+		`, ids, batch.ActorID)
+		if err != nil {
+			slog.Error("error closing active blocks: %v", utils.Err(err))
+			return nil, err
+		}
+
+		blocked := batch.Action == domain.BulkActionBlock
+		if blocked {
+			_, err = tx.Exec(`
+				INSERT INTO user_blocks (user_id, reason, note, blocked_by, expires_at)
+				SELECT id, $2, $3, $4, $5 FROM users WHERE id = ANY($1) AND deleted_at IS NULL
+			`, ids, batch.Block.Reason, batch.Block.Note, batch.ActorID, batch.Block.ExpiresAt)
+			if err != nil {
+				slog.Error("error inserting block records: %v", utils.Err(err))
+				return nil, err
+			}
+		}
+
+		query = `UPDATE users SET blocked = ` + strconv.FormatBool(blocked) + `, version = version + 1 WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id`
+	case domain.BulkActionDelete:
+		query = `DELETE FROM users WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id`
+	default:
+		return nil, errors.ErrInvalidBulkAction
+	}
+
+	rows, err := tx.Query(query, ids)
+	if err != nil {
+		slog.Error("error executing bulk action: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	affected := make([]int32, 0, len(batch.IDs))
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("error scanning user id: %v", utils.Err(err))
+			return nil, err
+		}
+		affected = append(affected, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over user ids: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if batch.Atomic && len(affected) < len(batch.IDs) {
+		return affected, errors.ErrUserNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return affected, nil
+}
+
+// UseBulkConfirmation records the bulk confirmation token with tokenHash as
+// used and reports whether it was unused. Expired tokens are purged first,
+// as they are rejected before they get here.
+func (r *PostgresUserRepository) UseBulkConfirmation(tokenHash string, expiresAt time.Time) (bool, error) {
+	if _, err := r.DB.Exec(`DELETE FROM bulk_confirmations WHERE expires_at < NOW()`); err != nil {
+		slog.Error("error purging bulk confirmations: %v", utils.Err(err))
+		return false, err
+	}
+
+	result, err := r.DB.Exec(`
+		INSERT INTO bulk_confirmations (token_hash, expires_at) VALUES ($1, $2)
+		ON CONFLICT (token_hash) DO NOTHING
+	`, tokenHash, expiresAt)
+	if err != nil {
+		slog.Error("error recording bulk confirmation: %v", utils.Err(err))
+		return false, err
+	}
+
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return false, err
+	}
+
+	return recorded == 1, nil
+}
+
+// duplicateFetchSize is the number of rows fetched from the duplicate block
+// cursor at a time.
+const duplicateFetchSize = 1000
+
+// ForEachDuplicateBlock calls fn for every group of users compared when
+// looking for duplicates, with the compared fields of the users in ID order.
+// Users are grouped by email address, by their name keys in either order,
+// and by birth date together with the initial of a name key. Groups of a
+// single user or more than maxBlockSize users are left out. The groups are
+// formed by the database and read through a server-side cursor, so only one
+// group is held in memory. An error from fn stops the iteration.
+func (r *PostgresUserRepository) ForEachDuplicateBlock(maxBlockSize int, fn func(block []domain.DuplicateProfile) error) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DECLARE duplicate_blocks NO SCROLL CURSOR FOR
+		WITH candidates AS (
+			SELECT id, COALESCE(first_name_key, '') AS first_name_key, COALESCE(last_name_key, '') AS last_name_key, date_of_birth, email_index
+			FROM users
+			WHERE deleted_at IS NULL AND anonymized_at IS NULL
+		), block_keys AS (
+			SELECT id, 'email:' || email_index AS block_key
+			FROM candidates
+			WHERE email_index IS NOT NULL
+			UNION
+			SELECT id, 'name:' || LEAST(first_name_key, last_name_key) || ' ' || GREATEST(first_name_key, last_name_key)
+			FROM candidates
+			WHERE first_name_key <> '' OR last_name_key <> ''
+			UNION
+			SELECT c.id, 'birth:' || to_char(c.date_of_birth, 'YYYY-MM-DD') || ':' || left(n.name_key, 1)
+			FROM candidates c CROSS JOIN LATERAL (VALUES (c.first_name_key), (c.last_name_key)) AS n(name_key)
+			WHERE c.date_of_birth > DATE '0001-01-01' AND n.name_key <> ''
+		), blocks AS (
+			SELECT block_key
+			FROM block_keys
+			GROUP BY block_key
+			HAVING COUNT(*) BETWEEN 2 AND $1
+		)
+		SELECT b.block_key, u.id, u.first_name, u.last_name, u.phone_number, COALESCE(u.email, ''), u.date_of_birth, u.location, u.registration_date
+		FROM blocks b
+		JOIN block_keys k ON k.block_key = b.block_key
+		JOIN users u ON u.id = k.id
+		ORDER BY b.block_key, u.id
+	`, maxBlockSize)
+	if err != nil {
+		slog.Error("error declaring duplicate block cursor: %v", utils.Err(err))
+		return err
+	}
+
+	var blockKey string
+	var block []domain.DuplicateProfile
+	for {
+		rows, err := tx.Query(fmt.Sprintf(`FETCH FORWARD %d FROM duplicate_blocks`, duplicateFetchSize))
+		if err != nil {
+			slog.Error("error fetching duplicate blocks: %v", utils.Err(err))
+			return err
+		}
+
+		var fetched int
+		err = scanRows(rows, func() error {
+			var key string
+			var profile domain.DuplicateProfile
+			if err := rows.Scan(
+				&key,
+				&profile.ID,
+				&profile.FirstName,
+				&profile.LastName,
+				&profile.PhoneNumber,
+				&profile.Email,
+				&profile.DateOfBirth,
+				&profile.Location,
+				&profile.RegistrationDate,
+			); err != nil {
+				return err
+			}
+			if err := decryptValues(r.Cipher, &profile.PhoneNumber, &profile.Email); err != nil {
+				return err
+			}
+			fetched++
+
+			if key != blockKey && len(block) > 0 {
+				if err := fn(block); err != nil {
+					return err
+				}
+				block = nil
+			}
+			blockKey = key
+			block = append(block, profile)
+
+			return nil
+		})
+		if err != nil {
+			slog.Error("error reading duplicate blocks: %v", utils.Err(err))
+			return err
+		}
+		if fetched < duplicateFetchSize {
+			break
+		}
+	}
+
+	if len(block) > 0 {
+		if err := fn(block); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MergeUsers merges merge.DuplicateID into merge.SurvivorID in one
+// transaction: the survivor is updated, the duplicate's notes, tags and
+// history move to it and the duplicate is soft-deleted. It returns the
+// updated survivor.
+func (r *PostgresUserRepository) MergeUsers(merge *domain.UserMerge) (*domain.UpdateUserResponse, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, merge.MergedBy); err != nil {
+		return nil, err
+	}
+
+	// The duplicate goes first so that an email address it hands over is
+	// free before the survivor takes it.
+	result, err := tx.Exec(`
+		UPDATE users
+		SET deleted_at = NOW(), merged_into = $1,
+			email = CASE WHEN $3 THEN '' ELSE email END,
+			email_index = CASE WHEN $3 THEN NULL ELSE email_index END,
+			email_verified = email_verified AND NOT $3,
+			version = version + 1
+		WHERE id = $2 AND deleted_at IS NULL
+	`, merge.SurvivorID, merge.DuplicateID, merge.ReleaseEmail)
+	if err != nil {
+		slog.Error("error deleting merged user: %v", utils.Err(err))
+		return nil, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if deleted == 0 {
+		return nil, errors.ErrUserNotFound
+	}
+
+	for _, statement := range []string{
+		`UPDATE user_notes SET user_id = $1 WHERE user_id = $2`,
+		`INSERT INTO user_tags (user_id, tag_id, tagged_by, tagged_at)
+		 SELECT $1, tag_id, tagged_by, tagged_at FROM user_tags WHERE user_id = $2
+		 ON CONFLICT DO NOTHING`,
+		`DELETE FROM user_tags WHERE user_id = $2`,
+		`UPDATE user_history SET user_id = $1, merged_from = COALESCE(merged_from, $2) WHERE user_id = $2`,
+	} {
+		if _, err := tx.Exec(statement, merge.SurvivorID, merge.DuplicateID); err != nil {
+			slog.Error("error moving merged user records: %v", utils.Err(err))
+			return nil, err
+		}
+	}
+
+	setClauses, args, err := patchAssignments(&merge.Survivor, r.Cipher)
+	if err != nil {
+		return nil, err
+	}
+	setClauses = append(setClauses, "version = version + 1")
+	args = append(args, merge.SurvivorID)
+
+	row := tx.QueryRow(fmt.Sprintf(`WITH u AS (
+                        UPDATE users SET %s
+                        WHERE id = $%d AND deleted_at IS NULL
+                        RETURNING *
+                    )
+                    SELECT `+userColumns+`
+                    FROM u
+                    `+userCurrentBlockJoin, strings.Join(setClauses, ", "), len(args)), args...)
+
+	survivor, err := scanUser(row, r.Cipher)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && strings.Contains(pqErr.Error(), "email") {
+			return nil, errors.ErrEmailInUse
+		}
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		slog.Error("error updating surviving user: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	user := domain.UpdateUserResponse(survivor)
+
+	return &user, nil
+}
+
+// exportFetchSize is the number of rows fetched from the export cursor at a
+// time.
+const exportFetchSize = 1000
+
+// ExportUsers calls fn for every user matching query and filter, in ID order.
+// Rows are read through a server-side cursor, so only exportFetchSize of them
+// are held in memory. Cancelling ctx or an error from fn stops the export.
+func (r *PostgresUserRepository) ExportUsers(ctx context.Context, query string, filter *domain.UserFilter, fn func(user *domain.GetUserResponse) error) error {
+	conditions, args, err := userSelectionConditions(query, filter, r.Cipher)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DECLARE user_export NO SCROLL CURSOR FOR
+		SELECT `+userColumns+`
+		FROM users u
+		`+userCurrentBlockJoin+`
+		`+whereClause(conditions)+`
+		ORDER BY u.id
+	`, args...)
+	if err != nil {
+		slog.Error("error declaring export cursor: %v", utils.Err(err))
+		return err
+	}
+
+	for {
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM user_export`, exportFetchSize))
+		if err != nil {
+			slog.Error("error fetching export rows: %v", utils.Err(err))
+			return err
+		}
+
+		fetched, err := r.exportRows(rows, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresUserRepository) exportRows(rows *sql.Rows, fn func(user *domain.GetUserResponse) error) (int, error) {
+	defer rows.Close()
+
+	var fetched int
+	for rows.Next() {
+		user, err := scanUser(rows, r.Cipher)
+		if err != nil {
+			slog.Error("error scanning user row: %v", utils.Err(err))
+			return 0, err
+		}
+		fetched++
+
+		if err := fn(&user); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over user rows: %v", utils.Err(err))
+		return 0, err
+	}
+
+	return fetched, nil
+}
+
+// SetProfilePhoto stores the user's new profile photo and returns the storage
+// key of the photo it replaced, if any.
+func (r *PostgresUserRepository) SetProfilePhoto(id int32, photo *domain.ProfilePhoto) (string, error) {
+	var previousKey string
+	err := r.DB.QueryRow(`
+		WITH previous AS (
+			SELECT profile_photo_key FROM users WHERE id = $1 FOR UPDATE
+		)
+		UPDATE users
+		SET profile_photo_url = $2, profile_photo_key = $3, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING (SELECT profile_photo_key FROM previous)
+	`, id, photo.URL, photo.Key).Scan(&previousKey)
+	if err == sql.ErrNoRows {
+		return "", errors.ErrUserNotFound
+	}
+	if err != nil {
+		slog.Error("error updating profile photo: %v", utils.Err(err))
+		return "", err
+	}
+
+	return previousKey, nil
+}
+
+// GetUserContacts returns the users holding any of the given phone numbers
+// or email addresses, which are looked up by their blind indexes. Deleted
+// users are returned too, as their numbers and addresses stay taken.
+func (r *PostgresUserRepository) GetUserContacts(phoneNumbers, emails []string) ([]domain.UserContact, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, phone_number, COALESCE(email, ''), deleted_at IS NOT NULL, merged_into
+		FROM users
+		WHERE phone_number_index = ANY($1) OR email_index = ANY($2)
+	`, pq.Array(blindIndexes(r.Cipher, phoneNumberIndexField, phoneNumbers)), pq.Array(blindIndexes(r.Cipher, emailIndexField, emails)))
+	if err != nil {
+		slog.Error("error getting user contacts: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []domain.UserContact
+	for rows.Next() {
+		var contact domain.UserContact
+		if err := rows.Scan(&contact.ID, &contact.PhoneNumber, &contact.Email, &contact.Deleted, &contact.MergedInto); err != nil {
+			slog.Error("error scanning user contact: %v", utils.Err(err))
+			return nil, err
+		}
+		if err := decryptValues(r.Cipher, &contact.PhoneNumber, &contact.Email); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating user contacts: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// importUpdateColumns are the assignments an upsert applies for each mapped
+// field. Attributes are merged into the existing ones and a changed email
+// address loses its verification, as in UpdateUser.
+var importUpdateColumns = map[string]string{
+	"first_name":    `first_name = EXCLUDED.first_name, first_name_key = EXCLUDED.first_name_key`,
+	"last_name":     `last_name = EXCLUDED.last_name, last_name_key = EXCLUDED.last_name_key`,
+	"gender":        `gender = EXCLUDED.gender`,
+	"date_of_birth": `date_of_birth = EXCLUDED.date_of_birth`,
+	"location":      `location = EXCLUDED.location`,
+	"email": `email = CASE WHEN users.email_index IS NOT DISTINCT FROM EXCLUDED.email_index THEN users.email ELSE EXCLUDED.email END,
+		email_index = EXCLUDED.email_index,
+		email_verified = (users.email_verified AND users.email_index IS NOT DISTINCT FROM EXCLUDED.email_index),
+		email_verified_at = CASE WHEN users.email_index IS NOT DISTINCT FROM EXCLUDED.email_index THEN users.email_verified_at END`,
+	"attributes": `attributes = users.attributes || EXCLUDED.attributes`,
+}
+
+// ImportUsers inserts the users of batch in a single transaction. With
+// UpsertByPhone a user whose phone number is already registered gets the
+// batch's fields overwritten instead, unless the user is deleted.
+func (r *PostgresUserRepository) ImportUsers(batch *domain.ImportBatch) (*domain.ImportBatchResult, error) {
+	query := `
+		INSERT INTO users (first_name, last_name, phone_number, gender, date_of_birth, location, email, attributes, first_name_key, last_name_key, phone_number_index, email_index)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	if batch.UpsertByPhone {
+		assignments := []string{`version = users.version + 1`}
+		for _, field := range batch.Fields {
+			if assignment, ok := importUpdateColumns[field]; ok {
+				assignments = append(assignments, assignment)
+			}
+		}
+		query += `
+		ON CONFLICT (phone_number_index) DO UPDATE SET ` + strings.Join(assignments, ", ") + `
+		WHERE users.deleted_at IS NULL`
+	}
+	// xmax is only set on rows that already existed.
+	query += `
+		RETURNING (xmax = 0)`
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		slog.Error("error starting transaction: %v", utils.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setChangeActor(tx, batch.ImportedBy); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		slog.Error("error preparing query: %v", utils.Err(err))
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var result domain.ImportBatchResult
+	for _, imported := range batch.Users {
+		user := imported.User
+
+		phoneNumber, phoneNumberIndex, err := encryptContact(r.Cipher, phoneNumberIndexField, user.PhoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		email, emailIndex, err := encryptContact(r.Cipher, emailIndexField, user.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		var inserted bool
+		err = stmt.QueryRow(
+			user.FirstName,
+			user.LastName,
+			phoneNumber,
+			user.Gender,
+			user.DateOfBirth,
+			user.Location,
+			email,
+			user.Attributes,
+			translit.Key(user.FirstName),
+			translit.Key(user.LastName),
+			phoneNumberIndex,
+			emailIndex,
+		).Scan(&inserted)
+		if err != nil {
+			// The phone number belongs to a deleted user, so nothing was
+			// written.
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("row %d: %w", imported.Row, errors.ErrPhoneNumberInUse)
+			}
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				if strings.Contains(pqErr.Error(), "phone_number") {
+					return nil, fmt.Errorf("row %d: %w", imported.Row, errors.ErrPhoneNumberInUse)
+				} else if strings.Contains(pqErr.Error(), "email") {
+					return nil, fmt.Errorf("row %d: %w", imported.Row, errors.ErrEmailInUse)
+				}
+			}
+			slog.Error("error importing user: %v", utils.Err(err))
+			return nil, err
+		}
+
+		if inserted {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("error committing transaction: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// setChangeActor records the admin making changes in tx, so that the user
+// history trigger can attribute them. Changes without a known actor are
+// recorded anonymously.
+func setChangeActor(tx *sql.Tx, actorID int32) error {
+	if actorID == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`SELECT set_config('admin_panel.actor_id', $1, true)`, strconv.Itoa(int(actorID)))
+	if err != nil {
+		slog.Error("error setting change actor: %v", utils.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetUserHistory returns a page of the recorded changes to a user, newest
+// first, including the history of users merged into it.
+func (r *PostgresUserRepository) GetUserHistory(id int32, page, pageSize int) (*domain.UserHistory, error) {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		slog.Error("error checking user existence: %v", utils.Err(err))
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.ErrUserNotFound
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT h.version, h.changes, h.changed_by, COALESCE(a.username, ''), h.changed_at, h.merged_from
+		FROM user_history h
+		LEFT JOIN admins a ON a.id = h.changed_by
+		WHERE h.user_id = $1
+		ORDER BY h.changed_at DESC, h.id DESC
+		LIMIT $2 OFFSET $3
+	`, id, pageSize, (page-1)*pageSize)
+	if err != nil {
+		slog.Error("error querying user history: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := domain.UserHistory{Entries: make([]domain.UserHistoryEntry, 0)}
+	for rows.Next() {
+		var entry domain.UserHistoryEntry
+		var changes []byte
+		if err := rows.Scan(&entry.Version, &changes, &entry.ChangedBy, &entry.ChangedByUsername, &entry.ChangedAt, &entry.MergedFrom); err != nil {
+			slog.Error("error scanning user history: %v", utils.Err(err))
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			slog.Error("error decoding user history changes: %v", utils.Err(err))
+			return nil, err
+		}
+		if err := decryptHistoryChanges(r.Cipher, entry.Changes); err != nil {
+			return nil, err
+		}
+		history.Entries = append(history.Entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over user history: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &history, nil
+}
+
+func (r *PostgresUserRepository) CountUserHistory(id int32) (int, error) {
+	var total int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM user_history WHERE user_id = $1`, id).Scan(&total)
+	if err != nil {
+		slog.Error("error counting user history: %v", utils.Err(err))
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetUserVersion returns the user as recorded at the given version. Only the
+// fields tracked by the history are set; tags and block details are not.
+func (r *PostgresUserRepository) GetUserVersion(id, version int32) (*domain.GetUserResponse, error) {
+	return r.userSnapshot(id, `
+		SELECT version, snapshot FROM user_history
+		WHERE user_id = $1 AND version = $2 AND merged_from IS NULL
+	`, id, version)
+}
+
+// GetUserAsOf returns the user as it was at the given time, i.e. the latest
+// version recorded at or before it.
+func (r *PostgresUserRepository) GetUserAsOf(id int32, asOf time.Time) (*domain.GetUserResponse, error) {
+	return r.userSnapshot(id, `
+		SELECT version, snapshot FROM user_history
+		WHERE user_id = $1 AND changed_at <= $2 AND merged_from IS NULL
+		ORDER BY version DESC
+		LIMIT 1
+	`, id, asOf)
+}
+
+func (r *PostgresUserRepository) userSnapshot(id int32, query string, args ...interface{}) (*domain.GetUserResponse, error) {
+	var version int32
+	var snapshot []byte
+	err := r.DB.QueryRow(query, args...).Scan(&version, &snapshot)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			slog.Error("error checking user existence: %v", utils.Err(err))
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrUserVersionNotFound
+	}
+	if err != nil {
+		slog.Error("error getting user version: %v", utils.Err(err))
+		return nil, err
+	}
+
+	var user domain.GetUserResponse
+	if err := json.Unmarshal(snapshot, &user); err != nil {
+		slog.Error("error decoding user snapshot: %v", utils.Err(err))
+		return nil, err
+	}
+	if err := decryptValues(r.Cipher, &user.PhoneNumber, &user.Email); err != nil {
+		return nil, err
+	}
+	user.Version = version
+
+	return &user, nil
+}
@@ -5,10 +5,16 @@ import (
 	mocks "admin-panel/internal/mocks/repository"
 	repository "admin-panel/internal/repository/postgres"
 	errors "admin-panel/pkg/lib/errors"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,7 +22,7 @@ func TestGetAllUsers(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	repo := repository.NewPostgresUserRepository(db)
+	repo := repository.NewPostgresUserRepository(db, testCipher)
 
 	testCases := []struct {
 		name           string
@@ -64,27 +70,240 @@ func TestGetAllUsers(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			query := `SELECT id, first_name, last_name, phone_number, blocked, registration_date, gender, date_of_birth, location, email, profile_photo_url FROM users ORDER BY id LIMIT \$1 OFFSET \$2`
+			query := `SELECT u.id, u.first_name, u.last_name, u.phone_number, u.blocked, u.registration_date, u.gender, u.date_of_birth, u.location, u.email, u.profile_photo_url, u.email_verified, u.email_verified_at, u.version, u.attributes, ARRAY\(SELECT t.name FROM user_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.user_id = u.id ORDER BY t.name\) AS tags, b.reason, b.expires_at FROM users u LEFT JOIN user_blocks b ON b.user_id = u.id AND b.unblocked_at IS NULL WHERE u.deleted_at IS NULL ORDER BY u.id LIMIT \$1 OFFSET \$2`
 
-			rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url"})
+			rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"})
 			for _, user := range tc.mockUsers {
-				rows.AddRow(user.ID, user.FirstName, user.LastName, user.PhoneNumber, user.Blocked, user.RegistrationDate, user.Gender, user.DateOfBirth, user.Location, user.Email, user.ProfilePhotoURL)
+				rows.AddRow(user.ID, user.FirstName, user.LastName, user.PhoneNumber, user.Blocked, user.RegistrationDate, user.Gender, user.DateOfBirth, user.Location, user.Email, user.ProfilePhotoURL, false, nil, 1, []byte(`{}`), "{}", nil, nil)
 			}
 			mock.ExpectPrepare(query)
 			mock.ExpectQuery(query).WithArgs(tc.limit, (tc.page-1)*tc.limit).WillReturnRows(rows)
 
-			users, _ := repo.GetAllUsers(tc.page, tc.limit)
+			users, _ := repo.GetAllUsers(tc.page, tc.limit, nil, nil)
 
 			assert.Equal(t, tc.expectedLength, len(users.Users))
 		})
 	}
 }
 
+func TestGetAllUsersWithAttributeFilter(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+		AddRow(1, "Kemal", "Atdayew", "+99362008971", false, time.Now(), "Male", time.Now(), "Ashgabat", "", "", false, nil, 1, []byte(`{"tier":"gold","vip":true}`), "{}", nil, nil)
+
+	query := `FROM users u LEFT JOIN user_blocks b ON b.user_id = u.id AND b.unblocked_at IS NULL WHERE u.deleted_at IS NULL AND u.attributes @> \$3::jsonb ORDER BY u.id LIMIT \$1 OFFSET \$2`
+	mock.ExpectPrepare(query)
+	mock.ExpectQuery(query).WithArgs(10, 0, `{"tier":"gold","vip":true}`).WillReturnRows(rows)
+
+	users, err := repo.GetAllUsers(1, 10, &domain.UserFilter{Attributes: map[string]interface{}{"tier": "gold", "vip": true}}, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, users.Users, 1)
+	assert.Equal(t, domain.UserAttributes{"tier": "gold", "vip": true}, users.Users[0].Attributes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllUsersSorted(t *testing.T) {
+	testCases := []struct {
+		name          string
+		sort          domain.Sort
+		expectedOrder string
+	}{
+		{
+			name:          "Tie broken by ID in the direction of the last field",
+			sort:          domain.Sort{{Field: "registration_date", Descending: true}, {Field: "last_name"}},
+			expectedOrder: `ORDER BY u.registration_date DESC, u.last_name, u.id LIMIT`,
+		},
+		{
+			name:          "Descending",
+			sort:          domain.Sort{{Field: "date_of_birth", Descending: true}},
+			expectedOrder: `ORDER BY u.date_of_birth DESC, u.id DESC LIMIT`,
+		},
+		{
+			name:          "ID needs no tie-break",
+			sort:          domain.Sort{{Field: "id", Descending: true}, {Field: "email"}},
+			expectedOrder: `ORDER BY u.id DESC LIMIT`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresUserRepository(db, testCipher)
+
+			query := `WHERE u.deleted_at IS NULL ` + tc.expectedOrder
+			mock.ExpectPrepare(query)
+			mock.ExpectQuery(query).WithArgs(10, 0).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			_, err := repo.GetAllUsers(1, 10, nil, tc.sort)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("Unknown field", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		_, err := repository.NewPostgresUserRepository(db, testCipher).GetAllUsers(1, 10, nil, domain.Sort{{Field: "password; DROP TABLE users"}})
+
+		assert.ErrorIs(t, err, errors.ErrInvalidSort)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetUsersByKeyset(t *testing.T) {
+	columns := []string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}
+	userRows := func(ids ...int) *sqlmock.Rows {
+		rows := sqlmock.NewRows(columns)
+		for _, id := range ids {
+			rows.AddRow(id, "Kemal", "Atdayew", "+99362008971", false, time.Now(), "Male", time.Now(), "Ashgabat", "", "", false, nil, 1, []byte(`{}`), "{}", nil, nil)
+		}
+		return rows
+	}
+
+	t.Run("First page", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		mock.ExpectQuery(`FROM users u LEFT JOIN user_blocks b ON b.user_id = u.id AND b.unblocked_at IS NULL WHERE u.deleted_at IS NULL ORDER BY u.registration_date DESC, u.id DESC LIMIT \$1`).
+			WithArgs(3).
+			WillReturnRows(userRows(9, 7, 4))
+
+		users, more, err := repository.NewPostgresUserRepository(db, testCipher).GetUsersByKeyset(nil, &domain.UsersKeyset{
+			Sort:  domain.Sort{{Field: "registration_date", Descending: true}},
+			Limit: 2,
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, more)
+		assert.Equal(t, []int32{9, 7}, []int32{users.Users[0].ID, users.Users[1].ID})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Backward from key", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		blocked := false
+		mock.ExpectQuery(`WHERE u.deleted_at IS NULL AND u.blocked = \$1 AND \(\(u.last_name < \$2\) OR \(u.last_name = \$2 AND u.first_name > \$3\) OR \(u.last_name = \$2 AND u.first_name = \$3 AND u.id > \$4\)\) ORDER BY u.last_name DESC, u.first_name, u.id LIMIT \$5`).
+			WithArgs(false, "Orazowa", "Bahar", "12", 3).
+			WillReturnRows(userRows(10, 6))
+
+		users, more, err := repository.NewPostgresUserRepository(db, testCipher).GetUsersByKeyset(&domain.UserFilter{Blocked: &blocked}, &domain.UsersKeyset{
+			Sort:     domain.Sort{{Field: "last_name"}, {Field: "first_name", Descending: true}},
+			Key:      []string{"Orazowa", "Bahar", "12"},
+			Backward: true,
+			Limit:    2,
+		})
+
+		assert.NoError(t, err)
+		assert.False(t, more)
+		assert.Equal(t, []int32{6, 10}, []int32{users.Users[0].ID, users.Users[1].ID})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Key not matching the sort", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		_, _, err := repository.NewPostgresUserRepository(db, testCipher).GetUsersByKeyset(nil, &domain.UsersKeyset{Key: []string{"Bahar", "12"}, Limit: 2})
+
+		assert.Equal(t, errors.ErrInvalidCursor, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetEstimatedUsersCount(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT reltuples::bigint FROM pg_class WHERE oid = 'users'::regclass`).
+		WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(125000))
+
+	estimate, err := repository.NewPostgresUserRepository(db, testCipher).GetEstimatedUsersCount()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 125000, estimate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTotalUsersCountWithFilter(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	blocked := false
+	minAge := 18
+
+	query := `SELECT COUNT\(\*\) FROM users u WHERE u.deleted_at IS NULL AND EXISTS \(SELECT 1 FROM user_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.user_id = u.id AND t.name = \$1\) AND u.blocked = \$2 AND u.date_of_birth <= CURRENT_DATE - make_interval\(years => \$3\)`
+	mock.ExpectQuery(query).
+		WithArgs("vip", false, 18).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	total, err := repo.GetTotalUsersCount(&domain.UserFilter{Tags: []string{"vip"}, Blocked: &blocked, MinAge: &minAge})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTotalUsersCountWithTypedFilter(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	hasEmail := true
+	hasPhoto := false
+	bornAfter := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	bornBefore := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	query := `SELECT COUNT\(\*\) FROM users u WHERE u.deleted_at IS NULL AND LOWER\(u.gender\) = LOWER\(\$1\) AND u.date_of_birth >= \$2 AND u.date_of_birth < \$3 AND \(u.email <> ''\) = \$4 AND \(u.profile_photo_url <> ''\) = \$5`
+	mock.ExpectQuery(query).
+		WithArgs("female", bornAfter, bornBefore, true, false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	total, err := repo.GetTotalUsersCount(&domain.UserFilter{Gender: "female", BornAfter: &bornAfter, BornBefore: &bornBefore, HasEmail: &hasEmail, HasPhoto: &hasPhoto})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSearchUsersCount(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	blocked := true
+
+	query := `SELECT COUNT\(\*\) FROM users u WHERE \(u.search_vector @@ to_tsquery\('simple', \$1 \|\| ':\*'\) OR \$1 <% u.search_text\) AND u.deleted_at IS NULL AND u.blocked = \$2`
+	mock.ExpectQuery(query).
+		WithArgs("kem", true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	total, err := repo.GetSearchUsersCount("kem", &domain.UserFilter{Blocked: &blocked})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetTotalUsersCount(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	repo := repository.NewPostgresUserRepository(db)
+	repo := repository.NewPostgresUserRepository(db, testCipher)
 
 	testCases := []struct {
 		name               string
@@ -109,7 +328,7 @@ func TestGetTotalUsersCount(t *testing.T) {
 			rows := sqlmock.NewRows([]string{"count"}).AddRow(tc.mockTotalUsers)
 			mock.ExpectQuery(query).WillReturnRows(rows)
 
-			totalUsers, err := repo.GetTotalUsersCount()
+			totalUsers, err := repo.GetTotalUsersCount(nil)
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedTotalUsers, totalUsers)
 		})
@@ -249,6 +468,53 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCreateUserEncryptsContacts(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`INSERT INTO users \(first_name, last_name, phone_number, gender, date_of_birth, location, email, profile_photo_url, attributes, first_name_key, last_name_key, phone_number_index, email_index\)`).
+		ExpectQuery().
+		WithArgs("Kemal", "Atdayew", encrypted("+99362008971"), "", time.Time{}, "", encrypted("kemal@example.com"), "", domain.UserAttributes{}, "kemal", "atdayev",
+			testCipher.BlindIndex("phone_number", "+99362008971"), testCipher.BlindIndex("email", "kemal@example.com")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+			AddRow(1, "Kemal", "Atdayew", mustEncrypt("+99362008971"), false, time.Now(), "", time.Time{}, "", mustEncrypt("kemal@example.com"), "", false, nil, 1, []byte(`{}`), "{}", nil, nil))
+	mock.ExpectCommit()
+
+	user, err := repo.CreateUser(&domain.CreateUserRequest{
+		FirstName:   "Kemal",
+		LastName:    "Atdayew",
+		PhoneNumber: "+99362008971",
+		Email:       "kemal@example.com",
+		Attributes:  domain.UserAttributes{},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "+99362008971", user.PhoneNumber)
+	assert.Equal(t, "kemal@example.com", user.Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateUserDuplicateEmail(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`INSERT INTO users`).
+		ExpectQuery().
+		WillReturnError(&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_email_index_key"`})
+	mock.ExpectRollback()
+
+	_, err := repo.CreateUser(&domain.CreateUserRequest{FirstName: "Kemal", PhoneNumber: "+99362008971", Email: "kemal@example.com"})
+
+	assert.Equal(t, errors.ErrEmailInUse, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateUser(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -328,6 +594,135 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestUpdateUserAnonymized(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(`UPDATE users SET .* WHERE id = \$9 AND deleted_at IS NULL AND anonymized_at IS NULL AND \(\$10::integer IS NULL OR version = \$10\)`)
+	prepared.ExpectQuery().WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT anonymized_at IS NOT NULL FROM users WHERE id = \$1 AND deleted_at IS NULL`).
+		WithArgs(int32(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"anonymized"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err := repo.UpdateUser(5, &domain.UpdateUserRequest{FirstName: "Kemal", LastName: "Atdayew", Email: "kemal@example.com"})
+
+	assert.Equal(t, errors.ErrUserAnonymized, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchUser(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	location := "Mary"
+	photo := ""
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+		AddRow(1, "Kemal", "Atdayew", "+99362008971", false, time.Now(), "Male", time.Now(), location, "atdayewkemal@gmail.com", photo, false, nil, 2, []byte(`{"tier":"gold"}`), "{}", nil, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('admin_panel.actor_id', \$1, true\)`).
+		WithArgs("7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`WITH u AS \( UPDATE users SET location = \$1, profile_photo_url = \$2, version = version \+ 1 WHERE id = \$3 AND deleted_at IS NULL AND anonymized_at IS NULL AND \(\$4::integer IS NULL OR version = \$4\) RETURNING \* \)`).
+		WithArgs(location, photo, int32(1), nil).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	user, err := repo.PatchUser(1, &domain.PatchUserRequest{
+		Location:        &location,
+		ProfilePhotoURL: &photo,
+		UpdatedBy:       7,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Mary", user.Location)
+	assert.Equal(t, "", user.ProfilePhotoURL)
+	assert.Equal(t, int32(2), user.Version)
+	assert.Equal(t, domain.UserAttributes{"tier": "gold"}, user.Attributes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchUserNameKeys(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	firstName := "Аман"
+	lastName := "Orazow"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WITH u AS \( UPDATE users SET first_name = \$1, first_name_key = \$2, last_name = \$3, last_name_key = \$4, version = version \+ 1 WHERE id = \$5`).
+		WithArgs(firstName, "aman", lastName, "orazov", int32(1), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+			AddRow(1, firstName, lastName, "+99362008971", false, time.Now(), "Male", time.Now(), "", "", "", false, nil, 2, []byte(`{}`), "{}", nil, nil))
+	mock.ExpectCommit()
+
+	user, err := repository.NewPostgresUserRepository(db, testCipher).PatchUser(1, &domain.PatchUserRequest{FirstName: &firstName, LastName: &lastName})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Аман", user.FirstName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchUserNotUpdated(t *testing.T) {
+	testCases := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		expectedErr error
+	}{
+		{
+			name:        "Stale version",
+			rows:        sqlmock.NewRows([]string{"anonymized"}).AddRow(false),
+			expectedErr: errors.ErrPreconditionFailed,
+		},
+		{
+			name:        "Anonymized user",
+			rows:        sqlmock.NewRows([]string{"anonymized"}).AddRow(true),
+			expectedErr: errors.ErrUserAnonymized,
+		},
+		{
+			name:        "Deleted user",
+			rows:        sqlmock.NewRows([]string{"anonymized"}),
+			expectedErr: errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresUserRepository(db, testCipher)
+
+			location := "Mary"
+			expectedVersion := int32(3)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`WITH u AS \( UPDATE users SET location = \$1, version = version \+ 1 WHERE id = \$2 AND deleted_at IS NULL AND anonymized_at IS NULL AND \(\$3::integer IS NULL OR version = \$3\) RETURNING \* \)`).
+				WithArgs(location, int32(1), expectedVersion).
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery(`SELECT anonymized_at IS NOT NULL FROM users WHERE id = \$1 AND deleted_at IS NULL`).
+				WithArgs(int32(1)).
+				WillReturnRows(tc.rows)
+			mock.ExpectRollback()
+
+			_, err := repo.PatchUser(1, &domain.PatchUserRequest{
+				Location:        &location,
+				ExpectedVersion: &expectedVersion,
+			})
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -353,9 +748,9 @@ func TestDeleteUser(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserRepository := new(mocks.MockUserRepository)
 
-			mockUserRepository.On("DeleteUser", tc.id).Return(tc.mockReturnErr)
+			mockUserRepository.On("DeleteUser", tc.id, (*int32)(nil)).Return(tc.mockReturnErr)
 
-			err := mockUserRepository.DeleteUser(tc.id)
+			err := mockUserRepository.DeleteUser(tc.id, nil)
 
 			assert.Equal(t, tc.expectedErr, err)
 		})
@@ -363,35 +758,71 @@ func TestDeleteUser(t *testing.T) {
 }
 
 func TestBlockUser(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour)
+
 	testCases := []struct {
-		name          string
-		id            int32
-		mockReturnErr error
-		expectedErr   error
+		name        string
+		id          int32
+		request     *domain.BlockUserRequest
+		exists      bool
+		expectedErr error
 	}{
 		{
-			name:          "Success",
-			id:            1,
-			mockReturnErr: nil,
-			expectedErr:   nil,
+			name: "Success",
+			id:   1,
+			request: &domain.BlockUserRequest{
+				Reason:    domain.BlockReasonSpam,
+				Note:      "Sending promotional messages",
+				ExpiresAt: &expiresAt,
+				BlockedBy: 7,
+			},
+			exists:      true,
+			expectedErr: nil,
 		},
 		{
-			name:          "User Not Found",
-			id:            2,
-			mockReturnErr: errors.ErrUserNotFound,
-			expectedErr:   errors.ErrUserNotFound,
+			name: "User Not Found",
+			id:   2,
+			request: &domain.BlockUserRequest{
+				Reason:    domain.BlockReasonFraud,
+				BlockedBy: 7,
+			},
+			exists:      false,
+			expectedErr: errors.ErrUserNotFound,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			mockUserRepository := new(mocks.MockUserRepository)
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
 
-			mockUserRepository.On("BlockUser", tc.id).Return(tc.mockReturnErr)
+			repo := repository.NewPostgresUserRepository(db, testCipher)
 
-			err := mockUserRepository.BlockUser(tc.id)
+			mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE id = \$1 AND deleted_at IS NULL\)`).
+				WithArgs(tc.id).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.exists))
+
+			if tc.exists {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SELECT set_config\('admin_panel.actor_id', \$1, true\)`).
+					WithArgs("7").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE user_blocks SET unblocked_at = NOW\(\), unblocked_by = \$2 WHERE user_id = \$1 AND unblocked_at IS NULL`).
+					WithArgs(tc.id, tc.request.BlockedBy).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO user_blocks`).
+					WithArgs(tc.id, tc.request.Reason, tc.request.Note, tc.request.BlockedBy, tc.request.ExpiresAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`UPDATE users SET blocked = true, version = version \+ 1 WHERE id = \$1`).
+					WithArgs(tc.id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			err := repo.BlockUser(tc.id, tc.request)
 
 			assert.Equal(t, tc.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -400,18 +831,21 @@ func TestUnblockUser(t *testing.T) {
 	testCases := []struct {
 		name          string
 		id            int32
+		unblockedBy   int32
 		mockReturnErr error
 		expectedErr   error
 	}{
 		{
 			name:          "Success",
 			id:            1,
+			unblockedBy:   7,
 			mockReturnErr: nil,
 			expectedErr:   nil,
 		},
 		{
 			name:          "User Not Found",
 			id:            2,
+			unblockedBy:   7,
 			mockReturnErr: errors.ErrUserNotFound,
 			expectedErr:   errors.ErrUserNotFound,
 		},
@@ -421,20 +855,67 @@ func TestUnblockUser(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserRepository := new(mocks.MockUserRepository)
 
-			mockUserRepository.On("UnblockUser", tc.id).Return(tc.mockReturnErr)
+			mockUserRepository.On("UnblockUser", tc.id, tc.unblockedBy).Return(tc.mockReturnErr)
 
-			err := mockUserRepository.UnblockUser(tc.id)
+			err := mockUserRepository.UnblockUser(tc.id, tc.unblockedBy)
 
 			assert.Equal(t, tc.expectedErr, err)
 		})
 	}
 }
 
+func TestGetUserBlocks(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	blockedAt := time.Now().Add(-48 * time.Hour)
+	unblockedAt := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE id = \$1 AND deleted_at IS NULL\)`).
+		WithArgs(int32(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "reason", "note", "blocked_by", "blocked_at", "expires_at", "unblocked_by", "unblocked_at"}).
+		AddRow(2, 1, "fraud", "", 7, time.Now(), nil, nil, nil).
+		AddRow(1, 1, "spam", "First warning", 7, blockedAt, unblockedAt, nil, unblockedAt)
+	mock.ExpectQuery(`SELECT id, user_id, reason, note, blocked_by, blocked_at, expires_at, unblocked_by, unblocked_at FROM user_blocks WHERE user_id = \$1`).
+		WithArgs(int32(1)).
+		WillReturnRows(rows)
+
+	blocks, err := repo.GetUserBlocks(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, blocks.Blocks, 2)
+	assert.Equal(t, domain.BlockReasonFraud, blocks.Blocks[0].Reason)
+	assert.Nil(t, blocks.Blocks[0].UnblockedAt)
+	assert.Equal(t, int32(7), *blocks.Blocks[1].BlockedBy)
+	assert.Nil(t, blocks.Blocks[1].UnblockedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnblockExpiredUsers(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectExec(`WITH expired AS \( UPDATE user_blocks SET unblocked_at = NOW\(\) WHERE unblocked_at IS NULL AND expires_at IS NOT NULL AND expires_at <= NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	unblocked, err := repo.UnblockExpiredUsers()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), unblocked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSearchUsers(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	repo := repository.NewPostgresUserRepository(db)
+	repo := repository.NewPostgresUserRepository(db, testCipher)
 
 	testCases := []struct {
 		name              string
@@ -479,24 +960,689 @@ func TestSearchUsers(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			searchQuery := `
-				SELECT id, first_name, last_name, phone_number, blocked,
-				registration_date, gender, date_of_birth, location,
-				email, profile_photo_url
-				FROM users
-				WHERE first_name ILIKE \$1 OR last_name ILIKE \$1 OR phone_number ILIKE \$1 OR email ILIKE \$1
-				ORDER BY id
-				LIMIT \$2 OFFSET \$3
+				SELECT u.id, u.first_name, u.last_name, u.phone_number, u.blocked, u.registration_date, u.gender, u.date_of_birth, u.location, u.email, u.profile_photo_url, u.email_verified, u.email_verified_at, u.version, u.attributes, ARRAY\(SELECT t.name FROM user_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.user_id = u.id ORDER BY t.name\) AS tags, b.reason, b.expires_at
+				FROM users u
+				LEFT JOIN user_blocks b ON b.user_id = u.id AND b.unblocked_at IS NULL
+				WHERE \(u.search_vector @@ to_tsquery\('simple', \$1 \|\| ':\*'\) OR \$1 <% u.search_text\) AND u.deleted_at IS NULL
+				ORDER BY ts_rank\(u.search_vector, to_tsquery\('simple', \$2\)\) \+ word_similarity\(\$3, u.search_text\) DESC, u.id
+				LIMIT \$4 OFFSET \$5
 			`
-			rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url"})
+			rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"})
 			for _, user := range tc.mockUsers {
-				rows.AddRow(user.ID, user.FirstName, user.LastName, user.PhoneNumber, user.Blocked, user.RegistrationDate, user.Gender, user.DateOfBirth, user.Location, user.Email, user.ProfilePhotoURL)
+				rows.AddRow(user.ID, user.FirstName, user.LastName, user.PhoneNumber, user.Blocked, user.RegistrationDate, user.Gender, user.DateOfBirth, user.Location, user.Email, user.ProfilePhotoURL, false, nil, 1, []byte(`{}`), "{}", nil, nil)
 			}
 			mock.ExpectPrepare(searchQuery)
-			mock.ExpectQuery(searchQuery).WithArgs("%"+tc.query+"%", tc.pageSize, (tc.page-1)*tc.pageSize).WillReturnRows(rows)
+			term := strings.ToLower(tc.query)
+			mock.ExpectQuery(searchQuery).WithArgs(term, term+":*", term, tc.pageSize, (tc.page-1)*tc.pageSize).WillReturnRows(rows)
 
-			users, _ := repo.SearchUsers(tc.query, tc.page, tc.pageSize)
+			users, _ := repo.SearchUsers(tc.query, tc.page, tc.pageSize, nil, nil)
 
 			assert.Equal(t, tc.expectedUserCount, len(users.Users))
 		})
 	}
 }
+
+func TestSearchUsersQueries(t *testing.T) {
+	testCases := []struct {
+		name         string
+		query        string
+		sort         domain.Sort
+		expectedSQL  string
+		expectedArgs []driver.Value
+	}{
+		{
+			name:  "Several terms",
+			query: "Aman Ashgabat",
+			expectedSQL: `WHERE \(u.search_vector @@ to_tsquery\('simple', \$1 \|\| ':\*'\) OR \$1 <% u.search_text\) AND \(u.search_vector @@ to_tsquery\('simple', \$2 \|\| ':\*'\) OR \$2 <% u.search_text\) AND u.deleted_at IS NULL ` +
+				`ORDER BY ts_rank\(u.search_vector, to_tsquery\('simple', \$3\)\) \+ word_similarity\(\$4, u.search_text\) DESC, u.id LIMIT \$5 OFFSET \$6`,
+			expectedArgs: []driver.Value{"aman", "ashgabat", "aman:* | ashgabat:*", "aman ashgabat", 10, 0},
+		},
+		{
+			name:  "Digits term",
+			query: "aman 8971",
+			expectedSQL: `AND \(u.search_vector @@ to_tsquery\('simple', \$2 \|\| ':\*'\) OR \$2 <% u.search_text\) AND u.deleted_at IS NULL ` +
+				`ORDER BY .* LIMIT \$5 OFFSET \$6`,
+			expectedArgs: []driver.Value{"aman", "8971", "aman:* | 8971:*", "aman 8971", 10, 0},
+		},
+		{
+			name:  "Cyrillic term",
+			query: "Аман",
+			expectedSQL: `WHERE \(u.search_vector @@ to_tsquery\('simple', \$1 \|\| ':\*'\) OR \$1 <% u.search_text OR u.search_vector @@ to_tsquery\('simple', \$2 \|\| ':\*'\) OR \$2 <% u.search_text\) AND u.deleted_at IS NULL ` +
+				`ORDER BY ts_rank\(u.search_vector, to_tsquery\('simple', \$3\)\) \+ word_similarity\(\$4, u.search_text\) DESC, u.id LIMIT \$5 OFFSET \$6`,
+			expectedArgs: []driver.Value{"аман", "aman", "аман:* | aman:*", "aman", 10, 0},
+		},
+		{
+			name:         "Phone number",
+			query:        "+993 65 12-34-56",
+			expectedSQL:  `WHERE u.phone_number_index = \$1 AND u.deleted_at IS NULL ORDER BY 0 DESC, u.id LIMIT \$2 OFFSET \$3`,
+			expectedArgs: []driver.Value{testCipher.BlindIndex("phone_number", "+99365123456"), 10, 0},
+		},
+		{
+			name:         "Email address",
+			query:        "Kemal@Example.com",
+			expectedSQL:  `WHERE u.email_index = \$1 AND u.deleted_at IS NULL ORDER BY 0 DESC, u.id LIMIT \$2 OFFSET \$3`,
+			expectedArgs: []driver.Value{testCipher.BlindIndex("email", "kemal@example.com"), 10, 0},
+		},
+		{
+			name:         "Explicit sort",
+			query:        "aman",
+			sort:         domain.Sort{{Field: "last_name"}},
+			expectedSQL:  `AND u.deleted_at IS NULL ORDER BY u.last_name, u.id LIMIT \$2 OFFSET \$3`,
+			expectedArgs: []driver.Value{"aman", 10, 0},
+		},
+		{
+			name:         "Nothing to search for",
+			query:        "!!!",
+			expectedSQL:  `WHERE FALSE AND u.deleted_at IS NULL ORDER BY 0 DESC, u.id LIMIT \$1 OFFSET \$2`,
+			expectedArgs: []driver.Value{10, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			mock.ExpectPrepare(tc.expectedSQL)
+			mock.ExpectQuery(tc.expectedSQL).WithArgs(tc.expectedArgs...).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			_, err := repository.NewPostgresUserRepository(db, testCipher).SearchUsers(tc.query, 1, 10, nil, tc.sort)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetProfilePhoto(t *testing.T) {
+	photo := &domain.ProfilePhoto{URL: "http://localhost:8080/media/users/1/photo/new/large.jpg", Key: "users/1/photo/new"}
+
+	testCases := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		expectedKey string
+		expectedErr error
+	}{
+		{
+			name:        "Replaces previous photo",
+			rows:        sqlmock.NewRows([]string{"profile_photo_key"}).AddRow("users/1/photo/old"),
+			expectedKey: "users/1/photo/old",
+		},
+		{
+			name:        "User not found",
+			rows:        sqlmock.NewRows([]string{"profile_photo_key"}),
+			expectedErr: errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresUserRepository(db, testCipher)
+
+			mock.ExpectQuery(`UPDATE users SET profile_photo_url = \$2, profile_photo_key = \$3, version = version \+ 1 WHERE id = \$1 AND deleted_at IS NULL RETURNING`).
+				WithArgs(int32(1), photo.URL, photo.Key).
+				WillReturnRows(tc.rows)
+
+			previousKey, err := repo.SetProfilePhoto(1, photo)
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedKey, previousKey)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserAsOf(t *testing.T) {
+	asOf := time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		snapshot    string
+		exists      bool
+		expectedErr error
+	}{
+		{
+			name:     "Decodes the snapshot",
+			snapshot: `{"id":1,"first_name":"Kemal","last_name":"Atdayew","phone_number":"+99362008971","blocked":false,"gender":"Male","registration_date":"2024-01-05T10:00:00.000000Z","date_of_birth":"2000-01-01T00:00:00Z","location":"Ashgabat","email":"old@example.com","profile_photo_url":"","email_verified":true,"email_verified_at":"2024-02-01T08:30:00.000000Z","attributes":{"tier":"gold"}}`,
+			exists:   true,
+		},
+		{
+			name:        "No version recorded yet",
+			exists:      true,
+			expectedErr: errors.ErrUserVersionNotFound,
+		},
+		{
+			name:        "User not found",
+			exists:      false,
+			expectedErr: errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresUserRepository(db, testCipher)
+
+			query := mock.ExpectQuery(`SELECT version, snapshot FROM user_history WHERE user_id = \$1 AND changed_at <= \$2 AND merged_from IS NULL ORDER BY version DESC LIMIT 1`).
+				WithArgs(int32(1), asOf)
+			if tc.snapshot != "" {
+				query.WillReturnRows(sqlmock.NewRows([]string{"version", "snapshot"}).AddRow(4, []byte(tc.snapshot)))
+			} else {
+				query.WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE id = \$1 AND deleted_at IS NULL\)`).
+					WithArgs(int32(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.exists))
+			}
+
+			user, err := repo.GetUserAsOf(1, asOf)
+
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr == nil {
+				assert.Equal(t, int32(4), user.Version)
+				assert.Equal(t, "old@example.com", user.Email)
+				assert.Equal(t, time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC), user.DateOfBirth)
+				assert.Equal(t, time.Date(2024, time.February, 1, 8, 30, 0, 0, time.UTC), *user.EmailVerifiedAt)
+				assert.Equal(t, domain.UserAttributes{"tier": "gold"}, user.Attributes)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserHistory(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	changedAt := time.Date(2024, time.October, 3, 9, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM users WHERE id = \$1 AND deleted_at IS NULL\)`).
+		WithArgs(int32(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM user_history h LEFT JOIN admins a ON a.id = h.changed_by WHERE h.user_id = \$1 ORDER BY h.changed_at DESC, h.id DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs(int32(1), 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "changes", "changed_by", "username", "changed_at", "merged_from"}).
+			AddRow(2, []byte(`{"email":{"old":"`+mustEncrypt("old@example.com")+`","new":"`+mustEncrypt("new@example.com")+`"},"phone_number":{"old":"+99362008971","new":"`+mustEncrypt("+99362008971")+`"}}`), 7, "alice", changedAt, nil).
+			AddRow(1, []byte(`{"first_name":{"old":null,"new":"Kemal"}}`), nil, "", changedAt, 4))
+
+	history, err := repo.GetUserHistory(1, 1, 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, history.Entries, 2)
+	assert.Equal(t, domain.FieldChange{Old: "old@example.com", New: "new@example.com"}, history.Entries[0].Changes["email"])
+	assert.NotContains(t, history.Entries[0].Changes, "phone_number", "encrypting a stored value changes nothing")
+	assert.Equal(t, "alice", history.Entries[0].ChangedByUsername)
+	assert.Nil(t, history.Entries[1].ChangedBy)
+	assert.Nil(t, history.Entries[0].MergedFrom)
+	assert.Equal(t, int32(4), *history.Entries[1].MergedFrom)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserContacts(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectQuery(`SELECT id, phone_number, COALESCE\(email, ''\), deleted_at IS NOT NULL, merged_into FROM users WHERE phone_number_index = ANY\(\$1\) OR email_index = ANY\(\$2\)`).
+		WithArgs(`{"`+testCipher.BlindIndex("phone_number", "+99362008971")+`"}`, `{"`+testCipher.BlindIndex("email", "kemal@example.com")+`"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone_number", "email", "deleted", "merged_into"}).
+			AddRow(1, mustEncrypt("+99362008971"), "", true, 3).
+			AddRow(2, mustEncrypt("+99362008972"), mustEncrypt("kemal@example.com"), false, nil))
+
+	contacts, err := repo.GetUserContacts([]string{"+99362008971"}, []string{"kemal@example.com", ""})
+
+	survivorID := int32(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.UserContact{
+		{ID: 1, PhoneNumber: "+99362008971", Deleted: true, MergedInto: &survivorID},
+		{ID: 2, PhoneNumber: "+99362008972", Email: "kemal@example.com"},
+	}, contacts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportUsers(t *testing.T) {
+	batch := &domain.ImportBatch{
+		Users: []domain.ImportUser{
+			{Row: 2, User: domain.CreateUserRequest{FirstName: "Kemal", PhoneNumber: "+99362008971", Attributes: domain.UserAttributes{}}},
+			{Row: 3, User: domain.CreateUserRequest{FirstName: "Aman", PhoneNumber: "+99362008972", Attributes: domain.UserAttributes{}}},
+		},
+		Fields:     []string{"first_name", "attributes"},
+		ImportedBy: 7,
+	}
+
+	t.Run("Upsert by phone", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		upsert := *batch
+		upsert.UpsertByPhone = true
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config\('admin_panel.actor_id', \$1, true\)`).
+			WithArgs("7").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		prepared := mock.ExpectPrepare(`INSERT INTO users .* ON CONFLICT \(phone_number_index\) DO UPDATE SET version = users.version \+ 1, first_name = EXCLUDED.first_name, first_name_key = EXCLUDED.first_name_key, attributes = users.attributes \|\| EXCLUDED.attributes WHERE users.deleted_at IS NULL RETURNING \(xmax = 0\)`)
+		prepared.ExpectQuery().
+			WithArgs("Kemal", "", encrypted("+99362008971"), "", time.Time{}, "", encrypted(""), domain.UserAttributes{}, "kemal", "", testCipher.BlindIndex("phone_number", "+99362008971"), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+		prepared.ExpectQuery().
+			WithArgs("Aman", "", encrypted("+99362008972"), "", time.Time{}, "", encrypted(""), domain.UserAttributes{}, "aman", "", testCipher.BlindIndex("phone_number", "+99362008972"), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+		mock.ExpectCommit()
+
+		result, err := repo.ImportUsers(&upsert)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.ImportBatchResult{Created: 1, Updated: 1}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Deleted user is not updated", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		upsert := *batch
+		upsert.UpsertByPhone = true
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 1))
+		prepared := mock.ExpectPrepare(`INSERT INTO users .* WHERE users.deleted_at IS NULL RETURNING \(xmax = 0\)`)
+		prepared.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"inserted"}))
+		mock.ExpectRollback()
+
+		result, err := repo.ImportUsers(&upsert)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errors.ErrPhoneNumberInUse)
+		assert.EqualError(t, err, "row 2: phone number already in use")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Duplicate rolls back the batch", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 1))
+		prepared := mock.ExpectPrepare(`INSERT INTO users .* RETURNING \(xmax = 0\)`)
+		prepared.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+		prepared.ExpectQuery().WillReturnError(&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_phone_number_index_key"`})
+		mock.ExpectRollback()
+
+		result, err := repo.ImportUsers(batch)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errors.ErrPhoneNumberInUse)
+		assert.EqualError(t, err, "row 3: phone number already in use")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUseBulkConfirmation(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+
+	testCases := []struct {
+		name     string
+		recorded int64
+		expected bool
+	}{
+		{"First use", 1, true},
+		{"Used before", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repository.NewPostgresUserRepository(db, testCipher)
+
+			mock.ExpectExec(`DELETE FROM bulk_confirmations WHERE expires_at < NOW\(\)`).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`INSERT INTO bulk_confirmations \(token_hash, expires_at\) VALUES \(\$1, \$2\) ON CONFLICT \(token_hash\) DO NOTHING`).
+				WithArgs("hash", expiresAt).
+				WillReturnResult(sqlmock.NewResult(0, tc.recorded))
+
+			unused, err := repo.UseBulkConfirmation("hash", expiresAt)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, unused)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	columns := []string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}
+
+	t.Run("Reads through a cursor", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DECLARE user_export NO SCROLL CURSOR FOR SELECT .* WHERE \(u.search_vector @@ .*\) AND u.deleted_at IS NULL AND EXISTS \(.* t.name = \$2\) ORDER BY u.id`).
+			WithArgs("kem", "vip").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FETCH FORWARD 1000 FROM user_export`).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "Kemal", "Atdayew", "+99362008971", false, time.Now(), "Male", time.Now(), "Ashgabat", "", "", false, nil, 1, []byte(`{}`), "{vip}", nil, nil).
+				AddRow(2, "Kemal", "Amanov", "+99362008972", false, time.Now(), "Male", time.Now(), "Mary", "", "", false, nil, 1, []byte(`{}`), "{vip}", nil, nil))
+		mock.ExpectCommit()
+
+		var ids []int32
+		err := repo.ExportUsers(context.Background(), "kem", &domain.UserFilter{Tags: []string{"vip"}}, func(user *domain.GetUserResponse) error {
+			ids = append(ids, user.ID)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{1, 2}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Callback error stops the export", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DECLARE user_export NO SCROLL CURSOR FOR SELECT .* FROM users u .* ORDER BY u.id`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FETCH FORWARD 1000 FROM user_export`).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "Kemal", "Atdayew", "+99362008971", false, time.Now(), "Male", time.Now(), "Ashgabat", "", "", false, nil, 1, []byte(`{}`), "{}", nil, nil))
+		mock.ExpectRollback()
+
+		writeErr := stderrors.New("broken pipe")
+		err := repo.ExportUsers(context.Background(), "", nil, func(user *domain.GetUserResponse) error {
+			return writeErr
+		})
+
+		assert.Equal(t, writeErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetUserIDs(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	blocked := false
+	mock.ExpectQuery(`SELECT u.id FROM users u WHERE \(u.search_vector @@ .*\) AND u.deleted_at IS NULL AND u.blocked = \$2 ORDER BY u.id LIMIT \$3`).
+		WithArgs("kem", false, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(4))
+
+	ids, err := repo.GetUserIDs("kem", &domain.UserFilter{Blocked: &blocked}, 11)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 4}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkUserAction(t *testing.T) {
+	t.Run("Block", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config\('admin_panel.actor_id', \$1, true\)`).
+			WithArgs("7").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE user_blocks SET unblocked_at = NOW\(\), unblocked_by = \$2 WHERE user_id = ANY\(\$1\) AND unblocked_at IS NULL`).
+			WithArgs("{1,2}", int32(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO user_blocks \(user_id, reason, note, blocked_by, expires_at\) SELECT id, \$2, \$3, \$4, \$5 FROM users WHERE id = ANY\(\$1\) AND deleted_at IS NULL`).
+			WithArgs("{1,2}", domain.BlockReasonSpam, "wave 3", int32(7), nil).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`UPDATE users SET blocked = true, version = version \+ 1 WHERE id = ANY\(\$1\) AND deleted_at IS NULL RETURNING id`).
+			WithArgs("{1,2}").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+		mock.ExpectCommit()
+
+		affected, err := repo.BulkUserAction(&domain.BulkBatch{
+			Action:  domain.BulkActionBlock,
+			IDs:     []int32{1, 2},
+			Block:   domain.BlockUserRequest{Reason: domain.BlockReasonSpam, Note: "wave 3"},
+			ActorID: 7,
+			Atomic:  true,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{1, 2}, affected)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Atomic delete with a missing user rolls back", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM users WHERE id = ANY\(\$1\) AND deleted_at IS NULL RETURNING id`).
+			WithArgs("{1,99}").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectRollback()
+
+		affected, err := repo.BulkUserAction(&domain.BulkBatch{
+			Action: domain.BulkActionDelete,
+			IDs:    []int32{1, 99},
+			Atomic: true,
+		})
+
+		assert.Equal(t, errors.ErrUserNotFound, err)
+		assert.Equal(t, []int32{1}, affected)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBackfillContactIndexes(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('admin_panel.skip_history', 'on', true\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, phone_number, COALESCE\(email, ''\) FROM users WHERE anonymized_at IS NULL AND \(\(phone_number_index IS NULL AND phone_number <> ''\) OR \(email_index IS NULL AND COALESCE\(email, ''\) <> ''\)\) ORDER BY id LIMIT \$1 FOR UPDATE`).
+		WithArgs(500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone_number", "email"}).
+			// Stored before encryption was enabled.
+			AddRow(1, "+99362008971", "kemal@example.com").
+			AddRow(2, "+99362008972", ""))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2, phone_number_index = \$3, email = \$4, email_index = \$5 WHERE id = \$1`).
+		WithArgs(int32(1), encrypted("+99362008971"), testCipher.BlindIndex("phone_number", "+99362008971"), encrypted("kemal@example.com"), testCipher.BlindIndex("email", "kemal@example.com")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2, phone_number_index = \$3, email = \$4, email_index = \$5 WHERE id = \$1`).
+		WithArgs(int32(2), encrypted("+99362008972"), testCipher.BlindIndex("phone_number", "+99362008972"), "", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	updated, err := repo.BackfillContactIndexes(500)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillContactIndexesDuplicate(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, phone_number, COALESCE\(email, ''\) FROM users`).
+		WithArgs(500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone_number", "email"}).AddRow(7, "+99362008971", "kemal@example.com"))
+	mock.ExpectExec(`UPDATE users SET phone_number = \$2`).
+		WillReturnError(&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_email_index_key"`})
+	mock.ExpectRollback()
+
+	_, err := repo.BackfillContactIndexes(500)
+
+	assert.ErrorIs(t, err, errors.ErrEmailInUse)
+	assert.Contains(t, err.Error(), "user 7")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForEachDuplicateBlock(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	birth := time.Date(1990, time.May, 4, 0, 0, 0, 0, time.UTC)
+	registered := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	columns := []string{"block_key", "id", "first_name", "last_name", "phone_number", "email", "date_of_birth", "location", "registration_date"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE duplicate_blocks NO SCROLL CURSOR FOR WITH candidates AS \( .* WHERE deleted_at IS NULL AND anonymized_at IS NULL \), block_keys AS \( .* \), blocks AS \( SELECT block_key FROM block_keys GROUP BY block_key HAVING COUNT\(\*\) BETWEEN 2 AND \$1 \) .* ORDER BY b.block_key, u.id`).
+		WithArgs(200).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 1000 FROM duplicate_blocks`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("birth:1990-05-04:k", 1, "Kemal", "Atdayew", mustEncrypt("+99362008971"), mustEncrypt(""), birth, "Ashgabat", registered).
+			AddRow("birth:1990-05-04:k", 3, "Kemal", "Atdaýew", mustEncrypt("+99365112233"), mustEncrypt("kemal@example.com"), birth, "Aşgabat", registered).
+			AddRow("name:atdayew kemal", 1, "Kemal", "Atdayew", mustEncrypt("+99362008971"), mustEncrypt(""), birth, "Ashgabat", registered).
+			AddRow("name:atdayew kemal", 3, "Kemal", "Atdaýew", mustEncrypt("+99365112233"), mustEncrypt("kemal@example.com"), birth, "Aşgabat", registered).
+			AddRow("name:atdayew kemal", 4, "Atdayev", "Kemal", mustEncrypt("+99361000000"), mustEncrypt(""), time.Time{}, "", registered))
+	mock.ExpectCommit()
+
+	var blocks [][]int32
+	err := repo.ForEachDuplicateBlock(200, func(block []domain.DuplicateProfile) error {
+		var ids []int32
+		for _, profile := range block {
+			ids = append(ids, profile.ID)
+		}
+		blocks = append(blocks, ids)
+
+		if block[0].ID == 1 {
+			assert.Equal(t, "+99362008971", block[0].PhoneNumber)
+			assert.Equal(t, "kemal@example.com", block[1].Email)
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int32{{1, 3}, {1, 3, 4}}, blocks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForEachDuplicateBlockStopsOnError(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPostgresUserRepository(db, testCipher)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE duplicate_blocks`).WithArgs(200).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 1000 FROM duplicate_blocks`).
+		WillReturnRows(sqlmock.NewRows([]string{"block_key", "id", "first_name", "last_name", "phone_number", "email", "date_of_birth", "location", "registration_date"}).
+			AddRow("name:a", 1, "A", "", mustEncrypt("+99362008971"), mustEncrypt(""), time.Time{}, "", time.Time{}).
+			AddRow("name:a", 2, "A", "", mustEncrypt("+99362008972"), mustEncrypt(""), time.Time{}, "", time.Time{}).
+			AddRow("name:b", 3, "B", "", mustEncrypt("+99362008973"), mustEncrypt(""), time.Time{}, "", time.Time{}).
+			AddRow("name:b", 4, "B", "", mustEncrypt("+99362008974"), mustEncrypt(""), time.Time{}, "", time.Time{}))
+	mock.ExpectRollback()
+
+	calls := 0
+	err := repo.ForEachDuplicateBlock(200, func(block []domain.DuplicateProfile) error {
+		calls++
+		return errors.ErrDatabaseError
+	})
+
+	assert.Equal(t, errors.ErrDatabaseError, err)
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeUsers(t *testing.T) {
+	email := "kemal@example.com"
+	merge := &domain.UserMerge{
+		SurvivorID:   1,
+		DuplicateID:  2,
+		Survivor:     domain.PatchUserRequest{Email: &email},
+		ReleaseEmail: true,
+		MergedBy:     7,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config\('admin_panel.actor_id', \$1, true\)`).
+			WithArgs("7").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE users SET deleted_at = NOW\(\), merged_into = \$1, email = CASE WHEN \$3 THEN '' ELSE email END, email_index = CASE WHEN \$3 THEN NULL ELSE email_index END, .* WHERE id = \$2 AND deleted_at IS NULL`).
+			WithArgs(int32(1), int32(2), true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE user_notes SET user_id = \$1 WHERE user_id = \$2`).
+			WithArgs(int32(1), int32(2)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO user_tags \(user_id, tag_id, tagged_by, tagged_at\) SELECT \$1, tag_id, tagged_by, tagged_at FROM user_tags WHERE user_id = \$2 ON CONFLICT DO NOTHING`).
+			WithArgs(int32(1), int32(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM user_tags WHERE user_id = \$2`).
+			WithArgs(int32(1), int32(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE user_history SET user_id = \$1, merged_from = COALESCE\(merged_from, \$2\) WHERE user_id = \$2`).
+			WithArgs(int32(1), int32(2)).
+			WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectQuery(`WITH u AS \( UPDATE users SET email = CASE WHEN email_index IS NOT DISTINCT FROM \$2 THEN email ELSE \$1 END, email_index = \$2, email_verified = \(email_verified AND email_index IS NOT DISTINCT FROM \$2\), email_verified_at = CASE WHEN email_index IS NOT DISTINCT FROM \$2 THEN email_verified_at END, version = version \+ 1 WHERE id = \$3 AND deleted_at IS NULL RETURNING \* \)`).
+			WithArgs(encrypted(email), testCipher.BlindIndex("email", email), int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "phone_number", "blocked", "registration_date", "gender", "date_of_birth", "location", "email", "profile_photo_url", "email_verified", "email_verified_at", "version", "attributes", "tags", "reason", "expires_at"}).
+				AddRow(1, "Kemal", "Atdayew", mustEncrypt("+99362008971"), false, time.Now(), "Male", time.Now(), "Ashgabat", mustEncrypt(email), "", false, nil, 6, []byte(`{}`), "{vip}", nil, nil))
+		mock.ExpectCommit()
+
+		survivor, err := repo.MergeUsers(merge)
+
+		assert.NoError(t, err)
+		assert.Equal(t, email, survivor.Email)
+		assert.Equal(t, []string{"vip"}, survivor.Tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Duplicate not found", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repository.NewPostgresUserRepository(db, testCipher)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT set_config`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE users SET deleted_at = NOW\(\)`).
+			WithArgs(int32(1), int32(2), true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.MergeUsers(merge)
+
+		assert.Equal(t, errors.ErrUserNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
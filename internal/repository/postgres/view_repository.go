@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"admin-panel/internal/domain"
+	errors "admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+const viewColumns = `id, name, query, filter, sort, columns, shared_admin_ids, shared_roles, owner_id, created_at, updated_at`
+
+type PostgresViewRepository struct {
+	DB *sql.DB
+}
+
+func NewPostgresViewRepository(db *sql.DB) *PostgresViewRepository {
+	return &PostgresViewRepository{DB: db}
+}
+
+func scanView(row utils.RowScanner) (domain.View, error) {
+	var view domain.View
+	var filter []byte
+	var adminIDs pq.Int32Array
+
+	if err := row.Scan(
+		&view.ID,
+		&view.Name,
+		&view.Query,
+		&filter,
+		&view.Sort,
+		pq.Array(&view.Columns),
+		&adminIDs,
+		pq.Array(&view.SharedWith.Roles),
+		&view.OwnerID,
+		&view.CreatedAt,
+		&view.UpdatedAt,
+	); err != nil {
+		return domain.View{}, err
+	}
+	view.SharedWith.AdminIDs = adminIDs
+
+	if err := json.Unmarshal(filter, &view.Filter); err != nil {
+		return domain.View{}, err
+	}
+
+	return view, nil
+}
+
+// GetViews returns the views visible to actor: their own and those shared
+// with them or their role.
+func (r *PostgresViewRepository) GetViews(actor domain.Actor) ([]domain.View, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+viewColumns+` FROM views
+		WHERE owner_id = $1 OR $1 = ANY(shared_admin_ids) OR $2 = ANY(shared_roles)
+		ORDER BY name, id`,
+		actor.AdminID, actor.Role)
+	if err != nil {
+		slog.Error("error querying views: %v", utils.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make([]domain.View, 0)
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			slog.Error("error scanning view: %v", utils.Err(err))
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating over views: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return views, nil
+}
+
+func (r *PostgresViewRepository) GetViewByID(id int32) (*domain.View, error) {
+	view, err := scanView(r.DB.QueryRow(`SELECT `+viewColumns+` FROM views WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrViewNotFound
+	}
+	if err != nil {
+		slog.Error("error getting view: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+func (r *PostgresViewRepository) CreateView(request *domain.ViewRequest) (*domain.View, error) {
+	filter, err := json.Marshal(request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := scanView(r.DB.QueryRow(`
+		INSERT INTO views (name, query, filter, sort, columns, shared_admin_ids, shared_roles, owner_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+viewColumns,
+		request.Name, request.Query, string(filter), request.Sort, pq.Array(request.Columns),
+		pq.Array(request.SharedWith.AdminIDs), pq.Array(request.SharedWith.Roles), request.OwnerID))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrViewAlreadyExists
+		}
+		slog.Error("error inserting view: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+// UpdateView replaces the definition and sharing of a view. Its owner does
+// not change.
+func (r *PostgresViewRepository) UpdateView(id int32, request *domain.ViewRequest) (*domain.View, error) {
+	filter, err := json.Marshal(request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := scanView(r.DB.QueryRow(`
+		UPDATE views SET name = $2, query = $3, filter = $4, sort = $5, columns = $6,
+			shared_admin_ids = $7, shared_roles = $8, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+viewColumns,
+		id, request.Name, request.Query, string(filter), request.Sort, pq.Array(request.Columns),
+		pq.Array(request.SharedWith.AdminIDs), pq.Array(request.SharedWith.Roles)))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.ErrViewAlreadyExists
+		}
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrViewNotFound
+		}
+		slog.Error("error updating view: %v", utils.Err(err))
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+func (r *PostgresViewRepository) DeleteView(id int32) error {
+	result, err := r.DB.Exec(`DELETE FROM views WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("error deleting view: %v", utils.Err(err))
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("error getting affected rows: %v", utils.Err(err))
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.ErrViewNotFound
+	}
+
+	return nil
+}
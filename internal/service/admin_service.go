@@ -4,6 +4,7 @@ import (
 	"admin-panel/internal/domain"
 	repository "admin-panel/internal/repository/interfaces"
 	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
 )
 
 type AdminService struct {
@@ -14,8 +15,12 @@ func NewAdminService(adminRepository repository.AdminRepository) *AdminService {
 	return &AdminService{AdminRepository: adminRepository}
 }
 
-func (s *AdminService) GetAllAdmins(page, pageSize int) (*domain.AdminsList, error) {
-	return s.AdminRepository.GetAllAdmins(page, pageSize)
+func (s *AdminService) GetAllAdmins(page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
+	if err := validateSort(sort, domain.AdminSortFields); err != nil {
+		return nil, err
+	}
+
+	return s.AdminRepository.GetAllAdmins(page, pageSize, sort)
 }
 
 func (s *AdminService) GetTotalAdminsCount() (int, error) {
@@ -31,15 +36,74 @@ func (s *AdminService) CreateAdmin(request *domain.CreateAdminRequest) (*domain.
 }
 
 func (s *AdminService) UpdateAdmin(id int32, request *domain.UpdateAdminRequest) (*domain.UpdateAdminResponse, error) {
+	if request.Role != "" {
+		if err := validateAdminRole(request.Role); err != nil {
+			return nil, err
+		}
+	}
+
 	return s.AdminRepository.UpdateAdmin(id, request)
 }
 
-func (s *AdminService) DeleteAdmin(id int32) error {
-	return s.AdminRepository.DeleteAdmin(id)
+// PatchAdmin applies a JSON Merge Patch or JSON Patch document to an admin
+// and persists only the fields that changed. The password is write-only and
+// appears empty in the document the patch is applied to. A non-nil
+// expectedVersion makes the write conditional on the admin's version.
+func (s *AdminService) PatchAdmin(id int32, format domain.PatchFormat, body []byte, expectedVersion *int32) (*domain.UpdateAdminResponse, error) {
+	admin, err := s.AdminRepository.GetAdminByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != nil && *expectedVersion != admin.Version {
+		return nil, errors.ErrPreconditionFailed
+	}
+
+	changes, err := applyPatch(domain.UpdateAdminRequest{
+		Username: admin.Username,
+		Role:     admin.Role,
+	}, format, body)
+	if err != nil {
+		return nil, err
+	}
+
+	request := domain.PatchAdminRequest{ExpectedVersion: expectedVersion}
+
+	if request.Username, err = changes.stringField("username", false); err != nil {
+		return nil, err
+	}
+	if request.Password, err = changes.stringField("password", false); err != nil {
+		return nil, err
+	}
+	if request.Role, err = changes.stringField("role", false); err != nil {
+		return nil, err
+	}
+	if request.Role != nil {
+		if err := validateAdminRole(*request.Role); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.AdminRepository.PatchAdmin(id, &request)
+}
+
+func (s *AdminService) DeleteAdmin(id int32, expectedVersion *int32) error {
+	return s.AdminRepository.DeleteAdmin(id, expectedVersion)
+}
+
+func (s *AdminService) SearchAdmins(query string, page, pageSize int, sort domain.Sort) (*domain.AdminsList, error) {
+	if err := validateSort(sort, domain.AdminSortFields); err != nil {
+		return nil, err
+	}
+
+	return s.AdminRepository.SearchAdmins(query, page, pageSize, sort)
 }
 
-func (s *AdminService) SearchAdmins(query string, page, pageSize int) (*domain.AdminsList, error) {
-	return s.AdminRepository.SearchAdmins(query, page, pageSize)
+func validateAdminRole(role string) error {
+	if !containsString(domain.AdminRoles, role) {
+		return errors.ErrInvalidAdminRole
+	}
+	return nil
 }
 
 var _ service.AdminService = &AdminService{}
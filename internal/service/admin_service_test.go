@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatchAdmin(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	testCases := []struct {
+		name            string
+		format          domain.PatchFormat
+		body            string
+		expectedRequest *domain.PatchAdminRequest
+		expectedErr     error
+	}{
+		{
+			name:            "Merge patch changes the role",
+			format:          domain.MergePatchFormat,
+			body:            `{"role":"super_admin"}`,
+			expectedRequest: &domain.PatchAdminRequest{Role: str("super_admin")},
+		},
+		{
+			name:            "JSON Patch changes the username",
+			format:          domain.JSONPatchFormat,
+			body:            `[{"op":"replace","path":"/username","value":"Admin2"}]`,
+			expectedRequest: &domain.PatchAdminRequest{Username: str("Admin2")},
+		},
+		{
+			name:        "Unknown role",
+			format:      domain.MergePatchFormat,
+			body:        `{"role":"owner"}`,
+			expectedErr: errors.ErrInvalidAdminRole,
+		},
+		{
+			name:        "Unknown role through JSON Patch",
+			format:      domain.JSONPatchFormat,
+			body:        `[{"op":"replace","path":"/role","value":"root"}]`,
+			expectedErr: errors.ErrInvalidAdminRole,
+		},
+		{
+			name:        "Role removed",
+			format:      domain.MergePatchFormat,
+			body:        `{"role":null}`,
+			expectedErr: errors.ErrFieldRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockAdminRepository)
+			mockRepo.On("GetAdminByID", int32(1)).Return(&domain.GetAdminResponse{ID: 1, Username: "Admin1", Role: "admin", Version: 2}, nil)
+			if tc.expectedRequest != nil {
+				mockRepo.On("PatchAdmin", int32(1), tc.expectedRequest).Return(&domain.UpdateAdminResponse{ID: 1}, nil)
+			}
+
+			s := service.NewAdminService(mockRepo)
+			_, err := s.PatchAdmin(1, tc.format, []byte(tc.body), nil)
+
+			if tc.expectedErr != nil {
+				assert.True(t, stderrors.Is(err, tc.expectedErr), "unexpected error %v", err)
+				mockRepo.AssertNotCalled(t, "PatchAdmin", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				mockRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestUpdateAdminRejectsUnknownRole(t *testing.T) {
+	mockRepo := new(mocks.MockAdminRepository)
+	s := service.NewAdminService(mockRepo)
+
+	_, err := s.UpdateAdmin(1, &domain.UpdateAdminRequest{Username: "Admin1", Password: "secret", Role: "owner"})
+
+	assert.Equal(t, errors.ErrInvalidAdminRole, err)
+	mockRepo.AssertNotCalled(t, "UpdateAdmin", mock.Anything, mock.Anything)
+}
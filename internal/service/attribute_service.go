@@ -0,0 +1,227 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const attributeDateLayout = "2006-01-02"
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type AttributeService struct {
+	AttributeRepository repository.AttributeRepository
+}
+
+func NewAttributeService(attributeRepository repository.AttributeRepository) *AttributeService {
+	return &AttributeService{AttributeRepository: attributeRepository}
+}
+
+func (s *AttributeService) GetAttributeDefinitions() (*domain.AttributeDefinitionsList, error) {
+	definitions, err := s.AttributeRepository.GetAttributeDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AttributeDefinitionsList{Attributes: definitions}, nil
+}
+
+func (s *AttributeService) CreateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	if err := validateAttributeDefinition(request); err != nil {
+		return nil, err
+	}
+
+	return s.AttributeRepository.CreateAttributeDefinition(request)
+}
+
+func (s *AttributeService) UpdateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	if err := validateAttributeDefinition(request); err != nil {
+		return nil, err
+	}
+
+	return s.AttributeRepository.UpdateAttributeDefinition(request)
+}
+
+func (s *AttributeService) DeleteAttributeDefinition(key string) error {
+	return s.AttributeRepository.DeleteAttributeDefinition(key)
+}
+
+func validateAttributeDefinition(request *domain.AttributeDefinitionRequest) error {
+	if !attributeKeyPattern.MatchString(request.Key) {
+		return fmt.Errorf("%w: key must start with a lowercase letter and contain only lowercase letters, digits and underscores", errors.ErrInvalidAttributeDefinition)
+	}
+
+	known := false
+	for _, attributeType := range domain.AttributeTypes {
+		if request.Type == attributeType {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("%w: unknown type %q", errors.ErrInvalidAttributeDefinition, request.Type)
+	}
+
+	if len(request.Enum) > 0 && request.Type != domain.AttributeTypeString {
+		return fmt.Errorf("%w: enum is only allowed for string attributes", errors.ErrInvalidAttributeDefinition)
+	}
+
+	seen := make(map[string]bool, len(request.Enum))
+	for _, value := range request.Enum {
+		if value == "" || seen[value] {
+			return fmt.Errorf("%w: enum values must be unique and non-empty", errors.ErrInvalidAttributeDefinition)
+		}
+		seen[value] = true
+	}
+
+	return nil
+}
+
+// validateAttributes checks attributes against the admin-defined schema and
+// returns them with null values dropped.
+func validateAttributes(definitions []domain.AttributeDefinition, attributes domain.UserAttributes) (domain.UserAttributes, error) {
+	byKey := make(map[string]domain.AttributeDefinition, len(definitions))
+	for _, definition := range definitions {
+		byKey[definition.Key] = definition
+	}
+
+	validated := make(domain.UserAttributes, len(attributes))
+	for key, value := range attributes {
+		if value == nil {
+			continue
+		}
+
+		definition, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not defined", errors.ErrInvalidAttributes, key)
+		}
+
+		if err := checkAttributeValue(definition, value); err != nil {
+			return nil, err
+		}
+		validated[key] = value
+	}
+
+	for _, definition := range definitions {
+		if _, ok := validated[definition.Key]; definition.Required && !ok {
+			return nil, fmt.Errorf("%w: %q is required", errors.ErrInvalidAttributes, definition.Key)
+		}
+	}
+
+	return validated, nil
+}
+
+func checkAttributeValue(definition domain.AttributeDefinition, value interface{}) error {
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: %q must be %s", errors.ErrInvalidAttributes, definition.Key, expected)
+	}
+
+	switch definition.Type {
+	case domain.AttributeTypeString:
+		str, ok := value.(string)
+		if !ok {
+			return invalid("a string")
+		}
+		if len(definition.Enum) > 0 && !containsString(definition.Enum, str) {
+			return invalid("one of " + strings.Join(definition.Enum, ", "))
+		}
+	case domain.AttributeTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return invalid("a number")
+		}
+	case domain.AttributeTypeInteger:
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return invalid("an integer")
+		}
+	case domain.AttributeTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return invalid("a boolean")
+		}
+	case domain.AttributeTypeDate:
+		str, ok := value.(string)
+		if !ok {
+			return invalid("a date in YYYY-MM-DD format")
+		}
+		if _, err := time.Parse(attributeDateLayout, str); err != nil {
+			return invalid("a date in YYYY-MM-DD format")
+		}
+	}
+
+	return nil
+}
+
+// typedAttributeFilter converts the string values of filter.Attributes, as
+// read from a query string, to the types of their attributes.
+func typedAttributeFilter(definitions []domain.AttributeDefinition, filter *domain.UserFilter) (*domain.UserFilter, error) {
+	if filter == nil || len(filter.Attributes) == 0 {
+		return filter, nil
+	}
+
+	byKey := make(map[string]domain.AttributeDefinition, len(definitions))
+	for _, definition := range definitions {
+		byKey[definition.Key] = definition
+	}
+
+	typed := *filter
+	typed.Attributes = make(map[string]interface{}, len(filter.Attributes))
+	for key, raw := range filter.Attributes {
+		definition, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not defined", errors.ErrInvalidAttributes, key)
+		}
+
+		value, err := parseAttributeValue(definition, fmt.Sprint(raw))
+		if err != nil {
+			return nil, err
+		}
+		typed.Attributes[key] = value
+	}
+
+	return &typed, nil
+}
+
+// parseAttributeValue converts str, as read from a query string or an
+// imported file, to the type of definition and checks it.
+func parseAttributeValue(definition domain.AttributeDefinition, str string) (interface{}, error) {
+	var value interface{} = str
+	switch definition.Type {
+	case domain.AttributeTypeNumber, domain.AttributeTypeInteger:
+		number, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be a number", errors.ErrInvalidAttributes, definition.Key)
+		}
+		value = number
+	case domain.AttributeTypeBoolean:
+		boolean, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be a boolean", errors.ErrInvalidAttributes, definition.Key)
+		}
+		value = boolean
+	}
+
+	if err := checkAttributeValue(definition, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var _ service.AttributeService = &AttributeService{}
@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateAttributeDefinition(t *testing.T) {
+	testCases := []struct {
+		name        string
+		request     domain.AttributeDefinitionRequest
+		expectedErr bool
+	}{
+		{
+			name:    "string enum",
+			request: domain.AttributeDefinitionRequest{Key: "tier", Type: domain.AttributeTypeString, Enum: []string{"gold", "silver"}},
+		},
+		{
+			name:        "invalid key",
+			request:     domain.AttributeDefinitionRequest{Key: "Tier Level", Type: domain.AttributeTypeString},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown type",
+			request:     domain.AttributeDefinitionRequest{Key: "tier", Type: "object"},
+			expectedErr: true,
+		},
+		{
+			name:        "enum on number",
+			request:     domain.AttributeDefinitionRequest{Key: "score", Type: domain.AttributeTypeNumber, Enum: []string{"1"}},
+			expectedErr: true,
+		},
+		{
+			name:        "duplicate enum value",
+			request:     domain.AttributeDefinitionRequest{Key: "tier", Type: domain.AttributeTypeString, Enum: []string{"gold", "gold"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attributeRepo := new(mocks.MockAttributeRepository)
+			attributeRepo.On("CreateAttributeDefinition", mock.Anything).Return(&domain.AttributeDefinition{Key: tc.request.Key}, nil)
+
+			s := service.NewAttributeService(attributeRepo)
+
+			_, err := s.CreateAttributeDefinition(&tc.request)
+
+			if tc.expectedErr {
+				assert.True(t, stderrors.Is(err, errors.ErrInvalidAttributeDefinition), "got %v", err)
+				attributeRepo.AssertNotCalled(t, "CreateAttributeDefinition", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			attributeRepo.AssertCalled(t, "CreateAttributeDefinition", &tc.request)
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/phone"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BulkService struct {
+	UserRepository      repository.UserRepository
+	SegmentRepository   repository.SegmentRepository
+	AttributeRepository repository.AttributeRepository
+	PhoneParser         *phone.Parser
+	Config              config.Bulk
+}
+
+func NewBulkService(userRepository repository.UserRepository, segmentRepository repository.SegmentRepository, attributeRepository repository.AttributeRepository, phoneParser *phone.Parser, cfg config.Bulk) *BulkService {
+	return &BulkService{
+		UserRepository:      userRepository,
+		SegmentRepository:   segmentRepository,
+		AttributeRepository: attributeRepository,
+		PhoneParser:         phoneParser,
+		Config:              cfg,
+	}
+}
+
+// RunBulkAction applies request.Action to the targeted users. When more
+// users than Config.ConfirmThreshold are targeted and request carries no
+// valid confirmation token, nothing is done and a confirmation is returned
+// together with errors.ErrBulkConfirmationRequired.
+func (s *BulkService) RunBulkAction(request *domain.BulkRequest) (*domain.BulkResponse, *domain.BulkConfirmation, error) {
+	if err := validateBulkRequest(request); err != nil {
+		return nil, nil, err
+	}
+
+	mode := request.Mode
+	if mode == "" {
+		mode = domain.BulkModeAtomic
+	}
+
+	ids, err := s.bulkTargets(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(ids) > s.Config.MaxUsers {
+		return nil, nil, fmt.Errorf("%w: at most %d users are allowed", errors.ErrBulkTooManyUsers, s.Config.MaxUsers)
+	}
+
+	if len(ids) > s.Config.ConfirmThreshold {
+		digest := bulkDigest(request, mode, ids)
+		confirmed, err := s.useConfirmation(request.ConfirmationToken, digest)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !confirmed {
+			expiresAt := time.Now().Add(s.Config.ConfirmationTTL)
+			token, err := s.confirmationToken(digest, expiresAt)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, &domain.BulkConfirmation{
+				Token:     token,
+				Affected:  len(ids),
+				ExpiresAt: expiresAt,
+			}, errors.ErrBulkConfirmationRequired
+		}
+	}
+
+	batch := domain.BulkBatch{
+		Action:  request.Action,
+		Block:   request.Block,
+		ActorID: request.ActorID,
+		Atomic:  true,
+	}
+
+	var results []domain.BulkResult
+	if mode == domain.BulkModeAtomic {
+		results, err = s.runAtomic(batch, ids)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		results = s.runBestEffort(batch, ids)
+	}
+
+	response := &domain.BulkResponse{
+		Action:  request.Action,
+		Mode:    mode,
+		Total:   len(ids),
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Status == domain.BulkStatusOK {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	return response, nil, nil
+}
+
+func validateBulkRequest(request *domain.BulkRequest) error {
+	if !containsBulkAction(request.Action) {
+		return errors.ErrInvalidBulkAction
+	}
+
+	if request.Mode != "" && request.Mode != domain.BulkModeAtomic && request.Mode != domain.BulkModeBestEffort {
+		return errors.ErrInvalidBulkMode
+	}
+
+	targets := 0
+	if len(request.IDs) > 0 {
+		targets++
+	}
+	if request.Query != "" || request.Filter != nil {
+		targets++
+	}
+	if request.SegmentID != nil {
+		targets++
+	}
+	if targets != 1 {
+		return errors.ErrBulkTargetRequired
+	}
+
+	if request.Action == domain.BulkActionBlock {
+		if !utils.IsValidBlockReason(request.Block.Reason) {
+			return errors.ErrInvalidBlockReason
+		}
+		if request.Block.ExpiresAt != nil && !request.Block.ExpiresAt.After(time.Now()) {
+			return errors.ErrInvalidBlockExpiry
+		}
+	}
+
+	return nil
+}
+
+// bulkTargets returns the IDs of the targeted users without duplicates. At
+// most one ID over Config.MaxUsers is looked up for queries and segments.
+// Queries are normalized as in SearchUsers, so that the users found are
+// those the search showed.
+func (s *BulkService) bulkTargets(request *domain.BulkRequest) ([]int32, error) {
+	if len(request.IDs) > 0 {
+		seen := make(map[int32]bool, len(request.IDs))
+		ids := make([]int32, 0, len(request.IDs))
+		for _, id := range request.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	filter := request.Filter
+	if request.SegmentID != nil {
+		segment, err := s.SegmentRepository.GetSegmentByID(*request.SegmentID)
+		if err != nil {
+			return nil, err
+		}
+		filter = &segment.Filter
+	}
+
+	filter, err := normalizeUserFilter(s.AttributeRepository, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UserRepository.GetUserIDs(searchTerm(s.PhoneParser, request.Query), filter, s.Config.MaxUsers+1)
+}
+
+// runAtomic applies batch to all ids in one transaction. If some users do
+// not exist the transaction is rolled back and the others are reported as
+// rolled back.
+func (s *BulkService) runAtomic(batch domain.BulkBatch, ids []int32) ([]domain.BulkResult, error) {
+	batch.IDs = ids
+
+	affected, err := s.UserRepository.BulkUserAction(&batch)
+	if err != nil && err != errors.ErrUserNotFound {
+		return nil, err
+	}
+
+	found := make(map[int32]bool, len(affected))
+	for _, id := range affected {
+		found[id] = true
+	}
+
+	results := make([]domain.BulkResult, 0, len(ids))
+	for _, id := range ids {
+		switch {
+		case !found[id]:
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkStatusNotFound, Error: errors.UserNotFound})
+		case err != nil:
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkStatusRolledBack})
+		default:
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkStatusOK})
+		}
+	}
+
+	return results, nil
+}
+
+// runBestEffort applies batch to each of ids in its own transaction.
+func (s *BulkService) runBestEffort(batch domain.BulkBatch, ids []int32) []domain.BulkResult {
+	results := make([]domain.BulkResult, 0, len(ids))
+	for _, id := range ids {
+		batch.IDs = []int32{id}
+
+		_, err := s.UserRepository.BulkUserAction(&batch)
+		switch {
+		case err == nil:
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkStatusOK})
+		case err == errors.ErrUserNotFound:
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkStatusNotFound, Error: errors.UserNotFound})
+		default:
+			slog.Error("Error running bulk action: ", utils.Err(err))
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkStatusFailed, Error: errors.InternalServerError})
+		}
+	}
+
+	return results
+}
+
+// bulkDigest identifies an operation for its confirmation token: who runs
+// which action, with which block reason, note and expiry, on which users.
+func bulkDigest(request *domain.BulkRequest, mode domain.BulkMode, ids []int32) []byte {
+	var expiresAt int64
+	if request.Block.ExpiresAt != nil {
+		expiresAt = request.Block.ExpiresAt.UnixNano()
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d|%s|%s|%q|%q|%d|", request.ActorID, request.Action, mode, request.Block.Reason, request.Block.Note, expiresAt)
+	for i, id := range ids {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(strconv.Itoa(int(id)))
+	}
+
+	sum := sha256.Sum256([]byte(builder.String()))
+	return sum[:]
+}
+
+// confirmationToken returns "<payload>.<signature>", both base64url encoded,
+// where the payload is "<expiry>.<nonce>" and the signature the HMAC-SHA256
+// of the payload and the digest. The nonce tells apart the tokens issued for
+// the same operation.
+func (s *BulkService) confirmationToken(digest []byte, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := strconv.FormatInt(expiresAt.Unix(), 10) + "." + hex.EncodeToString(nonce)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload, digest)), nil
+}
+
+// useConfirmation reports whether token confirms the operation with digest.
+// A valid token is recorded as used, so that it confirms the operation once.
+func (s *BulkService) useConfirmation(token string, digest []byte) (bool, error) {
+	expiresAt, ok := s.parseConfirmation(token, digest)
+	if !ok || !time.Now().Before(expiresAt) {
+		return false, nil
+	}
+
+	return s.UserRepository.UseBulkConfirmation(hashToken(token), expiresAt)
+}
+
+// parseConfirmation verifies the token's signature for digest and returns its
+// expiry.
+func (s *BulkService) parseConfirmation(token string, digest []byte) (time.Time, bool) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(string(payload), digest)) {
+		return time.Time{}, false
+	}
+
+	expiry, _, _ := strings.Cut(string(payload), ".")
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(expiresAt, 0), true
+}
+
+func (s *BulkService) sign(payload string, digest []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(s.Config.SecretKey))
+	mac.Write([]byte(payload))
+	mac.Write(digest)
+	return mac.Sum(nil)
+}
+
+func containsBulkAction(action domain.BulkAction) bool {
+	for _, supported := range domain.BulkActions {
+		if action == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+var _ service.BulkService = &BulkService{}
@@ -0,0 +1,246 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var bulkConfig = config.Bulk{MaxUsers: 5, ConfirmThreshold: 3, ConfirmationTTL: time.Minute, SecretKey: "secret"}
+
+func newBulkService(userRepo *mocks.MockUserRepository, segmentRepo *mocks.MockSegmentRepository) *service.BulkService {
+	return service.NewBulkService(userRepo, segmentRepo, attributeRepository(), phoneParser, bulkConfig)
+}
+
+func TestRunBulkActionAtomic(t *testing.T) {
+	testCases := []struct {
+		name            string
+		affected        []int32
+		repoErr         error
+		expectedResults []domain.BulkResult
+		expectedFailed  int
+	}{
+		{
+			name:     "All users exist",
+			affected: []int32{1, 2},
+			expectedResults: []domain.BulkResult{
+				{ID: 1, Status: domain.BulkStatusOK},
+				{ID: 2, Status: domain.BulkStatusOK},
+			},
+		},
+		{
+			name:     "Missing user rolls back the others",
+			affected: []int32{1},
+			repoErr:  errors.ErrUserNotFound,
+			expectedResults: []domain.BulkResult{
+				{ID: 1, Status: domain.BulkStatusRolledBack},
+				{ID: 2, Status: domain.BulkStatusNotFound, Error: errors.UserNotFound},
+			},
+			expectedFailed: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mocks.MockUserRepository)
+			userRepo.On("BulkUserAction", &domain.BulkBatch{
+				Action:  domain.BulkActionBlock,
+				IDs:     []int32{1, 2},
+				Block:   domain.BlockUserRequest{Reason: domain.BlockReasonFraud},
+				ActorID: 7,
+				Atomic:  true,
+			}).Return(tc.affected, tc.repoErr)
+
+			response, confirmation, err := newBulkService(userRepo, nil).RunBulkAction(&domain.BulkRequest{
+				Action:  domain.BulkActionBlock,
+				IDs:     []int32{1, 2, 1},
+				Block:   domain.BlockUserRequest{Reason: domain.BlockReasonFraud},
+				ActorID: 7,
+			})
+
+			assert.NoError(t, err)
+			assert.Nil(t, confirmation)
+			assert.Equal(t, domain.BulkModeAtomic, response.Mode)
+			assert.Equal(t, 2, response.Total)
+			assert.Equal(t, tc.expectedFailed, response.Failed)
+			assert.Equal(t, tc.expectedResults, response.Results)
+		})
+	}
+}
+
+func TestRunBulkActionBestEffort(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	batch := func(id int32) *domain.BulkBatch {
+		return &domain.BulkBatch{Action: domain.BulkActionDelete, IDs: []int32{id}, ActorID: 7, Atomic: true}
+	}
+	userRepo.On("BulkUserAction", batch(1)).Return([]int32{1}, nil)
+	userRepo.On("BulkUserAction", batch(2)).Return([]int32{}, errors.ErrUserNotFound)
+	userRepo.On("BulkUserAction", batch(3)).Return([]int32(nil), stderrors.New("deadlock detected"))
+
+	response, _, err := newBulkService(userRepo, nil).RunBulkAction(&domain.BulkRequest{
+		Action:  domain.BulkActionDelete,
+		Mode:    domain.BulkModeBestEffort,
+		IDs:     []int32{1, 2, 3},
+		ActorID: 7,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, []domain.BulkResult{
+		{ID: 1, Status: domain.BulkStatusOK},
+		{ID: 2, Status: domain.BulkStatusNotFound, Error: errors.UserNotFound},
+		{ID: 3, Status: domain.BulkStatusFailed, Error: errors.InternalServerError},
+	}, response.Results)
+}
+
+func TestRunBulkActionTargets(t *testing.T) {
+	t.Run("Search query and filter", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("GetUserIDs", "kem", &domain.UserFilter{Tags: []string{"vip"}}, 6).Return([]int32{4}, nil)
+		userRepo.On("BulkUserAction", mock.Anything).Return([]int32{4}, nil)
+
+		response, _, err := newBulkService(userRepo, nil).RunBulkAction(&domain.BulkRequest{
+			Action: domain.BulkActionUnblock,
+			Query:  "kem",
+			Filter: &domain.UserFilter{Tags: []string{"VIP"}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.BulkResult{{ID: 4, Status: domain.BulkStatusOK}}, response.Results)
+	})
+
+	t.Run("Local phone number query", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("GetUserIDs", "+99365123456", (*domain.UserFilter)(nil), 6).Return([]int32{4}, nil)
+		userRepo.On("BulkUserAction", mock.Anything).Return([]int32{4}, nil)
+
+		response, _, err := newBulkService(userRepo, nil).RunBulkAction(&domain.BulkRequest{
+			Action: domain.BulkActionUnblock,
+			Query:  "8 65 123456",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.BulkResult{{ID: 4, Status: domain.BulkStatusOK}}, response.Results)
+	})
+
+	t.Run("Segment", func(t *testing.T) {
+		location := "Mary"
+		segmentRepo := new(mocks.MockSegmentRepository)
+		segmentRepo.On("GetSegmentByID", int32(3)).Return(&domain.Segment{ID: 3, Filter: domain.UserFilter{Location: location}}, nil)
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("GetUserIDs", "", &domain.UserFilter{Location: location}, 6).Return([]int32{}, nil)
+		userRepo.On("BulkUserAction", mock.Anything).Return([]int32{}, nil)
+
+		segmentID := int32(3)
+		response, _, err := newBulkService(userRepo, segmentRepo).RunBulkAction(&domain.BulkRequest{
+			Action:    domain.BulkActionUnblock,
+			SegmentID: &segmentID,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, response.Total)
+	})
+
+	t.Run("Over the hard cap", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("GetUserIDs", "", &domain.UserFilter{}, 6).Return([]int32{1, 2, 3, 4, 5, 6}, nil)
+
+		_, _, err := newBulkService(userRepo, nil).RunBulkAction(&domain.BulkRequest{
+			Action: domain.BulkActionDelete,
+			Filter: &domain.UserFilter{},
+		})
+
+		assert.ErrorIs(t, err, errors.ErrBulkTooManyUsers)
+		userRepo.AssertNotCalled(t, "BulkUserAction", mock.Anything)
+	})
+}
+
+func TestRunBulkActionConfirmation(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("BulkUserAction", mock.Anything).Return([]int32{1, 2, 3, 4}, nil)
+	userRepo.On("UseBulkConfirmation", mock.Anything, mock.Anything).Return(true, nil).Once()
+	userRepo.On("UseBulkConfirmation", mock.Anything, mock.Anything).Return(false, nil)
+	bulkService := newBulkService(userRepo, nil)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	block := domain.BlockUserRequest{Reason: domain.BlockReasonSpam, Note: "Campaign", ExpiresAt: &expiresAt}
+	request := &domain.BulkRequest{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 4}, Block: block, ActorID: 7}
+
+	response, confirmation, err := bulkService.RunBulkAction(request)
+	assert.Equal(t, errors.ErrBulkConfirmationRequired, err)
+	assert.Nil(t, response)
+	require.NotNil(t, confirmation)
+	assert.Equal(t, 4, confirmation.Affected)
+	assert.NotEmpty(t, confirmation.Token)
+	userRepo.AssertNotCalled(t, "BulkUserAction", mock.Anything)
+
+	// The token only confirms the same operation by the same admin.
+	later := expiresAt.Add(time.Hour)
+	for _, other := range []*domain.BulkRequest{
+		{Action: domain.BulkActionDelete, IDs: []int32{1, 2, 3, 4}, ActorID: 7},
+		{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 5}, Block: block, ActorID: 7},
+		{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 4}, Block: block, ActorID: 8},
+		{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 4}, Block: domain.BlockUserRequest{Reason: domain.BlockReasonFraud, Note: "Campaign", ExpiresAt: &expiresAt}, ActorID: 7},
+		{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 4}, Block: domain.BlockUserRequest{Reason: domain.BlockReasonSpam, Note: "Other", ExpiresAt: &expiresAt}, ActorID: 7},
+		{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 4}, Block: domain.BlockUserRequest{Reason: domain.BlockReasonSpam, Note: "Campaign", ExpiresAt: &later}, ActorID: 7},
+		{Action: domain.BulkActionBlock, IDs: []int32{1, 2, 3, 4}, Block: domain.BlockUserRequest{Reason: domain.BlockReasonSpam, Note: "Campaign"}, ActorID: 7},
+	} {
+		other.ConfirmationToken = confirmation.Token
+		_, _, err := bulkService.RunBulkAction(other)
+		assert.Equal(t, errors.ErrBulkConfirmationRequired, err)
+	}
+	userRepo.AssertNotCalled(t, "UseBulkConfirmation", mock.Anything, mock.Anything)
+
+	request.ConfirmationToken = confirmation.Token
+	response, _, err = bulkService.RunBulkAction(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, response.Succeeded)
+	userRepo.AssertCalled(t, "UseBulkConfirmation", mock.AnythingOfType("string"), time.Unix(confirmation.ExpiresAt.Unix(), 0))
+
+	// The token confirms the operation once.
+	response, confirmation, err = bulkService.RunBulkAction(request)
+	assert.Equal(t, errors.ErrBulkConfirmationRequired, err)
+	assert.Nil(t, response)
+	assert.NotEqual(t, request.ConfirmationToken, confirmation.Token)
+	userRepo.AssertNumberOfCalls(t, "BulkUserAction", 1)
+}
+
+func TestRunBulkActionInvalidRequest(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	segmentID := int32(1)
+
+	testCases := []struct {
+		name        string
+		request     *domain.BulkRequest
+		expectedErr error
+	}{
+		{"Unknown action", &domain.BulkRequest{Action: "archive", IDs: []int32{1}}, errors.ErrInvalidBulkAction},
+		{"Unknown mode", &domain.BulkRequest{Action: domain.BulkActionDelete, Mode: "fast", IDs: []int32{1}}, errors.ErrInvalidBulkMode},
+		{"No target", &domain.BulkRequest{Action: domain.BulkActionDelete}, errors.ErrBulkTargetRequired},
+		{"Two targets", &domain.BulkRequest{Action: domain.BulkActionDelete, IDs: []int32{1}, SegmentID: &segmentID}, errors.ErrBulkTargetRequired},
+		{"Block without reason", &domain.BulkRequest{Action: domain.BulkActionBlock, IDs: []int32{1}}, errors.ErrInvalidBlockReason},
+		{"Block expiring in the past", &domain.BulkRequest{Action: domain.BulkActionBlock, IDs: []int32{1}, Block: domain.BlockUserRequest{Reason: domain.BlockReasonSpam, ExpiresAt: &past}}, errors.ErrInvalidBlockExpiry},
+		{"Too many IDs", &domain.BulkRequest{Action: domain.BulkActionDelete, IDs: []int32{1, 2, 3, 4, 5, 6}}, errors.ErrBulkTooManyUsers},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mocks.MockUserRepository)
+
+			_, _, err := newBulkService(userRepo, nil).RunBulkAction(tc.request)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			userRepo.AssertNotCalled(t, "BulkUserAction", mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/lib/errors"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// userCursor is the content of the opaque cursors of user listings: the sort
+// order it was issued for and the sort key of the user the next page follows
+// or, if Backward, the previous page precedes.
+type userCursor struct {
+	Sort     string   `json:"s"`
+	Key      []string `json:"k"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+func encodeUserCursor(cursor userCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor decodes a cursor issued for sort.
+func decodeUserCursor(encoded string, sort domain.Sort) (*userCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	var cursor userCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.Key) == 0 {
+		return nil, errors.ErrInvalidCursor
+	}
+	if cursor.Sort != sort.String() {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// userSortKey returns the sort key of user for sort, as expected by
+// domain.UsersKeyset: the values of the sort fields followed by the ID,
+// which ends the key wherever it appears.
+func userSortKey(user *domain.GetUserResponse, sort domain.Sort) []string {
+	key := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		if field.Field == "id" {
+			break
+		}
+
+		var value string
+		switch field.Field {
+		case "first_name":
+			value = user.FirstName
+		case "last_name":
+			value = user.LastName
+		case "registration_date":
+			value = user.RegistrationDate.Format(time.RFC3339Nano)
+		case "date_of_birth":
+			value = user.DateOfBirth.Format(time.RFC3339Nano)
+		case "location":
+			value = user.Location
+		case "gender":
+			value = user.Gender
+		case "blocked":
+			value = strconv.FormatBool(user.Blocked)
+		}
+		key = append(key, value)
+	}
+
+	return append(key, strconv.Itoa(int(user.ID)))
+}
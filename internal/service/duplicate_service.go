@@ -0,0 +1,275 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/similarity"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Weights of the signals making up a duplicate score. Signals that cannot be
+// compared are left out and the remaining weights rescaled.
+const (
+	duplicateNameWeight        = 0.4
+	duplicateEmailWeight       = 0.3
+	duplicateDateOfBirthWeight = 0.2
+	duplicateLocationWeight    = 0.1
+)
+
+type DuplicateService struct {
+	UserRepository repository.UserRepository
+	Config         config.Duplicates
+}
+
+func NewDuplicateService(userRepository repository.UserRepository, cfg config.Duplicates) *DuplicateService {
+	return &DuplicateService{
+		UserRepository: userRepository,
+		Config:         cfg,
+	}
+}
+
+// FindDuplicates returns a page of the pairs of users scoring at least
+// minScore, best first, together with the total number of such pairs. A
+// minScore of 0 selects the configured default.
+//
+// Only users sharing an email address, a name, or a birth date together with
+// the initial of a name are compared, which keeps the number of comparisons
+// far below all pairs of users. The repository forms these groups, so only
+// the matching pairs are held in memory.
+func (s *DuplicateService) FindDuplicates(minScore float64, page, pageSize int) (*domain.DuplicatesList, int, error) {
+	if minScore == 0 {
+		minScore = s.Config.MinScore
+	}
+	if minScore < 0 || minScore > 1 || math.IsNaN(minScore) {
+		return nil, 0, errors.ErrInvalidMinScore
+	}
+
+	// Users sharing several groups are compared once per group; the score is
+	// the same each time, so a matching pair is listed only once.
+	found := make(map[[2]int32]bool)
+	pairs := make([]domain.DuplicatePair, 0)
+	err := s.UserRepository.ForEachDuplicateBlock(s.Config.MaxBlockSize, func(block []domain.DuplicateProfile) error {
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+				key := [2]int32{block[i].ID, block[j].ID}
+				if found[key] {
+					continue
+				}
+
+				pair := scoreDuplicatePair(block[i], block[j])
+				if pair.Score >= minScore {
+					found[key] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].Users[0].ID != pairs[j].Users[0].ID {
+			return pairs[i].Users[0].ID < pairs[j].Users[0].ID
+		}
+		return pairs[i].Users[1].ID < pairs[j].Users[1].ID
+	})
+
+	total := len(pairs)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+
+	return &domain.DuplicatesList{Pairs: pairs[start:end]}, total, nil
+}
+
+// scoreDuplicatePair compares two users; a must have the lower ID.
+func scoreDuplicatePair(a, b domain.DuplicateProfile) domain.DuplicatePair {
+	signals := domain.DuplicateSignals{Name: nameSimilarity(a, b)}
+	weighted := duplicateNameWeight * signals.Name
+	weights := duplicateNameWeight
+
+	compare := func(signal **float64, weight, value float64) {
+		*signal = &value
+		weighted += weight * value
+		weights += weight
+	}
+
+	if emailA, emailB := comparableEmail(a.Email), comparableEmail(b.Email); emailA != "" && emailB != "" {
+		compare(&signals.Email, duplicateEmailWeight, equalScore(emailA == emailB))
+	}
+	if !a.DateOfBirth.IsZero() && !b.DateOfBirth.IsZero() {
+		compare(&signals.DateOfBirth, duplicateDateOfBirthWeight, dateOfBirthSimilarity(a, b))
+	}
+	if locationA, locationB := similarity.Normalize(a.Location), similarity.Normalize(b.Location); locationA != "" && locationB != "" {
+		compare(&signals.Location, duplicateLocationWeight, similarity.JaroWinkler(locationA, locationB))
+	}
+
+	return domain.DuplicatePair{
+		Score:   roundScore(weighted / weights),
+		Signals: roundSignals(signals),
+		Users:   []domain.DuplicateProfile{a, b},
+	}
+}
+
+// nameSimilarity compares first and last names pairwise, also with first and
+// last name swapped, as both orders are common when registering.
+func nameSimilarity(a, b domain.DuplicateProfile) float64 {
+	firstA, lastA := similarity.Normalize(a.FirstName), similarity.Normalize(a.LastName)
+	firstB, lastB := similarity.Normalize(b.FirstName), similarity.Normalize(b.LastName)
+
+	straight := (similarity.JaroWinkler(firstA, firstB) + similarity.JaroWinkler(lastA, lastB)) / 2
+	swapped := (similarity.JaroWinkler(firstA, lastB) + similarity.JaroWinkler(lastA, firstB)) / 2
+
+	return max(straight, swapped)
+}
+
+// dateOfBirthSimilarity is 1 for equal dates and 0.5 for dates that look like
+// typos of each other: day and month swapped or a single component differing.
+func dateOfBirthSimilarity(a, b domain.DuplicateProfile) float64 {
+	yearA, monthA, dayA := a.DateOfBirth.Date()
+	yearB, monthB, dayB := b.DateOfBirth.Date()
+
+	differing := 0
+	for _, equal := range []bool{yearA == yearB, monthA == monthB, dayA == dayB} {
+		if !equal {
+			differing++
+		}
+	}
+
+	switch {
+	case differing == 0:
+		return 1
+	case differing == 1, yearA == yearB && int(monthA) == dayB && dayA == int(monthB):
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// comparableEmail lower-cases an email address. Stored addresses are already
+// normalized, except for the case of older ones.
+func comparableEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func equalScore(equal bool) float64 {
+	if equal {
+		return 1
+	}
+	return 0
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
+
+func roundSignals(signals domain.DuplicateSignals) domain.DuplicateSignals {
+	signals.Name = roundScore(signals.Name)
+	for _, signal := range []*float64{signals.Email, signals.DateOfBirth, signals.Location} {
+		if signal != nil {
+			*signal = roundScore(*signal)
+		}
+	}
+	return signals
+}
+
+// MergeUsers merges request.DuplicateID into the user survivorID and returns
+// the updated survivor.
+func (s *DuplicateService) MergeUsers(survivorID int32, request *domain.MergeUsersRequest) (*domain.UpdateUserResponse, error) {
+	if request.DuplicateID == survivorID {
+		return nil, errors.ErrMergeSameUser
+	}
+
+	if err := validateMergeFields(request.Fields); err != nil {
+		return nil, err
+	}
+
+	survivor, err := s.UserRepository.GetUserByID(survivorID)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate, err := s.UserRepository.GetUserByID(request.DuplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	merge := &domain.UserMerge{
+		SurvivorID:  survivorID,
+		DuplicateID: request.DuplicateID,
+		MergedBy:    request.MergedBy,
+	}
+
+	patch := &merge.Survivor
+	for _, field := range domain.MergeFields {
+		if request.Fields[field] != domain.MergeSourceDuplicate {
+			continue
+		}
+
+		switch field {
+		case "first_name":
+			patch.FirstName = &duplicate.FirstName
+		case "last_name":
+			patch.LastName = &duplicate.LastName
+		case "gender":
+			patch.Gender = &duplicate.Gender
+		case "date_of_birth":
+			patch.DateOfBirth = &duplicate.DateOfBirth
+		case "location":
+			patch.Location = &duplicate.Location
+		case "email":
+			if duplicate.Email != survivor.Email {
+				patch.Email = &duplicate.Email
+				merge.ReleaseEmail = duplicate.Email != ""
+			}
+		case "profile_photo_url":
+			patch.ProfilePhotoURL = &duplicate.ProfilePhotoURL
+		}
+	}
+
+	for key, value := range duplicate.Attributes {
+		if _, ok := survivor.Attributes[key]; ok {
+			continue
+		}
+		if patch.Attributes == nil {
+			patch.Attributes = make(domain.UserAttributes, len(survivor.Attributes)+len(duplicate.Attributes))
+			for key, value := range survivor.Attributes {
+				patch.Attributes[key] = value
+			}
+		}
+		patch.Attributes[key] = value
+	}
+
+	return s.UserRepository.MergeUsers(merge)
+}
+
+func validateMergeFields(fields map[string]domain.MergeSource) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !containsString(domain.MergeFields, name) {
+			return fmt.Errorf("%w: %q cannot be merged", errors.ErrInvalidMergeFields, name)
+		}
+		if source := fields[name]; source != domain.MergeSourceSurvivor && source != domain.MergeSourceDuplicate {
+			return fmt.Errorf("%w: source of %q must be survivor or duplicate", errors.ErrInvalidMergeFields, name)
+		}
+	}
+
+	return nil
+}
+
+var _ service.DuplicateService = &DuplicateService{}
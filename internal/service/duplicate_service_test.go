@@ -0,0 +1,186 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var duplicatesConfig = config.Duplicates{MinScore: 0.8, MaxBlockSize: 3}
+
+func TestFindDuplicates(t *testing.T) {
+	birth := time.Date(1990, time.May, 4, 0, 0, 0, 0, time.UTC)
+	profiles := []domain.DuplicateProfile{
+		{ID: 1, FirstName: "Kemal", LastName: "Atdayew", PhoneNumber: "+99362008971", DateOfBirth: birth, Location: "Ashgabat"},
+		{ID: 2, FirstName: "Bahar", LastName: "Orazowa", PhoneNumber: "+99362008972", Email: "bahar@example.com", DateOfBirth: birth, Location: "Mary"},
+		{ID: 3, FirstName: "Kemal", LastName: "Atdaýew", PhoneNumber: "+99365112233", Email: "kemal@example.com", DateOfBirth: birth, Location: "Aşgabat"},
+		{ID: 4, FirstName: "Atdayev", LastName: "Kemal", PhoneNumber: "+99361000000", Email: "KEMAL@example.com", DateOfBirth: time.Date(1990, time.April, 5, 0, 0, 0, 0, time.UTC)},
+		{ID: 5, FirstName: "Bahar", LastName: "Orazowa", PhoneNumber: "+99362008975", Email: "orazowa@example.com", DateOfBirth: time.Date(1985, time.July, 1, 0, 0, 0, 0, time.UTC), Location: "Mary"},
+	}
+
+	// The groups the repository forms from these users: a shared email
+	// address, a shared name, the same name again with the birth date and
+	// another shared name. Users 1 and 4 share none.
+	blocks := [][]domain.DuplicateProfile{
+		{profiles[2], profiles[3]},
+		{profiles[0], profiles[2]},
+		{profiles[0], profiles[2]},
+		{profiles[1], profiles[4]},
+	}
+
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("ForEachDuplicateBlock", duplicatesConfig.MaxBlockSize, mock.Anything).Return(blocks, nil)
+	duplicateService := service.NewDuplicateService(userRepo, duplicatesConfig)
+
+	duplicates, total, err := duplicateService.FindDuplicates(0, 1, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, duplicates.Pairs, 2)
+
+	best := duplicates.Pairs[0]
+	assert.Equal(t, []int32{1, 3}, []int32{best.Users[0].ID, best.Users[1].ID})
+	assert.Equal(t, 1.0, best.Signals.Name)
+	assert.Nil(t, best.Signals.Email)
+	assert.Equal(t, 1.0, *best.Signals.DateOfBirth)
+	assert.Less(t, *best.Signals.Location, 1.0)
+	assert.InDelta(t, 0.995, best.Score, 0.001)
+
+	swapped := duplicates.Pairs[1]
+	assert.Equal(t, []int32{3, 4}, []int32{swapped.Users[0].ID, swapped.Users[1].ID})
+	assert.Equal(t, 1.0, *swapped.Signals.Email)
+	assert.Equal(t, 0.5, *swapped.Signals.DateOfBirth)
+	assert.Nil(t, swapped.Signals.Location)
+	assert.InDelta(t, 0.876, swapped.Score, 0.001)
+
+	t.Run("Lower minimum score and paging", func(t *testing.T) {
+		// Users 1 and 4 share no email, name or birth date and are never
+		// compared.
+		duplicates, total, err := duplicateService.FindDuplicates(0.5, 2, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, duplicates.Pairs, 1)
+		assert.Equal(t, int32(2), duplicates.Pairs[0].Users[0].ID)
+		assert.Equal(t, int32(5), duplicates.Pairs[0].Users[1].ID)
+	})
+
+	t.Run("Invalid minimum score", func(t *testing.T) {
+		_, _, err := duplicateService.FindDuplicates(1.5, 1, 10)
+
+		assert.Equal(t, errors.ErrInvalidMinScore, err)
+	})
+}
+
+func TestFindDuplicatesRepositoryError(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("ForEachDuplicateBlock", duplicatesConfig.MaxBlockSize, mock.Anything).Return([][]domain.DuplicateProfile{}, errors.ErrDatabaseError)
+
+	_, _, err := service.NewDuplicateService(userRepo, duplicatesConfig).FindDuplicates(0, 1, 10)
+
+	assert.Equal(t, errors.ErrDatabaseError, err)
+}
+
+func TestMergeUsers(t *testing.T) {
+	survivor := &domain.GetUserResponse{
+		ID:         1,
+		FirstName:  "Kemal",
+		LastName:   "Atdayew",
+		Email:      "",
+		Location:   "Ashgabat",
+		Attributes: domain.UserAttributes{"tier": "gold"},
+	}
+	duplicate := &domain.GetUserResponse{
+		ID:         2,
+		FirstName:  "Kemal",
+		LastName:   "Atdaýew",
+		Email:      "kemal@example.com",
+		Location:   "Mary",
+		Attributes: domain.UserAttributes{"tier": "silver", "newsletter": true},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("GetUserByID", int32(1)).Return(survivor, nil)
+		userRepo.On("GetUserByID", int32(2)).Return(duplicate, nil)
+
+		var merge *domain.UserMerge
+		userRepo.On("MergeUsers", mock.Anything).Run(func(args mock.Arguments) {
+			merge = args.Get(0).(*domain.UserMerge)
+		}).Return(&domain.UpdateUserResponse{ID: 1}, nil)
+
+		merged, err := service.NewDuplicateService(userRepo, duplicatesConfig).MergeUsers(1, &domain.MergeUsersRequest{
+			DuplicateID: 2,
+			Fields: map[string]domain.MergeSource{
+				"last_name": domain.MergeSourceDuplicate,
+				"email":     domain.MergeSourceDuplicate,
+				"location":  domain.MergeSourceSurvivor,
+			},
+			MergedBy: 7,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), merged.ID)
+		assert.Equal(t, int32(1), merge.SurvivorID)
+		assert.Equal(t, int32(2), merge.DuplicateID)
+		assert.Equal(t, int32(7), merge.MergedBy)
+		assert.True(t, merge.ReleaseEmail)
+		assert.Equal(t, "Atdaýew", *merge.Survivor.LastName)
+		assert.Equal(t, "kemal@example.com", *merge.Survivor.Email)
+		assert.Nil(t, merge.Survivor.FirstName)
+		assert.Nil(t, merge.Survivor.Location)
+		assert.Equal(t, domain.UserAttributes{"tier": "gold", "newsletter": true}, merge.Survivor.Attributes)
+	})
+
+	testCases := []struct {
+		name        string
+		request     *domain.MergeUsersRequest
+		duplicate   *domain.GetUserResponse
+		lookupErr   error
+		expectedErr error
+	}{
+		{
+			name:        "Same user",
+			request:     &domain.MergeUsersRequest{DuplicateID: 1},
+			expectedErr: errors.ErrMergeSameUser,
+		},
+		{
+			name:        "Unknown field",
+			request:     &domain.MergeUsersRequest{DuplicateID: 2, Fields: map[string]domain.MergeSource{"phone_number": domain.MergeSourceDuplicate}},
+			expectedErr: errors.ErrInvalidMergeFields,
+		},
+		{
+			name:        "Unknown source",
+			request:     &domain.MergeUsersRequest{DuplicateID: 2, Fields: map[string]domain.MergeSource{"email": "both"}},
+			expectedErr: errors.ErrInvalidMergeFields,
+		},
+		{
+			name:        "Duplicate not found",
+			request:     &domain.MergeUsersRequest{DuplicateID: 2},
+			duplicate:   (*domain.GetUserResponse)(nil),
+			lookupErr:   errors.ErrUserNotFound,
+			expectedErr: errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mocks.MockUserRepository)
+			userRepo.On("GetUserByID", int32(1)).Return(survivor, nil)
+			userRepo.On("GetUserByID", int32(2)).Return(tc.duplicate, tc.lookupErr)
+
+			_, err := service.NewDuplicateService(userRepo, duplicatesConfig).MergeUsers(1, tc.request)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			userRepo.AssertNotCalled(t, "MergeUsers", mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"admin-panel/pkg/email"
+	"admin-panel/pkg/lib/errors"
+)
+
+// normalizeEmail validates and normalizes an optional email address. An
+// empty address stays empty.
+func normalizeEmail(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	normalized, err := email.Normalize(raw)
+	if err != nil {
+		return "", errors.ErrInvalidEmail
+	}
+
+	return normalized, nil
+}
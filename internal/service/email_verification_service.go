@@ -0,0 +1,157 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/mailer"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EmailVerificationService struct {
+	EmailVerificationRepository repository.EmailVerificationRepository
+	UserRepository              repository.UserRepository
+	Mailer                      mailer.Mailer
+	Config                      config.EmailVerification
+}
+
+func NewEmailVerificationService(emailVerificationRepository repository.EmailVerificationRepository, userRepository repository.UserRepository, mailer mailer.Mailer, cfg config.EmailVerification) *EmailVerificationService {
+	return &EmailVerificationService{
+		EmailVerificationRepository: emailVerificationRepository,
+		UserRepository:              userRepository,
+		Mailer:                      mailer,
+		Config:                      cfg,
+	}
+}
+
+// SendVerification emails the user a signed, single-use link confirming
+// their current email address.
+func (s *EmailVerificationService) SendVerification(userID int32) error {
+	user, err := s.UserRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Email == "" {
+		return errors.ErrEmailMissing
+	}
+
+	if user.EmailVerified {
+		return errors.ErrEmailAlreadyVerified
+	}
+
+	expiresAt := time.Now().Add(s.Config.TokenTTL)
+	token, err := s.newToken(userID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	err = s.EmailVerificationRepository.CreateEmailVerification(&domain.EmailVerification{
+		UserID:    userID,
+		Email:     user.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimSuffix(s.Config.BaseURL, "/") + "/email/verify?token=" + url.QueryEscape(token)
+	err = s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link can be used once and expires on %s.\n",
+			user.FirstName, link, expiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		slog.Error("Error sending verification email:", utils.Err(err))
+		return fmt.Errorf("%w: %v", errors.ErrEmailDeliveryFailed, err)
+	}
+
+	return nil
+}
+
+// VerifyEmail checks the token's signature and expiry, then consumes it and
+// marks the user's email as verified.
+func (s *EmailVerificationService) VerifyEmail(token string) (*domain.UpdateUserResponse, error) {
+	expiresAt, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(expiresAt) {
+		return nil, errors.ErrVerificationLinkExpired
+	}
+
+	return s.EmailVerificationRepository.ConsumeEmailVerification(hashToken(token))
+}
+
+// newToken returns "<payload>.<signature>", both base64url encoded, where the
+// payload is "<user id>.<expiry>.<nonce>" and the signature its HMAC-SHA256.
+func (s *EmailVerificationService) newToken(userID int32, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := fmt.Sprintf("%d.%d.%s", userID, expiresAt.Unix(), hex.EncodeToString(nonce))
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign([]byte(payload))), nil
+}
+
+// parseToken verifies the token's signature and returns its expiry.
+func (s *EmailVerificationService) parseToken(token string) (time.Time, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return time.Time{}, errors.ErrInvalidVerificationLink
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return time.Time{}, errors.ErrInvalidVerificationLink
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return time.Time{}, errors.ErrInvalidVerificationLink
+	}
+
+	fields := strings.Split(string(payload), ".")
+	if len(fields) != 3 {
+		return time.Time{}, errors.ErrInvalidVerificationLink
+	}
+
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return time.Time{}, errors.ErrInvalidVerificationLink
+	}
+
+	return time.Unix(expiresAt, 0), nil
+}
+
+func (s *EmailVerificationService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(s.Config.SecretKey))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ service.EmailVerificationService = &EmailVerificationService{}
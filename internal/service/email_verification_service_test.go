@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/mailer"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var emailVerificationConfig = config.EmailVerification{
+	SecretKey: "secret",
+	TokenTTL:  time.Hour,
+	BaseURL:   "https://admin.example.com/",
+}
+
+func sendVerification(t *testing.T, cfg config.EmailVerification) (*service.EmailVerificationService, *mocks.MockEmailVerificationRepository, string, *domain.EmailVerification) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserByID", int32(1)).Return(&domain.GetUserResponse{ID: 1, FirstName: "Kemal", Email: "atdayewkemal@gmail.com"}, nil)
+
+	var created *domain.EmailVerification
+	verificationRepo := new(mocks.MockEmailVerificationRepository)
+	verificationRepo.On("CreateEmailVerification", mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(0).(*domain.EmailVerification)
+	}).Return(nil)
+
+	mail := mailer.NewMemoryMailer()
+	s := service.NewEmailVerificationService(verificationRepo, userRepo, mail, cfg)
+
+	assert.NoError(t, s.SendVerification(1))
+
+	message, ok := mail.LastMessage("atdayewkemal@gmail.com")
+	assert.True(t, ok)
+
+	link := regexp.MustCompile(`https://admin\.example\.com/email/verify\?token=\S+`).FindString(message.Body)
+	assert.NotEmpty(t, link)
+
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+
+	return s, verificationRepo, parsed.Query().Get("token"), created
+}
+
+func TestSendAndVerifyEmail(t *testing.T) {
+	s, verificationRepo, token, created := sendVerification(t, emailVerificationConfig)
+
+	assert.Equal(t, "atdayewkemal@gmail.com", created.Email)
+	assert.NotEqual(t, token, created.TokenHash, "the token must not be stored in plain text")
+
+	verificationRepo.On("ConsumeEmailVerification", created.TokenHash).Return(&domain.UpdateUserResponse{ID: 1, EmailVerified: true}, nil)
+
+	user, err := s.VerifyEmail(token)
+	assert.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+}
+
+func TestVerifyEmailRejectsBadTokens(t *testing.T) {
+	s, verificationRepo, token, _ := sendVerification(t, emailVerificationConfig)
+
+	_, err := s.VerifyEmail(token + "x")
+	assert.Equal(t, errors.ErrInvalidVerificationLink, err)
+
+	_, err = s.VerifyEmail("not-a-token")
+	assert.Equal(t, errors.ErrInvalidVerificationLink, err)
+
+	other := emailVerificationConfig
+	other.SecretKey = "another secret"
+	forger := service.NewEmailVerificationService(verificationRepo, nil, nil, other)
+	_, err = forger.VerifyEmail(token)
+	assert.Equal(t, errors.ErrInvalidVerificationLink, err)
+
+	expired := emailVerificationConfig
+	expired.TokenTTL = -time.Minute
+	s, _, token, _ = sendVerification(t, expired)
+	_, err = s.VerifyEmail(token)
+	assert.Equal(t, errors.ErrVerificationLinkExpired, err)
+
+	verificationRepo.AssertNotCalled(t, "ConsumeEmailVerification", mock.Anything)
+}
+
+func TestSendVerificationAlreadyVerified(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserByID", int32(1)).Return(&domain.GetUserResponse{ID: 1, Email: "atdayewkemal@gmail.com", EmailVerified: true}, nil)
+
+	verificationRepo := new(mocks.MockEmailVerificationRepository)
+	s := service.NewEmailVerificationService(verificationRepo, userRepo, mailer.NewMemoryMailer(), emailVerificationConfig)
+
+	assert.Equal(t, errors.ErrEmailAlreadyVerified, s.SendVerification(1))
+	verificationRepo.AssertNotCalled(t, "CreateEmailVerification", mock.Anything)
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/spreadsheet"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExportService struct {
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+}
+
+func NewExportService(userRepository repository.UserRepository, attributeRepository repository.AttributeRepository) *ExportService {
+	return &ExportService{
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+	}
+}
+
+// userEncoder writes exported users in one of the export formats.
+type userEncoder interface {
+	Encode(user *domain.GetUserResponse) error
+	Close() error
+}
+
+// ExportUsers writes every user selected by request to w as it is read from
+// the database. Invalid requests are rejected before anything is written.
+func (s *ExportService) ExportUsers(ctx context.Context, request *domain.ExportRequest, w io.Writer) error {
+	if !containsExportFormat(request.Format) {
+		return errors.ErrUnsupportedExportFormat
+	}
+
+	filter, err := normalizeUserFilter(s.AttributeRepository, request.Filter)
+	if err != nil {
+		return err
+	}
+
+	columns, err := s.exportColumns(request.Columns)
+	if err != nil {
+		return err
+	}
+
+	var encoder userEncoder
+	if request.Format == domain.ExportFormatNDJSON {
+		encoder = &ndjsonEncoder{encoder: json.NewEncoder(w), columns: columns}
+	} else {
+		writer, err := spreadsheet.NewWriter(w, spreadsheet.Format(request.Format))
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteRow(columns); err != nil {
+			return err
+		}
+		encoder = &rowEncoder{writer: writer, columns: columns}
+	}
+
+	err = s.UserRepository.ExportUsers(ctx, request.Query, filter, encoder.Encode)
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// exportColumns validates the requested columns, defaulting to
+// domain.ExportColumns.
+func (s *ExportService) exportColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return domain.ExportColumns, nil
+	}
+
+	var definitions []domain.AttributeDefinition
+	for _, column := range columns {
+		if containsString(domain.ExportColumns, column) {
+			continue
+		}
+
+		key, ok := strings.CutPrefix(column, domain.AttributeColumnPrefix)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an export column", errors.ErrInvalidExportColumns, column)
+		}
+
+		if definitions == nil {
+			var err error
+			if definitions, err = s.AttributeRepository.GetAttributeDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := findAttributeDefinition(definitions, key); !ok {
+			return nil, fmt.Errorf("%w: attribute %q is not defined", errors.ErrInvalidExportColumns, key)
+		}
+	}
+
+	return columns, nil
+}
+
+// exportValue returns the value of column for user. Dates of birth are
+// exported as YYYY-MM-DD.
+func exportValue(user *domain.GetUserResponse, column string) interface{} {
+	switch column {
+	case "id":
+		return user.ID
+	case "first_name":
+		return user.FirstName
+	case "last_name":
+		return user.LastName
+	case "phone_number":
+		return user.PhoneNumber
+	case "blocked":
+		return user.Blocked
+	case "gender":
+		return user.Gender
+	case "registration_date":
+		return user.RegistrationDate
+	case "date_of_birth":
+		return user.DateOfBirth.Format("2006-01-02")
+	case "location":
+		return user.Location
+	case "email":
+		return user.Email
+	case "profile_photo_url":
+		return user.ProfilePhotoURL
+	case "email_verified":
+		return user.EmailVerified
+	case "email_verified_at":
+		return user.EmailVerifiedAt
+	case "attributes":
+		return user.Attributes
+	case "tags":
+		return user.Tags
+	case "block_reason":
+		return user.BlockReason
+	case "block_expires_at":
+		return user.BlockExpiresAt
+	}
+
+	key := strings.TrimPrefix(column, domain.AttributeColumnPrefix)
+	return user.Attributes[key]
+}
+
+// exportCell formats an export value for a CSV or XLSX cell. Tags are
+// separated by semicolons and attribute objects written as JSON.
+func exportCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case []string:
+		return strings.Join(v, ";")
+	case domain.UserAttributes:
+		if len(v) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+
+	return fmt.Sprint(value)
+}
+
+type rowEncoder struct {
+	writer  spreadsheet.Writer
+	columns []string
+}
+
+func (e *rowEncoder) Encode(user *domain.GetUserResponse) error {
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = exportCell(exportValue(user, column))
+	}
+
+	return e.writer.WriteRow(row)
+}
+
+func (e *rowEncoder) Close() error {
+	return e.writer.Close()
+}
+
+// ndjsonEncoder writes one JSON object per user and line.
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (e *ndjsonEncoder) Encode(user *domain.GetUserResponse) error {
+	record := make(map[string]interface{}, len(e.columns))
+	for _, column := range e.columns {
+		record[column] = exportValue(user, column)
+	}
+
+	return e.encoder.Encode(record)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+func containsExportFormat(format domain.ExportFormat) bool {
+	for _, supported := range domain.ExportFormats {
+		if format == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+var _ service.ExportService = &ExportService{}
@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/spreadsheet"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func exportedUsers() []domain.GetUserResponse {
+	verifiedAt := time.Date(2024, time.February, 1, 8, 30, 0, 0, time.UTC)
+	return []domain.GetUserResponse{
+		{
+			ID:              1,
+			FirstName:       "Kemal",
+			LastName:        "Atdayew",
+			PhoneNumber:     "+99362008971",
+			DateOfBirth:     time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Location:        "Ashgabat, Turkmenistan",
+			EmailVerifiedAt: &verifiedAt,
+			Attributes:      domain.UserAttributes{"tier": "gold", "loyalty_points": float64(120)},
+			Tags:            []string{"partner", "vip"},
+		},
+		{
+			ID:          2,
+			FirstName:   "Aman",
+			PhoneNumber: "+99362008972",
+			Blocked:     true,
+		},
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   domain.ExportFormat
+		columns  []string
+		expected string
+	}{
+		{
+			name:    "CSV with selected columns",
+			format:  domain.ExportFormatCSV,
+			columns: []string{"id", "location", "date_of_birth", "email_verified_at", "tags", "attr.loyalty_points", "blocked"},
+			expected: "id,location,date_of_birth,email_verified_at,tags,attr.loyalty_points,blocked\n" +
+				"1,\"Ashgabat, Turkmenistan\",2000-01-01,2024-02-01T08:30:00Z,partner;vip,120,false\n" +
+				"2,,0001-01-01,,,,true\n",
+		},
+		{
+			name:    "JSON Lines",
+			format:  domain.ExportFormatNDJSON,
+			columns: []string{"id", "tags", "attr.tier"},
+			expected: `{"attr.tier":"gold","id":1,"tags":["partner","vip"]}` + "\n" +
+				`{"attr.tier":null,"id":2,"tags":null}` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mocks.MockUserRepository)
+			userRepo.On("ExportUsers", mock.Anything, "", (*domain.UserFilter)(nil), mock.Anything).Return(exportedUsers(), nil)
+
+			exportService := service.NewExportService(userRepo, attributeRepository())
+
+			var buf bytes.Buffer
+			err := exportService.ExportUsers(context.Background(), &domain.ExportRequest{Format: tc.format, Columns: tc.columns}, &buf)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestExportUsersXLSX(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("ExportUsers", mock.Anything, "kemal", mock.Anything, mock.Anything).Return(exportedUsers()[:1], nil)
+
+	exportService := service.NewExportService(userRepo, attributeRepository())
+
+	var buf bytes.Buffer
+	err := exportService.ExportUsers(context.Background(), &domain.ExportRequest{Format: domain.ExportFormatXLSX, Query: "kemal"}, &buf)
+	require.NoError(t, err)
+
+	rows, err := spreadsheet.Read(&buf, spreadsheet.FormatXLSX)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, domain.ExportColumns, rows[0])
+	assert.Equal(t, "Kemal", rows[1][1])
+}
+
+func TestExportUsersInvalidRequest(t *testing.T) {
+	testCases := []struct {
+		name        string
+		request     *domain.ExportRequest
+		expectedErr error
+	}{
+		{"Unknown format", &domain.ExportRequest{Format: "pdf"}, errors.ErrUnsupportedExportFormat},
+		{"Unknown column", &domain.ExportRequest{Format: domain.ExportFormatCSV, Columns: []string{"id", "password"}}, errors.ErrInvalidExportColumns},
+		{"Undefined attribute", &domain.ExportRequest{Format: domain.ExportFormatCSV, Columns: []string{"attr.color"}}, errors.ErrInvalidExportColumns},
+		{"Invalid filter", &domain.ExportRequest{Format: domain.ExportFormatCSV, Filter: &domain.UserFilter{Tags: []string{"Not A Tag"}}}, errors.ErrInvalidTagName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mocks.MockUserRepository)
+			exportService := service.NewExportService(userRepo, attributeRepository())
+
+			var buf bytes.Buffer
+			err := exportService.ExportUsers(context.Background(), tc.request, &buf)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Empty(t, buf.String())
+			userRepo.AssertNotCalled(t, "ExportUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
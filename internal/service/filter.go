@@ -0,0 +1,57 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+)
+
+// normalizeUserFilter validates filter, normalizes its tag names and converts
+// its attribute values to the types declared by their definitions.
+func normalizeUserFilter(attributeRepository repository.AttributeRepository, filter *domain.UserFilter) (*domain.UserFilter, error) {
+	if filter == nil {
+		return nil, nil
+	}
+
+	normalized := *filter
+
+	if len(filter.Tags) > 0 {
+		tags, err := normalizeTagNames(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		normalized.Tags = tags
+	}
+
+	if (filter.MinAge != nil && *filter.MinAge < 0) || (filter.MaxAge != nil && *filter.MaxAge < 0) {
+		return nil, fmt.Errorf("%w: ages must not be negative", errors.ErrInvalidFilter)
+	}
+
+	if filter.MinAge != nil && filter.MaxAge != nil && *filter.MinAge > *filter.MaxAge {
+		return nil, fmt.Errorf("%w: min_age must not exceed max_age", errors.ErrInvalidFilter)
+	}
+
+	if filter.BornAfter != nil && filter.BornBefore != nil && !filter.BornAfter.Before(*filter.BornBefore) {
+		return nil, fmt.Errorf("%w: born_after must be before born_before", errors.ErrInvalidFilter)
+	}
+
+	if filter.RegisteredAfter != nil && filter.RegisteredBefore != nil && !filter.RegisteredAfter.Before(*filter.RegisteredBefore) {
+		return nil, fmt.Errorf("%w: registered_after must be before registered_before", errors.ErrInvalidFilter)
+	}
+
+	if len(filter.Attributes) > 0 {
+		definitions, err := attributeRepository.GetAttributeDefinitions()
+		if err != nil {
+			return nil, err
+		}
+
+		typed, err := typedAttributeFilter(definitions, filter)
+		if err != nil {
+			return nil, err
+		}
+		normalized.Attributes = typed.Attributes
+	}
+
+	return &normalized, nil
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/phone"
+	"admin-panel/pkg/spreadsheet"
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// importDateLayouts are the date formats accepted in text cells.
+var importDateLayouts = []string{"2006-01-02", "02.01.2006", time.RFC3339}
+
+type ImportService struct {
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+	PhoneParser         *phone.Parser
+	Config              config.Import
+}
+
+func NewImportService(userRepository repository.UserRepository, attributeRepository repository.AttributeRepository, phoneParser *phone.Parser, cfg config.Import) *ImportService {
+	return &ImportService{
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+		PhoneParser:         phoneParser,
+		Config:              cfg,
+	}
+}
+
+// ImportUsers validates every row of a CSV or XLSX file with the rules of
+// UserService.CreateUser and, unless options.DryRun is set, writes the valid
+// rows in batches of Config.BatchSize. Invalid rows are reported, not
+// imported.
+func (s *ImportService) ImportUsers(content io.Reader, format spreadsheet.Format, options *domain.ImportOptions) (*domain.ImportReport, error) {
+	data, err := io.ReadAll(io.LimitReader(content, s.Config.MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.Config.MaxUploadSize {
+		return nil, errors.ErrImportFileTooLarge
+	}
+
+	rows, err := spreadsheet.Read(bytes.NewReader(data), format)
+	if err != nil {
+		if stderrors.Is(err, spreadsheet.ErrUnsupportedFormat) {
+			return nil, errors.ErrUnsupportedImportFormat
+		}
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidImportFile, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: header row is missing", errors.ErrInvalidImportFile)
+	}
+	if len(rows)-1 > s.Config.MaxRows {
+		return nil, fmt.Errorf("%w: at most %d rows are allowed", errors.ErrTooManyImportRows, s.Config.MaxRows)
+	}
+
+	definitions, err := s.AttributeRepository.GetAttributeDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := importColumns(rows[0], options.Mapping, definitions)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ImportReport{DryRun: options.DryRun, Errors: []domain.ImportRowError{}}
+
+	var users []domain.ImportUser
+	for i, row := range rows[1:] {
+		if isBlankRow(row) {
+			continue
+		}
+		report.TotalRows++
+
+		line := i + 2
+		user, rowErr := s.parseImportRow(row, columns, definitions, format)
+		if rowErr != nil {
+			rowErr.Row = line
+			report.Errors = append(report.Errors, *rowErr)
+			continue
+		}
+		users = append(users, domain.ImportUser{Row: line, User: *user})
+	}
+
+	users, err = s.checkImportDuplicates(users, definitions, options.UpsertByPhone, report)
+	if err != nil {
+		return nil, err
+	}
+	report.Valid = len(users)
+
+	if !options.DryRun {
+		s.writeImportBatches(users, importUpdateFields(columns), options, report)
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Row < report.Errors[j].Row
+	})
+	report.Failed = len(report.Errors)
+
+	return report, nil
+}
+
+// importColumns resolves the import field read from each column. Without a
+// mapping headers naming an import field are used and the others ignored.
+func importColumns(header []string, mapping domain.ImportMapping, definitions []domain.AttributeDefinition) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := positions[name]; !ok && name != "" {
+			positions[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	if len(mapping) == 0 {
+		for name, i := range positions {
+			if containsString(domain.ImportFields, name) || strings.HasPrefix(name, domain.AttributeColumnPrefix) {
+				columns[name] = i
+			}
+		}
+	} else {
+		for field, name := range mapping {
+			i, ok := positions[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("%w: column %q not found", errors.ErrInvalidImportMapping, name)
+			}
+			columns[field] = i
+		}
+	}
+
+	for field := range columns {
+		if containsString(domain.ImportFields, field) {
+			continue
+		}
+		key, ok := strings.CutPrefix(field, domain.AttributeColumnPrefix)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an import field", errors.ErrInvalidImportMapping, field)
+		}
+		if _, ok := findAttributeDefinition(definitions, key); !ok {
+			return nil, fmt.Errorf("%w: attribute %q is not defined", errors.ErrInvalidImportMapping, key)
+		}
+	}
+
+	if _, ok := columns["phone_number"]; !ok {
+		return nil, fmt.Errorf("%w: phone_number column is required", errors.ErrInvalidImportMapping)
+	}
+
+	return columns, nil
+}
+
+// importUpdateFields lists the fields an upsert overwrites, in the order of
+// domain.ImportFields, with "attributes" standing for all mapped attributes.
+func importUpdateFields(columns map[string]int) []string {
+	var fields []string
+	for _, field := range domain.ImportFields {
+		if _, ok := columns[field]; ok && field != "phone_number" {
+			fields = append(fields, field)
+		}
+	}
+
+	for field := range columns {
+		if strings.HasPrefix(field, domain.AttributeColumnPrefix) {
+			fields = append(fields, "attributes")
+			break
+		}
+	}
+
+	return fields
+}
+
+func (s *ImportService) parseImportRow(row []string, columns map[string]int, definitions []domain.AttributeDefinition, format spreadsheet.Format) (*domain.CreateUserRequest, *domain.ImportRowError) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	user := domain.CreateUserRequest{
+		FirstName:  cell("first_name"),
+		LastName:   cell("last_name"),
+		Gender:     cell("gender"),
+		Location:   cell("location"),
+		Attributes: domain.UserAttributes{},
+	}
+
+	phoneNumber, err := normalizePhoneNumber(s.PhoneParser, cell("phone_number"))
+	if err != nil {
+		message := errors.InvalidPhoneNumberFormat
+		if err == errors.ErrPhoneCountryNotAllowed {
+			message = errors.PhoneCountryNotAllowed
+		}
+		return nil, &domain.ImportRowError{Field: "phone_number", Message: message}
+	}
+	user.PhoneNumber = phoneNumber
+
+	if user.Email, err = normalizeEmail(cell("email")); err != nil {
+		return nil, &domain.ImportRowError{Field: "email", Message: errors.InvalidEmailFormat}
+	}
+
+	if value := cell("date_of_birth"); value != "" {
+		date, ok := parseImportDate(value, format)
+		if !ok {
+			return nil, &domain.ImportRowError{Field: "date_of_birth", Message: errors.InvalidImportDate}
+		}
+		user.DateOfBirth = date
+	}
+
+	for field := range columns {
+		key, ok := strings.CutPrefix(field, domain.AttributeColumnPrefix)
+		if !ok {
+			continue
+		}
+		value := cell(field)
+		if value == "" {
+			continue
+		}
+
+		definition, _ := findAttributeDefinition(definitions, key)
+		typed, err := parseAttributeValue(definition, value)
+		if err != nil {
+			return nil, &domain.ImportRowError{Field: field, Message: err.Error()}
+		}
+		user.Attributes[key] = typed
+	}
+
+	return &user, nil
+}
+
+// checkImportDuplicates drops the rows whose phone number or email address
+// is repeated in the file or already registered, reporting them. With
+// upsertByPhone a registered phone number marks the row as an update of
+// that user instead, unless the user was deleted or merged into another one.
+// Rows creating users must carry all required attributes.
+func (s *ImportService) checkImportDuplicates(users []domain.ImportUser, definitions []domain.AttributeDefinition, upsertByPhone bool, report *domain.ImportReport) ([]domain.ImportUser, error) {
+	var phoneNumbers, emails []string
+	phoneRows := make(map[string]bool, len(users))
+	emailRows := make(map[string]bool, len(users))
+
+	unique := users[:0]
+	for _, imported := range users {
+		user := imported.User
+		if phoneRows[user.PhoneNumber] {
+			report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Field: "phone_number", Message: errors.DuplicateImportRow})
+			continue
+		}
+		if user.Email != "" && emailRows[user.Email] {
+			report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Field: "email", Message: errors.DuplicateImportRow})
+			continue
+		}
+
+		phoneRows[user.PhoneNumber] = true
+		phoneNumbers = append(phoneNumbers, user.PhoneNumber)
+		if user.Email != "" {
+			emailRows[user.Email] = true
+			emails = append(emails, user.Email)
+		}
+		unique = append(unique, imported)
+	}
+
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	contacts, err := s.UserRepository.GetUserContacts(phoneNumbers, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	phoneOwners := make(map[string]domain.UserContact, len(contacts))
+	emailOwners := make(map[string]int32, len(contacts))
+	for _, contact := range contacts {
+		phoneOwners[contact.PhoneNumber] = contact
+		if contact.Email != "" {
+			emailOwners[contact.Email] = contact.ID
+		}
+	}
+
+	valid := unique[:0]
+	for _, imported := range unique {
+		user := imported.User
+
+		owner, registered := phoneOwners[user.PhoneNumber]
+		if registered && !upsertByPhone {
+			report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Field: "phone_number", Message: errors.PhoneNumberAlreadyInUse})
+			continue
+		}
+
+		if registered && owner.Deleted {
+			message := errors.DeletedUserPhoneNumber
+			if owner.MergedInto != nil {
+				message = fmt.Sprintf(errors.MergedUserPhoneNumber, *owner.MergedInto)
+			}
+			report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Field: "phone_number", Message: message})
+			continue
+		}
+		ownerID := owner.ID
+
+		if emailOwnerID, ok := emailOwners[user.Email]; ok && emailOwnerID != ownerID {
+			report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Field: "email", Message: errors.EmailAlreadyInUse})
+			continue
+		}
+
+		if !registered {
+			if _, err := validateAttributes(definitions, user.Attributes); err != nil {
+				report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Message: err.Error()})
+				continue
+			}
+		}
+
+		valid = append(valid, imported)
+	}
+
+	return valid, nil
+}
+
+// writeImportBatches writes users in transactions of Config.BatchSize rows.
+// A failed batch is rolled back and its rows reported; the remaining batches
+// are still written.
+func (s *ImportService) writeImportBatches(users []domain.ImportUser, fields []string, options *domain.ImportOptions, report *domain.ImportReport) {
+	batchSize := s.Config.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(users)
+	}
+
+	for start := 0; start < len(users); start += batchSize {
+		end := start + batchSize
+		if end > len(users) {
+			end = len(users)
+		}
+
+		batch := &domain.ImportBatch{
+			Users:         users[start:end],
+			Fields:        fields,
+			UpsertByPhone: options.UpsertByPhone,
+			ImportedBy:    options.ImportedBy,
+		}
+
+		result, err := s.UserRepository.ImportUsers(batch)
+		if err != nil {
+			message := errors.ImportBatchFailed
+			if stderrors.Is(err, errors.ErrPhoneNumberInUse) || stderrors.Is(err, errors.ErrEmailInUse) {
+				message += ": " + err.Error()
+			} else {
+				slog.Error("Error importing users: ", utils.Err(err))
+			}
+
+			for _, imported := range batch.Users {
+				report.Errors = append(report.Errors, domain.ImportRowError{Row: imported.Row, Message: message})
+			}
+			continue
+		}
+
+		report.Created += result.Created
+		report.Updated += result.Updated
+	}
+}
+
+// parseImportDate accepts the text formats in importDateLayouts and, for
+// XLSX files, Excel date serial numbers.
+func parseImportDate(value string, format spreadsheet.Format) (time.Time, bool) {
+	for _, layout := range importDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+
+	if format == spreadsheet.FormatXLSX {
+		return spreadsheet.ParseSerialDate(value)
+	}
+
+	return time.Time{}, false
+}
+
+func findAttributeDefinition(definitions []domain.AttributeDefinition, key string) (domain.AttributeDefinition, bool) {
+	for _, definition := range definitions {
+		if definition.Key == key {
+			return definition, true
+		}
+	}
+
+	return domain.AttributeDefinition{}, false
+}
+
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+var _ service.ImportService = &ImportService{}
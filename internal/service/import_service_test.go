@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/spreadsheet"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var importConfig = config.Import{MaxUploadSize: 1 << 20, MaxRows: 100, BatchSize: 2}
+
+const importCSV = `First_Name,Last_Name,Phone_Number,Email,Date_Of_Birth,attr.tier
+Kemal,Atdayew,+99362008971,kemal@example.com,2000-01-01,gold
+Aman,Amanov,62008972,,17.05.1999,
+Bad,Phone,12,,,
+Bad,Date,+99362008973,,yesterday,
+Bad,Tier,+99362008974,,,bronze
+Dup,Phone,+99362008971,,,
+Taken,Email,+99362008975,taken@example.com,,
+Taken,Phone,+99362008976,,,
+`
+
+func TestImportUsersDryRun(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserContacts",
+		[]string{"+99362008971", "+99362008972", "+99362008975", "+99362008976"},
+		[]string{"kemal@example.com", "taken@example.com"},
+	).Return([]domain.UserContact{
+		{ID: 5, PhoneNumber: "+99362000005", Email: "taken@example.com"},
+		{ID: 6, PhoneNumber: "+99362008976"},
+	}, nil)
+
+	importService := service.NewImportService(userRepo, attributeRepository(), phoneParser, importConfig)
+
+	report, err := importService.ImportUsers(strings.NewReader(importCSV), spreadsheet.FormatCSV, &domain.ImportOptions{DryRun: true})
+
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 8, report.TotalRows)
+	assert.Equal(t, 2, report.Valid)
+	assert.Equal(t, 6, report.Failed)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, []domain.ImportRowError{
+		{Row: 4, Field: "phone_number", Message: errors.InvalidPhoneNumberFormat},
+		{Row: 5, Field: "date_of_birth", Message: errors.InvalidImportDate},
+		{Row: 6, Field: "attr.tier", Message: `invalid attributes: "tier" must be one of gold, silver`},
+		{Row: 7, Field: "phone_number", Message: errors.DuplicateImportRow},
+		{Row: 8, Field: "email", Message: errors.EmailAlreadyInUse},
+		{Row: 9, Field: "phone_number", Message: errors.PhoneNumberAlreadyInUse},
+	}, report.Errors)
+	userRepo.AssertNotCalled(t, "ImportUsers", mock.Anything)
+}
+
+func TestImportUsersCommit(t *testing.T) {
+	csv := "phone,name,tier\n+99362008971,Kemal,gold\n+99362008972,Aman,\n+99362008973,Maral,silver\n"
+	mapping := domain.ImportMapping{"phone_number": "Phone", "first_name": "Name", "attr.tier": "Tier"}
+
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserContacts", mock.Anything, []string(nil)).
+		Return([]domain.UserContact{{ID: 2, PhoneNumber: "+99362008972"}}, nil)
+	userRepo.On("ImportUsers", mock.MatchedBy(func(batch *domain.ImportBatch) bool {
+		return len(batch.Users) == 2 && batch.Users[0].Row == 2
+	})).Return(&domain.ImportBatchResult{Created: 1, Updated: 1}, nil)
+	userRepo.On("ImportUsers", mock.MatchedBy(func(batch *domain.ImportBatch) bool {
+		return len(batch.Users) == 1 && batch.Users[0].Row == 4
+	})).Return((*domain.ImportBatchResult)(nil), stderrors.New("connection reset"))
+
+	importService := service.NewImportService(userRepo, attributeRepository(), phoneParser, importConfig)
+
+	report, err := importService.ImportUsers(strings.NewReader(csv), spreadsheet.FormatCSV, &domain.ImportOptions{
+		Mapping:       mapping,
+		UpsertByPhone: true,
+		ImportedBy:    7,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Valid)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, []domain.ImportRowError{{Row: 4, Message: errors.ImportBatchFailed}}, report.Errors)
+
+	batch := userRepo.Calls[1].Arguments.Get(0).(*domain.ImportBatch)
+	assert.Equal(t, []string{"first_name", "attributes"}, batch.Fields)
+	assert.True(t, batch.UpsertByPhone)
+	assert.Equal(t, int32(7), batch.ImportedBy)
+	assert.Equal(t, domain.UserAttributes{"tier": "gold"}, batch.Users[0].User.Attributes)
+}
+
+func TestImportUsersUpsertDeletedUser(t *testing.T) {
+	csv := "phone_number,first_name\n+99362008971,Kemal\n+99362008972,Aman\n+99362008973,Maral\n"
+
+	survivorID := int32(9)
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserContacts", mock.Anything, mock.Anything).Return([]domain.UserContact{
+		{ID: 1, PhoneNumber: "+99362008971", Deleted: true, MergedInto: &survivorID},
+		{ID: 2, PhoneNumber: "+99362008972", Deleted: true},
+		{ID: 3, PhoneNumber: "+99362008973"},
+	}, nil)
+
+	importService := service.NewImportService(userRepo, attributeRepository(), phoneParser, importConfig)
+
+	report, err := importService.ImportUsers(strings.NewReader(csv), spreadsheet.FormatCSV, &domain.ImportOptions{DryRun: true, UpsertByPhone: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Valid)
+	assert.Equal(t, []domain.ImportRowError{
+		{Row: 2, Field: "phone_number", Message: "Phone number belongs to a user merged into user 9, update that user instead"},
+		{Row: 3, Field: "phone_number", Message: errors.DeletedUserPhoneNumber},
+	}, report.Errors)
+}
+
+func TestImportUsersRequiredAttributes(t *testing.T) {
+	definitions := []domain.AttributeDefinition{{Key: "tier", Type: domain.AttributeTypeString, Required: true}}
+	csv := "phone_number,first_name\n+99362008971,Kemal\n+99362008972,Aman\n"
+
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserContacts", mock.Anything, mock.Anything).
+		Return([]domain.UserContact{{ID: 1, PhoneNumber: "+99362008971"}}, nil)
+
+	importService := service.NewImportService(userRepo, attributeRepository(definitions...), phoneParser, importConfig)
+
+	report, err := importService.ImportUsers(strings.NewReader(csv), spreadsheet.FormatCSV, &domain.ImportOptions{DryRun: true, UpsertByPhone: true})
+
+	// Updates keep the attributes they already have.
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Valid)
+	assert.Equal(t, []domain.ImportRowError{{Row: 3, Message: `invalid attributes: "tier" is required`}}, report.Errors)
+}
+
+func TestImportUsersFileErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		mapping     domain.ImportMapping
+		expectedErr error
+	}{
+		{"Empty file", "", nil, errors.ErrInvalidImportFile},
+		{"No phone column", "first_name\nKemal\n", nil, errors.ErrInvalidImportMapping},
+		{"Unknown mapped column", "a\n1\n", domain.ImportMapping{"phone_number": "phone"}, errors.ErrInvalidImportMapping},
+		{"Unknown field", "phone\n1\n", domain.ImportMapping{"phone_number": "phone", "nickname": "phone"}, errors.ErrInvalidImportMapping},
+		{"Undefined attribute", "phone_number,attr.color\n1,red\n", nil, errors.ErrInvalidImportMapping},
+		{"Too many rows", "phone_number\n1\n2\n3\n", nil, errors.ErrTooManyImportRows},
+		{"Too large", strings.Repeat("x", 65), nil, errors.ErrImportFileTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Import{MaxUploadSize: 64, MaxRows: 2, BatchSize: 10}
+			importService := service.NewImportService(new(mocks.MockUserRepository), attributeRepository(), phoneParser, cfg)
+
+			_, err := importService.ImportUsers(strings.NewReader(tc.content), spreadsheet.FormatCSV, &domain.ImportOptions{Mapping: tc.mapping})
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestImportUsersDateFormats(t *testing.T) {
+	csv := "phone_number,date_of_birth\n+99362008971,1999-05-17\n+99362008972,17.05.1999\n"
+
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserContacts", mock.Anything, mock.Anything).Return([]domain.UserContact(nil), nil)
+	userRepo.On("ImportUsers", mock.Anything).Return(&domain.ImportBatchResult{Created: 2}, nil)
+
+	importService := service.NewImportService(userRepo, attributeRepository(), phoneParser, importConfig)
+
+	report, err := importService.ImportUsers(strings.NewReader(csv), spreadsheet.FormatCSV, &domain.ImportOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	batch := userRepo.Calls[1].Arguments.Get(0).(*domain.ImportBatch)
+	expected := time.Date(1999, time.May, 17, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, expected, batch.Users[0].User.DateOfBirth)
+	assert.Equal(t, expected, batch.Users[1].User.DateOfBirth)
+}
@@ -3,11 +3,12 @@ package service
 import "admin-panel/internal/domain"
 
 type AdminService interface {
-	GetAllAdmins(page, pageSize int) (*domain.AdminsList, error)
+	GetAllAdmins(page, pageSize int, sort domain.Sort) (*domain.AdminsList, error)
 	GetTotalAdminsCount() (int, error)
 	GetAdminByID(id int32) (*domain.GetAdminResponse, error)
 	CreateAdmin(request *domain.CreateAdminRequest) (*domain.CreateAdminResponse, error)
 	UpdateAdmin(id int32, request *domain.UpdateAdminRequest) (*domain.UpdateAdminResponse, error)
-	DeleteAdmin(id int32) error
-	SearchAdmins(query string, page, pageSize int) (*domain.AdminsList, error)
+	PatchAdmin(id int32, format domain.PatchFormat, body []byte, expectedVersion *int32) (*domain.UpdateAdminResponse, error)
+	DeleteAdmin(id int32, expectedVersion *int32) error
+	SearchAdmins(query string, page, pageSize int, sort domain.Sort) (*domain.AdminsList, error)
 }
@@ -0,0 +1,10 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type AttributeService interface {
+	GetAttributeDefinitions() (*domain.AttributeDefinitionsList, error)
+	CreateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error)
+	UpdateAttributeDefinition(request *domain.AttributeDefinitionRequest) (*domain.AttributeDefinition, error)
+	DeleteAttributeDefinition(key string) error
+}
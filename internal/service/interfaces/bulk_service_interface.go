@@ -0,0 +1,7 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type BulkService interface {
+	RunBulkAction(request *domain.BulkRequest) (*domain.BulkResponse, *domain.BulkConfirmation, error)
+}
@@ -0,0 +1,8 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type DuplicateService interface {
+	FindDuplicates(minScore float64, page, pageSize int) (*domain.DuplicatesList, int, error)
+	MergeUsers(survivorID int32, request *domain.MergeUsersRequest) (*domain.UpdateUserResponse, error)
+}
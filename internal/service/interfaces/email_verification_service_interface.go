@@ -0,0 +1,8 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type EmailVerificationService interface {
+	SendVerification(userID int32) error
+	VerifyEmail(token string) (*domain.UpdateUserResponse, error)
+}
@@ -0,0 +1,11 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"context"
+	"io"
+)
+
+type ExportService interface {
+	ExportUsers(ctx context.Context, request *domain.ExportRequest, w io.Writer) error
+}
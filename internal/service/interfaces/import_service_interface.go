@@ -0,0 +1,11 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/spreadsheet"
+	"io"
+)
+
+type ImportService interface {
+	ImportUsers(content io.Reader, format spreadsheet.Format, options *domain.ImportOptions) (*domain.ImportReport, error)
+}
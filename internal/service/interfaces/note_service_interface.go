@@ -0,0 +1,11 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type NoteService interface {
+	GetNotes(userID int32, page, pageSize int, actor domain.Actor) (*domain.NotesList, int, error)
+	CreateNote(userID int32, request *domain.NoteRequest, actor domain.Actor) (*domain.Note, error)
+	UpdateNote(userID, noteID int32, request *domain.NoteRequest, actor domain.Actor) (*domain.Note, error)
+	SetNotePinned(userID, noteID int32, request *domain.PinNoteRequest, actor domain.Actor) (*domain.Note, error)
+	DeleteNote(userID, noteID int32, actor domain.Actor) error
+}
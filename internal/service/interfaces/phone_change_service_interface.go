@@ -0,0 +1,9 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type PhoneChangeService interface {
+	StartPhoneChange(userID int32, request *domain.StartPhoneChangeRequest) (*domain.PhoneChange, error)
+	VerifyPhoneChange(userID int32, request *domain.VerifyPhoneChangeRequest) (*domain.UpdateUserResponse, error)
+	ForcePhoneChange(userID int32, request *domain.ForcePhoneChangeRequest) (*domain.UpdateUserResponse, error)
+}
@@ -0,0 +1,10 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"io"
+)
+
+type PhotoService interface {
+	UploadPhoto(userID int32, content io.Reader) (*domain.UploadPhotoResponse, error)
+}
@@ -0,0 +1,12 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"io"
+)
+
+type PrivacyService interface {
+	GetUserData(id int32, actor domain.Actor) (*domain.UserDataExport, error)
+	WriteUserDataArchive(data *domain.UserDataExport, actor domain.Actor, w io.Writer) error
+	AnonymizeUser(id int32, actor domain.Actor) (*domain.UserAnonymization, error)
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"context"
+)
+
+type ReportService interface {
+	GetReports() (*domain.ReportsList, error)
+	GetReportByID(id int32) (*domain.Report, error)
+	CreateReport(request *domain.ReportRequest) (*domain.Report, error)
+	UpdateReport(id int32, request *domain.ReportRequest) (*domain.Report, error)
+	DeleteReport(id int32) error
+	RunReport(ctx context.Context, definition *domain.ReportDefinition) (*domain.ReportResult, error)
+	RunSavedReport(ctx context.Context, id int32) (*domain.ReportResult, error)
+}
@@ -0,0 +1,13 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type SegmentService interface {
+	GetSegments() (*domain.SegmentsList, error)
+	GetSegmentByID(id int32) (*domain.Segment, error)
+	CreateSegment(request *domain.SegmentRequest) (*domain.Segment, error)
+	UpdateSegment(id int32, request *domain.SegmentRequest) (*domain.Segment, error)
+	DeleteSegment(id int32) error
+	GetSegmentUsers(id int32, page, pageSize int) (*domain.UsersList, int, error)
+	CountSegmentUsers(id int32) (*domain.SegmentCount, error)
+}
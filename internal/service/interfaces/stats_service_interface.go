@@ -0,0 +1,7 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type StatsService interface {
+	GetUserStats(request *domain.UserStatsRequest) (*domain.UserStats, error)
+}
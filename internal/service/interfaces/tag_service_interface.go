@@ -0,0 +1,12 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type TagService interface {
+	GetTags() (*domain.TagsList, error)
+	CreateTag(request *domain.TagRequest) (*domain.Tag, error)
+	UpdateTag(id int32, request *domain.TagRequest) (*domain.Tag, error)
+	DeleteTag(id int32) error
+	TagUser(userID int32, request *domain.TagUserRequest) error
+	UntagUser(userID int32, tag string) error
+}
@@ -1,15 +1,26 @@
 package service
 
-import "admin-panel/internal/domain"
+import (
+	"admin-panel/internal/domain"
+	"time"
+)
 
 type UserService interface {
-	GetAllUsers(page, pageSize int) (*domain.UsersList, error)
-	GetTotalUsersCount() (int, error)
+	GetAllUsers(page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error)
+	GetTotalUsersCount(filter *domain.UserFilter) (int, error)
+	GetUsersByCursor(request *domain.UsersCursorRequest) (*domain.UsersCursorResponse, error)
 	GetUserByID(id int32) (*domain.GetUserResponse, error)
 	CreateUser(request *domain.CreateUserRequest) (*domain.CreateUserResponse, error)
 	UpdateUser(id int32, request *domain.UpdateUserRequest) (*domain.UpdateUserResponse, error)
-	DeleteUser(id int32) error
-	BlockUser(id int32) error
-	UnblockUser(id int32) error
-	SearchUsers(query string, page, pageSize int) (*domain.UsersList, error)
+	PatchUser(id int32, format domain.PatchFormat, body []byte, expectedVersion *int32, updatedBy int32) (*domain.UpdateUserResponse, error)
+	DeleteUser(id int32, expectedVersion *int32) error
+	BlockUser(id int32, request *domain.BlockUserRequest) error
+	UnblockUser(id, unblockedBy int32) error
+	GetUserBlocks(id int32) (*domain.UserBlocksList, error)
+	UnblockExpiredUsers() (int64, error)
+	SearchUsers(query string, page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error)
+	GetSearchUsersCount(query string, filter *domain.UserFilter) (int, error)
+	GetUserHistory(id int32, page, pageSize int) (*domain.UserHistory, int, error)
+	GetUserAsOf(id int32, asOf time.Time) (*domain.GetUserResponse, error)
+	RevertUser(id, version int32, request *domain.RevertUserRequest) (*domain.UpdateUserResponse, error)
 }
@@ -0,0 +1,11 @@
+package service
+
+import "admin-panel/internal/domain"
+
+type ViewService interface {
+	GetViews(actor domain.Actor) (*domain.ViewsList, error)
+	CreateView(request *domain.ViewRequest, actor domain.Actor) (*domain.View, error)
+	UpdateView(id int32, request *domain.ViewRequest, actor domain.Actor) (*domain.View, error)
+	DeleteView(id int32, actor domain.Actor) error
+	GetViewResults(id int32, page, pageSize int, actor domain.Actor) (*domain.ViewResults, int, error)
+}
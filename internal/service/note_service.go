@@ -0,0 +1,132 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxNoteLength = 10000
+
+type NoteService struct {
+	NoteRepository repository.NoteRepository
+}
+
+func NewNoteService(noteRepository repository.NoteRepository) *NoteService {
+	return &NoteService{NoteRepository: noteRepository}
+}
+
+// GetNotes returns a page of the notes on a user that the actor may read,
+// together with the total number of such notes.
+func (s *NoteService) GetNotes(userID int32, page, pageSize int, actor domain.Actor) (*domain.NotesList, int, error) {
+	notes, err := s.NoteRepository.GetNotes(userID, page, pageSize, actor.IsSuperAdmin())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.NoteRepository.CountNotes(userID, actor.IsSuperAdmin())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notes, total, nil
+}
+
+func (s *NoteService) CreateNote(userID int32, request *domain.NoteRequest, actor domain.Actor) (*domain.Note, error) {
+	normalized, err := normalizeNote(request, domain.NoteVisibilityAll, actor)
+	if err != nil {
+		return nil, err
+	}
+	normalized.AuthorID = actor.AdminID
+
+	return s.NoteRepository.CreateNote(userID, normalized)
+}
+
+// UpdateNote replaces the body and visibility of a note. Only the author may
+// edit a note; an omitted visibility keeps the current one.
+func (s *NoteService) UpdateNote(userID, noteID int32, request *domain.NoteRequest, actor domain.Actor) (*domain.Note, error) {
+	note, err := s.visibleNote(userID, noteID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.AuthorID == nil || *note.AuthorID != actor.AdminID {
+		return nil, errors.ErrNoteNotAuthor
+	}
+
+	normalized, err := normalizeNote(request, note.Visibility, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.NoteRepository.UpdateNote(userID, noteID, normalized)
+}
+
+func (s *NoteService) SetNotePinned(userID, noteID int32, request *domain.PinNoteRequest, actor domain.Actor) (*domain.Note, error) {
+	if _, err := s.visibleNote(userID, noteID, actor); err != nil {
+		return nil, err
+	}
+
+	return s.NoteRepository.SetNotePinned(userID, noteID, request.Pinned)
+}
+
+// DeleteNote deletes a note. Admins may delete their own notes; super admins
+// may delete any note.
+func (s *NoteService) DeleteNote(userID, noteID int32, actor domain.Actor) error {
+	note, err := s.visibleNote(userID, noteID, actor)
+	if err != nil {
+		return err
+	}
+
+	if !actor.IsSuperAdmin() && (note.AuthorID == nil || *note.AuthorID != actor.AdminID) {
+		return errors.ErrInsufficientPermission
+	}
+
+	return s.NoteRepository.DeleteNote(userID, noteID)
+}
+
+// visibleNote loads a note, reporting notes the actor may not read as not
+// found so that their existence is not disclosed.
+func (s *NoteService) visibleNote(userID, noteID int32, actor domain.Actor) (*domain.Note, error) {
+	note, err := s.NoteRepository.GetNoteByID(userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Visibility == domain.NoteVisibilitySuperAdmin && !actor.IsSuperAdmin() {
+		return nil, errors.ErrNoteNotFound
+	}
+
+	return note, nil
+}
+
+func normalizeNote(request *domain.NoteRequest, defaultVisibility domain.NoteVisibility, actor domain.Actor) (*domain.NoteRequest, error) {
+	normalized := *request
+
+	normalized.Body = strings.TrimSpace(request.Body)
+	if normalized.Body == "" {
+		return nil, errors.ErrNoteBodyRequired
+	}
+	if utf8.RuneCountInString(normalized.Body) > maxNoteLength {
+		return nil, errors.ErrNoteTooLong
+	}
+
+	switch normalized.Visibility {
+	case "":
+		normalized.Visibility = defaultVisibility
+	case domain.NoteVisibilityAll, domain.NoteVisibilitySuperAdmin:
+	default:
+		return nil, errors.ErrInvalidNoteVisibility
+	}
+
+	if normalized.Visibility == domain.NoteVisibilitySuperAdmin && !actor.IsSuperAdmin() {
+		return nil, errors.ErrInsufficientPermission
+	}
+
+	return &normalized, nil
+}
+
+var _ service.NoteService = &NoteService{}
@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	admin      = domain.Actor{AdminID: 7, Role: "admin"}
+	otherAdmin = domain.Actor{AdminID: 8, Role: "admin"}
+	superAdmin = domain.Actor{AdminID: 1, Role: "super_admin"}
+)
+
+func TestCreateNote(t *testing.T) {
+	testCases := []struct {
+		name            string
+		request         domain.NoteRequest
+		actor           domain.Actor
+		expectedRequest *domain.NoteRequest
+		expectedErr     error
+	}{
+		{
+			name:            "Defaults to visible to all admins",
+			request:         domain.NoteRequest{Body: "  Called on 3 Oct  "},
+			actor:           admin,
+			expectedRequest: &domain.NoteRequest{Body: "Called on 3 Oct", Visibility: domain.NoteVisibilityAll, AuthorID: 7},
+		},
+		{
+			name:            "Super admin restricts a note",
+			request:         domain.NoteRequest{Body: "ID check pending", Visibility: domain.NoteVisibilitySuperAdmin},
+			actor:           superAdmin,
+			expectedRequest: &domain.NoteRequest{Body: "ID check pending", Visibility: domain.NoteVisibilitySuperAdmin, AuthorID: 1},
+		},
+		{
+			name:        "Admin may not restrict a note",
+			request:     domain.NoteRequest{Body: "ID check pending", Visibility: domain.NoteVisibilitySuperAdmin},
+			actor:       admin,
+			expectedErr: errors.ErrInsufficientPermission,
+		},
+		{
+			name:        "Empty body",
+			request:     domain.NoteRequest{Body: " \n "},
+			actor:       admin,
+			expectedErr: errors.ErrNoteBodyRequired,
+		},
+		{
+			name:        "Body too long",
+			request:     domain.NoteRequest{Body: strings.Repeat("a", 10001)},
+			actor:       admin,
+			expectedErr: errors.ErrNoteTooLong,
+		},
+		{
+			name:        "Unknown visibility",
+			request:     domain.NoteRequest{Body: "hello", Visibility: "public"},
+			actor:       admin,
+			expectedErr: errors.ErrInvalidNoteVisibility,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			noteRepo := new(mocks.MockNoteRepository)
+			if tc.expectedRequest != nil {
+				noteRepo.On("CreateNote", int32(1), tc.expectedRequest).Return(&domain.Note{ID: 1}, nil)
+			}
+
+			noteService := service.NewNoteService(noteRepo)
+			_, err := noteService.CreateNote(1, &tc.request, tc.actor)
+
+			assert.Equal(t, tc.expectedErr, err)
+			noteRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateAndDeleteNotePermissions(t *testing.T) {
+	authorID := int32(7)
+	note := &domain.Note{ID: 3, UserID: 1, AuthorID: &authorID, Body: "old", Visibility: domain.NoteVisibilityAll}
+	restricted := &domain.Note{ID: 4, UserID: 1, AuthorID: &superAdmin.AdminID, Body: "secret", Visibility: domain.NoteVisibilitySuperAdmin}
+
+	testCases := []struct {
+		name        string
+		note        *domain.Note
+		actor       domain.Actor
+		update      bool
+		expectedErr error
+	}{
+		{name: "Author edits", note: note, actor: admin, update: true},
+		{name: "Other admin may not edit", note: note, actor: otherAdmin, update: true, expectedErr: errors.ErrNoteNotAuthor},
+		{name: "Super admin may not edit others' notes", note: note, actor: superAdmin, update: true, expectedErr: errors.ErrNoteNotAuthor},
+		{name: "Author deletes", note: note, actor: admin},
+		{name: "Super admin deletes others' notes", note: note, actor: superAdmin},
+		{name: "Other admin may not delete", note: note, actor: otherAdmin, expectedErr: errors.ErrInsufficientPermission},
+		{name: "Restricted note is hidden from admins", note: restricted, actor: admin, expectedErr: errors.ErrNoteNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			noteRepo := new(mocks.MockNoteRepository)
+			noteRepo.On("GetNoteByID", int32(1), tc.note.ID).Return(tc.note, nil)
+
+			noteService := service.NewNoteService(noteRepo)
+
+			var err error
+			if tc.update {
+				if tc.expectedErr == nil {
+					noteRepo.On("UpdateNote", int32(1), tc.note.ID, &domain.NoteRequest{Body: "new", Visibility: tc.note.Visibility}).Return(tc.note, nil)
+				}
+				_, err = noteService.UpdateNote(1, tc.note.ID, &domain.NoteRequest{Body: "new"}, tc.actor)
+			} else {
+				if tc.expectedErr == nil {
+					noteRepo.On("DeleteNote", int32(1), tc.note.ID).Return(nil)
+				}
+				err = noteService.DeleteNote(1, tc.note.ID, tc.actor)
+			}
+
+			assert.Equal(t, tc.expectedErr, err)
+			noteRepo.AssertExpectations(t)
+		})
+	}
+}
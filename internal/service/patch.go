@@ -0,0 +1,127 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/patch"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// patchChanges holds a resource document before and after a PATCH request
+// was applied, and extracts the fields that actually changed.
+type patchChanges struct {
+	before map[string]interface{}
+	after  map[string]interface{}
+}
+
+// applyPatch applies body, interpreted according to format, to the document
+// representation of resource.
+func applyPatch(resource interface{}, format domain.PatchFormat, body []byte) (*patchChanges, error) {
+	doc, err := patch.ToDocument(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched interface{}
+	switch format {
+	case domain.JSONPatchFormat:
+		patched, err = patch.Apply(doc, body)
+	default:
+		patched, err = patch.MergePatch(doc, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	before, _ := doc.(map[string]interface{})
+	after, ok := patched.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: patched document must be an object", patch.ErrInvalidPatch)
+	}
+
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			return nil, fmt.Errorf("%w: %q", errors.ErrFieldNotPatchable, field)
+		}
+	}
+
+	return &patchChanges{before: before, after: after}, nil
+}
+
+// stringField returns the new value of a string field, or nil if it did not
+// change. A null or removed nullable field is cleared to an empty string.
+func (c *patchChanges) stringField(field string, nullable bool) (*string, error) {
+	value, ok := c.after[field]
+	if !ok || value == nil {
+		if !nullable {
+			return nil, fmt.Errorf("%w: %q", errors.ErrFieldRequired, field)
+		}
+		if c.before[field] == "" {
+			return nil, nil
+		}
+		empty := ""
+		return &empty, nil
+	}
+
+	if reflect.DeepEqual(value, c.before[field]) {
+		return nil, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q must be a string", patch.ErrInvalidPatch, field)
+	}
+
+	if !nullable && str == "" {
+		return nil, fmt.Errorf("%w: %q", errors.ErrFieldRequired, field)
+	}
+
+	return &str, nil
+}
+
+// timeField returns the new value of a required RFC 3339 timestamp field, or
+// nil if it did not change.
+func (c *patchChanges) timeField(field string) (*time.Time, error) {
+	value, ok := c.after[field]
+	if !ok || value == nil {
+		return nil, fmt.Errorf("%w: %q", errors.ErrFieldRequired, field)
+	}
+
+	if reflect.DeepEqual(value, c.before[field]) {
+		return nil, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q must be an RFC 3339 timestamp", patch.ErrInvalidPatch, field)
+	}
+
+	parsed, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q must be an RFC 3339 timestamp", patch.ErrInvalidPatch, field)
+	}
+
+	return &parsed, nil
+}
+
+// attributesField returns the new custom attributes, or nil if they did not
+// change. A null or removed attributes field clears all attributes.
+func (c *patchChanges) attributesField(field string) (domain.UserAttributes, error) {
+	value := c.after[field]
+	if reflect.DeepEqual(value, c.before[field]) {
+		return nil, nil
+	}
+
+	if value == nil {
+		return domain.UserAttributes{}, nil
+	}
+
+	attributes, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %q must be an object", patch.ErrInvalidPatch, field)
+	}
+
+	return domain.UserAttributes(attributes), nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"admin-panel/pkg/email"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/phone"
+	stderrors "errors"
+	"strings"
+)
+
+// normalizePhoneNumber converts raw to E.164, translating parser failures
+// into the errors reported to API clients.
+func normalizePhoneNumber(parser *phone.Parser, raw string) (string, error) {
+	phoneNumber, err := parser.Parse(raw)
+	if err != nil {
+		if stderrors.Is(err, phone.ErrCountryNotAllowed) {
+			return "", errors.ErrPhoneCountryNotAllowed
+		}
+		return "", errors.ErrInvalidPhoneNumber
+	}
+
+	return phoneNumber, nil
+}
+
+// searchTerm converts a query that is a phone number in any accepted format
+// to its E.164 form, and one that is an email address to its canonical form,
+// as they are only matched exactly.
+func searchTerm(parser *phone.Parser, query string) string {
+	if phoneNumber, err := parser.Parse(query); err == nil {
+		return phoneNumber
+	}
+	if strings.Contains(query, "@") {
+		if address, err := email.Normalize(query); err == nil {
+			return address
+		}
+	}
+	return query
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/phone"
+	"admin-panel/pkg/sms"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+)
+
+const verificationCodeDigits = 6
+
+type PhoneChangeService struct {
+	PhoneChangeRepository repository.PhoneChangeRepository
+	UserRepository        repository.UserRepository
+	SMSGateway            sms.Gateway
+	PhoneParser           *phone.Parser
+	Config                config.PhoneChange
+}
+
+func NewPhoneChangeService(phoneChangeRepository repository.PhoneChangeRepository, userRepository repository.UserRepository, smsGateway sms.Gateway, phoneParser *phone.Parser, cfg config.PhoneChange) *PhoneChangeService {
+	return &PhoneChangeService{
+		PhoneChangeRepository: phoneChangeRepository,
+		UserRepository:        userRepository,
+		SMSGateway:            smsGateway,
+		PhoneParser:           phoneParser,
+		Config:                cfg,
+	}
+}
+
+// StartPhoneChange creates a pending phone change for the user and sends a
+// verification code to the new number.
+func (s *PhoneChangeService) StartPhoneChange(userID int32, request *domain.StartPhoneChangeRequest) (*domain.PhoneChange, error) {
+	phoneNumber, err := normalizePhoneNumber(s.PhoneParser, request.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNewPhoneNumber(user, phoneNumber); err != nil {
+		return nil, err
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.Config.CodeTTL)
+	change, err := s.PhoneChangeRepository.CreatePhoneChange(&domain.PhoneChange{
+		UserID:         userID,
+		OldPhoneNumber: user.PhoneNumber,
+		NewPhoneNumber: phoneNumber,
+		Status:         domain.PhoneChangePending,
+		RequestedBy:    &request.RequestedBy,
+		ExpiresAt:      &expiresAt,
+		CodeHash:       s.hashVerificationCode(code),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.Config.CodeTTL)
+	if err := s.SMSGateway.Send(phoneNumber, message); err != nil {
+		slog.Error("Error sending verification code:", utils.Err(err))
+		return nil, fmt.Errorf("%w: %v", errors.ErrSMSDeliveryFailed, err)
+	}
+
+	return change, nil
+}
+
+// VerifyPhoneChange commits the user's pending phone change when code matches
+// the one sent to the new number.
+func (s *PhoneChangeService) VerifyPhoneChange(userID int32, request *domain.VerifyPhoneChangeRequest) (*domain.UpdateUserResponse, error) {
+	change, err := s.PhoneChangeRepository.GetPendingPhoneChange(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.ExpiresAt != nil && time.Now().After(*change.ExpiresAt) {
+		return nil, errors.ErrPhoneChangeExpired
+	}
+
+	// The attempt is counted before the code is compared, so that concurrent
+	// guesses cannot get past MaxAttempts.
+	if err := s.PhoneChangeRepository.IncrementPhoneChangeAttempts(change.ID, s.Config.MaxAttempts); err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(s.hashVerificationCode(request.Code)), []byte(change.CodeHash)) {
+		return nil, errors.ErrInvalidVerificationCode
+	}
+
+	return s.PhoneChangeRepository.VerifyPhoneChange(change)
+}
+
+// ForcePhoneChange assigns a new phone number to the user without
+// verification. Any pending change for the user is cancelled.
+func (s *PhoneChangeService) ForcePhoneChange(userID int32, request *domain.ForcePhoneChangeRequest) (*domain.UpdateUserResponse, error) {
+	phoneNumber, err := normalizePhoneNumber(s.PhoneParser, request.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNewPhoneNumber(user, phoneNumber); err != nil {
+		return nil, err
+	}
+
+	return s.PhoneChangeRepository.ForcePhoneChange(&domain.PhoneChange{
+		UserID:         userID,
+		OldPhoneNumber: user.PhoneNumber,
+		NewPhoneNumber: phoneNumber,
+		Status:         domain.PhoneChangeForced,
+		Reason:         request.Reason,
+		RequestedBy:    &request.ForcedBy,
+	})
+}
+
+func (s *PhoneChangeService) checkNewPhoneNumber(user *domain.GetUserResponse, phoneNumber string) error {
+	if user.PhoneNumber == phoneNumber {
+		return errors.ErrPhoneNumberUnchanged
+	}
+
+	inUse, err := s.PhoneChangeRepository.IsPhoneNumberInUse(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		return errors.ErrPhoneNumberInUse
+	}
+
+	return nil
+}
+
+func generateVerificationCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < verificationCodeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
+
+// hashVerificationCode keys the hash with the secret key, as six digits
+// hashed alone are recovered by trying them all.
+func (s *PhoneChangeService) hashVerificationCode(code string) string {
+	mac := hmac.New(sha256.New, []byte(s.Config.SecretKey))
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var _ service.PhoneChangeService = &PhoneChangeService{}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/phone"
+	"admin-panel/pkg/sms"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var phoneChangeConfig = config.PhoneChange{CodeTTL: 10 * time.Minute, MaxAttempts: 3, SecretKey: "phone-change-secret"}
+
+var phoneParser, _ = phone.NewParser("TM", []string{"TM", "UZ"})
+
+func TestStartAndVerifyPhoneChange(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserByID", int32(1)).Return(&domain.GetUserResponse{ID: 1, PhoneNumber: "+99362008971"}, nil)
+
+	var pending *domain.PhoneChange
+	phoneRepo := new(mocks.MockPhoneChangeRepository)
+	phoneRepo.On("IsPhoneNumberInUse", "+99365123456").Return(false, nil)
+	phoneRepo.On("CreatePhoneChange", mock.Anything).Run(func(args mock.Arguments) {
+		pending = args.Get(0).(*domain.PhoneChange)
+	}).Return(&domain.PhoneChange{ID: 5}, nil)
+
+	gateway := sms.NewFakeGateway()
+	s := service.NewPhoneChangeService(phoneRepo, userRepo, gateway, phoneParser, phoneChangeConfig)
+
+	_, err := s.StartPhoneChange(1, &domain.StartPhoneChangeRequest{PhoneNumber: "8 65 123456", RequestedBy: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, "+99362008971", pending.OldPhoneNumber)
+	assert.Equal(t, "+99365123456", pending.NewPhoneNumber)
+	assert.Equal(t, int32(7), *pending.RequestedBy)
+	assert.NotEmpty(t, pending.CodeHash)
+
+	message, ok := gateway.LastMessage("+99365123456")
+	assert.True(t, ok)
+	code := regexp.MustCompile(`\d{6}`).FindString(message.Text)
+	assert.Len(t, code, 6)
+	assert.NotEqual(t, code, pending.CodeHash, "the code must not be stored in plain text")
+	unkeyed := sha256.Sum256([]byte(code))
+	assert.NotEqual(t, hex.EncodeToString(unkeyed[:]), pending.CodeHash, "the code hash must be keyed")
+
+	pending.ID = 5
+	phoneRepo.On("GetPendingPhoneChange", int32(1)).Return(pending, nil)
+	phoneRepo.On("IncrementPhoneChangeAttempts", int32(5), 3).Return(nil)
+	phoneRepo.On("VerifyPhoneChange", pending).Return(&domain.UpdateUserResponse{ID: 1, PhoneNumber: "+99365123456"}, nil)
+
+	user, err := s.VerifyPhoneChange(1, &domain.VerifyPhoneChangeRequest{Code: code})
+	assert.NoError(t, err)
+	assert.Equal(t, "+99365123456", user.PhoneNumber)
+}
+
+func TestStartPhoneChangeNumberInUse(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserByID", int32(1)).Return(&domain.GetUserResponse{ID: 1, PhoneNumber: "+99362008971"}, nil)
+
+	phoneRepo := new(mocks.MockPhoneChangeRepository)
+	phoneRepo.On("IsPhoneNumberInUse", "+99365123456").Return(true, nil)
+
+	gateway := sms.NewFakeGateway()
+	s := service.NewPhoneChangeService(phoneRepo, userRepo, gateway, phoneParser, phoneChangeConfig)
+
+	_, err := s.StartPhoneChange(1, &domain.StartPhoneChangeRequest{PhoneNumber: "+99365123456"})
+	assert.Equal(t, errors.ErrPhoneNumberInUse, err)
+
+	_, sent := gateway.LastMessage("+99365123456")
+	assert.False(t, sent)
+	phoneRepo.AssertNotCalled(t, "CreatePhoneChange", mock.Anything)
+}
+
+func TestVerifyPhoneChange(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Minute)
+
+	testCases := []struct {
+		name         string
+		change       *domain.PhoneChange
+		incrementErr error
+		expectedErr  error
+	}{
+		{
+			name:        "wrong code",
+			change:      &domain.PhoneChange{ID: 5, UserID: 1, ExpiresAt: &valid, CodeHash: "0000"},
+			expectedErr: errors.ErrInvalidVerificationCode,
+		},
+		{
+			name:        "expired code",
+			change:      &domain.PhoneChange{ID: 5, UserID: 1, ExpiresAt: &expired},
+			expectedErr: errors.ErrPhoneChangeExpired,
+		},
+		{
+			name:         "too many attempts",
+			change:       &domain.PhoneChange{ID: 5, UserID: 1, ExpiresAt: &valid, Attempts: 3},
+			incrementErr: errors.ErrTooManyVerificationAttempts,
+			expectedErr:  errors.ErrTooManyVerificationAttempts,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			phoneRepo := new(mocks.MockPhoneChangeRepository)
+			phoneRepo.On("GetPendingPhoneChange", int32(1)).Return(tc.change, nil)
+			phoneRepo.On("IncrementPhoneChangeAttempts", int32(5), 3).Return(tc.incrementErr)
+
+			s := service.NewPhoneChangeService(phoneRepo, new(mocks.MockUserRepository), sms.NewFakeGateway(), phoneParser, phoneChangeConfig)
+
+			_, err := s.VerifyPhoneChange(1, &domain.VerifyPhoneChangeRequest{Code: "123456"})
+
+			assert.Equal(t, tc.expectedErr, err)
+			phoneRepo.AssertNotCalled(t, "VerifyPhoneChange", mock.Anything)
+			if tc.expectedErr == errors.ErrPhoneChangeExpired {
+				phoneRepo.AssertNotCalled(t, "IncrementPhoneChangeAttempts", mock.Anything, mock.Anything)
+			} else {
+				phoneRepo.AssertCalled(t, "IncrementPhoneChangeAttempts", int32(5), 3)
+			}
+		})
+	}
+}
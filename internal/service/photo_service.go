@@ -0,0 +1,157 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	"admin-panel/pkg/imaging"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/storage"
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/google/uuid"
+)
+
+// PhotoVariant is a standard rendition produced for every uploaded photo.
+type PhotoVariant struct {
+	Name string
+	Size int
+	// Square variants are centre-cropped, the others keep the aspect ratio
+	// and only bound the longest side.
+	Square bool
+}
+
+// PhotoVariants are stored for every upload. The first one becomes the
+// user's profile_photo_url.
+var PhotoVariants = []PhotoVariant{
+	{Name: "large", Size: 1024},
+	{Name: "medium", Size: 256, Square: true},
+	{Name: "small", Size: 64, Square: true},
+}
+
+var allowedPhotoTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+const photoJPEGQuality = 85
+
+type PhotoService struct {
+	UserRepository repository.UserRepository
+	Storage        storage.Storage
+	Config         config.Photos
+}
+
+func NewPhotoService(userRepository repository.UserRepository, storage storage.Storage, cfg config.Photos) *PhotoService {
+	return &PhotoService{
+		UserRepository: userRepository,
+		Storage:        storage,
+		Config:         cfg,
+	}
+}
+
+// UploadPhoto validates the uploaded image, stores its standard renditions
+// and makes them the user's profile photo. The renditions of the previous
+// photo are removed afterwards.
+func (s *PhotoService) UploadPhoto(userID int32, content io.Reader) (*domain.UploadPhotoResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(content, s.Config.MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > s.Config.MaxUploadSize {
+		return nil, errors.ErrPhotoTooLarge
+	}
+
+	// The declared content type is not trusted; the bytes decide.
+	if !allowedPhotoTypes[http.DetectContentType(data)] {
+		return nil, errors.ErrUnsupportedPhotoType
+	}
+
+	// Check the dimensions before decoding, since decoding allocates the
+	// full bitmap.
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.ErrInvalidPhoto
+	}
+
+	if imageConfig.Width > s.Config.MaxDimension || imageConfig.Height > s.Config.MaxDimension {
+		return nil, errors.ErrPhotoTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.ErrInvalidPhoto
+	}
+
+	key := fmt.Sprintf("users/%d/photo/%s", userID, uuid.NewString())
+	response := &domain.UploadPhotoResponse{Thumbnails: make(map[string]string, len(PhotoVariants))}
+
+	for _, variant := range PhotoVariants {
+		var rendition image.Image
+		if variant.Square {
+			rendition = imaging.Thumbnail(img, variant.Size)
+		} else {
+			rendition = imaging.Fit(img, variant.Size)
+		}
+
+		var encoded bytes.Buffer
+		if err := jpeg.Encode(&encoded, rendition, &jpeg.Options{Quality: photoJPEGQuality}); err != nil {
+			return nil, err
+		}
+
+		variantKey := photoVariantKey(key, variant)
+		if err := s.Storage.Put(variantKey, &encoded, "image/jpeg"); err != nil {
+			s.deletePhoto(key)
+			return nil, fmt.Errorf("%w: %v", errors.ErrPhotoStorageFailed, err)
+		}
+
+		response.Thumbnails[variant.Name] = s.Storage.URL(variantKey)
+	}
+	response.ProfilePhotoURL = response.Thumbnails[PhotoVariants[0].Name]
+
+	previousKey, err := s.UserRepository.SetProfilePhoto(userID, &domain.ProfilePhoto{
+		URL: response.ProfilePhotoURL,
+		Key: key,
+	})
+	if err != nil {
+		s.deletePhoto(key)
+		return nil, err
+	}
+
+	if previousKey != "" {
+		s.deletePhoto(previousKey)
+	}
+
+	return response, nil
+}
+
+func (s *PhotoService) deletePhoto(key string) {
+	deletePhotoRenditions(s.Storage, key)
+}
+
+// deletePhotoRenditions removes every rendition stored below key. Failures
+// are only logged: a leftover file must not fail the request that replaced
+// it.
+func deletePhotoRenditions(storage storage.Storage, key string) {
+	for _, variant := range PhotoVariants {
+		if err := storage.Delete(photoVariantKey(key, variant)); err != nil {
+			slog.Error("Error deleting photo: ", utils.Err(err))
+		}
+	}
+}
+
+func photoVariantKey(key string, variant PhotoVariant) string {
+	return key + "/" + variant.Name + ".jpg"
+}
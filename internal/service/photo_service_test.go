@@ -0,0 +1,141 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/storage"
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var photosConfig = config.Photos{MaxUploadSize: 1 << 20, MaxDimension: 2000}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestUploadPhoto(t *testing.T) {
+	dir := t.TempDir()
+	photoStorage := storage.NewLocalStorage(dir, "http://localhost:8080/media")
+
+	var stored *domain.ProfilePhoto
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("SetProfilePhoto", int32(1), mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.ProfilePhoto)
+	}).Return("users/1/photo/old", nil)
+
+	// Renditions of the previous photo are removed after the upload.
+	oldLarge := filepath.Join(dir, "users", "1", "photo", "old", "large.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(oldLarge), 0o755))
+	require.NoError(t, os.WriteFile(oldLarge, []byte("old"), 0o644))
+
+	s := service.NewPhotoService(userRepo, photoStorage, photosConfig)
+
+	response, err := s.UploadPhoto(1, bytes.NewReader(encodePNG(t, 1600, 800)))
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(stored.Key, "users/1/photo/"))
+	assert.Equal(t, "http://localhost:8080/media/"+stored.Key+"/large.jpg", response.ProfilePhotoURL)
+	assert.Equal(t, response.ProfilePhotoURL, stored.URL)
+
+	expectedSizes := map[string]image.Point{
+		"large":  {1024, 512},
+		"medium": {256, 256},
+		"small":  {64, 64},
+	}
+	for name, size := range expectedSizes {
+		assert.Equal(t, "http://localhost:8080/media/"+stored.Key+"/"+name+".jpg", response.Thumbnails[name])
+
+		file, err := os.Open(filepath.Join(dir, filepath.FromSlash(stored.Key), name+".jpg"))
+		require.NoError(t, err)
+		cfg, err := jpeg.DecodeConfig(file)
+		file.Close()
+		require.NoError(t, err)
+		assert.Equal(t, size, image.Point{cfg.Width, cfg.Height}, name)
+	}
+
+	_, err = os.Stat(oldLarge)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUploadPhotoRejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		expectedErr error
+	}{
+		{
+			name:        "Not an image",
+			content:     []byte("%PDF-1.4 not a photo"),
+			expectedErr: errors.ErrUnsupportedPhotoType,
+		},
+		{
+			name:        "File too large",
+			content:     append(encodePNG(t, 10, 10), make([]byte, photosConfig.MaxUploadSize)...),
+			expectedErr: errors.ErrPhotoTooLarge,
+		},
+		{
+			name:        "Dimensions too large",
+			content:     encodePNG(t, 2001, 10),
+			expectedErr: errors.ErrPhotoTooLarge,
+		},
+		{
+			name:        "Truncated image",
+			content:     encodePNG(t, 10, 10)[:40],
+			expectedErr: errors.ErrInvalidPhoto,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(mocks.MockUserRepository)
+			dir := t.TempDir()
+			s := service.NewPhotoService(userRepo, storage.NewLocalStorage(dir, "http://localhost:8080/media"), photosConfig)
+
+			_, err := s.UploadPhoto(1, bytes.NewReader(tt.content))
+
+			assert.Equal(t, tt.expectedErr, err)
+			userRepo.AssertNotCalled(t, "SetProfilePhoto", mock.Anything, mock.Anything)
+
+			entries, _ := os.ReadDir(dir)
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestUploadPhotoUserNotFound(t *testing.T) {
+	dir := t.TempDir()
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("SetProfilePhoto", int32(1), mock.Anything).Return("", errors.ErrUserNotFound)
+
+	s := service.NewPhotoService(userRepo, storage.NewLocalStorage(dir, "http://localhost:8080/media"), photosConfig)
+
+	_, err := s.UploadPhoto(1, bytes.NewReader(encodePNG(t, 100, 100)))
+
+	assert.Equal(t, errors.ErrUserNotFound, err)
+
+	// The renditions stored for the missing user are cleaned up again.
+	var files []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	assert.Empty(t, files)
+}
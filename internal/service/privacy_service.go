@@ -0,0 +1,152 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/storage"
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type PrivacyService struct {
+	PrivacyRepository repository.PrivacyRepository
+	UserRepository    repository.UserRepository
+	NoteRepository    repository.NoteRepository
+	Storage           storage.Storage
+}
+
+func NewPrivacyService(privacyRepository repository.PrivacyRepository, userRepository repository.UserRepository, noteRepository repository.NoteRepository, storage storage.Storage) *PrivacyService {
+	return &PrivacyService{
+		PrivacyRepository: privacyRepository,
+		UserRepository:    userRepository,
+		NoteRepository:    noteRepository,
+		Storage:           storage,
+	}
+}
+
+// GetUserData collects everything held about a user: the profile, blocks,
+// the full field history, all notes, restricted ones included, and the
+// audit events. Only super admins may request it.
+func (s *PrivacyService) GetUserData(id int32, actor domain.Actor) (*domain.UserDataExport, error) {
+	if !actor.IsSuperAdmin() {
+		return nil, errors.ErrSuperAdminRequired
+	}
+
+	profile, err := s.UserRepository.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.UserRepository.GetUserBlocks(id)
+	if err != nil {
+		return nil, err
+	}
+
+	historyCount, err := s.UserRepository.CountUserHistory(id)
+	if err != nil {
+		return nil, err
+	}
+	history := &domain.UserHistory{Entries: make([]domain.UserHistoryEntry, 0)}
+	if historyCount > 0 {
+		if history, err = s.UserRepository.GetUserHistory(id, 1, historyCount); err != nil {
+			return nil, err
+		}
+	}
+
+	noteCount, err := s.NoteRepository.CountNotes(id, true)
+	if err != nil {
+		return nil, err
+	}
+	notes := &domain.NotesList{Notes: make([]domain.Note, 0)}
+	if noteCount > 0 {
+		if notes, err = s.NoteRepository.GetNotes(id, 1, noteCount, true); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := s.PrivacyRepository.GetUserEvents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UserDataExport{
+		Profile: profile,
+		Blocks:  blocks.Blocks,
+		History: history.Entries,
+		Notes:   notes.Notes,
+		Events:  events,
+	}, nil
+}
+
+// WriteUserDataArchive writes data as a ZIP archive with one JSON file per
+// part and a manifest.
+func (s *PrivacyService) WriteUserDataArchive(data *domain.UserDataExport, actor domain.Actor, w io.Writer) error {
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"blocks.json", data.Blocks},
+		{"history.json", data.History},
+		{"notes.json", data.Notes},
+		{"events.json", data.Events},
+	}
+
+	manifest := domain.UserDataExportManifest{
+		UserID:      data.Profile.ID,
+		GeneratedAt: time.Now().UTC(),
+		GeneratedBy: actor.AdminID,
+	}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.name)
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeArchiveJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := writeArchiveJSON(archive, file.name, file.content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeArchiveJSON(archive *zip.Writer, name string, content interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(content)
+}
+
+// AnonymizeUser irreversibly removes the personal data of a user, keeping
+// the record as a tombstone (see the repository for the fields affected),
+// and then deletes the user's photos. Only super admins may anonymize.
+func (s *PrivacyService) AnonymizeUser(id int32, actor domain.Actor) (*domain.UserAnonymization, error) {
+	if !actor.IsSuperAdmin() {
+		return nil, errors.ErrSuperAdminRequired
+	}
+
+	result, err := s.PrivacyRepository.AnonymizeUser(id, actor.AdminID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range result.PhotoKeys {
+		deletePhotoRenditions(s.Storage, key)
+	}
+
+	return result, nil
+}
+
+var _ service.PrivacyService = &PrivacyService{}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/storage"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	privacyAdmin      = domain.Actor{AdminID: 1, Role: "admin"}
+	privacySuperAdmin = domain.Actor{AdminID: 2, Role: "super_admin"}
+)
+
+func TestExportUserData(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetUserByID", int32(1)).Return(&domain.GetUserResponse{ID: 1, FirstName: "Aman"}, nil)
+	userRepo.On("GetUserBlocks", int32(1)).Return(&domain.UserBlocksList{Blocks: []domain.UserBlock{{ID: 4, UserID: 1}}}, nil)
+	userRepo.On("CountUserHistory", int32(1)).Return(2, nil)
+	userRepo.On("GetUserHistory", int32(1), 1, 2).Return(&domain.UserHistory{Entries: []domain.UserHistoryEntry{{Version: 2}, {Version: 1}}}, nil)
+	noteRepo := new(mocks.MockNoteRepository)
+	noteRepo.On("CountNotes", int32(1), true).Return(0, nil)
+	privacyRepo := new(mocks.MockPrivacyRepository)
+	privacyRepo.On("GetUserEvents", int32(1)).Return([]domain.UserEvent{{Type: "blocked"}}, nil)
+
+	s := service.NewPrivacyService(privacyRepo, userRepo, noteRepo, nil)
+
+	data, err := s.GetUserData(1, privacySuperAdmin)
+	require.NoError(t, err)
+
+	// Notes are counted including restricted ones; with none, none are fetched.
+	noteRepo.AssertNotCalled(t, "GetNotes")
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteUserDataArchive(data, privacySuperAdmin, &buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	var names []string
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+
+		files[file.Name] = content
+		names = append(names, file.Name)
+	}
+
+	assert.Equal(t, []string{"manifest.json", "profile.json", "blocks.json", "history.json", "notes.json", "events.json"}, names)
+
+	var manifest domain.UserDataExportManifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, int32(1), manifest.UserID)
+	assert.Equal(t, int32(2), manifest.GeneratedBy)
+	assert.Equal(t, names[1:], manifest.Files)
+
+	var profile domain.GetUserResponse
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, "Aman", profile.FirstName)
+
+	var history []domain.UserHistoryEntry
+	require.NoError(t, json.Unmarshal(files["history.json"], &history))
+	assert.Len(t, history, 2)
+
+	assert.JSONEq(t, `[]`, string(files["notes.json"]))
+	assert.JSONEq(t, `[{"type":"blocked","at":"0001-01-01T00:00:00Z","actor_id":null,"details":null}]`, string(files["events.json"]))
+}
+
+func TestDataSubjectRequestsRequireSuperAdmin(t *testing.T) {
+	privacyRepo := new(mocks.MockPrivacyRepository)
+	userRepo := new(mocks.MockUserRepository)
+	s := service.NewPrivacyService(privacyRepo, userRepo, new(mocks.MockNoteRepository), nil)
+
+	_, err := s.GetUserData(1, privacyAdmin)
+	assert.Equal(t, errors.ErrSuperAdminRequired, err)
+
+	_, err = s.AnonymizeUser(1, privacyAdmin)
+	assert.Equal(t, errors.ErrSuperAdminRequired, err)
+
+	userRepo.AssertNotCalled(t, "GetUserByID", int32(1))
+	privacyRepo.AssertNotCalled(t, "AnonymizeUser", int32(1), int32(1))
+}
+
+func TestAnonymizeUserDeletesPhotos(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "users", "1", "photo", "a", "large.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(photo), 0o755))
+	require.NoError(t, os.WriteFile(photo, []byte("photo"), 0o644))
+
+	privacyRepo := new(mocks.MockPrivacyRepository)
+	privacyRepo.On("AnonymizeUser", int32(1), int32(2)).Return(&domain.UserAnonymization{ID: 1, PhotoKeys: []string{"users/1/photo/a"}}, nil)
+
+	s := service.NewPrivacyService(privacyRepo, new(mocks.MockUserRepository), new(mocks.MockNoteRepository), storage.NewLocalStorage(dir, "http://localhost:8080/media"))
+
+	result, err := s.AnonymizeUser(1, privacySuperAdmin)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), result.ID)
+	assert.NoFileExists(t, photo)
+}
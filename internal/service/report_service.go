@@ -0,0 +1,358 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	maxReportDimensions   = 4
+	maxReportMeasures     = 10
+	maxReportFilters      = 20
+	maxReportFilterValues = 100
+)
+
+type ReportService struct {
+	ReportRepository repository.ReportRepository
+	Config           config.Reports
+}
+
+func NewReportService(reportRepository repository.ReportRepository, cfg config.Reports) *ReportService {
+	return &ReportService{
+		ReportRepository: reportRepository,
+		Config:           cfg,
+	}
+}
+
+func (s *ReportService) GetReports() (*domain.ReportsList, error) {
+	reports, err := s.ReportRepository.GetReports()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ReportsList{Reports: reports}, nil
+}
+
+func (s *ReportService) GetReportByID(id int32) (*domain.Report, error) {
+	return s.ReportRepository.GetReportByID(id)
+}
+
+func (s *ReportService) CreateReport(request *domain.ReportRequest) (*domain.Report, error) {
+	normalized, err := s.normalizeReport(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ReportRepository.CreateReport(normalized)
+}
+
+func (s *ReportService) UpdateReport(id int32, request *domain.ReportRequest) (*domain.Report, error) {
+	normalized, err := s.normalizeReport(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ReportRepository.UpdateReport(id, normalized)
+}
+
+func (s *ReportService) DeleteReport(id int32) error {
+	return s.ReportRepository.DeleteReport(id)
+}
+
+// RunReport runs a report definition on demand. Queries running longer than
+// Config.Timeout are cancelled and reported as ErrReportTimedOut.
+func (s *ReportService) RunReport(ctx context.Context, definition *domain.ReportDefinition) (*domain.ReportResult, error) {
+	normalized, err := s.normalizeReportDefinition(definition)
+	if err != nil {
+		return nil, err
+	}
+	if normalized.Limit == 0 {
+		normalized.Limit = s.Config.MaxRows
+	}
+
+	if s.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Config.Timeout)
+		defer cancel()
+	}
+
+	result, err := s.ReportRepository.RunReport(ctx, normalized)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.ErrReportTimedOut
+	}
+
+	return result, err
+}
+
+// RunSavedReport runs a saved report. Its definition is validated again and
+// limits saved before Config.MaxRows was lowered are capped to it.
+func (s *ReportService) RunSavedReport(ctx context.Context, id int32) (*domain.ReportResult, error) {
+	report, err := s.ReportRepository.GetReportByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := report.Definition
+	if definition.Limit > s.Config.MaxRows {
+		definition.Limit = s.Config.MaxRows
+	}
+
+	return s.RunReport(ctx, &definition)
+}
+
+func (s *ReportService) normalizeReport(request *domain.ReportRequest) (*domain.ReportRequest, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", errors.ErrInvalidReport)
+	}
+
+	definition, err := s.normalizeReportDefinition(&request.Definition)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *request
+	normalized.Name = name
+	normalized.Definition = *definition
+
+	return &normalized, nil
+}
+
+// normalizeReportDefinition checks a definition against domain.ReportFields
+// and fills in the defaults: date dimensions are grouped by day and reports
+// without measures count users. A zero limit is kept, to be replaced by
+// Config.MaxRows when the report runs. Filter values are converted to the
+// types of their fields.
+func (s *ReportService) normalizeReportDefinition(definition *domain.ReportDefinition) (*domain.ReportDefinition, error) {
+	if len(definition.Dimensions) > maxReportDimensions {
+		return nil, fmt.Errorf("%w: at most %d dimensions are allowed", errors.ErrInvalidReport, maxReportDimensions)
+	}
+	if len(definition.Measures) > maxReportMeasures {
+		return nil, fmt.Errorf("%w: at most %d measures are allowed", errors.ErrInvalidReport, maxReportMeasures)
+	}
+	if len(definition.Filters) > maxReportFilters {
+		return nil, fmt.Errorf("%w: at most %d filters are allowed", errors.ErrInvalidReport, maxReportFilters)
+	}
+
+	normalized := domain.ReportDefinition{
+		Dimensions: make([]domain.ReportDimension, 0, len(definition.Dimensions)),
+		Measures:   make([]domain.ReportMeasure, 0, len(definition.Measures)),
+		Filters:    make([]domain.ReportFilter, 0, len(definition.Filters)),
+		Limit:      definition.Limit,
+	}
+
+	for _, dimension := range definition.Dimensions {
+		fieldType, ok := domain.ReportFields[dimension.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, dimension.Field)
+		}
+
+		if fieldType == domain.ReportFieldDate {
+			if dimension.Bucket == "" {
+				dimension.Bucket = domain.ReportBucketDay
+			}
+			if !containsReportBucket(dimension.Bucket) {
+				return nil, fmt.Errorf("%w: bucket must be day, week, month, quarter or year", errors.ErrInvalidReport)
+			}
+		} else if dimension.Bucket != "" {
+			return nil, fmt.Errorf("%w: %q is not a date and cannot be bucketed", errors.ErrInvalidReport, dimension.Field)
+		}
+
+		normalized.Dimensions = append(normalized.Dimensions, dimension)
+	}
+
+	if len(definition.Measures) == 0 {
+		normalized.Measures = append(normalized.Measures, domain.ReportMeasure{Function: domain.ReportCount})
+	}
+	for _, measure := range definition.Measures {
+		if err := checkReportMeasure(measure); err != nil {
+			return nil, err
+		}
+		normalized.Measures = append(normalized.Measures, measure)
+	}
+
+	columns := normalized.Columns()
+	for i, column := range columns {
+		if containsString(columns[:i], column) {
+			return nil, fmt.Errorf("%w: column %q is listed more than once", errors.ErrInvalidReport, column)
+		}
+	}
+
+	for _, filter := range definition.Filters {
+		normalizedFilter, err := normalizeReportFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		normalized.Filters = append(normalized.Filters, normalizedFilter)
+	}
+
+	sort := domain.ParseSort(definition.Sort)
+	if err := validateSort(sort, columns); err != nil {
+		return nil, err
+	}
+	normalized.Sort = sort.String()
+
+	if normalized.Limit < 0 || normalized.Limit > s.Config.MaxRows {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", errors.ErrInvalidReport, s.Config.MaxRows)
+	}
+
+	return &normalized, nil
+}
+
+func checkReportMeasure(measure domain.ReportMeasure) error {
+	if measure.Function == domain.ReportCount {
+		if measure.Field != "" {
+			return fmt.Errorf("%w: count takes no field, use count_distinct", errors.ErrInvalidReport)
+		}
+		return nil
+	}
+
+	fieldType, ok := domain.ReportFields[measure.Field]
+	if !ok {
+		return fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, measure.Field)
+	}
+
+	switch measure.Function {
+	case domain.ReportCountDistinct:
+		return nil
+	case domain.ReportMin, domain.ReportMax:
+		if fieldType == domain.ReportFieldDate || fieldType == domain.ReportFieldNumber {
+			return nil
+		}
+	case domain.ReportAvg, domain.ReportSum:
+		if fieldType == domain.ReportFieldNumber {
+			return nil
+		}
+	default:
+		return fmt.Errorf("%w: unknown function %q", errors.ErrInvalidReport, measure.Function)
+	}
+
+	return fmt.Errorf("%w: %s does not apply to %q", errors.ErrInvalidReport, measure.Function, measure.Field)
+}
+
+// normalizeReportFilter checks the operator of filter against its field and
+// converts its value: strings are lowercased, dates parsed and lists of
+// values turned into typed slices.
+func normalizeReportFilter(filter domain.ReportFilter) (domain.ReportFilter, error) {
+	fieldType, ok := domain.ReportFields[filter.Field]
+	if !ok {
+		return domain.ReportFilter{}, fmt.Errorf("%w: unknown field %q", errors.ErrInvalidReport, filter.Field)
+	}
+
+	if !containsReportOperator(domain.ReportOperators[fieldType], filter.Operator) {
+		return domain.ReportFilter{}, fmt.Errorf("%w: operator %q does not apply to %q", errors.ErrInvalidReport, filter.Operator, filter.Field)
+	}
+
+	if filter.Operator != domain.ReportIn && filter.Operator != domain.ReportNotIn {
+		value, err := reportFilterValue(fieldType, filter.Value)
+		if err != nil {
+			return domain.ReportFilter{}, fmt.Errorf("%w: %q %v", errors.ErrInvalidReport, filter.Field, err)
+		}
+		filter.Value = value
+		return filter, nil
+	}
+
+	var values []interface{}
+	switch v := filter.Value.(type) {
+	case []interface{}:
+		values = v
+	case []string:
+		for _, value := range v {
+			values = append(values, value)
+		}
+	case []float64:
+		for _, value := range v {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 || len(values) > maxReportFilterValues {
+		return domain.ReportFilter{}, fmt.Errorf("%w: %s on %q takes a list of 1 to %d values", errors.ErrInvalidReport, filter.Operator, filter.Field, maxReportFilterValues)
+	}
+
+	strs := make([]string, 0, len(values))
+	numbers := make([]float64, 0, len(values))
+	for _, v := range values {
+		value, err := reportFilterValue(fieldType, v)
+		if err != nil {
+			return domain.ReportFilter{}, fmt.Errorf("%w: %q %v", errors.ErrInvalidReport, filter.Field, err)
+		}
+		switch value := value.(type) {
+		case string:
+			strs = append(strs, value)
+		case float64:
+			numbers = append(numbers, value)
+		}
+	}
+
+	if fieldType == domain.ReportFieldString {
+		filter.Value = strs
+	} else {
+		filter.Value = numbers
+	}
+	return filter, nil
+}
+
+// reportFilterValue converts a single filter value to the type of its field.
+// Numbers must be whole, as age is in whole years.
+func reportFilterValue(fieldType domain.ReportFieldType, value interface{}) (interface{}, error) {
+	switch fieldType {
+	case domain.ReportFieldString:
+		if str, ok := value.(string); ok {
+			return strings.ToLower(strings.TrimSpace(str)), nil
+		}
+		return nil, fmt.Errorf("must be a string")
+	case domain.ReportFieldBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("must be true or false")
+	case domain.ReportFieldNumber:
+		if number, ok := value.(float64); ok && number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return number, nil
+		}
+		return nil, fmt.Errorf("must be a whole number")
+	case domain.ReportFieldDate:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			if date, err := time.Parse("2006-01-02", v); err == nil {
+				return date, nil
+			}
+			if date, err := time.Parse(time.RFC3339, v); err == nil {
+				return date, nil
+			}
+		}
+		return nil, fmt.Errorf("must be a date in YYYY-MM-DD format or an RFC 3339 timestamp")
+	}
+
+	return nil, fmt.Errorf("has an unsupported type")
+}
+
+func containsReportBucket(bucket domain.ReportBucket) bool {
+	for _, b := range domain.ReportBuckets {
+		if b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+func containsReportOperator(operators []domain.ReportOperator, operator domain.ReportOperator) bool {
+	for _, o := range operators {
+		if o == operator {
+			return true
+		}
+	}
+	return false
+}
+
+var _ service.ReportService = &ReportService{}
@@ -0,0 +1,167 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var reportsConfig = config.Reports{MaxRows: 100}
+
+func TestRunReportNormalizesDefinition(t *testing.T) {
+	reportRepo := new(mocks.MockReportRepository)
+	expected := &domain.ReportDefinition{
+		Dimensions: []domain.ReportDimension{{Field: "location"}, {Field: "registration_date", Bucket: domain.ReportBucketDay}},
+		Measures:   []domain.ReportMeasure{{Function: domain.ReportCount}},
+		Filters: []domain.ReportFilter{
+			{Field: "registration_date", Operator: domain.ReportGte, Value: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+			{Field: "gender", Operator: domain.ReportIn, Value: []string{"female", "male"}},
+			{Field: "age", Operator: domain.ReportLt, Value: float64(65)},
+		},
+		Sort:  "-count,location",
+		Limit: 100,
+	}
+	reportRepo.On("RunReport", mock.Anything, expected).Return(&domain.ReportResult{}, nil)
+
+	_, err := service.NewReportService(reportRepo, reportsConfig).RunReport(context.Background(), &domain.ReportDefinition{
+		Dimensions: []domain.ReportDimension{{Field: "location"}, {Field: "registration_date"}},
+		Filters: []domain.ReportFilter{
+			{Field: "registration_date", Operator: domain.ReportGte, Value: "2024-04-01"},
+			{Field: "gender", Operator: domain.ReportIn, Value: []interface{}{" Female", "MALE"}},
+			{Field: "age", Operator: domain.ReportLt, Value: float64(65)},
+		},
+		Sort: " -count, location",
+	})
+
+	require.NoError(t, err)
+	reportRepo.AssertExpectations(t)
+}
+
+func TestRunReportValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		definition  domain.ReportDefinition
+		expectedErr error
+	}{
+		{
+			name:        "Unknown dimension",
+			definition:  domain.ReportDefinition{Dimensions: []domain.ReportDimension{{Field: "phone_number"}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Bucket on a string",
+			definition:  domain.ReportDefinition{Dimensions: []domain.ReportDimension{{Field: "gender", Bucket: domain.ReportBucketMonth}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Unknown bucket",
+			definition:  domain.ReportDefinition{Dimensions: []domain.ReportDimension{{Field: "date_of_birth", Bucket: "decade"}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Average of a string",
+			definition:  domain.ReportDefinition{Measures: []domain.ReportMeasure{{Function: domain.ReportAvg, Field: "location"}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Duplicate column",
+			definition:  domain.ReportDefinition{Measures: []domain.ReportMeasure{{Function: domain.ReportCount}, {Function: domain.ReportCount}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Operator not applying to booleans",
+			definition:  domain.ReportDefinition{Filters: []domain.ReportFilter{{Field: "blocked", Operator: domain.ReportGt, Value: true}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Value of the wrong type",
+			definition:  domain.ReportDefinition{Filters: []domain.ReportFilter{{Field: "registration_date", Operator: domain.ReportLt, Value: "Q2"}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Fractional age",
+			definition:  domain.ReportDefinition{Filters: []domain.ReportFilter{{Field: "age", Operator: domain.ReportEq, Value: 30.5}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Empty list",
+			definition:  domain.ReportDefinition{Filters: []domain.ReportFilter{{Field: "location", Operator: domain.ReportIn, Value: []interface{}{}}}},
+			expectedErr: errors.ErrInvalidReport,
+		},
+		{
+			name:        "Sort on a missing column",
+			definition:  domain.ReportDefinition{Sort: "location"},
+			expectedErr: errors.ErrInvalidSort,
+		},
+		{
+			name:        "Limit above the maximum",
+			definition:  domain.ReportDefinition{Limit: 101},
+			expectedErr: errors.ErrInvalidReport,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reportRepo := new(mocks.MockReportRepository)
+
+			_, err := service.NewReportService(reportRepo, reportsConfig).RunReport(context.Background(), &tc.definition)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			reportRepo.AssertNotCalled(t, "RunReport", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRunSavedReport(t *testing.T) {
+	t.Run("Limit is capped", func(t *testing.T) {
+		reportRepo := new(mocks.MockReportRepository)
+		reportRepo.On("GetReportByID", int32(3)).Return(&domain.Report{ID: 3, Definition: domain.ReportDefinition{Limit: 5000}}, nil)
+		reportRepo.On("RunReport", mock.Anything, mock.MatchedBy(func(definition *domain.ReportDefinition) bool {
+			return definition.Limit == 100
+		})).Return(&domain.ReportResult{}, nil)
+
+		_, err := service.NewReportService(reportRepo, reportsConfig).RunSavedReport(context.Background(), 3)
+
+		require.NoError(t, err)
+		reportRepo.AssertExpectations(t)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		reportRepo := new(mocks.MockReportRepository)
+		reportRepo.On("GetReportByID", int32(3)).Return(&domain.Report{ID: 3}, nil)
+		reportRepo.On("RunReport", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+			Return((*domain.ReportResult)(nil), context.DeadlineExceeded)
+
+		_, err := service.NewReportService(reportRepo, config.Reports{MaxRows: 100, Timeout: time.Millisecond}).RunSavedReport(context.Background(), 3)
+
+		assert.Equal(t, errors.ErrReportTimedOut, err)
+	})
+}
+
+func TestCreateReportKeepsDefaultLimit(t *testing.T) {
+	reportRepo := new(mocks.MockReportRepository)
+	reportRepo.On("CreateReport", mock.MatchedBy(func(request *domain.ReportRequest) bool {
+		return request.Name == "Blocked by location" && request.Definition.Limit == 0 && len(request.Definition.Measures) == 1
+	})).Return(&domain.Report{ID: 1}, nil)
+
+	reportService := service.NewReportService(reportRepo, reportsConfig)
+	_, err := reportService.CreateReport(&domain.ReportRequest{
+		Name:       " Blocked by location ",
+		Definition: domain.ReportDefinition{Dimensions: []domain.ReportDimension{{Field: "location"}}},
+	})
+	require.NoError(t, err)
+	reportRepo.AssertExpectations(t)
+
+	_, err = reportService.CreateReport(&domain.ReportRequest{Name: " "})
+	assert.ErrorIs(t, err, errors.ErrInvalidReport)
+}
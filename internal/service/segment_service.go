@@ -0,0 +1,116 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"strings"
+)
+
+type SegmentService struct {
+	SegmentRepository   repository.SegmentRepository
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+}
+
+func NewSegmentService(segmentRepository repository.SegmentRepository, userRepository repository.UserRepository, attributeRepository repository.AttributeRepository) *SegmentService {
+	return &SegmentService{
+		SegmentRepository:   segmentRepository,
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+	}
+}
+
+func (s *SegmentService) GetSegments() (*domain.SegmentsList, error) {
+	segments, err := s.SegmentRepository.GetSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SegmentsList{Segments: segments}, nil
+}
+
+func (s *SegmentService) GetSegmentByID(id int32) (*domain.Segment, error) {
+	return s.SegmentRepository.GetSegmentByID(id)
+}
+
+func (s *SegmentService) CreateSegment(request *domain.SegmentRequest) (*domain.Segment, error) {
+	normalized, err := s.normalizeSegment(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SegmentRepository.CreateSegment(normalized)
+}
+
+func (s *SegmentService) UpdateSegment(id int32, request *domain.SegmentRequest) (*domain.Segment, error) {
+	normalized, err := s.normalizeSegment(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SegmentRepository.UpdateSegment(id, normalized)
+}
+
+func (s *SegmentService) DeleteSegment(id int32) error {
+	return s.SegmentRepository.DeleteSegment(id)
+}
+
+// GetSegmentUsers returns a page of the users currently matching the segment
+// together with the total number of matching users.
+func (s *SegmentService) GetSegmentUsers(id int32, page, pageSize int) (*domain.UsersList, int, error) {
+	segment, err := s.SegmentRepository.GetSegmentByID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := s.UserRepository.GetAllUsers(page, pageSize, &segment.Filter, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.UserRepository.GetTotalUsersCount(&segment.Filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (s *SegmentService) CountSegmentUsers(id int32) (*domain.SegmentCount, error) {
+	segment, err := s.SegmentRepository.GetSegmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.UserRepository.GetTotalUsersCount(&segment.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SegmentCount{ID: id, Count: count}, nil
+}
+
+// normalizeSegment validates the segment and stores its filter in normalized
+// form, so that evaluating it later needs no further conversion.
+func (s *SegmentService) normalizeSegment(request *domain.SegmentRequest) (*domain.SegmentRequest, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", errors.ErrInvalidSegment)
+	}
+
+	filter, err := normalizeUserFilter(s.AttributeRepository, &request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *request
+	normalized.Name = name
+	normalized.Filter = *filter
+
+	return &normalized, nil
+}
+
+var _ service.SegmentService = &SegmentService{}
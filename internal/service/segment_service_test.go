@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSegment(t *testing.T) {
+	minAge, maxAge := 30, 20
+
+	testCases := []struct {
+		name        string
+		request     domain.SegmentRequest
+		expectedErr error
+	}{
+		{
+			name:    "Valid segment",
+			request: domain.SegmentRequest{Name: " VIPs ", Filter: domain.UserFilter{Tags: []string{"VIP"}}},
+		},
+		{
+			name:        "Missing name",
+			request:     domain.SegmentRequest{Name: " "},
+			expectedErr: errors.ErrInvalidSegment,
+		},
+		{
+			name:        "Inverted age range",
+			request:     domain.SegmentRequest{Name: "Adults", Filter: domain.UserFilter{MinAge: &minAge, MaxAge: &maxAge}},
+			expectedErr: errors.ErrInvalidFilter,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			segmentRepo := new(mocks.MockSegmentRepository)
+			if tc.expectedErr == nil {
+				segmentRepo.On("CreateSegment", mock.MatchedBy(func(request *domain.SegmentRequest) bool {
+					return request.Name == "VIPs" && assert.ObjectsAreEqual([]string{"vip"}, request.Filter.Tags)
+				})).Return(&domain.Segment{ID: 1, Name: "VIPs"}, nil)
+			}
+
+			segmentService := service.NewSegmentService(segmentRepo, new(mocks.MockUserRepository), attributeRepository())
+			_, err := segmentService.CreateSegment(&tc.request)
+
+			assert.True(t, stderrors.Is(err, tc.expectedErr), "unexpected error: %v", err)
+			segmentRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCountSegmentUsers(t *testing.T) {
+	blocked := false
+	filter := domain.UserFilter{Tags: []string{"vip"}, Blocked: &blocked}
+
+	segmentRepo := new(mocks.MockSegmentRepository)
+	segmentRepo.On("GetSegmentByID", int32(3)).Return(&domain.Segment{ID: 3, Name: "VIPs", Filter: filter}, nil)
+
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetTotalUsersCount", &filter).Return(12, nil)
+
+	segmentService := service.NewSegmentService(segmentRepo, userRepo, attributeRepository())
+	count, err := segmentService.CountSegmentUsers(3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.SegmentCount{ID: 3, Count: 12}, count)
+	userRepo.AssertExpectations(t)
+}
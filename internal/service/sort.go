@@ -0,0 +1,24 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+)
+
+// validateSort checks that sort only orders by the given fields, each at most
+// once.
+func validateSort(sort domain.Sort, fields []string) error {
+	seen := make(map[string]bool, len(sort))
+	for _, field := range sort {
+		if !containsString(fields, field.Field) {
+			return fmt.Errorf("%w: %q is not sortable", errors.ErrInvalidSort, field.Field)
+		}
+		if seen[field.Field] {
+			return fmt.Errorf("%w: %q is listed more than once", errors.ErrInvalidSort, field.Field)
+		}
+		seen[field.Field] = true
+	}
+
+	return nil
+}
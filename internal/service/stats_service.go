@@ -0,0 +1,278 @@
+package service
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	// Time zones are resolved without relying on the host's zoneinfo.
+	_ "time/tzdata"
+)
+
+const (
+	// defaultStatsDays is the number of days up to today covered when a
+	// request names no start date.
+	defaultStatsDays = 30
+	// maxStatsBuckets bounds the length of the registrations series.
+	maxStatsBuckets = 1000
+
+	defaultTopLocations = 10
+	maxTopLocations     = 100
+)
+
+// ageBuckets group users by age in whole years; max is included.
+var ageBuckets = []struct {
+	label    string
+	min, max int
+}{
+	{"under 18", 0, 17},
+	{"18-24", 18, 24},
+	{"25-34", 25, 34},
+	{"35-44", 35, 44},
+	{"45-54", 45, 54},
+	{"55-64", 55, 64},
+	{"65+", 65, math.MaxInt},
+}
+
+type StatsService struct {
+	StatsRepository repository.StatsRepository
+	Config          config.Stats
+
+	mu    sync.Mutex
+	cache map[statsCacheKey]cachedStats
+}
+
+// statsCacheKey identifies a normalized request.
+type statsCacheKey struct {
+	from, to     string
+	interval     domain.StatsInterval
+	timeZone     string
+	topLocations int
+}
+
+type cachedStats struct {
+	stats   *domain.UserStats
+	expires time.Time
+}
+
+func NewStatsService(statsRepository repository.StatsRepository, cfg config.Stats) *StatsService {
+	return &StatsService{
+		StatsRepository: statsRepository,
+		Config:          cfg,
+		cache:           make(map[statsCacheKey]cachedStats),
+	}
+}
+
+// GetUserStats returns registrations per day, week or month over the
+// requested range together with breakdowns of all current users. Without a
+// range the last 30 days up to today are covered. Results are cached for
+// Config.CacheTTL, so they may lag behind by as much.
+func (s *StatsService) GetUserStats(request *domain.UserStatsRequest) (*domain.UserStats, error) {
+	timeZone := request.TimeZone
+	if timeZone == "" {
+		timeZone = s.Config.TimeZone
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil || timeZone == "" {
+		return nil, fmt.Errorf("%w: unknown time zone %q", errors.ErrInvalidStatsRequest, timeZone)
+	}
+
+	interval := request.Interval
+	if interval == "" {
+		interval = domain.StatsIntervalDay
+	}
+	if !containsStatsInterval(interval) {
+		return nil, fmt.Errorf("%w: interval must be day, week or month", errors.ErrInvalidStatsRequest)
+	}
+
+	topLocations := request.TopLocations
+	if topLocations == 0 {
+		topLocations = defaultTopLocations
+	}
+	if topLocations < 0 || topLocations > maxTopLocations {
+		return nil, fmt.Errorf("%w: top_locations must be between 1 and %d", errors.ErrInvalidStatsRequest, maxTopLocations)
+	}
+
+	today := dateIn(time.Now(), location)
+	to := today
+	if !request.To.IsZero() {
+		to = dateIn(request.To, location)
+	}
+	from := to.AddDate(0, 0, -(defaultStatsDays - 1))
+	if !request.From.IsZero() {
+		from = dateIn(request.From, location)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from must not be after to", errors.ErrInvalidStatsRequest)
+	}
+
+	buckets := statsBuckets(from, to, interval)
+	if len(buckets) > maxStatsBuckets {
+		return nil, fmt.Errorf("%w: the range spans more than %d %ss", errors.ErrInvalidStatsRequest, maxStatsBuckets, interval)
+	}
+
+	key := statsCacheKey{
+		from:         from.Format("2006-01-02"),
+		to:           to.Format("2006-01-02"),
+		interval:     interval,
+		timeZone:     location.String(),
+		topLocations: topLocations,
+	}
+	if stats := s.cached(key); stats != nil {
+		return stats, nil
+	}
+
+	counts, err := s.StatsRepository.GetUserStatsCounts(&domain.UserStatsQuery{
+		Start:        from,
+		End:          to.AddDate(0, 0, 1),
+		Interval:     interval,
+		TimeZone:     location.String(),
+		Today:        today,
+		TopLocations: topLocations,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.UserStats{
+		From:          key.from,
+		To:            key.to,
+		Interval:      interval,
+		TimeZone:      key.timeZone,
+		Total:         counts.Active + counts.Blocked,
+		Registrations: registrationSeries(buckets, counts.Registrations),
+		Status: domain.UserStatusStats{
+			Active:  counts.Active,
+			Blocked: counts.Blocked,
+		},
+		Genders:      counts.Genders,
+		TopLocations: counts.Locations,
+		AgeBuckets:   ageBucketCounts(counts.Ages),
+		GeneratedAt:  time.Now().UTC(),
+	}
+	if stats.Total > 0 {
+		stats.Status.BlockedRatio = roundScore(float64(counts.Blocked) / float64(stats.Total))
+	}
+
+	s.store(key, stats)
+
+	return stats, nil
+}
+
+func (s *StatsService) cached(key statsCacheKey) *domain.UserStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || !time.Now().Before(entry.expires) {
+		return nil
+	}
+	return entry.stats
+}
+
+// store caches stats, dropping expired entries so that the cache does not
+// grow with every distinct request.
+func (s *StatsService) store(key statsCacheKey, stats *domain.UserStats) {
+	if s.Config.CacheTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.cache {
+		if !now.Before(entry.expires) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedStats{stats: stats, expires: now.Add(s.Config.CacheTTL)}
+}
+
+// dateIn returns midnight in location of the date t falls on there. Dates
+// parsed without a zone are taken as they are written.
+func dateIn(t time.Time, location *time.Location) time.Time {
+	if t.Location() != time.UTC {
+		t = t.In(location)
+	}
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
+
+// statsBuckets returns the start dates of the buckets covering from to to,
+// aligned as by PostgreSQL's date_trunc: weeks start on Monday and months on
+// their first day.
+func statsBuckets(from, to time.Time, interval domain.StatsInterval) []time.Time {
+	start := from
+	switch interval {
+	case domain.StatsIntervalWeek:
+		start = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	case domain.StatsIntervalMonth:
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+	}
+
+	var buckets []time.Time
+	for bucket := start; !bucket.After(to); bucket = nextStatsBucket(bucket, interval) {
+		buckets = append(buckets, bucket)
+		if len(buckets) > maxStatsBuckets {
+			break
+		}
+	}
+	return buckets
+}
+
+func nextStatsBucket(bucket time.Time, interval domain.StatsInterval) time.Time {
+	switch interval {
+	case domain.StatsIntervalWeek:
+		return bucket.AddDate(0, 0, 7)
+	case domain.StatsIntervalMonth:
+		return bucket.AddDate(0, 1, 0)
+	default:
+		return bucket.AddDate(0, 0, 1)
+	}
+}
+
+// registrationSeries returns a count for every bucket, zero for the buckets
+// without registrations.
+func registrationSeries(buckets []time.Time, counts []domain.RegistrationCount) []domain.RegistrationCount {
+	byDate := make(map[string]int, len(counts))
+	for _, count := range counts {
+		byDate[count.Start.Format("2006-01-02")] = count.Count
+	}
+
+	series := make([]domain.RegistrationCount, len(buckets))
+	for i, bucket := range buckets {
+		series[i] = domain.RegistrationCount{Start: bucket, Count: byDate[bucket.Format("2006-01-02")]}
+	}
+	return series
+}
+
+func ageBucketCounts(ages map[int]int) []domain.AgeBucket {
+	buckets := make([]domain.AgeBucket, len(ageBuckets))
+	for i, bucket := range ageBuckets {
+		buckets[i].Label = bucket.label
+		for age, count := range ages {
+			if age >= bucket.min && age <= bucket.max {
+				buckets[i].Count += count
+			}
+		}
+	}
+	return buckets
+}
+
+func containsStatsInterval(interval domain.StatsInterval) bool {
+	for _, i := range domain.StatsIntervals {
+		if i == interval {
+			return true
+		}
+	}
+	return false
+}
+
+var _ service.StatsService = &StatsService{}
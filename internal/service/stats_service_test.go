@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"admin-panel/internal/config"
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var statsConfig = config.Stats{CacheTTL: time.Minute, TimeZone: "Asia/Ashgabat"}
+
+func statsDate(date string) time.Time {
+	t, _ := time.Parse("2006-01-02", date)
+	return t
+}
+
+func TestGetUserStats(t *testing.T) {
+	counts := &domain.UserStatsCounts{
+		Registrations: []domain.RegistrationCount{
+			{Start: statsDate("2024-01-08"), Count: 4},
+		},
+		Active:    3,
+		Blocked:   1,
+		Genders:   []domain.StatsGroup{{Value: "female", Count: 3}, {Value: "male", Count: 1}},
+		Locations: []domain.StatsGroup{{Value: "Ashgabat", Count: 4}},
+		Ages:      map[int]int{16: 1, 30: 2, 70: 1, -1: 1},
+	}
+
+	statsRepo := new(mocks.MockStatsRepository)
+	statsRepo.On("GetUserStatsCounts", mock.MatchedBy(func(query *domain.UserStatsQuery) bool {
+		ashgabat, _ := time.LoadLocation("Asia/Ashgabat")
+		return query.Start.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, ashgabat)) &&
+			query.End.Equal(time.Date(2024, 1, 18, 0, 0, 0, 0, ashgabat)) &&
+			query.Interval == domain.StatsIntervalWeek &&
+			query.TimeZone == "Asia/Ashgabat" &&
+			query.TopLocations == 10
+	})).Return(counts, nil).Once()
+
+	statsService := service.NewStatsService(statsRepo, statsConfig)
+	request := &domain.UserStatsRequest{From: statsDate("2024-01-03"), To: statsDate("2024-01-17"), Interval: domain.StatsIntervalWeek}
+
+	stats, err := statsService.GetUserStats(request)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-01-03", stats.From)
+	assert.Equal(t, "2024-01-17", stats.To)
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, 0.25, stats.Status.BlockedRatio)
+
+	var series []int
+	var starts []string
+	for _, registration := range stats.Registrations {
+		starts = append(starts, registration.Start.Format("2006-01-02"))
+		series = append(series, registration.Count)
+	}
+	assert.Equal(t, []string{"2024-01-01", "2024-01-08", "2024-01-15"}, starts)
+	assert.Equal(t, []int{0, 4, 0}, series)
+
+	assert.Equal(t, []domain.AgeBucket{
+		{Label: "under 18", Count: 1},
+		{Label: "18-24"},
+		{Label: "25-34", Count: 2},
+		{Label: "35-44"},
+		{Label: "45-54"},
+		{Label: "55-64"},
+		{Label: "65+", Count: 1},
+	}, stats.AgeBuckets)
+
+	// The same request is served from the cache.
+	cached, err := statsService.GetUserStats(request)
+	require.NoError(t, err)
+	assert.Same(t, stats, cached)
+	statsRepo.AssertExpectations(t)
+}
+
+func TestGetUserStatsMonthBuckets(t *testing.T) {
+	statsRepo := new(mocks.MockStatsRepository)
+	statsRepo.On("GetUserStatsCounts", mock.Anything).Return(&domain.UserStatsCounts{}, nil)
+
+	stats, err := service.NewStatsService(statsRepo, config.Stats{TimeZone: "UTC"}).GetUserStats(&domain.UserStatsRequest{
+		From:     statsDate("2024-01-15"),
+		To:       statsDate("2024-03-02"),
+		Interval: domain.StatsIntervalMonth,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, stats.Registrations, 3)
+	assert.Equal(t, "2024-02-01", stats.Registrations[1].Start.Format("2006-01-02"))
+	assert.Zero(t, stats.Status.BlockedRatio)
+
+	// Without a cache TTL every request reaches the repository.
+	_, err = service.NewStatsService(statsRepo, config.Stats{TimeZone: "UTC"}).GetUserStats(&domain.UserStatsRequest{})
+	require.NoError(t, err)
+	statsRepo.AssertNumberOfCalls(t, "GetUserStatsCounts", 2)
+}
+
+func TestGetUserStatsValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request domain.UserStatsRequest
+	}{
+		{name: "Unknown time zone", request: domain.UserStatsRequest{TimeZone: "Mars/Olympus"}},
+		{name: "Unknown interval", request: domain.UserStatsRequest{Interval: "hour"}},
+		{name: "From after to", request: domain.UserStatsRequest{From: statsDate("2024-02-01"), To: statsDate("2024-01-01")}},
+		{name: "Too many buckets", request: domain.UserStatsRequest{From: statsDate("2000-01-01"), To: statsDate("2024-01-01")}},
+		{name: "Too many locations", request: domain.UserStatsRequest{TopLocations: 101}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statsRepo := new(mocks.MockStatsRepository)
+
+			_, err := service.NewStatsService(statsRepo, statsConfig).GetUserStats(&tc.request)
+
+			assert.ErrorIs(t, err, errors.ErrInvalidStatsRequest)
+			statsRepo.AssertNotCalled(t, "GetUserStatsCounts", mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"regexp"
+	"strings"
+)
+
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type TagService struct {
+	TagRepository repository.TagRepository
+}
+
+func NewTagService(tagRepository repository.TagRepository) *TagService {
+	return &TagService{TagRepository: tagRepository}
+}
+
+func (s *TagService) GetTags() (*domain.TagsList, error) {
+	tags, err := s.TagRepository.GetTags()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TagsList{Tags: tags}, nil
+}
+
+func (s *TagService) CreateTag(request *domain.TagRequest) (*domain.Tag, error) {
+	name, err := normalizeTagName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *request
+	normalized.Name = name
+
+	return s.TagRepository.CreateTag(&normalized)
+}
+
+func (s *TagService) UpdateTag(id int32, request *domain.TagRequest) (*domain.Tag, error) {
+	name, err := normalizeTagName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *request
+	normalized.Name = name
+
+	return s.TagRepository.UpdateTag(id, &normalized)
+}
+
+func (s *TagService) DeleteTag(id int32) error {
+	return s.TagRepository.DeleteTag(id)
+}
+
+func (s *TagService) TagUser(userID int32, request *domain.TagUserRequest) error {
+	tags, err := normalizeTagNames(request.Tags)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return errors.ErrTagsRequired
+	}
+
+	normalized := *request
+	normalized.Tags = tags
+
+	return s.TagRepository.TagUser(userID, &normalized)
+}
+
+func (s *TagService) UntagUser(userID int32, tag string) error {
+	name, err := normalizeTagName(tag)
+	if err != nil {
+		return err
+	}
+
+	return s.TagRepository.UntagUser(userID, name)
+}
+
+// normalizeTagName lowercases and trims a tag name, so that "VIP " and "vip"
+// are the same tag.
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !tagNamePattern.MatchString(name) {
+		return "", errors.ErrInvalidTagName
+	}
+
+	return name, nil
+}
+
+// normalizeTagNames normalizes names and drops duplicates, keeping order.
+func normalizeTagNames(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+
+	return normalized, nil
+}
+
+var _ service.TagService = &TagService{}
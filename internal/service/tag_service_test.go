@@ -0,0 +1,51 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagUser(t *testing.T) {
+	testCases := []struct {
+		name         string
+		tags         []string
+		expectedTags []string
+		expectedErr  error
+	}{
+		{
+			name:         "Normalizes and deduplicates tags",
+			tags:         []string{" VIP ", "beta", "vip"},
+			expectedTags: []string{"vip", "beta"},
+		},
+		{
+			name:        "Invalid tag name",
+			tags:        []string{"vip", "not a tag"},
+			expectedErr: errors.ErrInvalidTagName,
+		},
+		{
+			name:        "No tags",
+			tags:        []string{},
+			expectedErr: errors.ErrTagsRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tagRepo := new(mocks.MockTagRepository)
+			if tc.expectedErr == nil {
+				tagRepo.On("TagUser", int32(1), &domain.TagUserRequest{Tags: tc.expectedTags, TaggedBy: 7}).Return(nil)
+			}
+
+			tagService := service.NewTagService(tagRepo)
+			err := tagService.TagUser(1, &domain.TagUserRequest{Tags: tc.tags, TaggedBy: 7})
+
+			assert.Equal(t, tc.expectedErr, err)
+			tagRepo.AssertExpectations(t)
+		})
+	}
+}
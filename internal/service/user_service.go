@@ -4,50 +4,483 @@ import (
 	"admin-panel/internal/domain"
 	repository "admin-panel/internal/repository/interfaces"
 	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/utils"
+	"admin-panel/pkg/phone"
+	"admin-panel/pkg/search"
+	"context"
+	"log/slog"
+	"time"
 )
 
 type UserService struct {
-	UserRepository repository.UserRepository
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+	PhoneParser         *phone.Parser
 }
 
-func NewUserService(userRepository repository.UserRepository) *UserService {
-	return &UserService{UserRepository: userRepository}
+func NewUserService(userRepository repository.UserRepository, attributeRepository repository.AttributeRepository, phoneParser *phone.Parser) *UserService {
+	return &UserService{
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+		PhoneParser:         phoneParser,
+	}
 }
 
-func (s *UserService) GetAllUsers(page, pageSize int) (*domain.UsersList, error) {
-	return s.UserRepository.GetAllUsers(page, pageSize)
+func (s *UserService) GetAllUsers(page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	filter, err := s.typedFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSort(sort, domain.UserSortFields); err != nil {
+		return nil, err
+	}
+
+	return s.UserRepository.GetAllUsers(page, pageSize, filter, sort)
+}
+
+func (s *UserService) GetTotalUsersCount(filter *domain.UserFilter) (int, error) {
+	filter, err := s.typedFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.UserRepository.GetTotalUsersCount(filter)
+}
+
+// GetUsersByCursor returns the page of users selected by request.Cursor,
+// with cursors to the pages before and after it. Unlike page numbers,
+// cursors keep their position when users are added or removed.
+func (s *UserService) GetUsersByCursor(request *domain.UsersCursorRequest) (*domain.UsersCursorResponse, error) {
+	filter, err := s.typedFilter(request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSort(request.Sort, domain.UserSortFields); err != nil {
+		return nil, err
+	}
+
+	if request.Count != domain.CountNone && request.Count != domain.CountExact && request.Count != domain.CountEstimated {
+		return nil, errors.ErrInvalidCountMode
+	}
+
+	keyset := &domain.UsersKeyset{Sort: request.Sort, Limit: request.Limit}
+	if request.Cursor != "" {
+		cursor, err := decodeUserCursor(request.Cursor, request.Sort)
+		if err != nil {
+			return nil, err
+		}
+		keyset.Key, keyset.Backward = cursor.Key, cursor.Backward
+	}
+
+	users, more, err := s.UserRepository.GetUsersByKeyset(filter, keyset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.UsersCursorResponse{Users: users}
+
+	if n := len(users.Users); n > 0 {
+		// Coming from a cursor, there are users on the side it points back to.
+		hasNext, hasPrev := more, keyset.Key != nil
+		if keyset.Backward {
+			hasNext, hasPrev = hasPrev, hasNext
+		}
+
+		sort := request.Sort.String()
+		if hasNext {
+			response.NextCursor = encodeUserCursor(userCursor{Sort: sort, Key: userSortKey(&users.Users[n-1], request.Sort)})
+		}
+		if hasPrev {
+			response.PrevCursor = encodeUserCursor(userCursor{Sort: sort, Key: userSortKey(&users.Users[0], request.Sort), Backward: true})
+		}
+	}
+
+	switch request.Count {
+	case domain.CountExact:
+		total, err := s.UserRepository.GetTotalUsersCount(filter)
+		if err != nil {
+			return nil, err
+		}
+		response.Total = &total
+	case domain.CountEstimated:
+		total, estimated, err := s.estimatedUsersCount(filter)
+		if err != nil {
+			return nil, err
+		}
+		response.Total = &total
+		response.TotalEstimated = estimated
+	}
+
+	return response, nil
+}
+
+// estimatedUsersCount estimates the number of users when there is no filter
+// and the table statistics allow it, and counts them exactly otherwise. It
+// reports whether the count is an estimate.
+func (s *UserService) estimatedUsersCount(filter *domain.UserFilter) (int, bool, error) {
+	if filter == nil {
+		estimate, err := s.UserRepository.GetEstimatedUsersCount()
+		if err != nil {
+			return 0, false, err
+		}
+		if estimate >= 0 {
+			return estimate, true, nil
+		}
+	}
+
+	total, err := s.UserRepository.GetTotalUsersCount(filter)
+	return total, false, err
 }
 
-func (s *UserService) GetTotalUsersCount() (int, error) {
-	return s.UserRepository.GetTotalUsersCount()
+func (s *UserService) typedFilter(filter *domain.UserFilter) (*domain.UserFilter, error) {
+	return normalizeUserFilter(s.AttributeRepository, filter)
+}
+
+// validAttributes checks attributes against the admin-defined schema.
+func (s *UserService) validAttributes(attributes domain.UserAttributes) (domain.UserAttributes, error) {
+	definitions, err := s.AttributeRepository.GetAttributeDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	return validateAttributes(definitions, attributes)
 }
 
 func (s *UserService) GetUserByID(id int32) (*domain.GetUserResponse, error) {
 	return s.UserRepository.GetUserByID(id)
 }
 
+// CreateUser stores a new user with the phone number normalized to E.164,
+// the email address normalized by email.Normalize and the custom attributes
+// validated against their definitions.
 func (s *UserService) CreateUser(request *domain.CreateUserRequest) (*domain.CreateUserResponse, error) {
-	return s.UserRepository.CreateUser(request)
+	phoneNumber, err := normalizePhoneNumber(s.PhoneParser, request.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := s.validAttributes(request.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *request
+	normalized.PhoneNumber = phoneNumber
+	normalized.Email = email
+	normalized.Attributes = attributes
+
+	return s.UserRepository.CreateUser(&normalized)
 }
 
 func (s *UserService) UpdateUser(id int32, request *domain.UpdateUserRequest) (*domain.UpdateUserResponse, error) {
-	return s.UserRepository.UpdateUser(id, request)
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := s.validAttributes(request.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *request
+	normalized.Email = email
+	normalized.Attributes = attributes
+
+	return s.UserRepository.UpdateUser(id, &normalized)
+}
+
+// PatchUser applies a JSON Merge Patch or JSON Patch document to the
+// editable fields of a user and persists only the fields that changed.
+// A non-nil expectedVersion makes the write conditional on the user's version.
+func (s *UserService) PatchUser(id int32, format domain.PatchFormat, body []byte, expectedVersion *int32, updatedBy int32) (*domain.UpdateUserResponse, error) {
+	user, err := s.UserRepository.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != nil && *expectedVersion != user.Version {
+		return nil, errors.ErrPreconditionFailed
+	}
+
+	changes, err := applyPatch(domain.UpdateUserRequest{
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Gender:          user.Gender,
+		DateOfBirth:     user.DateOfBirth,
+		Location:        user.Location,
+		Email:           user.Email,
+		ProfilePhotoURL: user.ProfilePhotoURL,
+		Attributes:      user.Attributes,
+	}, format, body)
+	if err != nil {
+		return nil, err
+	}
+
+	request := domain.PatchUserRequest{ExpectedVersion: expectedVersion, UpdatedBy: updatedBy}
+
+	if request.FirstName, err = changes.stringField("first_name", false); err != nil {
+		return nil, err
+	}
+	if request.LastName, err = changes.stringField("last_name", false); err != nil {
+		return nil, err
+	}
+	if request.Gender, err = changes.stringField("gender", false); err != nil {
+		return nil, err
+	}
+	if request.DateOfBirth, err = changes.timeField("date_of_birth"); err != nil {
+		return nil, err
+	}
+	if request.Location, err = changes.stringField("location", true); err != nil {
+		return nil, err
+	}
+	if request.Email, err = changes.stringField("email", true); err != nil {
+		return nil, err
+	}
+	if request.Email != nil {
+		email, err := normalizeEmail(*request.Email)
+		if err != nil {
+			return nil, err
+		}
+		request.Email = &email
+	}
+	if request.ProfilePhotoURL, err = changes.stringField("profile_photo_url", true); err != nil {
+		return nil, err
+	}
+	if request.Attributes, err = changes.attributesField("attributes"); err != nil {
+		return nil, err
+	}
+	if request.Attributes != nil {
+		if request.Attributes, err = s.validAttributes(request.Attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.UserRepository.PatchUser(id, &request)
+}
+
+func (s *UserService) DeleteUser(id int32, expectedVersion *int32) error {
+	return s.UserRepository.DeleteUser(id, expectedVersion)
 }
 
-func (s *UserService) DeleteUser(id int32) error {
-	return s.UserRepository.DeleteUser(id)
+func (s *UserService) BlockUser(id int32, request *domain.BlockUserRequest) error {
+	return s.UserRepository.BlockUser(id, request)
 }
 
-func (s *UserService) BlockUser(id int32) error {
-	return s.UserRepository.BlockUser(id)
+func (s *UserService) UnblockUser(id, unblockedBy int32) error {
+	return s.UserRepository.UnblockUser(id, unblockedBy)
 }
 
-func (s *UserService) UnblockUser(id int32) error {
-	return s.UserRepository.UnblockUser(id)
+func (s *UserService) GetUserBlocks(id int32) (*domain.UserBlocksList, error) {
+	return s.UserRepository.GetUserBlocks(id)
 }
 
-func (s *UserService) SearchUsers(query string, page, pageSize int) (*domain.UsersList, error) {
-	return s.UserRepository.SearchUsers(query, page, pageSize)
+func (s *UserService) UnblockExpiredUsers() (int64, error) {
+	return s.UserRepository.UnblockExpiredUsers()
+}
+
+// RunBlockExpiryWorker periodically lifts temporary blocks whose expiry has
+// passed. It blocks until ctx is cancelled.
+func (s *UserService) RunBlockExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			unblocked, err := s.UnblockExpiredUsers()
+			if err != nil {
+				slog.Error("Error unblocking expired users:", utils.Err(err))
+				continue
+			}
+			if unblocked > 0 {
+				slog.Info("Unblocked users with expired blocks", slog.Int64("count", unblocked))
+			}
+		}
+	}
+}
+
+// nameKeysBatchSize is the number of users BackfillNameKeys updates per
+// transaction.
+const nameKeysBatchSize = 500
+
+// BackfillNameKeys computes the name search keys of the users that have none
+// yet, batch by batch, until all have or ctx is cancelled.
+func (s *UserService) BackfillNameKeys(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		updated, err := s.UserRepository.BackfillNameKeys(nameKeysBatchSize)
+		if err != nil {
+			slog.Error("Error backfilling name search keys:", utils.Err(err))
+			return
+		}
+		if updated == 0 {
+			break
+		}
+		total += updated
+	}
+
+	if total > 0 {
+		slog.Info("Backfilled name search keys", slog.Int("count", total))
+	}
+}
+
+// contactIndexesBatchSize is the number of users BackfillContactIndexes
+// updates per transaction.
+const contactIndexesBatchSize = 500
+
+// BackfillContactIndexes encrypts and indexes the phone numbers and email
+// addresses of the users that have no blind indexes yet, batch by batch,
+// until all have. Users without indexes escape the uniqueness checks and
+// lookups by phone number or email, so it runs before requests are served.
+func (s *UserService) BackfillContactIndexes() error {
+	total := 0
+	for {
+		updated, err := s.UserRepository.BackfillContactIndexes(contactIndexesBatchSize)
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			break
+		}
+		total += updated
+	}
+
+	if total > 0 {
+		slog.Info("Backfilled phone number and email indexes", slog.Int("count", total))
+	}
+
+	return nil
+}
+
+// SearchUsers searches users by name, phone number or email. A query that is
+// a phone number in any accepted format is matched in its E.164 form, and one
+// that is an email address in its canonical form.
+func (s *UserService) SearchUsers(query string, page, pageSize int, filter *domain.UserFilter, sort domain.Sort) (*domain.UsersList, error) {
+	filter, err := s.typedFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSort(sort, domain.UserSortFields); err != nil {
+		return nil, err
+	}
+
+	query = searchTerm(s.PhoneParser, query)
+
+	users, err := s.UserRepository.SearchUsers(query, page, pageSize, filter, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := search.Parse(query)
+	for i := range users.Users {
+		users.Users[i].Highlights = searchHighlights(&users.Users[i], parsed)
+	}
+
+	return users, nil
+}
+
+// searchHighlights returns the highlighted fields of user matching query.
+func searchHighlights(user *domain.GetUserResponse, query search.Query) map[string]string {
+	highlights := make(map[string]string)
+
+	for name, value := range map[string]string{
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"location":   user.Location,
+	} {
+		if highlighted, ok := search.Highlight(value, query); ok {
+			highlights[name] = highlighted
+		}
+	}
+
+	if highlighted, ok := search.HighlightPhone(user.PhoneNumber, query); ok {
+		highlights["phone_number"] = highlighted
+	}
+	if highlighted, ok := search.HighlightEmail(user.Email, query); ok {
+		highlights["email"] = highlighted
+	}
+
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// GetSearchUsersCount returns the number of users matching query and filter.
+func (s *UserService) GetSearchUsersCount(query string, filter *domain.UserFilter) (int, error) {
+	filter, err := s.typedFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.UserRepository.GetSearchUsersCount(searchTerm(s.PhoneParser, query), filter)
+}
+
+// GetUserHistory returns a page of the recorded changes to a user together
+// with the total number of recorded versions.
+func (s *UserService) GetUserHistory(id int32, page, pageSize int) (*domain.UserHistory, int, error) {
+	history, err := s.UserRepository.GetUserHistory(id, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.UserRepository.CountUserHistory(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return history, total, nil
+}
+
+func (s *UserService) GetUserAsOf(id int32, asOf time.Time) (*domain.GetUserResponse, error) {
+	return s.UserRepository.GetUserAsOf(id, asOf)
+}
+
+// RevertUser restores the editable fields of a user to their values at a
+// previous version. The phone number, blocked state and profile photo have
+// their own workflows and are left as they are. The revert is recorded as a
+// new version, so it can itself be reverted.
+func (s *UserService) RevertUser(id, version int32, request *domain.RevertUserRequest) (*domain.UpdateUserResponse, error) {
+	target, err := s.UserRepository.GetUserVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.UserRepository.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedVersion := request.ExpectedVersion
+	if expectedVersion == nil {
+		// Guard against changes made after the current state was read.
+		expectedVersion = &current.Version
+	}
+
+	return s.UpdateUser(id, &domain.UpdateUserRequest{
+		FirstName:       target.FirstName,
+		LastName:        target.LastName,
+		Gender:          target.Gender,
+		DateOfBirth:     target.DateOfBirth,
+		Location:        target.Location,
+		Email:           target.Email,
+		ProfilePhotoURL: current.ProfilePhotoURL,
+		Attributes:      target.Attributes,
+		ExpectedVersion: expectedVersion,
+		UpdatedBy:       request.RevertedBy,
+	})
 }
 
 var _ service.UserService = &UserService{}
@@ -0,0 +1,580 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/lib/patch"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var attributeDefinitions = []domain.AttributeDefinition{
+	{Key: "tier", Type: domain.AttributeTypeString, Enum: []string{"gold", "silver"}},
+	{Key: "loyalty_points", Type: domain.AttributeTypeInteger},
+	{Key: "newsletter", Type: domain.AttributeTypeBoolean},
+	{Key: "contract_signed", Type: domain.AttributeTypeDate},
+}
+
+func attributeRepository(definitions ...domain.AttributeDefinition) *mocks.MockAttributeRepository {
+	if definitions == nil {
+		definitions = attributeDefinitions
+	}
+
+	attributeRepo := new(mocks.MockAttributeRepository)
+	attributeRepo.On("GetAttributeDefinitions").Return(definitions, nil)
+	return attributeRepo
+}
+
+func TestPatchUser(t *testing.T) {
+	dateOfBirth := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	newDateOfBirth := time.Date(1999, time.May, 17, 0, 0, 0, 0, time.UTC)
+
+	currentUser := &domain.GetUserResponse{
+		ID:              1,
+		FirstName:       "Kemal",
+		LastName:        "Atdayew",
+		PhoneNumber:     "+99362008971",
+		Gender:          "Male",
+		DateOfBirth:     dateOfBirth,
+		Location:        "Ashgabat",
+		Email:           "atdayewkemal@gmail.com",
+		ProfilePhotoURL: "https://example.com/profile.jpg",
+	}
+
+	str := func(s string) *string { return &s }
+
+	testCases := []struct {
+		name            string
+		format          domain.PatchFormat
+		body            string
+		expectedRequest *domain.PatchUserRequest
+		expectedErr     error
+	}{
+		{
+			name:            "Merge patch updates only supplied fields",
+			format:          domain.MergePatchFormat,
+			body:            `{"location":"Mary"}`,
+			expectedRequest: &domain.PatchUserRequest{Location: str("Mary")},
+		},
+		{
+			name:            "Merge patch null clears optional field",
+			format:          domain.MergePatchFormat,
+			body:            `{"profile_photo_url":null}`,
+			expectedRequest: &domain.PatchUserRequest{ProfilePhotoURL: str("")},
+		},
+		{
+			name:            "Merge patch with unchanged values is a no-op",
+			format:          domain.MergePatchFormat,
+			body:            `{"first_name":"Kemal","date_of_birth":"2000-01-01T00:00:00Z"}`,
+			expectedRequest: &domain.PatchUserRequest{},
+		},
+		{
+			name:        "Merge patch null on required field",
+			format:      domain.MergePatchFormat,
+			body:        `{"date_of_birth":null}`,
+			expectedErr: errors.ErrFieldRequired,
+		},
+		{
+			name:        "Merge patch on read-only field",
+			format:      domain.MergePatchFormat,
+			body:        `{"phone_number":"+99365000000"}`,
+			expectedErr: errors.ErrFieldNotPatchable,
+		},
+		{
+			name:   "JSON patch replaces fields",
+			format: domain.JSONPatchFormat,
+			body:   `[{"op":"test","path":"/last_name","value":"Atdayew"},{"op":"replace","path":"/last_name","value":"Atayev"},{"op":"replace","path":"/date_of_birth","value":"1999-05-17T00:00:00Z"}]`,
+			expectedRequest: &domain.PatchUserRequest{
+				LastName:    str("Atayev"),
+				DateOfBirth: &newDateOfBirth,
+			},
+		},
+		{
+			name:            "JSON patch remove clears optional field",
+			format:          domain.JSONPatchFormat,
+			body:            `[{"op":"remove","path":"/email"}]`,
+			expectedRequest: &domain.PatchUserRequest{Email: str("")},
+		},
+		{
+			name:            "Merge patch sets custom attribute",
+			format:          domain.MergePatchFormat,
+			body:            `{"attributes":{"tier":"gold","loyalty_points":120}}`,
+			expectedRequest: &domain.PatchUserRequest{Attributes: domain.UserAttributes{"tier": "gold", "loyalty_points": float64(120)}},
+		},
+		{
+			name:        "Merge patch with invalid custom attribute",
+			format:      domain.MergePatchFormat,
+			body:        `{"attributes":{"tier":"bronze"}}`,
+			expectedErr: errors.ErrInvalidAttributes,
+		},
+		{
+			name:        "JSON patch failed test",
+			format:      domain.JSONPatchFormat,
+			body:        `[{"op":"test","path":"/first_name","value":"Aman"},{"op":"replace","path":"/first_name","value":"Merdan"}]`,
+			expectedErr: patch.ErrTestFailed,
+		},
+		{
+			name:        "JSON patch with unknown operation",
+			format:      domain.JSONPatchFormat,
+			body:        `[{"op":"swap","path":"/first_name"}]`,
+			expectedErr: patch.ErrInvalidPatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockRepo.On("GetUserByID", int32(1)).Return(currentUser, nil)
+			mockRepo.On("PatchUser", int32(1), mock.Anything).Return(&domain.UpdateUserResponse{ID: 1}, nil)
+
+			s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+			_, err := s.PatchUser(1, tc.format, []byte(tc.body), nil, 0)
+
+			if tc.expectedErr != nil {
+				assert.True(t, stderrors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				mockRepo.AssertNotCalled(t, "PatchUser", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "PatchUser", int32(1), tc.expectedRequest)
+		})
+	}
+}
+
+func TestPatchUserStaleVersion(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetUserByID", int32(1)).Return(&domain.GetUserResponse{ID: 1, FirstName: "Kemal", Version: 4}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	expectedVersion := int32(3)
+	_, err := s.PatchUser(1, domain.MergePatchFormat, []byte(`{"location":"Mary"}`), &expectedVersion, 0)
+
+	assert.Equal(t, errors.ErrPreconditionFailed, err)
+	mockRepo.AssertNotCalled(t, "PatchUser", mock.Anything, mock.Anything)
+}
+
+func TestCreateUserNormalizesPhoneNumber(t *testing.T) {
+	testCases := []struct {
+		name          string
+		phoneNumber   string
+		expectedPhone string
+		expectedErr   error
+	}{
+		{
+			name:          "local format",
+			phoneNumber:   "8 (62) 00-89-71",
+			expectedPhone: "+99362008971",
+		},
+		{
+			name:          "international format of an allowed country",
+			phoneNumber:   "00998 90 123 45 67",
+			expectedPhone: "+998901234567",
+		},
+		{
+			name:        "country not allowed",
+			phoneNumber: "+7 701 123 4567",
+			expectedErr: errors.ErrPhoneCountryNotAllowed,
+		},
+		{
+			name:        "unknown operator",
+			phoneNumber: "+99312345678",
+			expectedErr: errors.ErrInvalidPhoneNumber,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockRepo.On("CreateUser", mock.Anything).Return(&domain.CreateUserResponse{ID: 1}, nil)
+
+			s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+			request := &domain.CreateUserRequest{FirstName: "Kemal", PhoneNumber: tc.phoneNumber}
+			_, err := s.CreateUser(request)
+
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+				mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "CreateUser", &domain.CreateUserRequest{FirstName: "Kemal", PhoneNumber: tc.expectedPhone, Attributes: domain.UserAttributes{}})
+		})
+	}
+}
+
+func TestSearchUsersByLocalPhoneNumber(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("SearchUsers", mock.Anything, 1, 10, (*domain.UserFilter)(nil), domain.Sort(nil)).Return(&domain.UsersList{}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	_, err := s.SearchUsers("865123456", 1, 10, nil, nil)
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "SearchUsers", "+99365123456", 1, 10, (*domain.UserFilter)(nil), domain.Sort(nil))
+
+	_, err = s.SearchUsers("Kemal", 1, 10, nil, nil)
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "SearchUsers", "Kemal", 1, 10, (*domain.UserFilter)(nil), domain.Sort(nil))
+}
+
+func TestSearchUsersHighlights(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("SearchUsers", mock.Anything, 1, 10, (*domain.UserFilter)(nil), domain.Sort(nil)).Return(&domain.UsersList{Users: []domain.GetUserResponse{
+		{ID: 1, FirstName: "Aman", LastName: "Orazow", PhoneNumber: "+99365128971", Location: "Ashgabat", Email: "aman@example.com"},
+		{ID: 2, FirstName: "Amanmyrat", LastName: "Bayramow", PhoneNumber: "+99361000000", Location: "Mary"},
+	}}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	users, err := s.SearchUsers("aman ashgabad", 1, 10, nil, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"first_name": "<mark>Aman</mark>",
+		"location":   "<mark>Ashgabat</mark>",
+	}, users.Users[0].Highlights)
+	assert.Equal(t, map[string]string{"first_name": "<mark>Amanmyrat</mark>"}, users.Users[1].Highlights)
+
+	t.Run("Phone number", func(t *testing.T) {
+		users, err := s.SearchUsers("865128971", 1, 10, nil, nil)
+
+		require.NoError(t, err)
+		mockRepo.AssertCalled(t, "SearchUsers", "+99365128971", 1, 10, (*domain.UserFilter)(nil), domain.Sort(nil))
+		assert.Equal(t, map[string]string{"phone_number": "+<mark>99365128971</mark>"}, users.Users[0].Highlights)
+	})
+
+	t.Run("Email address", func(t *testing.T) {
+		users, err := s.SearchUsers(" Aman@Example.com ", 1, 10, nil, nil)
+
+		require.NoError(t, err)
+		mockRepo.AssertCalled(t, "SearchUsers", "aman@example.com", 1, 10, (*domain.UserFilter)(nil), domain.Sort(nil))
+		assert.Equal(t, map[string]string{"email": "<mark>aman@example.com</mark>"}, users.Users[0].Highlights)
+		assert.Nil(t, users.Users[1].Highlights)
+	})
+}
+
+func TestGetSearchUsersCount(t *testing.T) {
+	bornAfter := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	bornBefore := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetSearchUsersCount", "+99365123456", &domain.UserFilter{BornAfter: &bornAfter, BornBefore: &bornBefore}).Return(2, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	total, err := s.GetSearchUsersCount("865123456", &domain.UserFilter{BornAfter: &bornAfter, BornBefore: &bornBefore})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	_, err = s.GetSearchUsersCount("Kemal", &domain.UserFilter{BornAfter: &bornBefore, BornBefore: &bornAfter})
+	assert.ErrorIs(t, err, errors.ErrInvalidFilter)
+	assert.EqualError(t, err, "invalid filter: born_after must be before born_before")
+	mockRepo.AssertNumberOfCalls(t, "GetSearchUsersCount", 1)
+}
+
+func TestGetUsersByCursor(t *testing.T) {
+	sort := domain.Sort{{Field: "registration_date", Descending: true}}
+	registered := time.Date(2024, time.May, 1, 10, 30, 0, 123456000, time.UTC)
+	user := func(id int32) domain.GetUserResponse {
+		return domain.GetUserResponse{ID: id, RegistrationDate: registered}
+	}
+
+	mockRepo := new(mocks.MockUserRepository)
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	mockRepo.On("GetUsersByKeyset", (*domain.UserFilter)(nil), &domain.UsersKeyset{Sort: sort, Limit: 2}).
+		Return(&domain.UsersList{Users: []domain.GetUserResponse{user(9), user(7)}}, true, nil)
+
+	first, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Limit: 2, Sort: sort})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+	assert.Nil(t, first.Total)
+
+	mockRepo.On("GetUsersByKeyset", (*domain.UserFilter)(nil), &domain.UsersKeyset{Sort: sort, Key: []string{"2024-05-01T10:30:00.123456Z", "7"}, Limit: 2}).
+		Return(&domain.UsersList{Users: []domain.GetUserResponse{user(4)}}, false, nil)
+
+	second, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Cursor: first.NextCursor, Limit: 2, Sort: sort})
+	require.NoError(t, err)
+	assert.Empty(t, second.NextCursor)
+	assert.NotEmpty(t, second.PrevCursor)
+
+	mockRepo.On("GetUsersByKeyset", (*domain.UserFilter)(nil), &domain.UsersKeyset{Sort: sort, Key: []string{"2024-05-01T10:30:00.123456Z", "4"}, Backward: true, Limit: 2}).
+		Return(&domain.UsersList{Users: []domain.GetUserResponse{user(9), user(7)}}, false, nil)
+
+	back, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Cursor: second.PrevCursor, Limit: 2, Sort: sort})
+	require.NoError(t, err)
+	assert.Equal(t, first.NextCursor, back.NextCursor)
+	assert.Empty(t, back.PrevCursor)
+
+	t.Run("Cursor of another sort order", func(t *testing.T) {
+		_, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Cursor: first.NextCursor, Limit: 2})
+		assert.Equal(t, errors.ErrInvalidCursor, err)
+	})
+
+	t.Run("Malformed cursor", func(t *testing.T) {
+		_, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Cursor: "not a cursor", Limit: 2, Sort: sort})
+		assert.Equal(t, errors.ErrInvalidCursor, err)
+	})
+
+	t.Run("Invalid count mode", func(t *testing.T) {
+		_, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Limit: 2, Sort: sort, Count: "approximate"})
+		assert.Equal(t, errors.ErrInvalidCountMode, err)
+	})
+}
+
+func TestGetUsersByCursorCount(t *testing.T) {
+	blocked := true
+
+	testCases := []struct {
+		name              string
+		filter            *domain.UserFilter
+		count             domain.CountMode
+		estimate          int
+		exact             int
+		expectedTotal     int
+		expectedEstimated bool
+	}{
+		{name: "Exact", count: domain.CountExact, exact: 120, expectedTotal: 120},
+		{name: "Estimated", count: domain.CountEstimated, estimate: 118, expectedTotal: 118, expectedEstimated: true},
+		{name: "Estimated without statistics", count: domain.CountEstimated, estimate: -1, exact: 120, expectedTotal: 120},
+		{name: "Estimated with filter", filter: &domain.UserFilter{Blocked: &blocked}, count: domain.CountEstimated, exact: 3, expectedTotal: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockRepo.On("GetUsersByKeyset", tc.filter, mock.Anything).Return(&domain.UsersList{Users: []domain.GetUserResponse{}}, false, nil)
+			mockRepo.On("GetEstimatedUsersCount").Return(tc.estimate, nil)
+			mockRepo.On("GetTotalUsersCount", tc.filter).Return(tc.exact, nil)
+
+			s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+			response, err := s.GetUsersByCursor(&domain.UsersCursorRequest{Limit: 10, Filter: tc.filter, Count: tc.count})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTotal, *response.Total)
+			assert.Equal(t, tc.expectedEstimated, response.TotalEstimated)
+		})
+	}
+}
+
+func TestCreateUserNormalizesEmail(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("CreateUser", mock.Anything).Return(&domain.CreateUserResponse{ID: 1}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	_, err := s.CreateUser(&domain.CreateUserRequest{PhoneNumber: "+99362008971", Email: " Kemal@GMail.com "})
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "CreateUser", &domain.CreateUserRequest{PhoneNumber: "+99362008971", Email: "kemal@gmail.com", Attributes: domain.UserAttributes{}})
+
+	_, err = s.CreateUser(&domain.CreateUserRequest{PhoneNumber: "+99362008971", Email: "Kemal <kemal@gmail.com>"})
+	assert.Equal(t, errors.ErrInvalidEmail, err)
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 1)
+}
+
+func TestCreateUserValidatesAttributes(t *testing.T) {
+	testCases := []struct {
+		name               string
+		attributes         domain.UserAttributes
+		definitions        []domain.AttributeDefinition
+		expectedAttributes domain.UserAttributes
+		expectedErr        string
+	}{
+		{
+			name:               "valid values",
+			attributes:         domain.UserAttributes{"tier": "silver", "loyalty_points": float64(10), "newsletter": true, "contract_signed": "2024-02-29"},
+			expectedAttributes: domain.UserAttributes{"tier": "silver", "loyalty_points": float64(10), "newsletter": true, "contract_signed": "2024-02-29"},
+		},
+		{
+			name:               "null values are dropped",
+			attributes:         domain.UserAttributes{"tier": nil},
+			expectedAttributes: domain.UserAttributes{},
+		},
+		{
+			name:        "undefined key",
+			attributes:  domain.UserAttributes{"shoe_size": float64(42)},
+			expectedErr: `invalid attributes: "shoe_size" is not defined`,
+		},
+		{
+			name:        "value outside enum",
+			attributes:  domain.UserAttributes{"tier": "bronze"},
+			expectedErr: `invalid attributes: "tier" must be one of gold, silver`,
+		},
+		{
+			name:        "fractional integer",
+			attributes:  domain.UserAttributes{"loyalty_points": 1.5},
+			expectedErr: `invalid attributes: "loyalty_points" must be an integer`,
+		},
+		{
+			name:        "malformed date",
+			attributes:  domain.UserAttributes{"contract_signed": "29.02.2024"},
+			expectedErr: `invalid attributes: "contract_signed" must be a date in YYYY-MM-DD format`,
+		},
+		{
+			name:        "missing required attribute",
+			attributes:  domain.UserAttributes{},
+			definitions: []domain.AttributeDefinition{{Key: "tier", Type: domain.AttributeTypeString, Required: true}},
+			expectedErr: `invalid attributes: "tier" is required`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockRepo.On("CreateUser", mock.Anything).Return(&domain.CreateUserResponse{ID: 1}, nil)
+
+			s := service.NewUserService(mockRepo, attributeRepository(tc.definitions...), phoneParser)
+
+			_, err := s.CreateUser(&domain.CreateUserRequest{PhoneNumber: "+99362008971", Attributes: tc.attributes})
+
+			if tc.expectedErr != "" {
+				assert.True(t, stderrors.Is(err, errors.ErrInvalidAttributes))
+				assert.EqualError(t, err, tc.expectedErr)
+				mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "CreateUser", &domain.CreateUserRequest{PhoneNumber: "+99362008971", Attributes: tc.expectedAttributes})
+		})
+	}
+}
+
+func TestGetAllUsersConvertsAttributeFilter(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetAllUsers", 1, 10, mock.Anything, domain.Sort(nil)).Return(&domain.UsersList{}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	_, err := s.GetAllUsers(1, 10, &domain.UserFilter{Attributes: map[string]interface{}{"tier": "gold", "loyalty_points": "120", "newsletter": "true"}}, nil)
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "GetAllUsers", 1, 10, &domain.UserFilter{Attributes: map[string]interface{}{"tier": "gold", "loyalty_points": float64(120), "newsletter": true}}, domain.Sort(nil))
+
+	_, err = s.GetAllUsers(1, 10, &domain.UserFilter{Attributes: map[string]interface{}{"newsletter": "maybe"}}, nil)
+	assert.EqualError(t, err, `invalid attributes: "newsletter" must be a boolean`)
+}
+
+func TestGetAllUsersValidatesSort(t *testing.T) {
+	sort := domain.Sort{{Field: "registration_date", Descending: true}, {Field: "last_name"}}
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetAllUsers", 1, 10, (*domain.UserFilter)(nil), sort).Return(&domain.UsersList{}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+
+	_, err := s.GetAllUsers(1, 10, nil, sort)
+	assert.NoError(t, err)
+
+	_, err = s.GetAllUsers(1, 10, nil, domain.Sort{{Field: "phone_number"}})
+	assert.EqualError(t, err, `invalid sort: "phone_number" is not sortable`)
+
+	_, err = s.GetAllUsers(1, 10, nil, domain.Sort{{Field: "last_name"}, {Field: "last_name", Descending: true}})
+	assert.EqualError(t, err, `invalid sort: "last_name" is listed more than once`)
+
+	mockRepo.AssertNumberOfCalls(t, "GetAllUsers", 1)
+}
+
+func TestRevertUser(t *testing.T) {
+	dateOfBirth := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	target := &domain.GetUserResponse{
+		ID:              1,
+		FirstName:       "Kemal",
+		LastName:        "Atdayew",
+		PhoneNumber:     "+99361111111",
+		Gender:          "Male",
+		DateOfBirth:     dateOfBirth,
+		Location:        "Ashgabat",
+		Email:           "old@example.com",
+		ProfilePhotoURL: "https://cdn.example.com/old.jpg",
+		Attributes:      domain.UserAttributes{"tier": "gold"},
+		Version:         2,
+	}
+	current := &domain.GetUserResponse{
+		ID:              1,
+		FirstName:       "Kemal",
+		LastName:        "Atdayew",
+		PhoneNumber:     "+99362008971",
+		Gender:          "Male",
+		DateOfBirth:     dateOfBirth,
+		Location:        "Mary",
+		Email:           "new@example.com",
+		ProfilePhotoURL: "https://cdn.example.com/new.jpg",
+		Attributes:      domain.UserAttributes{"tier": "silver"},
+		Version:         5,
+	}
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetUserVersion", int32(1), int32(2)).Return(target, nil)
+	mockRepo.On("GetUserByID", int32(1)).Return(current, nil)
+	mockRepo.On("UpdateUser", int32(1), mock.Anything).Return(&domain.UpdateUserResponse{ID: 1, Version: 6}, nil)
+
+	s := service.NewUserService(mockRepo, attributeRepository(), phoneParser)
+	user, err := s.RevertUser(1, 2, &domain.RevertUserRequest{RevertedBy: 7})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), user.Version)
+
+	expectedVersion := int32(5)
+	mockRepo.AssertCalled(t, "UpdateUser", int32(1), &domain.UpdateUserRequest{
+		FirstName:       "Kemal",
+		LastName:        "Atdayew",
+		Gender:          "Male",
+		DateOfBirth:     dateOfBirth,
+		Location:        "Ashgabat",
+		Email:           "old@example.com",
+		ProfilePhotoURL: "https://cdn.example.com/new.jpg",
+		Attributes:      domain.UserAttributes{"tier": "gold"},
+		ExpectedVersion: &expectedVersion,
+		UpdatedBy:       7,
+	})
+}
+
+func TestBackfillContactIndexes(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("BackfillContactIndexes", 500).Return(500, nil).Once()
+	mockRepo.On("BackfillContactIndexes", 500).Return(3, nil).Once()
+	mockRepo.On("BackfillContactIndexes", 500).Return(0, nil).Once()
+
+	err := service.NewUserService(mockRepo, attributeRepository(), phoneParser).BackfillContactIndexes()
+
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "BackfillContactIndexes", 3)
+}
+
+func TestBackfillContactIndexesDuplicate(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("BackfillContactIndexes", 500).Return(0, fmt.Errorf("user 7: %w", errors.ErrEmailInUse)).Once()
+
+	err := service.NewUserService(mockRepo, attributeRepository(), phoneParser).BackfillContactIndexes()
+
+	assert.ErrorIs(t, err, errors.ErrEmailInUse)
+	mockRepo.AssertNumberOfCalls(t, "BackfillContactIndexes", 1)
+}
+
+func TestBackfillNameKeys(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("BackfillNameKeys", 500).Return(500, nil).Once()
+	mockRepo.On("BackfillNameKeys", 500).Return(12, nil).Once()
+	mockRepo.On("BackfillNameKeys", 500).Return(0, nil).Once()
+
+	service.NewUserService(mockRepo, attributeRepository(), phoneParser).BackfillNameKeys(context.Background())
+
+	mockRepo.AssertNumberOfCalls(t, "BackfillNameKeys", 3)
+}
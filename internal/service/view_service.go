@@ -0,0 +1,339 @@
+package service
+
+import (
+	"admin-panel/internal/domain"
+	repository "admin-panel/internal/repository/interfaces"
+	service "admin-panel/internal/service/interfaces"
+	"admin-panel/pkg/lib/errors"
+	"admin-panel/pkg/phone"
+	"admin-panel/pkg/search"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxViewNameLength = 128
+
+type ViewService struct {
+	ViewRepository      repository.ViewRepository
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+	PhoneParser         *phone.Parser
+}
+
+func NewViewService(viewRepository repository.ViewRepository, userRepository repository.UserRepository, attributeRepository repository.AttributeRepository, phoneParser *phone.Parser) *ViewService {
+	return &ViewService{
+		ViewRepository:      viewRepository,
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+		PhoneParser:         phoneParser,
+	}
+}
+
+// GetViews returns the views visible to actor.
+func (s *ViewService) GetViews(actor domain.Actor) (*domain.ViewsList, error) {
+	views, err := s.ViewRepository.GetViews(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ViewsList{Views: views}, nil
+}
+
+// CreateView saves a view owned by actor.
+func (s *ViewService) CreateView(request *domain.ViewRequest, actor domain.Actor) (*domain.View, error) {
+	normalized, err := s.normalizeView(request)
+	if err != nil {
+		return nil, err
+	}
+	normalized.OwnerID = actor.AdminID
+
+	return s.ViewRepository.CreateView(normalized)
+}
+
+// UpdateView replaces the definition and sharing of a view. Only the owner
+// may change a view.
+func (s *ViewService) UpdateView(id int32, request *domain.ViewRequest, actor domain.Actor) (*domain.View, error) {
+	view, err := s.visibleView(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !view.OwnedBy(actor) {
+		return nil, errors.ErrViewNotOwner
+	}
+
+	normalized, err := s.normalizeView(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ViewRepository.UpdateView(id, normalized)
+}
+
+// DeleteView deletes a view. Admins may delete their own views; super admins
+// may delete any view they can see.
+func (s *ViewService) DeleteView(id int32, actor domain.Actor) error {
+	view, err := s.visibleView(id, actor)
+	if err != nil {
+		return err
+	}
+
+	if !actor.IsSuperAdmin() && !view.OwnedBy(actor) {
+		return errors.ErrViewNotOwner
+	}
+
+	return s.ViewRepository.DeleteView(id)
+}
+
+// GetViewResults returns a page of the users currently matching a view
+// together with the total number of matching users. The view is validated
+// against the current attribute schema first; what no longer applies is left
+// out of the returned view and reported as a warning instead of failing.
+func (s *ViewService) GetViewResults(id int32, page, pageSize int, actor domain.Actor) (*domain.ViewResults, int, error) {
+	view, err := s.visibleView(id, actor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resolved, warnings, err := s.resolveView(view)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := &resolved.Filter
+	sort := domain.ParseSort(resolved.Sort)
+
+	var users *domain.UsersList
+	var total int
+	if resolved.Query != "" {
+		query := searchTerm(s.PhoneParser, resolved.Query)
+		if users, err = s.UserRepository.SearchUsers(query, page, pageSize, filter, sort); err != nil {
+			return nil, 0, err
+		}
+		if total, err = s.UserRepository.GetSearchUsersCount(query, filter); err != nil {
+			return nil, 0, err
+		}
+
+		parsed := search.Parse(query)
+		for i := range users.Users {
+			users.Users[i].Highlights = searchHighlights(&users.Users[i], parsed)
+		}
+	} else {
+		if users, err = s.UserRepository.GetAllUsers(page, pageSize, filter, sort); err != nil {
+			return nil, 0, err
+		}
+		if total, err = s.UserRepository.GetTotalUsersCount(filter); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return &domain.ViewResults{View: resolved, Warnings: warnings, Users: users}, total, nil
+}
+
+// visibleView loads a view, reporting views the actor may not see as not
+// found so that their existence is not disclosed.
+func (s *ViewService) visibleView(id int32, actor domain.Actor) (*domain.View, error) {
+	view, err := s.ViewRepository.GetViewByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !view.VisibleTo(actor) {
+		return nil, errors.ErrViewNotFound
+	}
+
+	return view, nil
+}
+
+// resolveView checks a saved view against the current attribute schema and
+// sort fields, which may have changed since the view was saved. Attribute
+// filters, sort fields and columns that no longer apply are dropped with a
+// warning each.
+func (s *ViewService) resolveView(view *domain.View) (*domain.View, []string, error) {
+	resolved := *view
+	var warnings []string
+
+	definitions, err := s.AttributeRepository.GetAttributeDefinitions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var attributes map[string]interface{}
+	keys := make([]string, 0, len(view.Filter.Attributes))
+	for key := range view.Filter.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		definition, ok := findAttributeDefinition(definitions, key)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("filter on attribute %q ignored: it is no longer defined", key))
+			continue
+		}
+
+		value, err := parseAttributeValue(definition, fmt.Sprint(view.Filter.Attributes[key]))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("filter on attribute %q ignored: %v", key, err))
+			continue
+		}
+
+		if attributes == nil {
+			attributes = make(map[string]interface{}, len(keys))
+		}
+		attributes[key] = value
+	}
+
+	filter := view.Filter
+	filter.Attributes = nil
+	normalized, err := normalizeUserFilter(s.AttributeRepository, &filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	normalized.Attributes = attributes
+	resolved.Filter = *normalized
+
+	var sortFields domain.Sort
+	for _, field := range domain.ParseSort(view.Sort) {
+		if !containsString(domain.UserSortFields, field.Field) {
+			warnings = append(warnings, fmt.Sprintf("sort on %q ignored: it is no longer sortable", field.Field))
+			continue
+		}
+		sortFields = append(sortFields, field)
+	}
+	resolved.Sort = sortFields.String()
+
+	resolved.Columns = make([]string, 0, len(view.Columns))
+	for _, column := range view.Columns {
+		if err := checkViewColumn(definitions, column); err != nil {
+			warnings = append(warnings, fmt.Sprintf("column %q ignored: it no longer exists", column))
+			continue
+		}
+		resolved.Columns = append(resolved.Columns, column)
+	}
+
+	return &resolved, warnings, nil
+}
+
+// normalizeView validates a view before it is saved, storing its filter in
+// normalized form and its sort order in the format of the sort parameter.
+// Without columns a view displays all of domain.ViewColumns.
+func (s *ViewService) normalizeView(request *domain.ViewRequest) (*domain.ViewRequest, error) {
+	normalized := *request
+
+	normalized.Name = strings.TrimSpace(request.Name)
+	if normalized.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", errors.ErrInvalidView)
+	}
+	if utf8.RuneCountInString(normalized.Name) > maxViewNameLength {
+		return nil, fmt.Errorf("%w: name must be at most %d characters", errors.ErrInvalidView, maxViewNameLength)
+	}
+
+	normalized.Query = strings.TrimSpace(request.Query)
+
+	filter, err := normalizeUserFilter(s.AttributeRepository, &request.Filter)
+	if err != nil {
+		return nil, err
+	}
+	normalized.Filter = *filter
+
+	sortFields := domain.ParseSort(request.Sort)
+	if err := validateSort(sortFields, domain.UserSortFields); err != nil {
+		return nil, err
+	}
+	normalized.Sort = sortFields.String()
+
+	if normalized.Columns, err = s.viewColumns(request.Columns); err != nil {
+		return nil, err
+	}
+
+	if normalized.SharedWith, err = normalizeViewSharing(request.SharedWith); err != nil {
+		return nil, err
+	}
+
+	return &normalized, nil
+}
+
+// viewColumns validates the columns of a view, defaulting to
+// domain.ViewColumns.
+func (s *ViewService) viewColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return domain.ViewColumns, nil
+	}
+
+	definitions, err := s.AttributeRepository.GetAttributeDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if err := checkViewColumn(definitions, column); err != nil {
+			return nil, err
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: column %q is listed more than once", errors.ErrInvalidView, column)
+		}
+		seen[column] = true
+	}
+
+	return columns, nil
+}
+
+// checkViewColumn checks that column is one of domain.ViewColumns or names a
+// defined attribute.
+func checkViewColumn(definitions []domain.AttributeDefinition, column string) error {
+	if containsString(domain.ViewColumns, column) {
+		return nil
+	}
+
+	key, ok := strings.CutPrefix(column, domain.AttributeColumnPrefix)
+	if !ok {
+		return fmt.Errorf("%w: %q is not a view column", errors.ErrInvalidView, column)
+	}
+	if _, ok := findAttributeDefinition(definitions, key); !ok {
+		return fmt.Errorf("%w: attribute %q is not defined", errors.ErrInvalidView, key)
+	}
+
+	return nil
+}
+
+// normalizeViewSharing checks the roles a view is shared with and sorts and
+// deduplicates the admins and roles.
+func normalizeViewSharing(sharing domain.ViewSharing) (domain.ViewSharing, error) {
+	var normalized domain.ViewSharing
+
+	for _, id := range sharing.AdminIDs {
+		if id <= 0 {
+			return domain.ViewSharing{}, fmt.Errorf("%w: invalid admin ID %d", errors.ErrInvalidView, id)
+		}
+		if !containsInt32(normalized.AdminIDs, id) {
+			normalized.AdminIDs = append(normalized.AdminIDs, id)
+		}
+	}
+	sort.Slice(normalized.AdminIDs, func(i, j int) bool { return normalized.AdminIDs[i] < normalized.AdminIDs[j] })
+
+	for _, role := range sharing.Roles {
+		if !containsString(domain.AdminRoles, role) {
+			return domain.ViewSharing{}, fmt.Errorf("%w: unknown role %q", errors.ErrInvalidView, role)
+		}
+		if !containsString(normalized.Roles, role) {
+			normalized.Roles = append(normalized.Roles, role)
+		}
+	}
+	sort.Strings(normalized.Roles)
+
+	return normalized, nil
+}
+
+func containsInt32(values []int32, value int32) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var _ service.ViewService = &ViewService{}
@@ -0,0 +1,211 @@
+package service_test
+
+import (
+	"admin-panel/internal/domain"
+	mocks "admin-panel/internal/mocks/repository"
+	"admin-panel/internal/service"
+	"admin-panel/pkg/lib/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	viewOwner      = domain.Actor{AdminID: 1, Role: "admin"}
+	sharedAdmin    = domain.Actor{AdminID: 2, Role: "admin"}
+	viewSuperAdmin = domain.Actor{AdminID: 3, Role: "super_admin"}
+)
+
+func TestCreateView(t *testing.T) {
+	testCases := []struct {
+		name        string
+		request     domain.ViewRequest
+		expected    *domain.ViewRequest
+		expectedErr error
+	}{
+		{
+			name: "Valid view",
+			request: domain.ViewRequest{
+				Name:       " Blocked VIPs ",
+				Query:      " aman ",
+				Filter:     domain.UserFilter{Tags: []string{"VIP"}, Attributes: map[string]interface{}{"loyalty_points": "10"}},
+				Sort:       "-registration_date, last_name",
+				Columns:    []string{"first_name", "attr.tier"},
+				SharedWith: domain.ViewSharing{AdminIDs: []int32{5, 2, 5}, Roles: []string{"super_admin", "super_admin"}},
+			},
+			expected: &domain.ViewRequest{
+				Name:       "Blocked VIPs",
+				Query:      "aman",
+				Filter:     domain.UserFilter{Tags: []string{"vip"}, Attributes: map[string]interface{}{"loyalty_points": float64(10)}},
+				Sort:       "-registration_date,last_name",
+				Columns:    []string{"first_name", "attr.tier"},
+				SharedWith: domain.ViewSharing{AdminIDs: []int32{2, 5}, Roles: []string{"super_admin"}},
+				OwnerID:    1,
+			},
+		},
+		{
+			name:     "Default columns",
+			request:  domain.ViewRequest{Name: "All"},
+			expected: &domain.ViewRequest{Name: "All", Columns: domain.ViewColumns, OwnerID: 1},
+		},
+		{
+			name:        "Missing name",
+			request:     domain.ViewRequest{Name: " "},
+			expectedErr: errors.ErrInvalidView,
+		},
+		{
+			name:        "Unknown column",
+			request:     domain.ViewRequest{Name: "All", Columns: []string{"password"}},
+			expectedErr: errors.ErrInvalidView,
+		},
+		{
+			name:        "Undefined attribute column",
+			request:     domain.ViewRequest{Name: "All", Columns: []string{"attr.unknown"}},
+			expectedErr: errors.ErrInvalidView,
+		},
+		{
+			name:        "Unknown role",
+			request:     domain.ViewRequest{Name: "All", SharedWith: domain.ViewSharing{Roles: []string{"moderator"}}},
+			expectedErr: errors.ErrInvalidView,
+		},
+		{
+			name:        "Unsortable field",
+			request:     domain.ViewRequest{Name: "All", Sort: "phone_number"},
+			expectedErr: errors.ErrInvalidSort,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			viewRepo := new(mocks.MockViewRepository)
+			if tc.expected != nil {
+				viewRepo.On("CreateView", tc.expected).Return(&domain.View{ID: 1}, nil)
+			}
+
+			viewService := service.NewViewService(viewRepo, new(mocks.MockUserRepository), attributeRepository(), phoneParser)
+			_, err := viewService.CreateView(&tc.request, viewOwner)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			viewRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateAndDeleteViewPermissions(t *testing.T) {
+	ownerID := int32(1)
+	view := &domain.View{ID: 4, Name: "Mine", OwnerID: &ownerID, SharedWith: domain.ViewSharing{Roles: []string{"admin", "super_admin"}}}
+	private := &domain.View{ID: 5, Name: "Private", OwnerID: &ownerID}
+
+	testCases := []struct {
+		name        string
+		view        *domain.View
+		actor       domain.Actor
+		delete      bool
+		expectedErr error
+	}{
+		{name: "Owner may update", view: view, actor: viewOwner},
+		{name: "Shared admin may not update", view: view, actor: sharedAdmin, expectedErr: errors.ErrViewNotOwner},
+		{name: "Super admin may not update", view: view, actor: viewSuperAdmin, expectedErr: errors.ErrViewNotOwner},
+		{name: "Super admin may delete shared view", view: view, actor: viewSuperAdmin, delete: true},
+		{name: "Shared admin may not delete", view: view, actor: sharedAdmin, delete: true, expectedErr: errors.ErrViewNotOwner},
+		{name: "Private view is hidden", view: private, actor: viewSuperAdmin, delete: true, expectedErr: errors.ErrViewNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			viewRepo := new(mocks.MockViewRepository)
+			viewRepo.On("GetViewByID", tc.view.ID).Return(tc.view, nil)
+			viewRepo.On("UpdateView", tc.view.ID, mock.Anything).Return(tc.view, nil)
+			viewRepo.On("DeleteView", tc.view.ID).Return(nil)
+
+			viewService := service.NewViewService(viewRepo, new(mocks.MockUserRepository), attributeRepository(), phoneParser)
+
+			var err error
+			if tc.delete {
+				err = viewService.DeleteView(tc.view.ID, tc.actor)
+			} else {
+				_, err = viewService.UpdateView(tc.view.ID, &domain.ViewRequest{Name: "Renamed"}, tc.actor)
+			}
+
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr != nil {
+				viewRepo.AssertNotCalled(t, "UpdateView", mock.Anything, mock.Anything)
+				viewRepo.AssertNotCalled(t, "DeleteView", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGetViewResults(t *testing.T) {
+	ownerID := int32(1)
+	blocked := true
+	view := &domain.View{
+		ID:   7,
+		Name: "Gold members",
+		Filter: domain.UserFilter{
+			Blocked:    &blocked,
+			Attributes: map[string]interface{}{"tier": "gold", "loyalty_points": "many", "referrer": "aman"},
+		},
+		Sort:       "-registration_date,referrer",
+		Columns:    []string{"first_name", "attr.tier", "attr.referrer"},
+		SharedWith: domain.ViewSharing{AdminIDs: []int32{2}},
+		OwnerID:    &ownerID,
+	}
+	expectedFilter := &domain.UserFilter{Blocked: &blocked, Attributes: map[string]interface{}{"tier": "gold"}}
+	expectedSort := domain.Sort{{Field: "registration_date", Descending: true}}
+
+	t.Run("Listing", func(t *testing.T) {
+		viewRepo := new(mocks.MockViewRepository)
+		viewRepo.On("GetViewByID", int32(7)).Return(view, nil)
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("GetAllUsers", 2, 10, expectedFilter, expectedSort).Return(&domain.UsersList{Users: []domain.GetUserResponse{{ID: 1}}}, nil)
+		userRepo.On("GetTotalUsersCount", expectedFilter).Return(11, nil)
+
+		results, total, err := service.NewViewService(viewRepo, userRepo, attributeRepository(), phoneParser).GetViewResults(7, 2, 10, sharedAdmin)
+
+		require.NoError(t, err)
+		assert.Equal(t, 11, total)
+		assert.Len(t, results.Users.Users, 1)
+		assert.Equal(t, "-registration_date", results.View.Sort)
+		assert.Equal(t, []string{"first_name", "attr.tier"}, results.View.Columns)
+		assert.Equal(t, *expectedFilter, results.View.Filter)
+		assert.Equal(t, []string{
+			`filter on attribute "loyalty_points" ignored: invalid attributes: "loyalty_points" must be a number`,
+			`filter on attribute "referrer" ignored: it is no longer defined`,
+			`sort on "referrer" ignored: it is no longer sortable`,
+			`column "attr.referrer" ignored: it no longer exists`,
+		}, results.Warnings)
+
+		// The stored view is left as saved.
+		assert.Equal(t, "-registration_date,referrer", view.Sort)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		searchView := *view
+		searchView.Query = "+993 65 12-34-56"
+
+		viewRepo := new(mocks.MockViewRepository)
+		viewRepo.On("GetViewByID", int32(7)).Return(&searchView, nil)
+		userRepo := new(mocks.MockUserRepository)
+		userRepo.On("SearchUsers", "+99365123456", 1, 10, expectedFilter, expectedSort).
+			Return(&domain.UsersList{Users: []domain.GetUserResponse{{ID: 1, PhoneNumber: "+99365123456"}}}, nil)
+		userRepo.On("GetSearchUsersCount", "+99365123456", expectedFilter).Return(1, nil)
+
+		results, total, err := service.NewViewService(viewRepo, userRepo, attributeRepository(), phoneParser).GetViewResults(7, 1, 10, viewOwner)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "+<mark>99365123456</mark>", results.Users.Users[0].Highlights["phone_number"])
+	})
+
+	t.Run("Not shared", func(t *testing.T) {
+		viewRepo := new(mocks.MockViewRepository)
+		viewRepo.On("GetViewByID", int32(7)).Return(view, nil)
+
+		_, _, err := service.NewViewService(viewRepo, new(mocks.MockUserRepository), attributeRepository(), phoneParser).GetViewResults(7, 1, 10, viewSuperAdmin)
+
+		assert.Equal(t, errors.ErrViewNotFound, err)
+	})
+}
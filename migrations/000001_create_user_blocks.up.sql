@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS user_blocks (
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason       VARCHAR(32)  NOT NULL,
+    note         TEXT         NOT NULL DEFAULT '',
+    blocked_by   INTEGER      REFERENCES admins (id) ON DELETE SET NULL,
+    blocked_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at   TIMESTAMP,
+    unblocked_by INTEGER      REFERENCES admins (id) ON DELETE SET NULL,
+    unblocked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_blocks_user_id_idx ON user_blocks (user_id, blocked_at DESC);
+
+-- A user has at most one active block.
+CREATE UNIQUE INDEX IF NOT EXISTS user_blocks_active_idx ON user_blocks (user_id) WHERE unblocked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS user_blocks_expires_at_idx ON user_blocks (expires_at) WHERE unblocked_at IS NULL AND expires_at IS NOT NULL;
+
+-- Users blocked before block records existed get an open-ended record.
+INSERT INTO user_blocks (user_id, reason)
+SELECT id, 'other' FROM users WHERE blocked = true;
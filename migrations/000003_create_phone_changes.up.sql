@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS phone_changes (
+    id               SERIAL PRIMARY KEY,
+    user_id          INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    old_phone_number VARCHAR(20) NOT NULL,
+    new_phone_number VARCHAR(20) NOT NULL,
+    status           VARCHAR(16) NOT NULL,
+    reason           TEXT        NOT NULL DEFAULT '',
+    code_hash        VARCHAR(64) NOT NULL DEFAULT '',
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    requested_by     INTEGER     REFERENCES admins (id) ON DELETE SET NULL,
+    requested_at     TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at       TIMESTAMP,
+    completed_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS phone_changes_user_id_idx ON phone_changes (user_id, requested_at DESC);
+
+-- A user has at most one pending phone change.
+CREATE UNIQUE INDEX IF NOT EXISTS phone_changes_pending_idx ON phone_changes (user_id) WHERE status = 'pending';
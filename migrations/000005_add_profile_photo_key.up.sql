@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_photo_key VARCHAR(255) NOT NULL DEFAULT '';
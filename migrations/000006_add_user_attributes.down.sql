@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_attribute_definitions;
+
+DROP INDEX IF EXISTS users_attributes_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS attributes;
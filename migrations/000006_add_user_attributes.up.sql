@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS users_attributes_idx ON users USING GIN (attributes jsonb_path_ops);
+
+CREATE TABLE IF NOT EXISTS user_attribute_definitions (
+    key         VARCHAR(64) PRIMARY KEY,
+    type        VARCHAR(16) NOT NULL,
+    enum_values TEXT[]      NOT NULL DEFAULT '{}',
+    required    BOOLEAN     NOT NULL DEFAULT false,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_tags (
+    user_id   INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tag_id    INTEGER   NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    tagged_by INTEGER   REFERENCES admins (id) ON DELETE SET NULL,
+    tagged_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_tags_tag_id_idx ON user_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS segments (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(128) NOT NULL UNIQUE,
+    description TEXT         NOT NULL DEFAULT '',
+    filter      JSONB        NOT NULL DEFAULT '{}',
+    created_by  INTEGER      REFERENCES admins (id) ON DELETE SET NULL,
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_notes (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    author_id  INTEGER     REFERENCES admins (id) ON DELETE SET NULL,
+    body       TEXT        NOT NULL,
+    pinned     BOOLEAN     NOT NULL DEFAULT FALSE,
+    visibility VARCHAR(16) NOT NULL DEFAULT 'all' CHECK (visibility IN ('all', 'super_admin')),
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_notes_user_id_idx ON user_notes (user_id, pinned DESC, created_at DESC);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS users_record_history ON users;
+DROP FUNCTION IF EXISTS record_user_history();
+DROP FUNCTION IF EXISTS user_snapshot(users);
+DROP TABLE IF EXISTS user_history;
@@ -0,0 +1,80 @@
+-- Every change to a user is recorded as a snapshot of the tracked fields
+-- together with the fields that changed. The snapshot keys and time formats
+-- match the user API representation so that snapshots decode directly.
+CREATE TABLE IF NOT EXISTS user_history (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    version    INTEGER   NOT NULL,
+    snapshot   JSONB     NOT NULL,
+    changes    JSONB     NOT NULL DEFAULT '{}',
+    changed_by INTEGER   REFERENCES admins (id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS user_history_changed_at_idx ON user_history (user_id, changed_at);
+
+CREATE OR REPLACE FUNCTION user_snapshot(u users) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'id', u.id,
+        'first_name', u.first_name,
+        'last_name', u.last_name,
+        'phone_number', u.phone_number,
+        'blocked', u.blocked,
+        'gender', u.gender,
+        'registration_date', to_char(u.registration_date, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'date_of_birth', to_char(u.date_of_birth, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'location', u.location,
+        'email', u.email,
+        'profile_photo_url', u.profile_photo_url,
+        'email_verified', u.email_verified,
+        'email_verified_at', to_char(u.email_verified_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'attributes', u.attributes
+    )
+$$ LANGUAGE SQL STABLE;
+
+-- The acting admin is taken from the transaction-local admin_panel.actor_id
+-- setting, which the application sets before changing a user.
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS TRIGGER AS $$
+DECLARE
+    new_snapshot JSONB := user_snapshot(NEW);
+    old_snapshot JSONB := '{}';
+    diff         JSONB;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_snapshot := user_snapshot(OLD);
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', o.value, 'new', n.value)), '{}')
+    INTO diff
+    FROM jsonb_each(new_snapshot) n
+    LEFT JOIN jsonb_each(old_snapshot) o ON o.key = n.key
+    WHERE n.key <> 'id' AND o.value IS DISTINCT FROM n.value;
+
+    -- Version bumps that leave the tracked fields alone, such as tagging,
+    -- are not part of the field history.
+    IF TG_OP = 'UPDATE' AND diff = '{}' THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO user_history (user_id, version, snapshot, changes, changed_by)
+    VALUES (NEW.id, NEW.version, new_snapshot, diff,
+            NULLIF(current_setting('admin_panel.actor_id', true), '')::INTEGER)
+    ON CONFLICT (user_id, version) DO UPDATE
+        SET snapshot = EXCLUDED.snapshot,
+            changes = user_history.changes || EXCLUDED.changes,
+            changed_by = COALESCE(EXCLUDED.changed_by, user_history.changed_by),
+            changed_at = EXCLUDED.changed_at;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_record_history
+    AFTER INSERT OR UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION record_user_history();
+
+-- Users created before history was recorded start with their current state.
+INSERT INTO user_history (user_id, version, snapshot)
+SELECT u.id, u.version, user_snapshot(u) FROM users u
+ON CONFLICT (user_id, version) DO NOTHING;
@@ -0,0 +1,41 @@
+UPDATE user_history SET user_id = merged_from WHERE merged_from IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS TRIGGER AS $$
+DECLARE
+    new_snapshot JSONB := user_snapshot(NEW);
+    old_snapshot JSONB := '{}';
+    diff         JSONB;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_snapshot := user_snapshot(OLD);
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', o.value, 'new', n.value)), '{}')
+    INTO diff
+    FROM jsonb_each(new_snapshot) n
+    LEFT JOIN jsonb_each(old_snapshot) o ON o.key = n.key
+    WHERE n.key <> 'id' AND o.value IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'UPDATE' AND diff = '{}' THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO user_history (user_id, version, snapshot, changes, changed_by)
+    VALUES (NEW.id, NEW.version, new_snapshot, diff,
+            NULLIF(current_setting('admin_panel.actor_id', true), '')::INTEGER)
+    ON CONFLICT (user_id, version) DO UPDATE
+        SET snapshot = EXCLUDED.snapshot,
+            changes = user_history.changes || EXCLUDED.changes,
+            changed_by = COALESCE(EXCLUDED.changed_by, user_history.changed_by),
+            changed_at = EXCLUDED.changed_at;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS user_history_version_idx;
+ALTER TABLE user_history ADD CONSTRAINT user_history_user_id_version_key UNIQUE (user_id, version);
+ALTER TABLE user_history DROP COLUMN IF EXISTS merged_from;
+
+ALTER TABLE users DROP COLUMN IF EXISTS merged_into;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,43 @@
+-- A user merged into another one is soft-deleted: the row is kept for
+-- reference but no longer listed, found or changed.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS merged_into INTEGER REFERENCES users (id) ON DELETE SET NULL;
+
+-- History moved to the surviving user keeps the versions it was recorded
+-- with, so versions are only unique among the user's own entries.
+ALTER TABLE user_history ADD COLUMN IF NOT EXISTS merged_from INTEGER;
+ALTER TABLE user_history DROP CONSTRAINT IF EXISTS user_history_user_id_version_key;
+CREATE UNIQUE INDEX IF NOT EXISTS user_history_version_idx ON user_history (user_id, version) WHERE merged_from IS NULL;
+
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS TRIGGER AS $$
+DECLARE
+    new_snapshot JSONB := user_snapshot(NEW);
+    old_snapshot JSONB := '{}';
+    diff         JSONB;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_snapshot := user_snapshot(OLD);
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', o.value, 'new', n.value)), '{}')
+    INTO diff
+    FROM jsonb_each(new_snapshot) n
+    LEFT JOIN jsonb_each(old_snapshot) o ON o.key = n.key
+    WHERE n.key <> 'id' AND o.value IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'UPDATE' AND diff = '{}' THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO user_history (user_id, version, snapshot, changes, changed_by)
+    VALUES (NEW.id, NEW.version, new_snapshot, diff,
+            NULLIF(current_setting('admin_panel.actor_id', true), '')::INTEGER)
+    ON CONFLICT (user_id, version) WHERE merged_from IS NULL DO UPDATE
+        SET snapshot = EXCLUDED.snapshot,
+            changes = user_history.changes || EXCLUDED.changes,
+            changed_by = COALESCE(EXCLUDED.changed_by, user_history.changed_by),
+            changed_at = EXCLUDED.changed_at;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS admins_role_id_idx;
+DROP INDEX IF EXISTS admins_username_id_idx;
+
+DROP INDEX IF EXISTS users_email_id_idx;
+DROP INDEX IF EXISTS users_location_id_idx;
+DROP INDEX IF EXISTS users_date_of_birth_id_idx;
+DROP INDEX IF EXISTS users_first_name_id_idx;
+DROP INDEX IF EXISTS users_last_name_id_idx;
+DROP INDEX IF EXISTS users_registration_date_id_idx;
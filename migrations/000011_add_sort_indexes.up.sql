@@ -0,0 +1,12 @@
+-- Listings sort by the chosen fields followed by the ID, so that these
+-- indexes serve both the order and the tie-break. Fields with few distinct
+-- values, such as gender and blocked, are left to the planner.
+CREATE INDEX IF NOT EXISTS users_registration_date_id_idx ON users (registration_date, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_last_name_id_idx ON users (last_name, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_first_name_id_idx ON users (first_name, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_date_of_birth_id_idx ON users (date_of_birth, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_location_id_idx ON users (location, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_email_id_idx ON users (email, id) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS admins_username_id_idx ON admins (username, id);
+CREATE INDEX IF NOT EXISTS admins_role_id_idx ON admins (role, id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS users_phone_digits_trgm_idx;
+DROP INDEX IF EXISTS users_search_text_trgm_idx;
+DROP INDEX IF EXISTS users_search_vector_idx;
+
+ALTER TABLE users DROP COLUMN IF EXISTS phone_digits;
+ALTER TABLE users DROP COLUMN IF EXISTS search_text;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+
+DROP EXTENSION IF EXISTS pg_trgm;
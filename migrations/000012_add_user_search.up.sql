@@ -0,0 +1,22 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The words of a user's name, email and location, for full-text prefix
+-- matching. Email addresses are split at @ and dots so that their parts
+-- match on their own.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', first_name || ' ' || last_name || ' ' || translate(email, '@.', '  ') || ' ' || location)
+) STORED;
+
+-- The same text lower-cased, for trigram similarity, which catches typos.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+    lower(first_name || ' ' || last_name || ' ' || email || ' ' || location)
+) STORED;
+
+-- Phone numbers reduced to their digits, whatever their formatting.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_digits TEXT GENERATED ALWAYS AS (
+    regexp_replace(phone_number, '[^0-9]', '', 'g')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS users_search_text_trgm_idx ON users USING GIN (search_text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_phone_digits_trgm_idx ON users USING GIN (phone_digits gin_trgm_ops);
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS users_search_text_trgm_idx;
+DROP INDEX IF EXISTS users_search_vector_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS search_text;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE users ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', first_name || ' ' || last_name || ' ' || translate(email, '@.', '  ') || ' ' || location)
+) STORED;
+
+ALTER TABLE users ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+    lower(first_name || ' ' || last_name || ' ' || email || ' ' || location)
+) STORED;
+
+CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS users_search_text_trgm_idx ON users USING GIN (search_text gin_trgm_ops);
+
+DROP INDEX IF EXISTS users_name_keys_missing_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS last_name_key;
+ALTER TABLE users DROP COLUMN IF EXISTS first_name_key;
@@ -0,0 +1,26 @@
+-- Transliterated, diacritic-folded keys of the first and last name, computed
+-- by the application so that names written in Latin and Cyrillic match each
+-- other. NULL until computed; existing users are backfilled at startup.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS first_name_key TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_name_key TEXT;
+
+CREATE INDEX IF NOT EXISTS users_name_keys_missing_idx ON users (id) WHERE first_name_key IS NULL;
+
+-- The search columns are rebuilt to include the name keys.
+DROP INDEX IF EXISTS users_search_text_trgm_idx;
+DROP INDEX IF EXISTS users_search_vector_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS search_text;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE users ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', first_name || ' ' || last_name || ' ' || translate(email, '@.', '  ') || ' ' || location
+        || ' ' || COALESCE(first_name_key, '') || ' ' || COALESCE(last_name_key, ''))
+) STORED;
+
+ALTER TABLE users ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+    lower(first_name || ' ' || last_name || ' ' || email || ' ' || location
+        || ' ' || COALESCE(first_name_key, '') || ' ' || COALESCE(last_name_key, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS users_search_text_trgm_idx ON users USING GIN (search_text gin_trgm_ops);
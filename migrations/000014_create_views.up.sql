@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS views (
+    id               SERIAL PRIMARY KEY,
+    name             VARCHAR(128) NOT NULL,
+    query            TEXT         NOT NULL DEFAULT '',
+    filter           JSONB        NOT NULL DEFAULT '{}',
+    sort             TEXT         NOT NULL DEFAULT '',
+    columns          TEXT[]       NOT NULL DEFAULT '{}',
+    shared_admin_ids INTEGER[]    NOT NULL DEFAULT '{}',
+    shared_roles     TEXT[]       NOT NULL DEFAULT '{}',
+    owner_id         INTEGER      REFERENCES admins (id) ON DELETE SET NULL,
+    created_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (owner_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS views_shared_admin_ids_idx ON views USING GIN (shared_admin_ids);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS reports (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(128) NOT NULL UNIQUE,
+    description TEXT         NOT NULL DEFAULT '',
+    definition  JSONB        NOT NULL,
+    created_by  INTEGER      REFERENCES admins (id) ON DELETE SET NULL,
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
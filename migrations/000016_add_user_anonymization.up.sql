@@ -0,0 +1,3 @@
+-- Anonymized users keep their row as a tombstone, without personal data, so
+-- that statistics and references to them stay consistent.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;
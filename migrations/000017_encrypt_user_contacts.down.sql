@@ -0,0 +1,85 @@
+-- The restored constraints and search columns work on plaintext only, so the
+-- rollback is refused until rotate-encryption-keys -decrypt has decrypted
+-- all values.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE phone_number LIKE 'enc:%' OR email LIKE 'enc:%')
+        OR EXISTS (SELECT 1 FROM phone_changes WHERE old_phone_number LIKE 'enc:%' OR new_phone_number LIKE 'enc:%')
+        OR EXISTS (SELECT 1 FROM email_verifications WHERE email LIKE 'enc:%')
+        OR EXISTS (SELECT 1 FROM user_history WHERE snapshot::text LIKE '%"enc:%' OR changes::text LIKE '%"enc:%')
+    THEN
+        RAISE EXCEPTION 'phone numbers and email addresses are still encrypted: stop the service and run rotate-encryption-keys -decrypt first';
+    END IF;
+END
+$$;
+
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS TRIGGER AS $$
+DECLARE
+    new_snapshot JSONB := user_snapshot(NEW);
+    old_snapshot JSONB := '{}';
+    diff         JSONB;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_snapshot := user_snapshot(OLD);
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', o.value, 'new', n.value)), '{}')
+    INTO diff
+    FROM jsonb_each(new_snapshot) n
+    LEFT JOIN jsonb_each(old_snapshot) o ON o.key = n.key
+    WHERE n.key <> 'id' AND o.value IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'UPDATE' AND diff = '{}' THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO user_history (user_id, version, snapshot, changes, changed_by)
+    VALUES (NEW.id, NEW.version, new_snapshot, diff,
+            NULLIF(current_setting('admin_panel.actor_id', true), '')::INTEGER)
+    ON CONFLICT (user_id, version) WHERE merged_from IS NULL DO UPDATE
+        SET snapshot = EXCLUDED.snapshot,
+            changes = user_history.changes || EXCLUDED.changes,
+            changed_by = COALESCE(EXCLUDED.changed_by, user_history.changed_by),
+            changed_at = EXCLUDED.changed_at;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The width of the users columns was never defined by a migration, so they
+-- stay TEXT.
+ALTER TABLE phone_changes ALTER COLUMN old_phone_number TYPE VARCHAR(20), ALTER COLUMN new_phone_number TYPE VARCHAR(20);
+ALTER TABLE email_verifications ALTER COLUMN email TYPE VARCHAR(254);
+
+DROP INDEX IF EXISTS users_search_text_trgm_idx;
+DROP INDEX IF EXISTS users_search_vector_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS search_text;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE users ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', first_name || ' ' || last_name || ' ' || translate(email, '@.', '  ') || ' ' || location
+        || ' ' || COALESCE(first_name_key, '') || ' ' || COALESCE(last_name_key, ''))
+) STORED;
+
+ALTER TABLE users ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+    lower(first_name || ' ' || last_name || ' ' || email || ' ' || location
+        || ' ' || COALESCE(first_name_key, '') || ' ' || COALESCE(last_name_key, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS users_search_text_trgm_idx ON users USING GIN (search_text gin_trgm_ops);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_digits TEXT GENERATED ALWAYS AS (
+    regexp_replace(phone_number, '[^0-9]', '', 'g')
+) STORED;
+CREATE INDEX IF NOT EXISTS users_phone_digits_trgm_idx ON users USING GIN (phone_digits gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS users_email_id_idx ON users (email, id) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS users_email_index_key;
+DROP INDEX IF EXISTS users_phone_number_index_key;
+ALTER TABLE users ADD CONSTRAINT users_phone_number_key UNIQUE (phone_number);
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE email <> '';
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_index;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_number_index;
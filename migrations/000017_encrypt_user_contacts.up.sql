@@ -0,0 +1,90 @@
+-- Phone numbers and email addresses are encrypted by the application and
+-- looked up by their blind indexes, keyed hashes of the plaintext. Existing
+-- values remain readable as plaintext. The service encrypts those of users
+-- and computes their indexes at startup, before serving requests; run
+-- rotate-encryption-keys to encrypt the ones kept in the user history, phone
+-- changes and email verifications.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number_index TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_index TEXT;
+
+-- Encrypting the same value twice gives different results, so uniqueness is
+-- enforced on the indexes instead. Empty email addresses have no index.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_phone_number_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_number_index_key ON users (phone_number_index);
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_index_key ON users (email_index);
+
+-- Encrypted values can be neither sorted nor searched by parts: email
+-- addresses leave the search columns and phone numbers are matched by their
+-- index only.
+DROP INDEX IF EXISTS users_email_id_idx;
+
+DROP INDEX IF EXISTS users_phone_digits_trgm_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_digits;
+
+DROP INDEX IF EXISTS users_search_text_trgm_idx;
+DROP INDEX IF EXISTS users_search_vector_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS search_text;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+
+-- An encrypted value carries its encrypted data key, nonces and tags and is
+-- base64-encoded, so it is far longer than the plaintext. The columns that
+-- depended on the plaintext columns are dropped above, which allows changing
+-- their type.
+ALTER TABLE users ALTER COLUMN phone_number TYPE TEXT, ALTER COLUMN email TYPE TEXT;
+ALTER TABLE phone_changes ALTER COLUMN old_phone_number TYPE TEXT, ALTER COLUMN new_phone_number TYPE TEXT;
+ALTER TABLE email_verifications ALTER COLUMN email TYPE TEXT;
+
+ALTER TABLE users ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', first_name || ' ' || last_name || ' ' || location
+        || ' ' || COALESCE(first_name_key, '') || ' ' || COALESCE(last_name_key, ''))
+) STORED;
+
+ALTER TABLE users ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+    lower(first_name || ' ' || last_name || ' ' || location
+        || ' ' || COALESCE(first_name_key, '') || ' ' || COALESCE(last_name_key, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS users_search_text_trgm_idx ON users USING GIN (search_text gin_trgm_ops);
+
+-- Re-encrypting a value under a new key changes the stored text but not the
+-- value, so key rotation turns history recording off for its transaction
+-- with the admin_panel.skip_history setting.
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS TRIGGER AS $$
+DECLARE
+    new_snapshot JSONB := user_snapshot(NEW);
+    old_snapshot JSONB := '{}';
+    diff         JSONB;
+BEGIN
+    IF current_setting('admin_panel.skip_history', true) = 'on' THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        old_snapshot := user_snapshot(OLD);
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', o.value, 'new', n.value)), '{}')
+    INTO diff
+    FROM jsonb_each(new_snapshot) n
+    LEFT JOIN jsonb_each(old_snapshot) o ON o.key = n.key
+    WHERE n.key <> 'id' AND o.value IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'UPDATE' AND diff = '{}' THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO user_history (user_id, version, snapshot, changes, changed_by)
+    VALUES (NEW.id, NEW.version, new_snapshot, diff,
+            NULLIF(current_setting('admin_panel.actor_id', true), '')::INTEGER)
+    ON CONFLICT (user_id, version) WHERE merged_from IS NULL DO UPDATE
+        SET snapshot = EXCLUDED.snapshot,
+            changes = user_history.changes || EXCLUDED.changes,
+            changed_by = COALESCE(EXCLUDED.changed_by, user_history.changed_by),
+            changed_at = EXCLUDED.changed_at;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
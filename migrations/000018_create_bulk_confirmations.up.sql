@@ -0,0 +1,8 @@
+-- Bulk confirmation tokens are recorded when used, so that each confirms its
+-- operation once.
+CREATE TABLE IF NOT EXISTS bulk_confirmations (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS bulk_confirmations_expires_at_idx ON bulk_confirmations (expires_at);
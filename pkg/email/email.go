@@ -0,0 +1,56 @@
+// Package email validates email addresses and normalizes them to a canonical
+// form suitable for storage and uniqueness checks.
+package email
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+const (
+	maxAddressLength   = 254
+	maxLocalPartLength = 64
+)
+
+// Normalize validates raw as an RFC 5322 addr-spec and returns it in
+// canonical form: lower case, with an internationalized domain converted to
+// its ASCII (punycode) form. Display names, comments and angle brackets are
+// rejected, as are domains without a dot.
+//
+// Local parts are lower-cased too. RFC 5321 allows case-sensitive mailboxes,
+// but no mainstream provider uses them and folding keeps the uniqueness of
+// stored addresses meaningful.
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, "<>") {
+		return "", ErrInvalidAddress
+	}
+
+	at := strings.LastIndex(raw, "@")
+	if at <= 0 || at == len(raw)-1 {
+		return "", ErrInvalidAddress
+	}
+
+	localPart := strings.ToLower(raw[:at])
+	domain, err := idna.Lookup.ToASCII(strings.ToLower(raw[at+1:]))
+	if err != nil || !strings.Contains(domain, ".") {
+		return "", ErrInvalidAddress
+	}
+
+	normalized := localPart + "@" + domain
+	if len(localPart) > maxLocalPartLength || len(normalized) > maxAddressLength {
+		return "", ErrInvalidAddress
+	}
+
+	address, err := mail.ParseAddress(normalized)
+	if err != nil || address.Name != "" {
+		return "", ErrInvalidAddress
+	}
+
+	return normalized, nil
+}
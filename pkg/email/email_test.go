@@ -0,0 +1,38 @@
+package email_test
+
+import (
+	"admin-panel/pkg/email"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		expected    string
+		expectedErr error
+	}{
+		{name: "plain", raw: "atdayewkemal@gmail.com", expected: "atdayewkemal@gmail.com"},
+		{name: "mixed case and spaces", raw: "  Kemal.Atdayew@GMail.COM ", expected: "kemal.atdayew@gmail.com"},
+		{name: "plus tag", raw: "kemal+news@example.com", expected: "kemal+news@example.com"},
+		{name: "internationalized domain", raw: "info@пример.рф", expected: "info@xn--e1afmkfd.xn--p1ai"},
+		{name: "display name", raw: "Kemal <kemal@example.com>", expectedErr: email.ErrInvalidAddress},
+		{name: "comment", raw: "kemal@example.com (Kemal)", expectedErr: email.ErrInvalidAddress},
+		{name: "missing domain", raw: "kemal@", expectedErr: email.ErrInvalidAddress},
+		{name: "missing local part", raw: "@example.com", expectedErr: email.ErrInvalidAddress},
+		{name: "domain without dot", raw: "kemal@localhost", expectedErr: email.ErrInvalidAddress},
+		{name: "double dot", raw: "kemal..atdayew@example.com", expectedErr: email.ErrInvalidAddress},
+		{name: "no at sign", raw: "kemal.example.com", expectedErr: email.ErrInvalidAddress},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			normalized, err := email.Normalize(tc.raw)
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expected, normalized)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Package encryption encrypts personal data before it is stored and derives
+// the blind indexes it is looked up by.
+//
+// Values are encrypted with envelope encryption: each value gets a random
+// data key, which encrypts it with AES-256-GCM and is itself encrypted with
+// the current version of the configured master keys. Rotating the master key
+// re-encrypts the data keys only. Encrypted values are stored as text:
+//
+//	enc:<key version>:<encrypted data key>:<encrypted value>
+//
+// with both parts base64-encoded, nonce first. Values without the enc:
+// prefix are taken for plaintext stored before encryption was enabled.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	prefix  = "enc:"
+	keySize = 32
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown encryption key version")
+	ErrInvalidValue = errors.New("invalid encrypted value")
+)
+
+// The fields phone numbers and email addresses are blind-indexed as. Every
+// writer of an index must use these, or lookups stop matching.
+const (
+	PhoneNumberField = "phone_number"
+	EmailField       = "email"
+)
+
+var encoding = base64.RawStdEncoding
+
+// Cipher encrypts and decrypts values and computes their blind indexes.
+type Cipher struct {
+	keys     map[int]cipher.AEAD
+	current  int
+	indexKey []byte
+}
+
+// New returns a Cipher like NewCipher, taking the keys encoded in base64.
+func New(keys map[int]string, current int, indexKey string) (*Cipher, error) {
+	decodedKeys := make(map[int][]byte, len(keys))
+	for version, encoded := range keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %d is not valid base64: %w", version, err)
+		}
+		decodedKeys[version] = key
+	}
+
+	decodedIndexKey, err := base64.StdEncoding.DecodeString(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("index key is not valid base64: %w", err)
+	}
+
+	return NewCipher(decodedKeys, current, decodedIndexKey)
+}
+
+// NewCipher returns a Cipher encrypting with keys[current] and decrypting
+// with any of keys. Keys are 32 bytes long; their versions are positive.
+func NewCipher(keys map[int][]byte, current int, indexKey []byte) (*Cipher, error) {
+	c := &Cipher{
+		keys:     make(map[int]cipher.AEAD, len(keys)),
+		current:  current,
+		indexKey: indexKey,
+	}
+
+	for version, key := range keys {
+		if version <= 0 {
+			return nil, fmt.Errorf("key version %d is not positive", version)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %d must be %d bytes long", version, keySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		c.keys[version] = aead
+	}
+
+	if _, ok := c.keys[current]; !ok {
+		return nil, fmt.Errorf("%w: current key %d is not configured", ErrUnknownKey, current)
+	}
+	if len(indexKey) < keySize {
+		return nil, fmt.Errorf("index key must be at least %d bytes long", keySize)
+	}
+
+	return c, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts plaintext under a new data key wrapped with the current
+// key. The empty string stays empty, so that a missing value still reads as
+// missing.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return c.wrap(c.current, dataKey, sealed)
+}
+
+// Decrypt returns the plaintext of value. Values that are not encrypted are
+// returned as they are.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	version, wrapped, sealed, ok, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return value, nil
+	}
+
+	dataKey, err := c.unwrap(version, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, sealed, nil)
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+
+	return string(plaintext), nil
+}
+
+// Current reports whether value is empty or encrypted with the current key,
+// that is whether Reencrypt would leave it alone.
+func (c *Cipher) Current(value string) bool {
+	if value == "" {
+		return true
+	}
+	version, _, _, ok, err := parse(value)
+	return ok && err == nil && version == c.current
+}
+
+// Reencrypt returns value with its data key wrapped with the current key
+// instead of the one it was encrypted with; the value itself is not
+// decrypted. Plaintext values are encrypted.
+func (c *Cipher) Reencrypt(value string) (string, error) {
+	version, wrapped, sealed, ok, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return c.Encrypt(value)
+	}
+	if version == c.current {
+		return value, nil
+	}
+
+	dataKey, err := c.unwrap(version, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	return c.wrap(c.current, dataKey, sealed)
+}
+
+// BlindIndex returns the keyed hash equal values of field are looked up and
+// kept unique by. Including field keeps equal values of different fields
+// apart. The empty string has no index and yields "".
+func (c *Cipher) BlindIndex(field, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return encoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Cipher) wrap(version int, dataKey, sealed []byte) (string, error) {
+	header := prefix + strconv.Itoa(version)
+
+	// The header is authenticated along with the data key, so that the
+	// version cannot be swapped.
+	wrapped, err := seal(c.keys[version], dataKey, []byte(header))
+	if err != nil {
+		return "", err
+	}
+
+	return header + ":" + encoding.EncodeToString(wrapped) + ":" + encoding.EncodeToString(sealed), nil
+}
+
+func (c *Cipher) unwrap(version int, wrapped []byte) ([]byte, error) {
+	aead, ok := c.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+
+	dataKey, err := open(aead, wrapped, []byte(prefix+strconv.Itoa(version)))
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+	return dataKey, nil
+}
+
+// parse splits an encrypted value into its parts, reporting with ok whether
+// value is encrypted at all.
+func parse(value string) (version int, wrapped, sealed []byte, ok bool, err error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return 0, nil, nil, false, nil
+	}
+
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return 0, nil, nil, true, ErrInvalidValue
+	}
+
+	if version, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, nil, nil, true, ErrInvalidValue
+	}
+	if wrapped, err = encoding.DecodeString(parts[1]); err != nil {
+		return 0, nil, nil, true, ErrInvalidValue
+	}
+	if sealed, err = encoding.DecodeString(parts[2]); err != nil {
+		return 0, nil, nil, true, ErrInvalidValue
+	}
+
+	return version, wrapped, sealed, true, nil
+}
+
+// seal encrypts plaintext with a random nonce, which it prepends.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidValue
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,123 @@
+package encryption_test
+
+import (
+	"admin-panel/pkg/encryption"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1     = bytes.Repeat([]byte{1}, 32)
+	key2     = bytes.Repeat([]byte{2}, 32)
+	indexKey = bytes.Repeat([]byte{3}, 32)
+)
+
+func newCipher(t *testing.T, keys map[int][]byte, current int) *encryption.Cipher {
+	c, err := encryption.NewCipher(keys, current, indexKey)
+	require.NoError(t, err)
+	return c
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := newCipher(t, map[int][]byte{1: key1}, 1)
+
+	first, err := c.Encrypt("+99365123456")
+	require.NoError(t, err)
+	second, err := c.Encrypt("+99365123456")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "enc:1:"))
+	assert.NotContains(t, first, "99365123456")
+	assert.NotEqual(t, first, second, "every value gets its own data key and nonce")
+
+	plaintext, err := c.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "+99365123456", plaintext)
+
+	empty, err := c.Encrypt("")
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+
+	// Values stored before encryption was enabled read as they are.
+	legacy, err := c.Decrypt("aman@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "aman@example.com", legacy)
+}
+
+func TestDecryptRejectsTamperedValues(t *testing.T) {
+	c := newCipher(t, map[int][]byte{1: key1, 2: key2}, 1)
+
+	value, err := c.Encrypt("aman@example.com")
+	require.NoError(t, err)
+	parts := strings.Split(value, ":")
+
+	testCases := []struct {
+		name        string
+		value       string
+		expectedErr error
+	}{
+		{name: "Malformed", value: "enc:1:abc", expectedErr: encryption.ErrInvalidValue},
+		{name: "Swapped key version", value: strings.Join([]string{parts[0], "2", parts[2], parts[3]}, ":"), expectedErr: encryption.ErrInvalidValue},
+		{name: "Unknown key version", value: strings.Join([]string{parts[0], "3", parts[2], parts[3]}, ":"), expectedErr: encryption.ErrUnknownKey},
+		{name: "Altered value", value: value[:len(value)-2] + "AA", expectedErr: encryption.ErrInvalidValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.Decrypt(tc.value)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	old := newCipher(t, map[int][]byte{1: key1}, 1)
+	value, err := old.Encrypt("aman@example.com")
+	require.NoError(t, err)
+
+	rotated := newCipher(t, map[int][]byte{1: key1, 2: key2}, 2)
+	assert.False(t, rotated.Current(value))
+
+	reencrypted, err := rotated.Reencrypt(value)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(reencrypted, "enc:2:"))
+	assert.True(t, rotated.Current(reencrypted))
+
+	// The old key is no longer needed once the value is re-encrypted.
+	current := newCipher(t, map[int][]byte{2: key2}, 2)
+	plaintext, err := current.Decrypt(reencrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "aman@example.com", plaintext)
+
+	encrypted, err := current.Reencrypt("+99365123456")
+	require.NoError(t, err)
+	assert.True(t, current.Current(encrypted))
+	assert.True(t, current.Current(""))
+	assert.False(t, current.Current("+99365123456"))
+}
+
+func TestBlindIndex(t *testing.T) {
+	c := newCipher(t, map[int][]byte{1: key1}, 1)
+	rotated := newCipher(t, map[int][]byte{1: key1, 2: key2}, 2)
+
+	index := c.BlindIndex("email", "aman@example.com")
+	assert.Equal(t, index, rotated.BlindIndex("email", "aman@example.com"), "indexes do not depend on the encryption key")
+	assert.NotEqual(t, index, c.BlindIndex("email", "ali@example.com"))
+	assert.NotEqual(t, index, c.BlindIndex("phone_number", "aman@example.com"))
+	assert.Equal(t, "", c.BlindIndex("email", ""))
+}
+
+func TestNewCipherValidatesKeys(t *testing.T) {
+	_, err := encryption.NewCipher(map[int][]byte{1: key1}, 2, indexKey)
+	assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+
+	_, err = encryption.NewCipher(map[int][]byte{1: key1[:16]}, 1, indexKey)
+	assert.Error(t, err)
+
+	_, err = encryption.NewCipher(map[int][]byte{1: key1}, 1, nil)
+	assert.Error(t, err)
+}
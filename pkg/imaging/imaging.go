@@ -0,0 +1,56 @@
+// Package imaging produces resized renditions of uploaded images.
+package imaging
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// Fit scales img down so that neither side exceeds max, keeping the aspect
+// ratio. Images that already fit are returned unchanged.
+func Fit(img image.Image, max int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= max && height <= max {
+		return img
+	}
+
+	if width >= height {
+		height = maxInt(1, height*max/width)
+		width = max
+	} else {
+		width = maxInt(1, width*max/height)
+		height = max
+	}
+
+	return scale(img, bounds, width, height)
+}
+
+// Thumbnail crops the centred square of img and scales it to size x size.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := image.Rect(x, y, x+side, y+side)
+
+	return scale(img, square, size, size)
+}
+
+func scale(img image.Image, src image.Rectangle, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,42 @@
+package imaging
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name           string
+		width, height  int
+		max            int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"Landscape", 2000, 1000, 1024, 1024, 512},
+		{"Portrait", 300, 1200, 600, 150, 600},
+		{"AlreadyFits", 640, 480, 1024, 640, 480},
+		{"VeryThin", 5000, 2, 100, 100, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+
+			result := Fit(img, tt.max)
+
+			assert.Equal(t, tt.expectedWidth, result.Bounds().Dx())
+			assert.Equal(t, tt.expectedHeight, result.Bounds().Dy())
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img := image.NewRGBA(image.Rect(10, 10, 410, 210))
+
+	result := Thumbnail(img, 64)
+
+	assert.Equal(t, image.Rect(0, 0, 64, 64), result.Bounds())
+}
@@ -29,14 +29,72 @@ var (
 
 // user & admin
 const (
+	UnsupportedPatchFormat   = "Unsupported patch format, use application/merge-patch+json or application/json-patch+json"
+	PatchTestFailed          = "Patch test operation failed"
+	AdminAlreadyExists       = "Admin with the same username already exists"
+	InvalidAdminRole         = "Role must be admin or super_admin"
+	PreconditionFailed       = "Precondition failed: the resource has been modified"
+	PreconditionRequired     = "If-Match header is required"
 	InternalServerError      = "Internal server error"
 	InvalidID                = "Invalid ID"
 	InvalidRequestBody       = "Invalid request body"
 	InvalidPhoneNumberFormat = "Invalid phone number format"
+	PhoneCountryNotAllowed   = "Phone numbers from this country are not accepted"
+	InvalidEmailFormat       = "Invalid email address"
 	SearchQueryRequired      = "Search query is required"
 	UserNotFound             = "User not found"
 	PhoneNumberAlreadyInUse  = "Phone number already in use"
 	EmailAlreadyInUse        = "Email already in use"
+	InvalidBlockReason       = "Invalid block reason"
+	InvalidBlockExpiry       = "Block expiry must be in the future"
+)
+
+// bulk actions
+const (
+	InvalidBulkAction        = "Action must be block, unblock or delete"
+	InvalidBulkMode          = "Mode must be atomic or best_effort"
+	BulkTargetRequired       = "Exactly one of ids, query/filter or segment_id is required"
+	BulkConfirmationRequired = "This operation affects many users, repeat it with confirmation_token to proceed"
+)
+
+var (
+	ErrInvalidBulkAction        = errors.New("invalid bulk action")
+	ErrInvalidBulkMode          = errors.New("invalid bulk mode")
+	ErrBulkTargetRequired       = errors.New("bulk target required")
+	ErrBulkTooManyUsers         = errors.New("bulk operation exceeds the maximum number of users")
+	ErrBulkConfirmationRequired = errors.New("bulk operation requires confirmation")
+)
+
+// duplicates
+const (
+	InvalidMinScore = "min_score must be a number between 0 and 1"
+	MergeSameUser   = "duplicate_id must be the ID of another user"
+)
+
+var (
+	ErrInvalidMinScore    = errors.New("invalid minimum score")
+	ErrMergeSameUser      = errors.New("cannot merge a user into itself")
+	ErrInvalidMergeFields = errors.New("invalid merge fields")
+)
+
+// phone change
+const (
+	PhoneNumberUnchanged        = "New phone number matches the current one"
+	PhoneChangeNotFound         = "No pending phone number change"
+	PhoneChangeExpired          = "Verification code has expired"
+	InvalidVerificationCode     = "Invalid verification code"
+	TooManyVerificationAttempts = "Too many verification attempts, start a new phone number change"
+	ReasonRequired              = "Reason is required"
+	SMSDeliveryFailed           = "Failed to send verification code"
+)
+
+var (
+	ErrPhoneNumberUnchanged        = errors.New("new phone number matches the current one")
+	ErrPhoneChangeNotFound         = errors.New("no pending phone number change")
+	ErrPhoneChangeExpired          = errors.New("verification code has expired")
+	ErrInvalidVerificationCode     = errors.New("invalid verification code")
+	ErrTooManyVerificationAttempts = errors.New("too many verification attempts")
+	ErrSMSDeliveryFailed           = errors.New("failed to send verification code")
 )
 
 var (
@@ -48,14 +106,217 @@ var (
 	ErrFillRequiredFields     = errors.New("username, password, and role are required fields")
 	ErrGettingTotalAdminCount = errors.New("error getting total admins count")
 	ErrGettingAdmins          = errors.New("error getting admins")
+	ErrFieldNotPatchable      = errors.New("field cannot be patched")
+	ErrFieldRequired          = errors.New("field cannot be null or empty")
+	ErrInvalidAdminRole       = errors.New("invalid admin role")
+)
+
+var (
+	ErrPreconditionFailed = errors.New("resource version does not match If-Match")
+)
+
+var (
+	ErrUserNotFound           = errors.New("user not found")
+	ErrUserAlreadyExists      = errors.New("user already exists")
+	ErrPhoneNumberInUse       = errors.New("phone number already in use")
+	ErrEmailInUse             = errors.New("email already in use")
+	ErrInvalidEmail           = errors.New("invalid email address")
+	ErrInvalidPhoneNumber     = errors.New("invalid phone number format")
+	ErrPhoneCountryNotAllowed = errors.New("phone numbers from this country are not accepted")
+	ErrInvalidBlockReason     = errors.New("invalid block reason")
+	ErrInvalidBlockExpiry     = errors.New("block expiry must be in the future")
+)
+
+// user history
+const (
+	UserVersionNotFound = "User version not found"
+	InvalidAsOf         = "Invalid as_of timestamp, use RFC 3339"
+)
+
+var (
+	ErrUserVersionNotFound = errors.New("user version not found")
+)
+
+// email verification
+const (
+	EmailMissing             = "User has no email address"
+	EmailAlreadyVerified     = "Email address is already verified"
+	InvalidVerificationLink  = "Invalid or already used verification link"
+	VerificationLinkExpired  = "Verification link has expired"
+	EmailDeliveryFailed      = "Failed to send verification email"
+	VerificationTokenMissing = "Verification token is required"
+)
+
+var (
+	ErrEmailMissing            = errors.New("user has no email address")
+	ErrEmailAlreadyVerified    = errors.New("email address is already verified")
+	ErrInvalidVerificationLink = errors.New("invalid or already used verification link")
+	ErrVerificationLinkExpired = errors.New("verification link has expired")
+	ErrEmailDeliveryFailed     = errors.New("failed to send verification email")
+)
+
+// profile photos
+const (
+	MultipartRequired    = "Request must be multipart/form-data"
+	PhotoMissing         = "Multipart field \"photo\" is required"
+	PhotoTooLarge        = "Photo exceeds the maximum file size or dimensions"
+	UnsupportedPhotoType = "Photo must be a JPEG, PNG or GIF image"
+	InvalidPhoto         = "Photo could not be decoded"
+	PhotoStorageFailed   = "Failed to store photo"
+)
+
+var (
+	ErrPhotoTooLarge        = errors.New("photo exceeds the maximum size")
+	ErrUnsupportedPhotoType = errors.New("unsupported photo type")
+	ErrInvalidPhoto         = errors.New("photo could not be decoded")
+	ErrPhotoStorageFailed   = errors.New("failed to store photo")
+)
+
+// import
+const (
+	ImportFileMissing       = "Multipart field \"file\" is required"
+	ImportFileTooLarge      = "Import file is too large"
+	UnsupportedImportFormat = "Import file must be CSV or XLSX"
+	InvalidImportMapping    = "Invalid column mapping"
+	InvalidImportFile       = "Import file could not be read"
+	TooManyImportRows       = "Import file has too many rows"
+	InvalidImportDate       = "Invalid date, use YYYY-MM-DD, DD.MM.YYYY or RFC 3339"
+	DuplicateImportRow      = "Value appears more than once in the file"
+	MergedUserPhoneNumber   = "Phone number belongs to a user merged into user %d, update that user instead"
+	DeletedUserPhoneNumber  = "Phone number belongs to a deleted user"
+	ImportBatchFailed       = "Row was not imported because its batch failed"
+)
+
+var (
+	ErrImportFileTooLarge      = errors.New("import file is too large")
+	ErrUnsupportedImportFormat = errors.New("unsupported import format")
+	ErrInvalidImportMapping    = errors.New("invalid column mapping")
+	ErrInvalidImportFile       = errors.New("import file could not be read")
+	ErrTooManyImportRows       = errors.New("too many import rows")
+)
+
+// export
+const (
+	UnsupportedExportFormat = "Export format must be csv, ndjson or xlsx"
+)
+
+var (
+	ErrUnsupportedExportFormat = errors.New("unsupported export format")
+	ErrInvalidExportColumns    = errors.New("invalid export columns")
+)
+
+// custom attributes
+const (
+	AttributeNotFound          = "Attribute not found"
+	AttributeAlreadyExists     = "Attribute already exists"
+	InvalidAttributeDefinition = "Invalid attribute definition"
+)
+
+var (
+	ErrInvalidAttributes          = errors.New("invalid attributes")
+	ErrAttributeNotFound          = errors.New("attribute not found")
+	ErrAttributeAlreadyExists     = errors.New("attribute already exists")
+	ErrInvalidAttributeDefinition = errors.New("invalid attribute definition")
+)
+
+// tags
+const (
+	TagNotFound      = "Tag not found"
+	TagAlreadyExists = "Tag already exists"
+	InvalidTagName   = "Tag names must be 1-64 lowercase letters, digits, hyphens or underscores"
+	TagsRequired     = "At least one tag is required"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+	ErrInvalidTagName   = errors.New("invalid tag name")
+	ErrTagsRequired     = errors.New("at least one tag is required")
+)
+
+// segments
+const (
+	SegmentNotFound      = "Segment not found"
+	SegmentAlreadyExists = "Segment already exists"
+)
+
+var (
+	ErrSegmentNotFound      = errors.New("segment not found")
+	ErrSegmentAlreadyExists = errors.New("segment already exists")
+	ErrInvalidSegment       = errors.New("invalid segment")
+	ErrInvalidFilter        = errors.New("invalid filter")
+)
+
+// views
+const (
+	ViewNotFound      = "View not found"
+	ViewAlreadyExists = "You already have a view with this name"
+	ViewNotOwner      = "Only the owner can change this view"
+)
+
+var (
+	ErrViewNotFound      = errors.New("view not found")
+	ErrViewAlreadyExists = errors.New("view already exists")
+	ErrViewNotOwner      = errors.New("only the owner can change this view")
+	ErrInvalidView       = errors.New("invalid view")
+)
+
+// data subject requests
+const (
+	UserAlreadyAnonymized = "User has already been anonymized"
+	UserAnonymized        = "User has been anonymized and cannot be changed"
+	SuperAdminRequired    = "Only super admins can export or anonymize user data"
+)
+
+var (
+	ErrUserAlreadyAnonymized = errors.New("user has already been anonymized")
+	ErrUserAnonymized        = errors.New("user has been anonymized")
+	ErrSuperAdminRequired    = errors.New("only super admins can export or anonymize user data")
+)
+
+// reports
+const (
+	ReportNotFound          = "Report not found"
+	ReportAlreadyExists     = "Report already exists"
+	UnsupportedReportFormat = "Report format must be json or csv"
+	ReportTimedOut          = "Report took too long, narrow it down with filters or fewer dimensions"
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrReportAlreadyExists = errors.New("report already exists")
+	ErrInvalidReport       = errors.New("invalid report")
+	ErrReportTimedOut      = errors.New("report timed out")
+)
+
+// statistics
+var (
+	ErrInvalidStatsRequest = errors.New("invalid statistics request")
+)
+
+// sorting & cursors
+var (
+	ErrInvalidSort      = errors.New("invalid sort")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidCountMode = errors.New("count must be exact or estimated")
+)
+
+// notes
+const (
+	NoteNotFound          = "Note not found"
+	NoteBodyRequired      = "Note body is required"
+	NoteTooLong           = "Note body is too long"
+	InvalidNoteVisibility = "Invalid note visibility"
+	NoteNotAuthor         = "Only the author can edit this note"
 )
 
 var (
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrPhoneNumberInUse   = errors.New("phone number already in use")
-	ErrEmailInUse         = errors.New("email already in use")
-	ErrInvalidPhoneNumber = errors.New("invalid phone number format")
+	ErrNoteNotFound           = errors.New("note not found")
+	ErrNoteBodyRequired       = errors.New("note body is required")
+	ErrNoteTooLong            = errors.New("note body is too long")
+	ErrInvalidNoteVisibility  = errors.New("invalid note visibility")
+	ErrNoteNotAuthor          = errors.New("only the author can edit this note")
+	ErrInsufficientPermission = errors.New("insufficient permissions")
 )
 
 // middleware
@@ -0,0 +1,347 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902)
+// documents to generic JSON values as produced by encoding/json.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrTestFailed   = errors.New("patch test operation failed")
+)
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ToDocument converts v into its generic JSON representation.
+func ToDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// MergePatch applies an RFC 7396 merge patch to doc and returns the result.
+// doc itself is left untouched.
+func MergePatch(doc interface{}, patch []byte) (interface{}, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return mergeValue(deepCopy(doc), p), nil
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+// Apply applies an RFC 6902 JSON Patch to doc and returns the result. The
+// operations are applied atomically: on error doc is left untouched and no
+// partial result is returned.
+func Apply(doc interface{}, patch []byte) (interface{}, error) {
+	var operations []Operation
+
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	result := deepCopy(doc)
+	for i, operation := range operations {
+		var err error
+		result, err = applyOperation(result, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return result, nil
+}
+
+func applyOperation(doc interface{}, operation Operation) (interface{}, error) {
+	switch operation.Op {
+	case "add", "replace", "test":
+		if len(operation.Value) == 0 {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch operation.Op {
+		case "add":
+			return add(doc, operation.Path, value)
+		case "replace":
+			if _, err := get(doc, operation.Path); err != nil {
+				return nil, err
+			}
+			doc, err := remove(doc, operation.Path)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, operation.Path, value)
+		default:
+			current, err := get(doc, operation.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, operation.Path)
+	case "move", "copy":
+		value, err := get(doc, operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "move" {
+			if strings.HasPrefix(operation.Path, operation.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+			}
+			doc, err = remove(doc, operation.From)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+
+		return add(doc, operation.Path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, operation.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, pointer)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, pointer)
+		}
+	}
+
+	return current, nil
+}
+
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		var index int
+		if last == "-" {
+			index = len(node)
+		} else {
+			index, err = arrayIndex(last, len(node))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		updated := append(node[:index:index], append([]interface{}{value}, node[index:]...)...)
+		return replaceParent(doc, tokens[:len(tokens)-1], updated)
+	default:
+		return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, pointer)
+	}
+}
+
+func remove(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	parent, err := get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, pointer)
+		}
+		delete(node, last)
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		updated := append(node[:index:index], node[index+1:]...)
+		return replaceParent(doc, tokens[:len(tokens)-1], updated)
+	default:
+		return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, pointer)
+	}
+}
+
+// replaceParent stores a modified array back at the location given by tokens,
+// since slices cannot be grown in place.
+func replaceParent(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	}
+
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("%w: array index %q out of range", ErrInvalidPatch, token)
+	}
+
+	return index, nil
+}
+
+func joinPointer(tokens []string) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteString("/")
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return builder.String()
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
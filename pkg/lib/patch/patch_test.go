@@ -0,0 +1,299 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func document(t *testing.T, raw string) interface{} {
+	t.Helper()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &doc))
+	return doc
+}
+
+func TestApply(t *testing.T) {
+	const doc = `{"name":"Kemal","tags":["a","b","c"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`
+
+	testCases := []struct {
+		name     string
+		patch    string
+		expected string
+		err      error
+	}{
+		{
+			name:     "Add object member",
+			patch:    `[{"op":"add","path":"/location","value":"Mary"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2,"location":"Mary"}`,
+		},
+		{
+			name:     "Add replaces existing member",
+			patch:    `[{"op":"add","path":"/name","value":"Aman"}]`,
+			expected: `{"name":"Aman","tags":["a","b","c"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Add inserts into array",
+			patch:    `[{"op":"add","path":"/tags/1","value":"x"}]`,
+			expected: `{"name":"Kemal","tags":["a","x","b","c"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Add at array length appends",
+			patch:    `[{"op":"add","path":"/tags/3","value":"x"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c","x"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Add with dash appends",
+			patch:    `[{"op":"add","path":"/tags/-","value":"x"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c","x"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Add nested member",
+			patch:    `[{"op":"add","path":"/address/street","value":"Magtymguly"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c"],"address":{"city":"Ashgabat","street":"Magtymguly"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Add replaces whole document",
+			patch:    `[{"op":"add","path":"","value":{"name":"Aman"}}]`,
+			expected: `{"name":"Aman"}`,
+		},
+		{
+			name:  "Add past array length",
+			patch: `[{"op":"add","path":"/tags/4","value":"x"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Add to missing parent",
+			patch: `[{"op":"add","path":"/missing/city","value":"Mary"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Add without value",
+			patch: `[{"op":"add","path":"/location"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:     "Remove object member",
+			patch:    `[{"op":"remove","path":"/address"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c"],"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Remove array element",
+			patch:    `[{"op":"remove","path":"/tags/0"}]`,
+			expected: `{"name":"Kemal","tags":["b","c"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "Remove missing member",
+			patch: `[{"op":"remove","path":"/location"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Remove array element past the end",
+			patch: `[{"op":"remove","path":"/tags/3"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Remove with dash",
+			patch: `[{"op":"remove","path":"/tags/-"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Remove whole document",
+			patch: `[{"op":"remove","path":""}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:     "Replace member",
+			patch:    `[{"op":"replace","path":"/address/city","value":"Mary"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c"],"address":{"city":"Mary"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Replace array element",
+			patch:    `[{"op":"replace","path":"/tags/2","value":"z"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","z"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "Replace missing member",
+			patch: `[{"op":"replace","path":"/location","value":"Mary"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:     "Move member",
+			patch:    `[{"op":"move","from":"/address/city","path":"/city"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c"],"address":{},"a/b":1,"m~n":2,"city":"Ashgabat"}`,
+		},
+		{
+			name:     "Move array element",
+			patch:    `[{"op":"move","from":"/tags/0","path":"/tags/-"}]`,
+			expected: `{"name":"Kemal","tags":["b","c","a"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "Move into own child",
+			patch: `[{"op":"move","from":"/address","path":"/address/old"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Move from missing member",
+			patch: `[{"op":"move","from":"/location","path":"/city"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:     "Copy member",
+			patch:    `[{"op":"copy","from":"/address","path":"/billing"},{"op":"replace","path":"/billing/city","value":"Mary"}]`,
+			expected: `{"name":"Kemal","tags":["a","b","c"],"address":{"city":"Ashgabat"},"billing":{"city":"Mary"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:     "Test passes",
+			patch:    `[{"op":"test","path":"/tags","value":["a","b","c"]},{"op":"replace","path":"/name","value":"Aman"}]`,
+			expected: `{"name":"Aman","tags":["a","b","c"],"address":{"city":"Ashgabat"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "Test fails",
+			patch: `[{"op":"test","path":"/name","value":"Aman"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "Test missing member",
+			patch: `[{"op":"test","path":"/location","value":"Mary"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Unknown operation",
+			patch: `[{"op":"merge","path":"/name","value":"Aman"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Unknown operation member",
+			patch: `[{"op":"add","path":"/name","value":"Aman","extra":true}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "Not an array of operations",
+			patch: `{"op":"add","path":"/name","value":"Aman"}`,
+			err:   ErrInvalidPatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := document(t, doc)
+
+			result, err := Apply(original, []byte(tc.patch))
+
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err), "unexpected error %v", err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, document(t, tc.expected), result)
+			}
+			assert.Equal(t, document(t, doc), original, "the original document must not change")
+		})
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	original := document(t, `{"name":"Kemal","tags":["a"]}`)
+
+	result, err := Apply(original, []byte(`[{"op":"add","path":"/tags/-","value":"b"},{"op":"test","path":"/name","value":"Aman"}]`))
+
+	assert.True(t, errors.Is(err, ErrTestFailed))
+	assert.Nil(t, result)
+	assert.Equal(t, document(t, `{"name":"Kemal","tags":["a"]}`), original)
+}
+
+func TestPointers(t *testing.T) {
+	doc := `{"a/b":1,"m~n":2,"~1":3,"":4,"list":[10,20]}`
+
+	testCases := []struct {
+		name     string
+		pointer  string
+		expected interface{}
+		err      bool
+	}{
+		{name: "Escaped slash", pointer: "/a~1b", expected: float64(1)},
+		{name: "Escaped tilde", pointer: "/m~0n", expected: float64(2)},
+		{name: "Tilde unescaped last", pointer: "/~01", expected: float64(3)},
+		{name: "Empty member name", pointer: "/", expected: float64(4)},
+		{name: "Array index", pointer: "/list/1", expected: float64(20)},
+		{name: "Whole document", pointer: "", expected: document(t, doc)},
+		{name: "Missing leading slash", pointer: "list/0", err: true},
+		{name: "Unescaped slash does not match", pointer: "/a/b", err: true},
+		{name: "Missing member", pointer: "/missing", err: true},
+		{name: "Index out of range", pointer: "/list/2", err: true},
+		{name: "Negative index", pointer: "/list/-1", err: true},
+		{name: "Leading zero", pointer: "/list/01", err: true},
+		{name: "Non-numeric index", pointer: "/list/first", err: true},
+		{name: "Empty index", pointer: "/list/", err: true},
+		{name: "Dash does not exist", pointer: "/list/-", err: true},
+		{name: "Through a scalar", pointer: "/a~1b/c", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := get(document(t, doc), tc.pointer)
+
+			if tc.err {
+				assert.True(t, errors.Is(err, ErrInvalidPatch), "unexpected error %v", err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestJoinPointer(t *testing.T) {
+	tokens, err := parsePointer("/a~1b/m~0n/~01")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a/b", "m~n", "~1"}, tokens)
+	assert.Equal(t, "/a~1b/m~0n/~01", joinPointer(tokens))
+}
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396, appendix A.
+	testCases := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.doc+" "+tc.patch, func(t *testing.T) {
+			original := document(t, tc.doc)
+
+			result, err := MergePatch(original, []byte(tc.patch))
+
+			require.NoError(t, err)
+			assert.Equal(t, document(t, tc.expected), result)
+			assert.Equal(t, document(t, tc.doc), original, "the original document must not change")
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	_, err := MergePatch(map[string]interface{}{}, []byte(`{"a":`))
+
+	assert.True(t, errors.Is(err, ErrInvalidPatch))
+}
@@ -3,12 +3,21 @@ package status
 import "net/http"
 
 const (
-	BadRequest          = http.StatusBadRequest
-	Unauthorized        = http.StatusUnauthorized
-	NotFound            = http.StatusNotFound
-	OK                  = http.StatusOK
-	InternalServerError = http.StatusInternalServerError
-	Forbidden           = http.StatusForbidden
-	Conflict            = http.StatusConflict
-	Created             = http.StatusCreated
+	BadRequest            = http.StatusBadRequest
+	Unauthorized          = http.StatusUnauthorized
+	NotFound              = http.StatusNotFound
+	OK                    = http.StatusOK
+	InternalServerError   = http.StatusInternalServerError
+	Forbidden             = http.StatusForbidden
+	Conflict              = http.StatusConflict
+	Created               = http.StatusCreated
+	UnsupportedMediaType  = http.StatusUnsupportedMediaType
+	PreconditionFailed    = http.StatusPreconditionFailed
+	PreconditionRequired  = http.StatusPreconditionRequired
+	Accepted              = http.StatusAccepted
+	Gone                  = http.StatusGone
+	TooManyRequests       = http.StatusTooManyRequests
+	BadGateway            = http.StatusBadGateway
+	RequestEntityTooLarge = http.StatusRequestEntityTooLarge
+	GatewayTimeout        = http.StatusGatewayTimeout
 )
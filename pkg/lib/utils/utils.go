@@ -2,13 +2,18 @@ package utils
 
 import (
 	"admin-panel/internal/domain"
-	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strings"
+
+	"github.com/lib/pq"
 )
 
+// RowScanner is implemented by both *sql.Row and *sql.Rows.
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 func Err(err error) slog.Attr {
 	return slog.Attr{
 		Key:   "error",
@@ -16,9 +21,13 @@ func Err(err error) slog.Attr {
 	}
 }
 
-func IsValidPhoneNumber(phoneNumber string) bool {
-	const validPrefix = "+993"
-	return len(phoneNumber) == 12 && strings.HasPrefix(phoneNumber, validPrefix)
+func IsValidBlockReason(reason domain.BlockReason) bool {
+	for _, validReason := range domain.BlockReasons {
+		if reason == validReason {
+			return true
+		}
+	}
+	return false
 }
 
 func RespondWithErrorJSON(w http.ResponseWriter, status int, message string) {
@@ -42,10 +51,12 @@ func RespondWithJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func ScanUserRow(rows *sql.Rows) (domain.GetUserResponse, error) {
+// ScanUserRow scans a row selected with the repository's user column list,
+// including the reason and expiry of the user's current block.
+func ScanUserRow(row RowScanner) (domain.GetUserResponse, error) {
 	var user domain.GetUserResponse
 
-	if err := rows.Scan(
+	if err := row.Scan(
 		&user.ID,
 		&user.FirstName,
 		&user.LastName,
@@ -57,8 +68,14 @@ func ScanUserRow(rows *sql.Rows) (domain.GetUserResponse, error) {
 		&user.Location,
 		&user.Email,
 		&user.ProfilePhotoURL,
+		&user.EmailVerified,
+		&user.EmailVerifiedAt,
+		&user.Version,
+		&user.Attributes,
+		pq.Array(&user.Tags),
+		&user.BlockReason,
+		&user.BlockExpiresAt,
 	); err != nil {
-		slog.Error("Error scanning user row: %v", Err(err))
 		return domain.GetUserResponse{}, err
 	}
 
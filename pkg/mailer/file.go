@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer writes every message to an .eml file in a directory, so that
+// emails sent during local development can be opened with a mail client.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(message Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(message.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, message), 0o644)
+}
+
+var _ Mailer = &FileMailer{}
@@ -0,0 +1,52 @@
+// Package mailer delivers plain text emails through SMTP or, for local
+// development and tests, to files or memory.
+package mailer
+
+import (
+	"admin-panel/internal/config"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// New returns the Mailer selected by cfg.Driver.
+func New(cfg config.Mailer) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return NewFileMailer(cfg.FileDir, cfg.From), nil
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", cfg.Driver)
+	}
+}
+
+// format renders message as an RFC 5322 email with a UTF-8 text body.
+func format(from string, message Message) []byte {
+	var builder strings.Builder
+
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + message.To + "\r\n")
+	builder.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return []byte(builder.String())
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import "sync"
+
+// MemoryMailer keeps sent messages in memory. It is meant for tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+// LastMessage returns the most recent message sent to the given address.
+func (m *MemoryMailer) LastMessage(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
+
+var _ Mailer = &MemoryMailer{}
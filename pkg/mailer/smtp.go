@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer returns a Mailer sending through the given SMTP server. Plain
+// authentication is used when username is set.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	mailer := &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		from: from,
+	}
+
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return mailer
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, format(m.from, message))
+}
+
+var _ Mailer = &SMTPMailer{}
@@ -0,0 +1,55 @@
+package phone
+
+// Country holds the numbering rules used to parse and validate phone numbers
+// of a single country.
+type Country struct {
+	// Region is the ISO 3166-1 alpha-2 code, e.g. "TM".
+	Region string
+	// CallingCode is the international dialling code without "+".
+	CallingCode string
+	// TrunkPrefix is dialled before national numbers inside the country,
+	// e.g. the "8" in "8 65 123456". Empty when the country has none.
+	TrunkPrefix string
+	// NationalNumberLength is the number of digits after the calling code.
+	NationalNumberLength int
+	// OperatorPrefixes are the accepted leading digits of national numbers.
+	OperatorPrefixes []string
+}
+
+// Countries are the countries whose numbers can be parsed, keyed by region.
+var Countries = map[string]Country{
+	"TM": {
+		Region:               "TM",
+		CallingCode:          "993",
+		TrunkPrefix:          "8",
+		NationalNumberLength: 8,
+		OperatorPrefixes:     []string{"61", "62", "63", "64", "65", "71"},
+	},
+	"UZ": {
+		Region:               "UZ",
+		CallingCode:          "998",
+		NationalNumberLength: 9,
+		OperatorPrefixes:     []string{"20", "33", "50", "55", "77", "88", "90", "91", "93", "94", "95", "97", "98", "99"},
+	},
+	"KZ": {
+		Region:               "KZ",
+		CallingCode:          "7",
+		TrunkPrefix:          "8",
+		NationalNumberLength: 10,
+		OperatorPrefixes:     []string{"700", "701", "702", "705", "706", "707", "708", "747", "771", "775", "776", "777", "778"},
+	},
+	"AF": {
+		Region:               "AF",
+		CallingCode:          "93",
+		TrunkPrefix:          "0",
+		NationalNumberLength: 9,
+		OperatorPrefixes:     []string{"70", "71", "72", "73", "74", "75", "76", "77", "78", "79"},
+	},
+	"IR": {
+		Region:               "IR",
+		CallingCode:          "98",
+		TrunkPrefix:          "0",
+		NationalNumberLength: 10,
+		OperatorPrefixes:     []string{"90", "91", "92", "93", "99"},
+	},
+}
@@ -0,0 +1,155 @@
+// Package phone parses phone numbers written in local or international
+// formats and normalizes them to E.164.
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidNumber     = errors.New("invalid phone number")
+	ErrCountryNotAllowed = errors.New("phone numbers from this country are not allowed")
+	ErrUnknownRegion     = errors.New("unknown region")
+)
+
+// Parser normalizes phone numbers of a fixed set of allowed countries.
+type Parser struct {
+	defaultCountry Country
+	allowed        []Country
+}
+
+// NewParser returns a Parser accepting numbers from the given regions.
+// Numbers written without a calling code are read as numbers of
+// defaultRegion, which must be one of the allowed regions.
+func NewParser(defaultRegion string, allowedRegions []string) (*Parser, error) {
+	parser := &Parser{}
+
+	for _, region := range allowedRegions {
+		country, ok := Countries[strings.ToUpper(strings.TrimSpace(region))]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRegion, region)
+		}
+		parser.allowed = append(parser.allowed, country)
+	}
+
+	defaultCountry, ok := parser.country(strings.ToUpper(defaultRegion))
+	if !ok {
+		return nil, fmt.Errorf("%w: default region %q is not allowed", ErrUnknownRegion, defaultRegion)
+	}
+	parser.defaultCountry = defaultCountry
+
+	return parser, nil
+}
+
+// Parse normalizes raw to E.164, e.g. "8 65 123456" becomes "+99365123456"
+// when Turkmenistan is the default region. Spaces, dashes, dots and
+// parentheses are ignored.
+func (p *Parser) Parse(raw string) (string, error) {
+	digits, international, ok := clean(raw)
+	if !ok {
+		return "", ErrInvalidNumber
+	}
+
+	var country Country
+	var nationalNumber string
+
+	if international {
+		country, nationalNumber, ok = p.splitCallingCode(digits)
+		if !ok {
+			return "", ErrCountryNotAllowed
+		}
+	} else {
+		country = p.defaultCountry
+		nationalNumber = digits
+		if country.TrunkPrefix != "" && len(digits) == country.NationalNumberLength+len(country.TrunkPrefix) {
+			nationalNumber = strings.TrimPrefix(digits, country.TrunkPrefix)
+		}
+	}
+
+	if !country.isValidNationalNumber(nationalNumber) {
+		return "", ErrInvalidNumber
+	}
+
+	return "+" + country.CallingCode + nationalNumber, nil
+}
+
+// IsValid reports whether raw is a valid number of an allowed country.
+func (p *Parser) IsValid(raw string) bool {
+	_, err := p.Parse(raw)
+	return err == nil
+}
+
+func (p *Parser) country(region string) (Country, bool) {
+	for _, country := range p.allowed {
+		if country.Region == region {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
+// splitCallingCode finds the allowed country whose calling code starts
+// digits, preferring the longest match.
+func (p *Parser) splitCallingCode(digits string) (Country, string, bool) {
+	var match Country
+	var found bool
+
+	for _, country := range p.allowed {
+		if strings.HasPrefix(digits, country.CallingCode) && len(country.CallingCode) > len(match.CallingCode) {
+			match = country
+			found = true
+		}
+	}
+
+	if !found {
+		return Country{}, "", false
+	}
+
+	return match, strings.TrimPrefix(digits, match.CallingCode), true
+}
+
+func (c Country) isValidNationalNumber(number string) bool {
+	if len(number) != c.NationalNumberLength {
+		return false
+	}
+
+	for _, prefix := range c.OperatorPrefixes {
+		if strings.HasPrefix(number, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clean strips formatting characters from raw and reports whether the number
+// was written in international format, i.e. with a leading "+" or "00".
+func clean(raw string) (digits string, international bool, ok bool) {
+	raw = strings.TrimSpace(raw)
+
+	if strings.HasPrefix(raw, "+") {
+		international = true
+		raw = raw[1:]
+	}
+
+	var builder strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			builder.WriteRune(r)
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", false, false
+		}
+	}
+
+	digits = builder.String()
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	return digits, international, digits != ""
+}
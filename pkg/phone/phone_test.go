@@ -0,0 +1,48 @@
+package phone_test
+
+import (
+	"admin-panel/pkg/phone"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	parser, err := phone.NewParser("TM", []string{"TM", "UZ", "KZ"})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		raw         string
+		expected    string
+		expectedErr error
+	}{
+		{name: "E.164", raw: "+99362008971", expected: "+99362008971"},
+		{name: "local with trunk prefix", raw: "8 65 123456", expected: "+99365123456"},
+		{name: "local without trunk prefix", raw: "65-12-34-56", expected: "+99365123456"},
+		{name: "international with 00", raw: "00 998 (90) 123-45-67", expected: "+998901234567"},
+		{name: "single digit calling code", raw: "+7 701 123 4567", expected: "+77011234567"},
+		{name: "country not allowed", raw: "+93 70 123 4567", expectedErr: phone.ErrCountryNotAllowed},
+		{name: "unknown operator", raw: "+99312345678", expectedErr: phone.ErrInvalidNumber},
+		{name: "too short", raw: "8 65 1234", expectedErr: phone.ErrInvalidNumber},
+		{name: "letters", raw: "+993 6x 123456", expectedErr: phone.ErrInvalidNumber},
+		{name: "empty", raw: " ", expectedErr: phone.ErrInvalidNumber},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			normalized, err := parser.Parse(tc.raw)
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expected, normalized)
+		})
+	}
+}
+
+func TestNewParserRejectsUnknownRegions(t *testing.T) {
+	_, err := phone.NewParser("TM", []string{"TM", "XX"})
+	assert.ErrorIs(t, err, phone.ErrUnknownRegion)
+
+	_, err = phone.NewParser("UZ", []string{"TM"})
+	assert.ErrorIs(t, err, phone.ErrUnknownRegion)
+}
@@ -0,0 +1,167 @@
+// Package search parses user search queries and highlights what they match.
+package search
+
+import (
+	"admin-panel/pkg/similarity"
+	"admin-panel/pkg/translit"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxTerms caps the number of terms of a query; further words are ignored.
+const MaxTerms = 8
+
+// fuzzyThreshold is the similarity from which a word is taken for a typo of
+// a term when highlighting.
+const fuzzyThreshold = 0.85
+
+// phonePattern matches queries that look like a phone number in any format.
+var phonePattern = regexp.MustCompile(`^\+?[\d\s().-]*\d[\d\s().-]*$`)
+
+// emailPattern matches queries that look like an email address.
+var emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+$`)
+
+// Query is a parsed search query.
+type Query struct {
+	// Terms are the lower-cased words of the query, which must all match.
+	Terms []string
+	// Phone holds the digits of a query that is a phone number, which is
+	// matched against phone numbers only. Terms is empty then.
+	Phone string
+	// Email holds the lower-cased query if it is an email address, which is
+	// matched against email addresses only. Terms is empty then.
+	Email string
+}
+
+// Parse splits query into terms, or recognizes it as a phone number, so that
+// "+993 (65) 12-34-56" matches the digits 99365123456 regardless of how the
+// phone number was formatted when stored, or as an email address.
+func Parse(query string) Query {
+	query = strings.TrimSpace(query)
+
+	if phonePattern.MatchString(query) {
+		return Query{Phone: digits(query)}
+	}
+	if emailPattern.MatchString(query) {
+		return Query{Email: strings.ToLower(query)}
+	}
+
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), isSeparator) {
+		if len(terms) == MaxTerms {
+			break
+		}
+		terms = append(terms, word)
+	}
+
+	return Query{Terms: terms}
+}
+
+// Empty reports whether q matches nothing.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0 && q.Phone == "" && q.Email == ""
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// Highlight returns text, HTML-escaped, with the words matching a term of q
+// wrapped in <mark> tags, and reports whether any word matched. A word
+// matches a term it starts with or, for terms of four letters or more, one
+// it is a likely typo of. Words and terms are compared in either script,
+// through their transliteration keys.
+func Highlight(text string, q Query) (string, bool) {
+	var builder strings.Builder
+	matched := false
+
+	for len(text) > 0 {
+		start := strings.IndexFunc(text, func(r rune) bool { return !isSeparator(r) })
+		if start < 0 {
+			builder.WriteString(html.EscapeString(text))
+			break
+		}
+		builder.WriteString(html.EscapeString(text[:start]))
+		text = text[start:]
+
+		end := strings.IndexFunc(text, isSeparator)
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		text = text[end:]
+
+		if matchesTerm(word, q.Terms) {
+			matched = true
+			builder.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			builder.WriteString(html.EscapeString(word))
+		}
+	}
+
+	return builder.String(), matched
+}
+
+func matchesTerm(word string, terms []string) bool {
+	lower := strings.ToLower(word)
+	key := translit.Key(word)
+	for _, term := range terms {
+		termKey := translit.Key(term)
+		if strings.HasPrefix(lower, term) || termKey != "" && strings.HasPrefix(key, termKey) {
+			return true
+		}
+		if len([]rune(term)) >= 4 && similarity.JaroWinkler(key, termKey) >= fuzzyThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// HighlightPhone returns phoneNumber, HTML-escaped, with the part holding the
+// digits of q.Phone wrapped in <mark> tags, and reports whether it holds
+// them.
+func HighlightPhone(phoneNumber string, q Query) (string, bool) {
+	if q.Phone == "" {
+		return html.EscapeString(phoneNumber), false
+	}
+
+	// Byte offsets of the digits of phoneNumber.
+	var offsets []int
+	for i, r := range phoneNumber {
+		if r >= '0' && r <= '9' {
+			offsets = append(offsets, i)
+		}
+	}
+
+	index := strings.Index(digits(phoneNumber), q.Phone)
+	if index < 0 {
+		return html.EscapeString(phoneNumber), false
+	}
+
+	start := offsets[index]
+	end := offsets[index+len(q.Phone)-1] + 1
+
+	return html.EscapeString(phoneNumber[:start]) +
+		"<mark>" + html.EscapeString(phoneNumber[start:end]) + "</mark>" +
+		html.EscapeString(phoneNumber[end:]), true
+}
+
+// HighlightEmail returns address, HTML-escaped, wrapped in <mark> tags if it
+// is q.Email, and reports whether it is.
+func HighlightEmail(address string, q Query) (string, bool) {
+	if q.Email == "" || !strings.EqualFold(address, q.Email) {
+		return html.EscapeString(address), false
+	}
+	return "<mark>" + html.EscapeString(address) + "</mark>", true
+}
@@ -0,0 +1,87 @@
+package search_test
+
+import (
+	"admin-panel/pkg/search"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected search.Query
+	}{
+		{"Aman Ashgabat", search.Query{Terms: []string{"aman", "ashgabat"}}},
+		{"  atdaýew,  kemal!", search.Query{Terms: []string{"atdaýew", "kemal"}}},
+		{"Kemal@Example.com", search.Query{Email: "kemal@example.com"}},
+		{"kemal example.com", search.Query{Terms: []string{"kemal", "example", "com"}}},
+		{"+993 (65) 12-34-56", search.Query{Phone: "99365123456"}},
+		{"8971", search.Query{Phone: "8971"}},
+		{"aman 8971", search.Query{Terms: []string{"aman", "8971"}}},
+		{"a b c d e f g h i j", search.Query{Terms: []string{"a", "b", "c", "d", "e", "f", "g", "h"}}},
+		{"!!!", search.Query{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, search.Parse(tt.input))
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		query           string
+		expected        string
+		expectedMatched bool
+	}{
+		{"Prefix", "Aman Orazow", "ama", "<mark>Aman</mark> Orazow", true},
+		{"Several terms", "kemal.atdayew@example.com", "kem example", "<mark>kemal</mark>.atdayew@<mark>example</mark>.com", true},
+		{"Typo", "Ashgabat", "ashgabad", "<mark>Ashgabat</mark>", true},
+		{"No typo tolerance for short terms", "Mary", "mra", "Mary", false},
+		{"Cyrillic text", "Аман Оразов", "orazow", "Аман <mark>Оразов</mark>", true},
+		{"Cyrillic query", "Şamuhammet Atdaýew", "шаму", "<mark>Şamuhammet</mark> Atdaýew", true},
+		{"Escaped", "<b>Aman</b>", "aman", "&lt;b&gt;<mark>Aman</mark>&lt;/b&gt;", true},
+		{"No match", "Bahar", "aman", "Bahar", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			highlighted, matched := search.Highlight(tt.text, search.Parse(tt.query))
+
+			assert.Equal(t, tt.expected, highlighted)
+			assert.Equal(t, tt.expectedMatched, matched)
+		})
+	}
+}
+
+func TestHighlightPhone(t *testing.T) {
+	highlighted, matched := search.HighlightPhone("+993 65 12-34-56", search.Parse("651234"))
+	assert.True(t, matched)
+	assert.Equal(t, "+993 <mark>65 12-34</mark>-56", highlighted)
+
+	highlighted, matched = search.HighlightPhone("+99365123456", search.Parse("+993 (65) 12-34-56"))
+	assert.True(t, matched)
+	assert.Equal(t, "+<mark>99365123456</mark>", highlighted)
+
+	_, matched = search.HighlightPhone("+99365123456", search.Parse("777"))
+	assert.False(t, matched)
+
+	_, matched = search.HighlightPhone("+99365123456", search.Parse("aman"))
+	assert.False(t, matched)
+}
+
+func TestHighlightEmail(t *testing.T) {
+	highlighted, matched := search.HighlightEmail("Kemal@example.com", search.Parse("kemal@example.com"))
+	assert.True(t, matched)
+	assert.Equal(t, "<mark>Kemal@example.com</mark>", highlighted)
+
+	_, matched = search.HighlightEmail("kemal@example.com", search.Parse("aman@example.com"))
+	assert.False(t, matched)
+
+	_, matched = search.HighlightEmail("kemal@example.com", search.Parse("kemal"))
+	assert.False(t, matched)
+}
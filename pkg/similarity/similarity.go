@@ -0,0 +1,103 @@
+// Package similarity compares short strings such as personal names.
+package similarity
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize lower-cases s, strips diacritics and punctuation and collapses
+// runs of white space, so that "Ýazmyrat  Öwezow-" and "yazmyrat owezow"
+// compare equal.
+func Normalize(s string) string {
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		stripped = s
+	}
+
+	var builder strings.Builder
+	space := false
+	for _, r := range strings.ToLower(stripped) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && builder.Len() > 0 {
+				builder.WriteByte(' ')
+			}
+			space = false
+			builder.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			space = true
+		}
+	}
+
+	return builder.String()
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of a and b, from 0 for
+// strings with nothing in common to 1 for equal strings. Strings sharing a
+// prefix score higher, which suits names where typos tend to come late.
+func JaroWinkler(a, b string) float64 {
+	jaro := Jaro(a, b)
+
+	ra, rb := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && prefix < 4 && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Jaro returns the Jaro similarity of a and b.
+func Jaro(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		for j := max(0, i-window); j < min(len(rb), i+window+1); j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+}
@@ -0,0 +1,49 @@
+package similarity_test
+
+import (
+	"admin-panel/pkg/similarity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Kemal", "kemal"},
+		{"  Ýazmyrat   Öwezow ", "yazmyrat owezow"},
+		{"Anna-Maria O'Neil", "anna maria oneil"},
+		{"Gurbanguły", "gurbanguły"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, similarity.Normalize(tt.input))
+		})
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected float64
+	}{
+		{"martha", "marhta", 0.961},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.813},
+		{"kemal", "kemal", 1},
+		{"kemal", "", 0},
+		{"", "", 1},
+		{"abc", "xyz", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, similarity.JaroWinkler(tt.a, tt.b), 0.001)
+			assert.InDelta(t, tt.expected, similarity.JaroWinkler(tt.b, tt.a), 0.001)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package sms
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Message is a text message recorded by FakeGateway.
+type Message struct {
+	PhoneNumber string
+	Text        string
+}
+
+// FakeGateway is a Gateway for local development and tests. Instead of
+// delivering messages it records them. Only the delivery is logged, the
+// messages carry verification codes.
+type FakeGateway struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{}
+}
+
+func (g *FakeGateway) Send(phoneNumber, message string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.messages = append(g.messages, Message{PhoneNumber: phoneNumber, Text: message})
+	slog.Debug("SMS recorded by fake gateway")
+
+	return nil
+}
+
+// LastMessage returns the most recent message sent to phoneNumber.
+func (g *FakeGateway) LastMessage(phoneNumber string) (Message, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i := len(g.messages) - 1; i >= 0; i-- {
+		if g.messages[i].PhoneNumber == phoneNumber {
+			return g.messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
+
+var _ Gateway = &FakeGateway{}
@@ -0,0 +1,31 @@
+// Package sms delivers text messages to users' phones.
+package sms
+
+import (
+	"admin-panel/internal/config"
+	"fmt"
+)
+
+// Gateway sends a text message to a phone number in E.164 format.
+type Gateway interface {
+	Send(phoneNumber, message string) error
+}
+
+// New returns the Gateway selected by cfg.Driver. The fake gateway delivers
+// nothing, so it is refused outside the local and dev environments.
+func New(cfg config.SMS, env string) (Gateway, error) {
+	switch cfg.Driver {
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("sms http_url must be set")
+		}
+		return NewHTTPGateway(cfg.HTTPURL, cfg.HTTPToken, cfg.HTTPTimeout), nil
+	case "fake":
+		if env != "local" && env != "dev" {
+			return nil, fmt.Errorf("fake SMS gateway is not allowed in the %q environment", env)
+		}
+		return NewFakeGateway(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,72 @@
+package sms
+
+import (
+	"admin-panel/internal/config"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     config.SMS
+		env     string
+		wantErr bool
+	}{
+		{name: "Fake in local", cfg: config.SMS{Driver: "fake"}, env: "local"},
+		{name: "Fake in dev", cfg: config.SMS{Driver: "fake"}, env: "dev"},
+		{name: "Fake in prod", cfg: config.SMS{Driver: "fake"}, env: "prod", wantErr: true},
+		{name: "HTTP", cfg: config.SMS{Driver: "http", HTTPURL: "http://sms.local/send"}, env: "prod"},
+		{name: "HTTP without URL", cfg: config.SMS{Driver: "http"}, env: "prod", wantErr: true},
+		{name: "Unknown driver", cfg: config.SMS{Driver: "pigeon"}, env: "dev", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway, err := New(tc.cfg, tc.env)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, gateway)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, gateway)
+			}
+		})
+	}
+}
+
+func TestHTTPGateway(t *testing.T) {
+	var received httpMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	gateway := NewHTTPGateway(server.URL, "secret", time.Second)
+
+	require.NoError(t, gateway.Send("+99365123456", "Your verification code is 123456."))
+	assert.Equal(t, httpMessage{To: "+99365123456", Text: "Your verification code is 123456."}, received)
+}
+
+func TestHTTPGatewayError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewHTTPGateway(server.URL, "", time.Second).Send("+99365123456", "Your verification code is 123456.")
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "123456")
+}
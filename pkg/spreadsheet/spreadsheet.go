@@ -0,0 +1,123 @@
+// Package spreadsheet reads and writes tabular files (CSV and XLSX) as rows
+// of strings.
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+// utf8BOM is written by Excel at the start of CSV files saved as UTF-8.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DetectFormat derives the format from a file name or content type.
+func DetectFormat(filename, contentType string) (Format, error) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"), strings.HasPrefix(contentType, "text/csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(lower, ".xlsx"),
+		contentType == "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return FormatXLSX, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// Read returns all rows of r, the header row included. XLSX files are read
+// from their first sheet with raw cell values, so dates arrive as Excel
+// serial numbers (see ParseSerialDate).
+func Read(r io.Reader, format Format) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatXLSX:
+		return readXLSX(r)
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	buffered := bufio.NewReader(r)
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	// Spreadsheet applications in locales with a decimal comma export CSV
+	// separated by semicolons. The header line decides.
+	delimiter := ','
+	line, _ := buffered.Peek(buffered.Size())
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if bytes.Count(line, []byte{';'}) > bytes.Count(line, []byte{','}) {
+		delimiter = ';'
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv: %w", err)
+	}
+
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("opening xlsx: %w", err)
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil
+	}
+
+	rows, err := file.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("reading xlsx: %w", err)
+	}
+
+	return rows, nil
+}
+
+// ParseSerialDate converts an Excel date serial number, as found in raw XLSX
+// cell values, to a date.
+func ParseSerialDate(value string) (time.Time, bool) {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial <= 0 {
+		return time.Time{}, false
+	}
+
+	date, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
@@ -0,0 +1,107 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected [][]string
+	}{
+		{
+			name:     "Comma",
+			input:    "first_name,phone_number\nJohn,+79991234567\n",
+			expected: [][]string{{"first_name", "phone_number"}, {"John", "+79991234567"}},
+		},
+		{
+			name:     "SemicolonWithBOM",
+			input:    "\xEF\xBB\xBFfirst_name;location\nJohn;\"Moscow, Russia\"\n",
+			expected: [][]string{{"first_name", "location"}, {"John", "Moscow, Russia"}},
+		},
+		{
+			name:     "RaggedRows",
+			input:    "a,b,c\n1,2\n",
+			expected: [][]string{{"a", "b", "c"}, {"1", "2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Read(strings.NewReader(tt.input), FormatCSV)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rows)
+		})
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	require.NoError(t, file.SetSheetRow(sheet, "A1", &[]interface{}{"first_name", "date_of_birth"}))
+	require.NoError(t, file.SetSheetRow(sheet, "A2", &[]interface{}{"John", time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)}))
+
+	var buf bytes.Buffer
+	require.NoError(t, file.Write(&buf))
+
+	rows, err := Read(&buf, FormatXLSX)
+
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"first_name", "date_of_birth"}, rows[0])
+	assert.Equal(t, "John", rows[1][0])
+
+	date, ok := ParseSerialDate(rows[1][1])
+	require.True(t, ok)
+	assert.Equal(t, "1990-05-17", date.Format("2006-01-02"))
+}
+
+func TestReadUnsupportedFormat(t *testing.T) {
+	_, err := Read(strings.NewReader(""), Format("ods"))
+
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestDetectFormat(t *testing.T) {
+	format, err := DetectFormat("Partners.CSV", "application/octet-stream")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = DetectFormat("partners", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXLSX, format)
+
+	_, err = DetectFormat("partners.ods", "")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestWriter(t *testing.T) {
+	rows := [][]string{{"id", "location"}, {"1", "Moscow, Russia"}}
+
+	for _, format := range []Format{FormatCSV, FormatXLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, format)
+			require.NoError(t, err)
+
+			for _, row := range rows {
+				require.NoError(t, writer.WriteRow(row))
+			}
+			require.NoError(t, writer.Close())
+
+			read, err := Read(&buf, format)
+
+			require.NoError(t, err)
+			assert.Equal(t, rows, read)
+		})
+	}
+}
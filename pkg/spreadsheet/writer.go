@@ -0,0 +1,91 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Writer writes rows of strings to a CSV or XLSX file.
+type Writer interface {
+	WriteRow(row []string) error
+	// Close completes the file. For XLSX nothing reaches the underlying
+	// writer before Close.
+	Close() error
+}
+
+// NewWriter returns a Writer producing format on w.
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) WriteRow(row []string) error {
+	return w.writer.Write(row)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// xlsxWriter writes a single sheet through excelize's stream writer, which
+// spills rows to a temporary file instead of keeping them in memory.
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	rows   int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (w *xlsxWriter) WriteRow(row []string) error {
+	w.rows++
+	if w.rows > excelize.TotalRows {
+		return fmt.Errorf("xlsx sheets hold at most %d rows", excelize.TotalRows)
+	}
+
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		values[i] = value
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, w.rows)
+	if err != nil {
+		return err
+	}
+
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+
+	return w.file.Write(w.out)
+}
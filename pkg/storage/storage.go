@@ -0,0 +1,53 @@
+// Package storage keeps uploaded files and exposes them by public URL.
+package storage
+
+import (
+	"admin-panel/internal/config"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores objects under slash-separated keys such as
+// "users/1/photo/large.jpg".
+type Storage interface {
+	Put(key string, content io.Reader, contentType string) error
+	// Delete removes the object stored under key. Deleting a missing object
+	// is not an error.
+	Delete(key string) error
+	// URL returns the public URL of the object stored under key.
+	URL(key string) string
+}
+
+// New returns the Storage selected by cfg.Driver.
+func New(cfg config.Storage) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStorage(cfg.LocalDir, cfg.LocalBaseURL), nil
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:      cfg.S3Endpoint,
+			Region:        cfg.S3Region,
+			Bucket:        cfg.S3Bucket,
+			AccessKey:     cfg.S3AccessKey,
+			SecretKey:     cfg.S3SecretKey,
+			PathStyle:     cfg.S3PathStyle,
+			PublicBaseURL: cfg.S3PublicBaseURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// cleanKey rejects keys that are empty, absolute or escape the storage root.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean(key)
+	if key == "" || cleaned != key || strings.HasPrefix(cleaned, "/") || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return cleaned, nil
+}
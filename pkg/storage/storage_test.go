@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "http://localhost:8080/media/")
+
+	err := s.Put("users/1/photo/small.jpg", strings.NewReader("image"), "image/jpeg")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "users", "1", "photo", "small.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "image", string(content))
+	assert.Equal(t, "http://localhost:8080/media/users/1/photo/small.jpg", s.URL("users/1/photo/small.jpg"))
+
+	require.NoError(t, s.Delete("users/1/photo/small.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "users", "1", "photo", "small.jpg"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, s.Delete("users/1/photo/small.jpg"))
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "http://localhost:8080/media")
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "users/../../secret"} {
+		err := s.Put(key, strings.NewReader("x"), "text/plain")
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+// fakeS3 is a minimal stand-in for an S3-compatible service that keeps
+// objects in memory and checks the headers every signed request carries.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string
+	requests []*http.Request
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r)
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		hash := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewS3Storage(S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "photos",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	require.NoError(t, err)
+	s.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	require.NoError(t, s.Put("users/1/photo/small.jpg", strings.NewReader("image"), "image/jpeg"))
+	assert.Equal(t, []byte("image"), fake.objects["/photos/users/1/photo/small.jpg"])
+	assert.Equal(t, "image/jpeg", fake.types["/photos/users/1/photo/small.jpg"])
+
+	authorization := fake.requests[0].Header.Get("Authorization")
+	assert.Contains(t, authorization, "Credential=access/20240102/us-east-1/s3/aws4_request")
+	assert.Contains(t, authorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date")
+	assert.Equal(t, "20240102T030405Z", fake.requests[0].Header.Get("X-Amz-Date"))
+
+	assert.Equal(t, server.URL+"/photos/users/1/photo/small.jpg", s.URL("users/1/photo/small.jpg"))
+
+	require.NoError(t, s.Delete("users/1/photo/small.jpg"))
+	assert.NotContains(t, fake.objects, "/photos/users/1/photo/small.jpg")
+}
+
+func TestS3StorageUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+	}))
+	defer server.Close()
+
+	s, err := NewS3Storage(S3Config{Endpoint: server.URL, Region: "us-east-1", Bucket: "photos", PathStyle: true})
+	require.NoError(t, err)
+
+	err = s.Put("users/1/photo/small.jpg", strings.NewReader("image"), "image/jpeg")
+
+	assert.ErrorContains(t, err, "unexpected status 403")
+}
+
+func TestS3StoragePublicURL(t *testing.T) {
+	s, err := NewS3Storage(S3Config{
+		Endpoint:      "https://s3.example.com",
+		Bucket:        "photos",
+		PublicBaseURL: "https://cdn.example.com/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/users/1/a.jpg", s.URL("users/1/a.jpg"))
+
+	s, err = NewS3Storage(S3Config{Endpoint: "https://s3.example.com", Bucket: "photos"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://photos.s3.example.com/users/1/a.jpg", s.URL("users/1/a.jpg"))
+}
@@ -0,0 +1,63 @@
+// Package translit folds personal names written in Turkmen or Russian, in
+// Latin or Cyrillic script, to a common Latin search key.
+package translit
+
+import (
+	"admin-panel/pkg/similarity"
+	"strings"
+	"unicode"
+)
+
+// cyrillic maps the lower-case Cyrillic letters of the Russian and Turkmen
+// alphabets to Latin. Е is handled separately as it depends on its position.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f",
+	'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'ә': "a", 'ө': "o", 'ү': "u", 'ң': "n", 'җ': "j",
+}
+
+// latin maps the Turkmen Latin letters that stand for a Cyrillic digraph, and
+// W, which Turkmen writes for В, to their folded spelling. The remaining
+// letters with diacritics simply lose them.
+var latin = map[rune]string{
+	'ç': "ch", 'ş': "sh", 'ž': "zh", 'w': "v",
+}
+
+// digraphs are the spellings that differ between common romanizations of
+// the same sound, replaced by a single one.
+var digraphs = strings.NewReplacer("dzh", "j", "kh", "h")
+
+// Key returns the search key of s: lower-case Latin letters without
+// diacritics, spelled the same whichever script and romanization the name
+// was written in, so that "Atdaýew", "Atdayev" and "Атдаев" all fold to
+// "atdayev". The key is meant for matching only; it is not a proper
+// transliteration.
+func Key(s string) string {
+	var builder strings.Builder
+	previous := ' '
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == 'е':
+			// Е reads as "ye" at the start of a word and after a vowel or a
+			// sign, which Turkmen Latin spells "ýe".
+			if !unicode.IsLetter(previous) || strings.ContainsRune("аеёиоуыэюяәөүъь", previous) {
+				builder.WriteString("ye")
+			} else {
+				builder.WriteString("e")
+			}
+		case cyrillic[r] != "" || r == 'ъ' || r == 'ь':
+			builder.WriteString(cyrillic[r])
+		case latin[r] != "":
+			builder.WriteString(latin[r])
+		default:
+			builder.WriteRune(r)
+		}
+		previous = r
+	}
+
+	return digraphs.Replace(similarity.Normalize(builder.String()))
+}
@@ -0,0 +1,36 @@
+package translit_test
+
+import (
+	"admin-panel/pkg/translit"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		inputs   []string
+		expected string
+	}{
+		{[]string{"Aman", "Аман", "AMAN"}, "aman"},
+		{[]string{"Orazow", "Оразов", "Orazov"}, "orazov"},
+		{[]string{"Atdaýew", "Atdayev", "Атдаев"}, "atdayev"},
+		{[]string{"Şamuhammet", "Shamuhammet", "Шамухаммет"}, "shamuhammet"},
+		{[]string{"Çary", "Chary", "Чары"}, "chary"},
+		{[]string{"Jeren", "Җерен", "Джерен"}, "jeren"},
+		{[]string{"Öwezow", "Өвезов", "Ovezov"}, "ovezov"},
+		{[]string{"Ýagmyr", "Ягмыр"}, "yagmyr"},
+		{[]string{"Hanowa", "Khanova", "Ханова"}, "hanova"},
+		{[]string{"Ýewgeniý", "Евгений"}, "yevgeniy"},
+		{[]string{"Anna-Mariya", "Анна-Мария"}, "anna mariya"},
+		{[]string{"", "  "}, ""},
+	}
+
+	for _, tt := range tests {
+		for _, input := range tt.inputs {
+			t.Run(input, func(t *testing.T) {
+				assert.Equal(t, tt.expected, translit.Key(input))
+			})
+		}
+	}
+}